@@ -0,0 +1,173 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnatchConfigSpec defines the desired configuration of kim-snatch.
+type SnatchConfigSpec struct {
+	// Priority of the configuration. If multiple SnatchConfigs exist, the settings of
+	// configurations with higher priority override the ones with lower priority.
+	// +kubebuilder:default=0
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// KymaWorkerPoolName is the name of the worker pool the kyma components will be scheduled on.
+	// +optional
+	KymaWorkerPoolName string `json:"kymaWorkerPoolName,omitempty"`
+
+	// OmittedNamespaces is the list of namespaces the webhook will never mutate pods in.
+	// +optional
+	OmittedNamespaces []string `json:"omittedNamespaces,omitempty"`
+
+	// Weight of the injected preferred node affinity.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+
+	// Mode is the way the node affinity is injected.
+	// +kubebuilder:validation:Enum=preferred;required
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// FailurePolicy of the mutating webhooks.
+	// +kubebuilder:validation:Enum=Ignore;Fail
+	// +optional
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+
+	// TimeoutSeconds of the mutating webhooks.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// Profile is a preset bundle of settings, explicitly set settings override the ones of the profile.
+	// +kubebuilder:validation:Enum=evaluation;production;strict-isolation
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// ExcludeRules are CEL expressions over the pod (object), matching pods are not mutated.
+	// +optional
+	ExcludeRules []string `json:"excludeRules,omitempty"`
+
+	// IncludeRules are CEL expressions over the pod (object), if set only matching pods are mutated.
+	// +optional
+	IncludeRules []string `json:"includeRules,omitempty"`
+
+	// Cleanup stops the injection of the node affinity and removes the node affinity
+	// kim-snatch added to the pod templates of the workloads, e.g. before an uninstall.
+	// +optional
+	Cleanup bool `json:"cleanup,omitempty"`
+}
+
+// Reasons of the pods running outside of the kyma worker pool.
+const (
+	// PlacementReasonCapacity is the reason of pods preferring the pool that were
+	// scheduled elsewhere, e.g. because the pool had no room left
+	PlacementReasonCapacity = "Capacity"
+	// PlacementReasonStalePod is the reason of pods created with the node affinity
+	// of another pool, e.g. before the pool was changed
+	PlacementReasonStalePod = "StalePod"
+	// PlacementReasonMutationSkipped is the reason of pods created without the node
+	// affinity of any pool, e.g. because the mutation was skipped or the pod was
+	// created before kim-snatch was installed
+	PlacementReasonMutationSkipped = "MutationSkipped"
+)
+
+// PlacementViolation describes a workload with pods running outside of the
+// kyma worker pool.
+type PlacementViolation struct {
+	// Namespace of the workload.
+	Namespace string `json:"namespace"`
+
+	// Kind of the workload, e.g. Deployment, or Pod for pods without a controller.
+	Kind string `json:"kind"`
+
+	// Name of the workload.
+	Name string `json:"name"`
+
+	// Pods is the number of pods of the workload running outside of the kyma worker pool.
+	Pods int32 `json:"pods"`
+
+	// Reason why the pods run outside of the kyma worker pool.
+	// +kubebuilder:validation:Enum=Capacity;StalePod;MutationSkipped
+	Reason string `json:"reason"`
+}
+
+// PlacementReport lists the workloads of the kyma namespaces with pods running
+// outside of the kyma worker pool.
+type PlacementReport struct {
+	// LastUpdateTime is the time the report was refreshed last.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+
+	// Pool is the worker pool the pods were expected on.
+	Pool string `json:"pool"`
+
+	// ViolatingPods is the number of pods running outside of the kyma worker pool.
+	ViolatingPods int32 `json:"violatingPods"`
+
+	// ViolatingWorkloads is the number of workloads with pods running outside of
+	// the kyma worker pool, the list of violations is truncated.
+	ViolatingWorkloads int32 `json:"violatingWorkloads"`
+
+	// Violations are the workloads with the most pods running outside of the kyma worker pool.
+	// +optional
+	Violations []PlacementViolation `json:"violations,omitempty"`
+}
+
+// SnatchConfigStatus defines the observed state of SnatchConfig.
+type SnatchConfigStatus struct {
+	// Conditions describe the state of the configuration.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// PlacementReport lists the workloads running outside of the kyma worker pool,
+	// it is only reported on the SnatchConfig the pool is configured in.
+	// +optional
+	PlacementReport *PlacementReport `json:"placementReport,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+// +kubebuilder:printcolumn:name="Pool",type=string,JSONPath=`.spec.kymaWorkerPoolName`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SnatchConfig is the Schema for the snatchconfigs API.
+type SnatchConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnatchConfigSpec   `json:"spec,omitempty"`
+	Status SnatchConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SnatchConfigList contains a list of SnatchConfig.
+type SnatchConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SnatchConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SnatchConfig{}, &SnatchConfigList{})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"time"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// fleetOptions configure the fleet mode in which a single kim-snatch in KCP
+// manages the configuration of many managed clusters.
+type fleetOptions struct {
+	namespace       string
+	templateName    string
+	kubeconfigLabel string
+	kubeconfigKey   string
+	targetNamespace string
+	resyncPeriod    time.Duration
+	metrics         metricsserver.Options
+	probeAddr       string
+}
+
+// runFleet runs kim-snatch in fleet mode, no webhooks are served.
+func runFleet(restConfig *rest.Config, opts fleetOptions) error {
+	kubeconfigs, err := labels.NewRequirement(opts.kubeconfigLabel, selection.Exists, nil)
+	if err != nil {
+		return err
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme: scheme,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Secret{}: {
+					Namespaces: map[string]cache.Config{opts.namespace: {}},
+					Label:      labels.NewSelector().Add(*kubeconfigs),
+				},
+				&snatchv1alpha1.SnatchConfig{}: {
+					Namespaces: map[string]cache.Config{opts.namespace: {}},
+				},
+			},
+		},
+		Metrics:                opts.metrics,
+		HealthProbeBindAddress: opts.probeAddr,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := (&controller.FleetReconciler{
+		Client:          mgr.GetClient(),
+		Namespace:       opts.namespace,
+		TemplateName:    opts.templateName,
+		KubeconfigLabel: opts.kubeconfigLabel,
+		KubeconfigKey:   opts.kubeconfigKey,
+		TargetNamespace: opts.targetNamespace,
+		FieldManager:    patchFieldManagerName,
+		ResyncPeriod:    opts.resyncPeriod,
+		NewClient:       controller.KubeconfigClient(scheme),
+	}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		return err
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		return err
+	}
+
+	logger.Info("starting manager in fleet mode", "namespace", opts.namespace, "template", opts.templateName)
+	return mgr.Start(ctrl.SetupSignalHandler())
+}
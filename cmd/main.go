@@ -16,82 +16,501 @@ limitations under the License.
 package main
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"flag"
 	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"os"
 	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/audit"
+	"github.com/kyma-project/kim-snatch/internal/breaker"
+	"github.com/kyma-project/kim-snatch/internal/certificate"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/discovery"
+	"github.com/kyma-project/kim-snatch/internal/events"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	"github.com/kyma-project/kim-snatch/internal/health"
 	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/podsecurity"
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/kyma-project/kim-snatch/internal/rbac"
+	"github.com/kyma-project/kim-snatch/internal/rules"
+	"github.com/kyma-project/kim-snatch/internal/version"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	clientdiscovery "k8s.io/client-go/discovery"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	cliflag "k8s.io/component-base/cli/flag"
 
 	admissionregistration "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
 	webhook "github.com/kyma-project/kim-snatch/internal/webhook/server"
 	webhookcorev1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	webhooksnatchv1alpha1 "github.com/kyma-project/kim-snatch/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
 const (
-	certDir                  = "/tmp/"
 	certificateAuthorityName = "ca.crt"
-	flagWebhookConfigName    = "webhook-cfg-name"
-	flagKymaWorkerPoolName   = "kyma-worker-pool-name"
+	flagFeatureGates         = "feature-gates"
+	defaultConfigNamespace   = "kyma-system"
 	patchFieldManagerName    = "snatch"
 	webhookServerKeyName     = "tls.key"
 	webhookServerCertName    = "tls.crt"
+	snatchConfigCRDName      = "snatchconfigs.kim-snatch.kyma-project.io"
+	configPath               = "/config"
+	debugPoolsPath           = "/debug/pools"
+	poolHealthPath           = "/healthz/pool"
+	debugDecisionsPath       = "/debug/decisions"
+	detailedHealthPath       = "/healthz/detailed"
+	debugPoolPath            = "/debug/pool"
+	leaderElectionID         = "kim-snatch.kyma-project.io"
+	networkPolicyName        = "kyma-project.io--kim-snatch-allow-ingress"
+
+	// eventReasonSnatchConfigsUnavailable is recorded on the Pod when kim-snatch
+	// starts without the SnatchConfig CRD
+	eventReasonSnatchConfigsUnavailable = "SnatchConfigsUnavailable"
+	// eventReasonPermissionsMissing is recorded on the Pod when the ClusterRoles
+	// of kim-snatch lack permissions on startup
+	eventReasonPermissionsMissing = "PermissionsMissing"
+	// eventReasonPodSecurityViolated is recorded on the Pod when it violates the
+	// restricted profile of the Pod Security Standards on startup
+	eventReasonPodSecurityViolated = "PodSecurityViolated"
+
+	// certificateWaitTimeout is the time the certificate requested from a
+	// provider is waited for on startup
+	certificateWaitTimeout = 5 * time.Minute
 )
 
 var (
 	scheme             = runtime.NewScheme()
 	logger             = ctrl.Log.WithName("setup")
 	errInvalidArgument = fmt.Errorf("invalid argument")
-	omittedNamespaces  = []string{"kube-system"}
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(admissionregistration.AddToScheme(scheme))
+	utilruntime.Must(snatchv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			logger.Error(err, "problem running migration")
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == revertCommand {
+		if err := runRevert(os.Args[2:]); err != nil {
+			logger.Error(err, "problem reverting workloads")
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var probeAddr string
+	var enableLeaderElection bool
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var pprofAddr string
+	var metricsDiagnostics bool
 	var tlsOpts []func(*tls.Config)
+	var tlsMinVersion string
+	var tlsCipherSuites string
 
-	var mWhCfgName string
-	var kymaWorkerPoolName string
+	var configNamespace string
+	var certificateProvider string
+	var certificateSecretName string
+	var certDir string
+	var webhookClientCAFile string
+	var webhookBindAddr string
+	var webhookClientNames string
+	var webhookSelfTestInterval time.Duration
+	var webhookDrainDelay time.Duration
+	var webhookShutdownTimeout time.Duration
+	var webhookMaxInFlight int
+	var webhookMaxQueuedPerClient int
+	var webhookMaxQueueWait time.Duration
+	var webhookRegistrationInterval time.Duration
+	var apiServerProbeInterval time.Duration
+	var apiServerProbeMaxBackoff time.Duration
+	var admissionEventInterval time.Duration
+	var admissionMetricsNamespaces int
+	var admissionDecisions int
+	var admissionDecisionEvents bool
+	var admissionSkipLogInterval time.Duration
+	var admissionSLOLatency time.Duration
+	var breakerMode string
+	var breakerFailures int
+	var breakerCooldown time.Duration
+	var eventOptions events.Options
+	var auditSinkURL string
+	var auditBufferSize int
+	var auditSigningSecret string
+	var webhookServiceName string
+	var certificateIssuerName string
+	var certificateIssuerKind string
+	var certificateParameters certificate.Parameters
+	var certificateExtraDNSNames string
+	var certificateRenewalWindow time.Duration
+	var caRolloverGracePeriod time.Duration
+	var certificateCheckInterval time.Duration
+	var configMapName string
+	var configSecretName string
+	var printEffectiveConfig bool
+	var configDriftInterval time.Duration
+	var capacityCheckInterval time.Duration
+	var poolLabelCheckInterval time.Duration
+	var saturationCheckInterval time.Duration
+	var selfPlacementCheckInterval time.Duration
+	var webhookPathMigrationInterval time.Duration
+	var networkPolicyInterval time.Duration
+	var patchSelfPlacement bool
+	var distributionCheckInterval time.Duration
+	var remediateWorkloads bool
+	var remediationInterval time.Duration
+	var remediationBackoff time.Duration
+	var remediationMaxBackoff time.Duration
+	var remediationFailureThreshold int
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var remediationKubeAPIQPS float64
+	var remediationKubeAPIBurst int
+	var deschedulingInterval time.Duration
+	var deschedulingMaxEvictions int
+	var deschedulingMaxDisruptions int
+	var deschedulingCooldown time.Duration
+	var rolloutOnConfigChange bool
+	var rolloutMaxConcurrent int
+	var rolloutCheckInterval time.Duration
+	var placementReportInterval time.Duration
+	var kymaModuleDefaults bool
+	var discoverPool bool
+	var poolDiscoveryConvention string
+	var poolDiscoveryInterval time.Duration
+	var runtimeName string
+	var runtimeNamespace string
+	var runtimeKubeconfig string
+	var gardenKubeconfig string
+	var gardenTokenFile string
+	var shootName string
+	var shootNamespace string
+	var shootCheckInterval time.Duration
+	var kymaName string
+	var kymaPlanProfiles string
+	var fleetMode bool
+	var fleet fleetOptions
+	var reconcilerOptions controller.ReconcilerOptions
 
-	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. "+
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8443", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
-	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to, "+
+		"0 disables the probe endpoint. The liveness and readiness probes of the Deployment must be removed then.")
+	flag.StringVar(&webhookBindAddr, "webhook-bind-address", ":9443", "The address the webhook server binds to, "+
+		"the port is required. The webhook Service must forward to the port, e.g. with hostNetwork.")
+	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
+		"Enable leader election to run several replicas. Every replica serves the webhook, the controllers "+
+			"updating shared resources, e.g. the certificates, the remediation, and the webhook configuration, only "+
+			"run on the leader.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true,
-		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
+		"If set, the metrics endpoint is served securely via HTTPS and requires a token authorized for the path. "+
+			"Use --metrics-secure=false to use HTTP instead.")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", "",
+		"The loopback address the pprof endpoint binds to, e.g. 127.0.0.1:8082 to capture profiles with kubectl "+
+			"port-forward, other addresses are refused. Empty or 0 disables the pprof endpoint.")
+	flag.BoolVar(&metricsDiagnostics, "metrics-diagnostics", false,
+		"If set, the pprof profiles and expvar variables are served on the metrics endpoint under "+
+			"/debug/pprof/ and /debug/vars, access requires authentication and authorization.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&tlsMinVersion, "tls-min-version", "",
+		"The minimum TLS version of the metrics and webhook servers, one of "+
+			strings.Join(cliflag.TLSPossibleVersions(), ", ")+". Empty keeps VersionTLS13 for the webhook server "+
+			"and VersionTLS12 for the metrics server.")
+	flag.StringVar(&tlsCipherSuites, "tls-cipher-suites", "",
+		"Comma separated list of the TLS 1.2 cipher suites of the metrics and webhook servers, empty uses the Go "+
+			"defaults. TLS 1.3 cipher suites aren't configurable. Possible values: "+
+			strings.Join(cliflag.TLSCipherPossibleValues(), ", ")+".")
 	// webhook flags
-	flag.StringVar(&mWhCfgName, flagWebhookConfigName, "", "The name of the mutating webhook configuration to be updated.")
-	flag.StringVar(&kymaWorkerPoolName, flagKymaWorkerPoolName, "", "The name of the workerpool the kyma components will be scheduled on.")
+	config.BindFlags(flag.CommandLine)
+	flag.StringVar(&certificateProvider, "certificate-provider", certificate.ProviderMounted,
+		"The provider of the webhook certificate, mounted reads the files of the Secret requested by the manifests, "+
+			"cert-manager and gardener request the certificate from the issuer of cert-manager or Gardener "+
+			"cert-management, self-signed generates and renews the certificate in the Secret.")
+	flag.StringVar(&certificateSecretName, "certificate-secret-name", "kim-snatch-certificates",
+		"The name of the Secret holding the webhook certificate, it is watched for a renewed CA bundle "+
+			"or holds the self-signed certificate. Leave empty if the mounted certificate isn't stored in a Secret, "+
+			"e.g. with a CSI secret store volume.")
+	flag.StringVar(&certDir, "certificate-dir", "/tmp/",
+		"The directory the tls.crt, tls.key, and ca.crt files of the mounted webhook certificate are read from.")
+	flag.StringVar(&webhookClientCAFile, "webhook-client-ca-file", "",
+		"If set, the webhook server requires the API Server to authenticate with a client certificate issued by "+
+			"the CA of this file.")
+	flag.StringVar(&webhookClientNames, "webhook-client-names", "",
+		"Comma separated list of names one of which the client certificate of the API Server must carry as "+
+			"common name or DNS name, empty accepts any certificate of the --webhook-client-ca-file CA.")
+	flag.DurationVar(&webhookDrainDelay, "webhook-drain-delay", 10*time.Second,
+		"The time the webhook server keeps serving admission requests after SIGTERM, so the replica is removed from "+
+			"the endpoints of the webhook service before the listener is closed.")
+	flag.DurationVar(&webhookShutdownTimeout, "webhook-shutdown-timeout", 30*time.Second,
+		"The time the webhook server waits for in-flight admission requests once the listener was closed.")
+	flag.IntVar(&webhookMaxInFlight, "webhook-max-in-flight", 50,
+		"The number of admission requests the webhook server handles at once, the other requests wait in a queue "+
+			"per client. 0 disables the limit.")
+	flag.IntVar(&webhookMaxQueuedPerClient, "webhook-max-queued-per-client", 100,
+		"The number of admission requests of a client, e.g. an API server instance, waiting for the webhook server, "+
+			"further requests are rejected right away.")
+	flag.DurationVar(&webhookMaxQueueWait, "webhook-max-queue-wait", time.Second,
+		"The time an admission request waits for the webhook server at most, a request waits half of the timeout "+
+			"of the API server if shorter. The failure policy applies to the rejected requests.")
+	flag.DurationVar(&webhookSelfTestInterval, "webhook-self-test-interval", 5*time.Minute,
+		"The interval in which kim-snatch sends a dry-run admission review to its own webhook server, "+
+			"0 disables the self-test. The self-test is disabled with --webhook-client-ca-file.")
+	flag.DurationVar(&webhookRegistrationInterval, "webhook-registration-check-interval", time.Minute,
+		"The interval in which kim-snatch verifies the MutatingWebhookConfiguration targets its webhook service and "+
+			"port and its caBundle verifies the serving certificate, the replica is unready while it doesn't. "+
+			"0 disables the check.")
+	flag.DurationVar(&apiServerProbeInterval, "apiserver-probe-interval", 10*time.Second,
+		"The interval in which kim-snatch probes the API server is reachable, 0 disables the probe. While it's "+
+			"unreachable, the admission requests are served from the caches and the readiness checks reading from "+
+			"the API server keep their last result.")
+	flag.DurationVar(&apiServerProbeMaxBackoff, "apiserver-probe-max-backoff", 2*time.Minute,
+		"The longest interval between two probes of the API server while it's unreachable, the interval doubles "+
+			"with every failed probe.")
+	flag.DurationVar(&admissionEventInterval, "admission-event-interval", time.Minute,
+		"The minimum interval between Warning events of the same reason recorded on a Pod or the controller of "+
+			"Pods with a generated name for notable webhook decisions, 0 disables the events.")
+	flag.IntVar(&admissionMetricsNamespaces, "admission-metrics-namespaces", 0,
+		"The maximum number of namespaces the admission metrics are labeled with, the requests of further "+
+			"namespaces are aggregated in the _other namespace. 0 disables the namespace label.")
+	flag.IntVar(&admissionDecisions, "admission-decisions", webhookcorev1.DefaultDecisionLogSize,
+		"The number of the last decisions of the Pod webhook served on the /debug/decisions endpoint, 0 disables "+
+			"the endpoint.")
+	flag.BoolVar(&admissionDecisionEvents, "admission-decision-events", false,
+		"If set, every decision of the Pod webhook is recorded as Normal event on the Pod or the controller of Pods "+
+			"with a generated name, rate limited by --admission-event-interval.")
+	flag.DurationVar(&admissionSkipLogInterval, "admission-skip-log-interval", webhookcorev1.DefaultSkipLogInterval,
+		"The interval in which a Pod the node affinity isn't injected in is logged once per skip class at debug "+
+			"level, 0 logs every skipped Pod.")
+	flag.StringVar(&breakerMode, "dependency-breaker-mode", webhookcorev1.BreakerModePassThrough,
+		"The way Pods are admitted while the namespace or node cache is unsynced or failing: pass-through passes "+
+			"them unchanged, defaults injects the configured placement without the namespace overrides or the "+
+			"state of the kyma worker pool.")
+	flag.IntVar(&breakerFailures, "dependency-breaker-failures", breaker.DefaultFailures,
+		"The number of consecutive failed lookups of the namespace or node cache opening its breaker, 0 only "+
+			"opens the breakers while the caches are unsynced.")
+	flag.DurationVar(&breakerCooldown, "dependency-breaker-cooldown", breaker.DefaultCooldown,
+		"The time a breaker stays open until a single lookup probes the cache again.")
+	flag.DurationVar(&admissionSLOLatency, "admission-slo-latency", webhookcorev1.DefaultSLOLatency,
+		"The latency the Pod webhook must mutate or pass a Pod within to meet the SLO, the requests are counted per "+
+			"outcome by the kim_snatch_admission_slo_requests_total metric.")
+	flag.DurationVar(&eventOptions.Interval, "event-dedup-interval", events.DefaultInterval,
+		"The interval in which identical events of an object are recorded once, 0 disables the deduplication.")
+	var eventQPS float64
+	flag.Float64Var(&eventQPS, "event-qps", events.DefaultQPS,
+		"The rate of events kim-snatch records per second, further events are dropped. 0 disables the limit.")
+	flag.IntVar(&eventOptions.Burst, "event-burst", events.DefaultBurst,
+		"The number of events kim-snatch records at once before --event-qps applies.")
+	flag.StringVar(&auditSinkURL, "audit-sink", "",
+		"Where structured audit records of the admission requests of Pods are written to, stdout or a http(s) URL "+
+			"the records are posted to as JSON lines, empty disables the audit records.")
+	flag.IntVar(&auditBufferSize, "audit-buffer-size", audit.DefaultBufferSize,
+		"The number of audit records buffered until they are written, records are dropped while the buffer is full.")
+	flag.StringVar(&auditSigningSecret, "audit-signing-secret", "",
+		"The name of the Secret in the configuration namespace holding the key the audit records are signed with, "+
+			"audit-signing-hmac-key or the PEM encoded audit-signing-ed25519-key, empty disables the signatures. "+
+			"The key is reloaded when the Secret is rotated.")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", "kim-snatch-webhook-service",
+		"The name of the Service of the webhook server the certificate is issued for.")
+	flag.StringVar(&certificateIssuerName, "certificate-issuer-name", "kim-snatch-kyma",
+		"The name of the issuer in the configuration namespace the cert-manager or gardener provider requests "+
+			"the certificate from.")
+	flag.StringVar(&certificateIssuerKind, "certificate-issuer-kind", "Issuer",
+		"The kind of the cert-manager issuer the certificate is requested from, Issuer or ClusterIssuer.")
+	flag.DurationVar(&certificateParameters.Duration, "certificate-duration", 0,
+		"The validity of the webhook certificate, 0 uses the default of the provider, 2160h for self-signed.")
+	flag.DurationVar(&certificateParameters.RenewBefore, "certificate-renew-before", 0,
+		"The time before its expiry the webhook certificate is renewed, 0 uses the default of the provider, "+
+			"720h for self-signed. Gardener cert-management renews the certificates with its own window.")
+	flag.StringVar(&certificateParameters.KeyAlgorithm, "certificate-key-algorithm", "",
+		"The algorithm of the webhook certificate key, ECDSA or RSA, empty uses the default of the provider, "+
+			"ECDSA for self-signed.")
+	flag.IntVar(&certificateParameters.KeySize, "certificate-key-size", 0,
+		"The size of the webhook certificate key, 256 or 384 for ECDSA and 2048, 3072 or 4096 for RSA, "+
+			"0 uses the default of the provider.")
+	flag.StringVar(&certificateExtraDNSNames, "certificate-dns-names", "",
+		"Comma separated list of additional DNS names the webhook certificate is issued for, "+
+			"e.g. for a custom Service in front of the webhook server.")
+	flag.DurationVar(&certificateRenewalWindow, "certificate-renewal-window", 7*24*time.Hour,
+		"The time before their expiry the webhook certificates are reported as not renewed.")
+	flag.DurationVar(&caRolloverGracePeriod, "ca-rollover-grace-period", 24*time.Hour,
+		"The time after a new CA became valid the replaced CAs are kept in the caBundle of the webhooks, "+
+			"so replicas still serving the old certificate are trusted. 0 replaces the CAs at once.")
+	flag.DurationVar(&certificateCheckInterval, "certificate-check-interval", time.Hour,
+		"The interval in which the expiry of the webhook certificates is checked.")
+	flag.StringVar(&configNamespace, "config-namespace", envOrDefault("POD_NAMESPACE", defaultConfigNamespace),
+		"The namespace of the configuration ConfigMap and SnatchConfigs.")
+	flag.StringVar(&configMapName, "config-map-name", "kim-snatch-config",
+		"The name of the ConfigMap the configuration is read from.")
+	flag.StringVar(&configSecretName, "config-secret-name", "kim-snatch-secrets",
+		"The name of the Secret the sensitive settings are read from.")
+	flag.BoolVar(&printEffectiveConfig, "print-effective-config", false,
+		"If set, the effective configuration and the origin of every setting is printed on startup.")
+	flag.DurationVar(&configDriftInterval, "config-drift-interval", 5*time.Minute,
+		"The interval in which the effective configuration is compared with its sources.")
+	flag.DurationVar(&capacityCheckInterval, "capacity-check-interval", time.Minute,
+		"The interval in which the capacity of the Kyma worker pool is read from the cluster-autoscaler status.")
+	flag.DurationVar(&poolLabelCheckInterval, "pool-label-check-interval", 5*time.Minute,
+		"The interval in which the nodes of the Kyma worker pool are verified to carry the pool label.")
+	flag.DurationVar(&saturationCheckInterval, "saturation-check-interval", time.Minute,
+		"The interval in which the resources requested on the Kyma worker pool are compared with its allocatable resources.")
+	flag.DurationVar(&webhookPathMigrationInterval, "webhook-path-migration-interval", 30*time.Second,
+		"The interval in which kim-snatch checks whether the webhooks are still registered with the paths of the "+
+			"previous release, they are moved to the current paths once the rollout of its Deployment completed. "+
+			"0 keeps the previous paths.")
+	flag.DurationVar(&networkPolicyInterval, "network-policy-interval", time.Minute,
+		"The interval in which the NetworkPolicy restricting the ingress to kim-snatch is applied, "+
+			"0 doesn't manage the NetworkPolicy.")
+	flag.DurationVar(&selfPlacementCheckInterval, "self-placement-check-interval", 5*time.Minute,
+		"The interval in which kim-snatch verifies that it runs on the Kyma worker pool itself.")
+	flag.BoolVar(&patchSelfPlacement, "patch-self-placement", false,
+		"If set, the node affinity of the Kyma worker pool is added to the own Deployment if kim-snatch runs outside of it.")
+	flag.DurationVar(&distributionCheckInterval, "distribution-check-interval", time.Minute,
+		"The interval in which the pods of the Kyma namespaces on and off the Kyma worker pool are counted.")
+	flag.BoolVar(&remediateWorkloads, "remediate-workloads", false,
+		"If set, the node affinity of the Kyma worker pool is added to the existing workloads of the Kyma namespaces.")
+	flag.DurationVar(&remediationInterval, "remediation-interval", 10*time.Minute,
+		"The interval in which the existing workloads of the Kyma namespaces are remediated.")
+	flag.DurationVar(&remediationBackoff, "remediation-backoff", time.Minute,
+		"The time after which a workload failing the remediation is retried, doubled with every further failure.")
+	flag.DurationVar(&remediationMaxBackoff, "remediation-max-backoff", time.Hour,
+		"The maximum time after which a workload failing the remediation is retried.")
+	flag.IntVar(&remediationFailureThreshold, "remediation-failure-threshold", 5,
+		"The number of consecutive failures after which the remediation of a workload is suspended until "+
+			"the workload changes, 0 never suspends it.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"The maximum number of requests per second of kim-snatch to the API server, sustained after the burst. "+
+			"A negative value disables the client-side rate limit.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"The maximum number of requests kim-snatch sends to the API server in a burst.")
+	flag.Float64Var(&remediationKubeAPIQPS, "remediation-kube-api-qps", 0,
+		"The maximum number of requests per second of the workload remediation to the API server, "+
+			"0 shares the rate limit of --kube-api-qps and --kube-api-burst with the rest of kim-snatch.")
+	flag.IntVar(&remediationKubeAPIBurst, "remediation-kube-api-burst", 0,
+		"The maximum number of requests the workload remediation sends to the API server in a burst, "+
+			"--kube-api-burst if 0.")
+	flag.DurationVar(&deschedulingInterval, "descheduling-interval", 5*time.Minute,
+		"The interval in which the Kyma Pods running outside of the Kyma worker pool are evicted.")
+	flag.IntVar(&deschedulingMaxEvictions, "descheduling-max-evictions", 5,
+		"The maximum number of Pods evicted per descheduling interval.")
+	flag.IntVar(&deschedulingMaxDisruptions, "descheduling-max-disruptions-per-namespace", 1,
+		"The maximum number of Pods per namespace being deleted or not ready for Pods to be evicted, 0 disables the limit.")
+	flag.DurationVar(&deschedulingCooldown, "descheduling-workload-cooldown", 30*time.Minute,
+		"The time after an eviction before the next Pod of the same workload is evicted.")
+	flag.BoolVar(&rolloutOnConfigChange, "rollout-on-config-change", false,
+		"If set, the workloads of the Kyma namespaces are restarted when the Kyma worker pool or the affinity mode changes.")
+	flag.IntVar(&rolloutMaxConcurrent, "rollout-max-concurrent", 1,
+		"The maximum number of workloads restarted at the same time after a configuration change.")
+	flag.DurationVar(&rolloutCheckInterval, "rollout-check-interval", 15*time.Second,
+		"The interval in which the progress of the restarted workloads is checked.")
+	flag.DurationVar(&placementReportInterval, "placement-report-interval", 5*time.Minute,
+		"The interval in which the workloads running outside of the Kyma worker pool are reported in the SnatchConfig status.")
+	flag.BoolVar(&discoverPool, "discover-pool", false,
+		"If set, the Kyma worker pool is discovered from the shoot-info ConfigMap and the node labels.")
+	flag.StringVar(&poolDiscoveryConvention, "pool-discovery-convention", discovery.DefaultKymaPoolName,
+		"The name of the worker pool considered the Kyma worker pool by convention.")
+	flag.DurationVar(&poolDiscoveryInterval, "pool-discovery-interval", 10*time.Minute,
+		"The interval in which the Kyma worker pool is discovered again.")
+	flag.StringVar(&runtimeName, "runtime-name", "",
+		"The name of the KIM Runtime CR the Kyma worker pool is taken from, the integration is disabled if empty.")
+	flag.StringVar(&runtimeNamespace, "runtime-namespace", "kcp-system", "The namespace of the KIM Runtime CR.")
+	flag.StringVar(&runtimeKubeconfig, "runtime-kubeconfig", "",
+		"The path to the kubeconfig of the cluster the KIM Runtime CR is read from, the own cluster is used if empty.")
+	flag.StringVar(&gardenKubeconfig, "garden-kubeconfig", "",
+		"The path to the kubeconfig of the Garden cluster, the configured pool is verified against the Shoot if set.")
+	flag.StringVar(&gardenTokenFile, "garden-token-file", "",
+		"The path to a projected token replacing the credentials of the Garden kubeconfig, it is re-read when rotated.")
+	flag.StringVar(&shootName, "shoot-name", "", "The name of the Shoot in the Garden cluster.")
+	flag.StringVar(&shootNamespace, "shoot-namespace", "", "The namespace of the Shoot in the Garden cluster.")
+	flag.DurationVar(&shootCheckInterval, "shoot-check-interval", 5*time.Minute,
+		"The interval in which the configured pool is verified against the Shoot.")
+	flag.BoolVar(&kymaModuleDefaults, "kyma-module-defaults", false,
+		"If set, the default SnatchConfig is generated from the Kyma CR of a managed Kyma installation.")
+	flag.StringVar(&kymaName, "kyma-name", "default", "The name of the Kyma CR in the configuration namespace.")
+	flag.StringVar(&kymaPlanProfiles, "kyma-plan-profiles", "",
+		"Comma separated list of plan=profile pairs overriding the profiles of the default SnatchConfig.")
+	flag.BoolVar(&fleetMode, "fleet", false,
+		"If set, kim-snatch runs in KCP and distributes the configuration to the managed clusters, no webhooks are served.")
+	flag.StringVar(&fleet.namespace, "fleet-namespace", "kcp-system",
+		"The namespace of the kubeconfig Secrets and the fleet SnatchConfig in fleet mode.")
+	flag.StringVar(&fleet.templateName, "fleet-template", controller.FleetSnatchConfigName,
+		"The name of the SnatchConfig distributed to the managed clusters in fleet mode.")
+	flag.StringVar(&fleet.kubeconfigLabel, "fleet-kubeconfig-label", controller.DefaultKubeconfigLabel,
+		"The label selecting the kubeconfig Secrets of the managed clusters in fleet mode.")
+	flag.StringVar(&fleet.kubeconfigKey, "fleet-kubeconfig-key", controller.DefaultKubeconfigKey,
+		"The key of the kubeconfig in the Secrets of the managed clusters in fleet mode.")
+	flag.StringVar(&fleet.targetNamespace, "fleet-target-namespace", defaultConfigNamespace,
+		"The configuration namespace of kim-snatch in the managed clusters in fleet mode.")
+	flag.DurationVar(&fleet.resyncPeriod, "fleet-resync-period", 10*time.Minute,
+		"The interval in which the configuration is distributed to every managed cluster again in fleet mode.")
+	flag.IntVar(&reconcilerOptions.MaxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of requests the config, ca-bundle, and workload-drift reconcilers reconcile in parallel each.")
+	flag.DurationVar(&reconcilerOptions.BaseDelay, "reconcile-retry-base-delay", 5*time.Millisecond,
+		"The delay of the first retry of a failed request of the config, ca-bundle, and workload-drift reconcilers, "+
+			"it doubles with every further failure up to --reconcile-retry-max-delay.")
+	flag.DurationVar(&reconcilerOptions.MaxDelay, "reconcile-retry-max-delay", 1000*time.Second,
+		"The longest delay of the retry of a failed request of the config, ca-bundle, and workload-drift reconcilers.")
+	flag.Var(featuregate.DefaultFeatureGate, flagFeatureGates, "A set of key=value pairs that describe feature gates "+
+		"for experimental features. Options are:\n"+strings.Join(featuregate.DefaultFeatureGate.KnownFeatures(), "\n"))
 
 	opts := zap.Options{
 		Development: true,
@@ -99,18 +518,8 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	// validate flags
-	for _, pair := range [][2]string{
-		{flagWebhookConfigName, mWhCfgName},
-		{flagKymaWorkerPoolName, kymaWorkerPoolName},
-	} {
-		if pair[1] == "" {
-			logger.Error(errInvalidArgument, pair[0], pair[0])
-			os.Exit(1)
-		}
-	}
-
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	logger.Info("feature gates", "states", featuregate.DefaultFeatureGate.States())
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
@@ -127,14 +536,51 @@ func main() {
 		tlsOpts = append(tlsOpts, disableHTTP2)
 	}
 
+	if tlsMinVersion != "" {
+		version, err := cliflag.TLSVersion(tlsMinVersion)
+		if err != nil {
+			logger.Error(err, "invalid tls min version")
+			os.Exit(1)
+		}
+		tlsOpts = append(tlsOpts, func(c *tls.Config) {
+			c.MinVersion = version
+		})
+	}
+	if breakerMode != webhookcorev1.BreakerModePassThrough && breakerMode != webhookcorev1.BreakerModeDefaults {
+		logger.Error(errInvalidArgument, "invalid dependency breaker mode", "mode", breakerMode)
+		os.Exit(1)
+	}
+	cipherSuites, err := cliflag.TLSCipherSuites(splitList(tlsCipherSuites))
+	if err != nil {
+		logger.Error(err, "invalid tls cipher suites")
+		os.Exit(1)
+	}
+	if len(cipherSuites) > 0 {
+		tlsOpts = append(tlsOpts, func(c *tls.Config) {
+			c.CipherSuites = cipherSuites
+		})
+	}
+
 	// creates the in-cluster config
-	config, err := rest.InClusterConfig()
+	restConfig, err := rest.InClusterConfig()
 	if err != nil {
 		logger.Error(err, "unable to create rest configuration")
 		os.Exit(1)
 	}
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
 
-	rtClient, err := client.New(config, client.Options{
+	if fleetMode {
+		fleet.metrics = metricsOptions(metricsAddr, secureMetrics, tlsOpts)
+		fleet.probeAddr = probeAddr
+		if err := runFleet(restConfig, fleet); err != nil {
+			logger.Error(err, "problem running fleet mode")
+			os.Exit(1)
+		}
+		return
+	}
+
+	rtClient, err := client.New(restConfig, client.Options{
 		Scheme: scheme,
 	})
 	if err != nil {
@@ -142,50 +588,345 @@ func main() {
 		os.Exit(1)
 	}
 
+	// the SnatchConfig CRD is optional, without it the configuration is only
+	// read from the ConfigMap, the environment, the flags, and the Secret
+	snatchConfigs, err := snatchConfigsInstalled(rtClient)
+	if err != nil {
+		logger.Error(err, "unable to discover the SnatchConfig API")
+		os.Exit(1)
+	}
+	if !snatchConfigs {
+		logger.Info("SnatchConfig CRD not installed, SnatchConfigs are ignored until restart", "crd", snatchConfigCRDName)
+	}
+
+	// configuration sources ordered by ascending precedence
+	var sources []config.LoaderSource
+	var resyncPeriod time.Duration
+	if discoverPool {
+		// the discovered pool is only used if no other source sets one
+		sources = append(sources,
+			config.WithSource(discovery.PoolSource(rtClient, config.DefaultPoolLabelKey, poolDiscoveryConvention)))
+		resyncPeriod = poolDiscoveryInterval
+	}
+	if runtimeName != "" {
+		runtimeClient, err := newRemoteClient(runtimeKubeconfig, rtClient)
+		if err != nil {
+			logger.Error(err, "unable to create runtime client")
+			os.Exit(1)
+		}
+		sources = append(sources, config.WithSource(discovery.RuntimeSource(runtimeClient,
+			client.ObjectKey{Namespace: runtimeNamespace, Name: runtimeName})))
+		resyncPeriod = poolDiscoveryInterval
+	}
+	sources = append(sources, config.WithSource(
+		config.ConfigMapSource(rtClient, client.ObjectKey{Namespace: configNamespace, Name: configMapName})))
+	if snatchConfigs {
+		sources = append(sources, config.WithCompositeSource(config.SnatchConfigsSource(rtClient, configNamespace)))
+	}
+	sources = append(sources,
+		config.WithSource(config.EnvSource()),
+		config.WithSource(config.FlagSource(flag.CommandLine)),
+		config.WithSecretSource(
+			config.SecretObjectSource(rtClient, client.ObjectKey{Namespace: configNamespace, Name: configSecretName})),
+	)
+	if auditSigningSecret != "" {
+		sources = append(sources, config.WithSecretSource(
+			config.SecretObjectSource(rtClient, client.ObjectKey{Namespace: configNamespace, Name: auditSigningSecret})))
+	}
+	loader := config.NewLoader(sources...)
+
+	effective, err := loader.Load(context.Background())
+	if err != nil {
+		logger.Error(err, "unable to load configuration")
+		os.Exit(1)
+	}
+
+	for _, conflict := range effective.Conflicts {
+		logger.Info("conflicting configuration", "key", conflict.Key, "priority", conflict.Priority,
+			"sources", conflict.Sources, "winner", conflict.Winner)
+	}
+
+	if printEffectiveConfig {
+		data, err := json.MarshalIndent(effective, "", "  ")
+		if err != nil {
+			logger.Error(err, "unable to marshal effective configuration")
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	}
+
+	cfg := effective.Config
+
+	// validate the complete configuration before anything is started
+	validationErrs := config.Validate(cfg, featuregate.DefaultFeatureGate)
+	if !slices.Contains(certificate.Providers, certificateProvider) {
+		validationErrs = append(validationErrs, field.NotSupported(field.NewPath("certificate-provider"),
+			certificateProvider, certificate.Providers))
+	}
+	certificateSecret := client.ObjectKey{Namespace: configNamespace, Name: certificateSecretName}
+	certificateDNSNames := []string{
+		fmt.Sprintf("%s.%s.svc", webhookServiceName, configNamespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", webhookServiceName, configNamespace),
+	}
+	for _, dnsName := range splitList(certificateExtraDNSNames) {
+		if !slices.Contains(certificateDNSNames, dnsName) {
+			certificateDNSNames = append(certificateDNSNames, dnsName)
+		}
+	}
+	for _, dnsName := range certificateDNSNames {
+		for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(dnsName, "*.")) {
+			validationErrs = append(validationErrs, field.Invalid(field.NewPath("certificate-dns-names"), dnsName, msg))
+		}
+	}
+	validationErrs = append(validationErrs, certificateParameters.Validate(field.NewPath("certificate"))...)
+	auditSink, auditErr := newAuditSink(auditSinkURL)
+	if auditErr != nil {
+		validationErrs = append(validationErrs, field.Invalid(field.NewPath("audit-sink"), auditSinkURL, auditErr.Error()))
+	}
+	var auditSigner audit.Signer
+	if auditSigningSecret != "" {
+		if auditSinkURL == "" {
+			validationErrs = append(validationErrs, field.Required(field.NewPath("audit-sink"),
+				"required with --audit-signing-secret"))
+		} else if auditSigner, err = audit.SignerFromSecret(effective.Secrets); err != nil {
+			validationErrs = append(validationErrs, field.Invalid(field.NewPath("audit-signing-secret"),
+				auditSigningSecret, err.Error()))
+		}
+	}
+	webhookHost, webhookPort, bindErr := parseBindAddress(webhookBindAddr)
+	if bindErr != nil {
+		validationErrs = append(validationErrs, field.Invalid(field.NewPath("webhook-bind-address"), webhookBindAddr,
+			bindErr.Error()))
+	}
+	var metricsPort int
+	if metricsAddr != "0" {
+		var metricsErr error
+		if _, metricsPort, metricsErr = parseBindAddress(metricsAddr); metricsErr != nil {
+			validationErrs = append(validationErrs, field.Invalid(field.NewPath("metrics-bind-address"), metricsAddr,
+				metricsErr.Error()))
+		}
+	}
+	// the profiles may contain the Secrets held in memory, the endpoint has no
+	// authentication, so it must not be reachable from outside of the Pod
+	if pprofAddr != "" && pprofAddr != "0" {
+		if host, _, pprofErr := parseBindAddress(pprofAddr); pprofErr != nil {
+			validationErrs = append(validationErrs, field.Invalid(field.NewPath("pprof-bind-address"), pprofAddr,
+				pprofErr.Error()))
+		} else if !loopbackHost(host) {
+			validationErrs = append(validationErrs, field.Invalid(field.NewPath("pprof-bind-address"), pprofAddr,
+				"must bind to a loopback address, e.g. 127.0.0.1:8082, use --metrics-diagnostics to serve "+
+					"the profiles with authentication"))
+		}
+	}
+	if webhookClientNames != "" && webhookClientCAFile == "" {
+		validationErrs = append(validationErrs, field.Required(field.NewPath("webhook-client-ca-file"),
+			"the client names are only verified with a client CA"))
+	}
+	if certificateSecretName == "" && certificateProvider != certificate.ProviderMounted {
+		validationErrs = append(validationErrs, field.Required(field.NewPath("certificate-secret-name"),
+			fmt.Sprintf("the %s certificate provider stores the certificate in a Secret", certificateProvider)))
+	}
+	if certificateProvider == certificate.ProviderCertManager &&
+		!slices.Contains([]string{"Issuer", "ClusterIssuer"}, certificateIssuerKind) {
+		validationErrs = append(validationErrs, field.NotSupported(field.NewPath("certificate-issuer-kind"),
+			certificateIssuerKind, []string{"Issuer", "ClusterIssuer"}))
+	}
+	var requester *certificate.Requester
+	if issuer, ok := certificate.Issuers[certificateProvider]; ok && len(validationErrs) == 0 {
+		requester = &certificate.Requester{
+			Client: rtClient,
+			Issuer: issuer,
+			Request: certificate.Request{
+				Secret:     certificateSecret,
+				DNSNames:   certificateDNSNames,
+				IssuerName: certificateIssuerName,
+				IssuerKind: certificateIssuerKind,
+				Parameters: certificateParameters,
+			},
+			Interval: certificateCheckInterval,
+		}
+		// the Secret is mounted as an optional volume, the files appear once
+		// the certificate is issued
+		if err := requester.Apply(context.Background()); err != nil {
+			logger.Error(err, "unable to request webhook certificate", "provider", certificateProvider)
+			os.Exit(1)
+		}
+		logger.Info("waiting for webhook certificate", "provider", certificateProvider, "secret", certificateSecret)
+		_ = wait.PollUntilContextTimeout(context.Background(), time.Second, certificateWaitTimeout, true,
+			func(context.Context) (bool, error) {
+				return len(config.ValidateFiles(field.NewPath("tls"), certDir,
+					certificateAuthorityName, webhookServerCertName, webhookServerKeyName)) == 0, nil
+			})
+	}
+	if certificateProvider != certificate.ProviderSelfSigned {
+		validationErrs = append(validationErrs, config.ValidateFiles(field.NewPath("tls"), certDir,
+			certificateAuthorityName, webhookServerCertName, webhookServerKeyName)...)
+	}
+	if len(validationErrs) > 0 {
+		for _, validationErr := range validationErrs {
+			logger.Error(errInvalidArgument, validationErr.ErrorBody(),
+				"field", validationErr.Field,
+				"type", validationErr.Type,
+				"value", validationErr.BadValue)
+		}
+		logger.Error(validationErrs.ToAggregate(), "invalid configuration", "errors", len(validationErrs))
+		os.Exit(1)
+	}
+
+	webhookTLSOpts := tlsOpts
+	var certificates *certificate.Manager
+	if certificateProvider == certificate.ProviderSelfSigned {
+		certificates = &certificate.Manager{
+			Client:     rtClient,
+			Secret:     certificateSecret,
+			DNSNames:   certificateDNSNames,
+			Parameters: certificateParameters,
+			OnRotate: func(ctx context.Context, caBundle []byte) error {
+				return updateCABundles(ctx, rtClient, cfg.WebhookConfigName, caBundle, caRolloverGracePeriod)
+			},
+			Interval: time.Hour,
+		}
+		// the webhook server is started with the certificate
+		if err := certificates.Ensure(context.Background()); err != nil {
+			logger.Error(err, "unable to provision webhook certificate")
+			os.Exit(1)
+		}
+		webhookTLSOpts = append(slices.Clone(tlsOpts), func(c *tls.Config) {
+			c.GetCertificate = certificates.GetCertificate
+		})
+	}
+
+	mtr := metrics.NewMetrics()
 	webhookServer := webhook.NewServer(webhook.Options{
-		TLSOpts:  tlsOpts,
+		Host:     webhookHost,
+		Port:     webhookPort,
+		TLSOpts:  webhookTLSOpts,
 		CertDir:  certDir,
 		KeyName:  webhookServerKeyName,
 		CertName: webhookServerCertName,
-		Callback: func(cert tls.Certificate) {
+		CAName:   certificateAuthorityName,
+		// the mounted certificate may be provisioned by a central PKI
+		DNSName: certificateDNSNames[0],
+		Callback: func(cert tls.Certificate) error {
 			// read regenerated certificate
 			certPath := path.Join(certDir, certificateAuthorityName)
 			data, err := os.ReadFile(certPath)
 			if err != nil {
-				logger.Error(err, "unable to read certificate")
-				os.Exit(1)
+				return fmt.Errorf("unable to read certificate: %w", err)
 			}
 			logger.Info("certificate loaded")
-
-			updateCABundle := callback.BuildUpdateCABundle(
-				context.Background(),
-				rtClient,
-				callback.BuildUpdateCABundleOpts{
-					Name:         mWhCfgName,
-					CABundle:     data,
-					FieldManager: patchFieldManagerName,
-				})
-
-			if err := retry.RetryOnConflict(retry.DefaultBackoff, updateCABundle); err != nil {
-				logger.Error(err, "unable to patch mutating webhook configuration")
-				os.Exit(1)
-			}
+			return updateCABundles(context.Background(), rtClient, cfg.WebhookConfigName, data,
+				caRolloverGracePeriod)
 		},
+		DrainDelay:         webhookDrainDelay,
+		ShutdownTimeout:    webhookShutdownTimeout,
+		MaxInFlight:        webhookMaxInFlight,
+		MaxQueuedPerClient: webhookMaxQueuedPerClient,
+		MaxQueueWait:       webhookMaxQueueWait,
+		Metrics:            mtr,
 	})
 
 	// Metrics endpoint is enabled in 'config/default/kustomization.yaml'. The Metrics options configure the server.
 	// More info:
 	// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.1/pkg/metrics/server
 	// - https://book.kubebuilder.io/reference/metrics.html
-	metricsServerOptions := metricsserver.Options{
-		BindAddress: metricsAddr,
+	store := config.NewStore(effective)
+
+	// the effective configuration is served next to the metrics, access requires
+	// authentication and authorization regardless of --metrics-secure, the
+	// secure metrics server authorizes all paths itself
+	authorized := func(handler http.Handler) (http.Handler, error) {
+		if secureMetrics {
+			return handler, nil
+		}
+		return authorizedHandler(restConfig, handler)
+	}
+	configHandler, err := authorized(config.Handler(store))
+	if err != nil {
+		logger.Error(err, "unable to create configuration handler")
+		os.Exit(1)
+	}
+
+	// the states of the subsystems are served for the module status
+	var subsystems health.Registry
+	subsystems.AddCheck(health.SubsystemCertificate, func() error {
+		return certificate.ReadyChecker(webhookServer.GetCertificate, certificateDNSNames[0])(nil)
+	})
+	detailedHealthHandler, err := authorized(health.Handler(&subsystems))
+	if err != nil {
+		logger.Error(err, "unable to create detailed health handler")
+		os.Exit(1)
+	}
+
+	poolWatcher := &pool.Watcher{
+		Config:      store.Config,
+		EventTarget: podReference(configNamespace),
+		Subsystem:   subsystems.Subsystem(health.SubsystemNodeCache),
+	}
+	poolsHandler, err := authorized(pool.PoolsHandler(poolWatcher))
+	if err != nil {
+		logger.Error(err, "unable to create pools handler")
+		os.Exit(1)
+	}
+
+	poolHealthHandler, err := authorized(pool.HealthHandler(poolWatcher))
+	if err != nil {
+		logger.Error(err, "unable to create pool health handler")
+		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	poolSnapshotHandler, err := authorized(pool.SnapshotHandler(poolWatcher))
+	if err != nil {
+		logger.Error(err, "unable to create pool snapshot handler")
+		os.Exit(1)
+	}
+
+	metricsServerOptions := metricsOptions(metricsAddr, secureMetrics, tlsOpts)
+	metricsServerOptions.ExtraHandlers = map[string]http.Handler{
+		configPath:         configHandler,
+		debugPoolsPath:     poolsHandler,
+		poolHealthPath:     poolHealthHandler,
+		debugPoolPath:      poolSnapshotHandler,
+		detailedHealthPath: detailedHealthHandler,
+	}
+	var decisionLog *webhookcorev1.DecisionLog
+	if admissionDecisions > 0 {
+		decisionLog = webhookcorev1.NewDecisionLog(admissionDecisions)
+		if metricsServerOptions.ExtraHandlers[debugDecisionsPath], err = authorized(
+			webhookcorev1.DecisionsHandler(decisionLog)); err != nil {
+			logger.Error(err, "unable to create decisions handler")
+			os.Exit(1)
+		}
+	}
+	if metricsDiagnostics {
+		for path, handler := range diagnosticsHandlers() {
+			if metricsServerOptions.ExtraHandlers[path], err = authorized(handler); err != nil {
+				logger.Error(err, "unable to create diagnostics handler", "path", path)
+				os.Exit(1)
+			}
+		}
+	}
+
+	cacheOpts := cacheOptions(configNamespace, cfg.WebhookConfigName, cfg.PoolLabelKey, snatchConfigs)
+	// the webhook server is stopped before the leadership is released
+	gracefulShutdownTimeout := webhookDrainDelay + webhookShutdownTimeout
+	managerConfig := ctrl.GetConfigOrDie()
+	managerConfig.QPS = float32(kubeAPIQPS)
+	managerConfig.Burst = kubeAPIBurst
+	mgr, err := ctrl.NewManager(managerConfig, ctrl.Options{
+		Scheme:                 scheme,
+		Cache:                  cacheOpts,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         false,
+		PprofBindAddress:       pprofAddr,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       leaderElectionID,
+		// the lease is kept next to the configuration
+		LeaderElectionNamespace: configNamespace,
+		// a standby replica takes over right away instead of waiting for the lease to expire
+		LeaderElectionReleaseOnCancel: true,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
 		NewClient: func(config *rest.Config, options client.Options) (client.Client, error) {
 			return rtClient, nil
 		},
@@ -194,31 +935,561 @@ func main() {
 		logger.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
-	mtr := metrics.NewMetrics()
+
+	configSecretCache, err := secretCache(mgr, client.ObjectKey{Namespace: configNamespace, Name: configSecretName})
+	if err != nil {
+		logger.Error(err, "unable to create secret cache", "secret", configSecretName)
+		os.Exit(1)
+	}
+	var certificateSecretCache cache.Cache
+	if certificateSecretName != "" {
+		if certificateSecretCache, err = secretCache(mgr, certificateSecret); err != nil {
+			logger.Error(err, "unable to create secret cache", "secret", certificateSecretName)
+			os.Exit(1)
+		}
+	}
+	configSecretCaches := []cache.Cache{configSecretCache}
+	if auditSigningSecret != "" {
+		auditSigningSecretCache, err := secretCache(mgr,
+			client.ObjectKey{Namespace: configNamespace, Name: auditSigningSecret})
+		if err != nil {
+			logger.Error(err, "unable to create secret cache", "secret", auditSigningSecret)
+			os.Exit(1)
+		}
+		configSecretCaches = append(configSecretCaches, auditSigningSecretCache)
+	}
+
+	var auditLogger *audit.Logger
+	if httpSink, ok := auditSink.(*audit.HTTPSink); ok {
+		// the token is read from the reloaded Secret, so it can be rotated
+		httpSink.Token = func() string {
+			token, _ := store.Get().Secrets.Get(config.SecretKeyAuditSinkToken)
+			return token
+		}
+	}
+	if auditSink != nil {
+		auditLogger = audit.NewLogger(auditSink, audit.Options{
+			BufferSize:    auditBufferSize,
+			ConfigVersion: func() string { return store.Config().Hash() },
+			Metrics:       mtr,
+			Signer:        auditSigner,
+		})
+		if err := mgr.Add(auditLogger); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "audit")
+			os.Exit(1)
+		}
+	}
+
+	mtr.SetBuildInfo(version.Info())
+	mtr.SetConfigHash(store.Config().Hash())
+
+	// all features record their events with the shared recorder, so pod storms
+	// don't flood the API server with events
+	eventOptions.QPS = float32(eventQPS)
+	eventOptions.Metrics = mtr
+	recorder := events.NewRecorder(mgr.GetEventRecorderFor("kim-snatch"), eventOptions)
+	if target := podReference(configNamespace); target != nil && !snatchConfigs {
+		recorder.Event(target, corev1.EventTypeWarning, eventReasonSnatchConfigsUnavailable, fmt.Sprintf(
+			"the CRD %s is not installed, the configuration is read from the ConfigMap, the environment, "+
+				"the flags, and the Secret only", snatchConfigCRDName))
+	}
+
+	// the permissions are verified before the first request could fail on them
+	permissionSubsystems := []string{rbac.SubsystemManager, rbac.SubsystemWebhook, rbac.SubsystemCertificate,
+		rbac.SubsystemNodes}
+	if remediateWorkloads || rolloutOnConfigChange {
+		permissionSubsystems = append(permissionSubsystems, rbac.SubsystemRemediation)
+	}
+	checkPermissions(context.TODO(), rtClient, recorder, podReference(configNamespace), configNamespace,
+		permissionSubsystems)
+	checkPodSecurity(context.TODO(), rtClient, recorder, podReference(configNamespace))
 
 	var nodeList corev1.NodeList
 	if err := rtClient.List(context.TODO(), &nodeList, client.MatchingLabels{
-		"worker.gardener.cloud/pool": kymaWorkerPoolName,
+		cfg.PoolLabelKey: cfg.KymaWorkerPoolName,
 	}); err != nil {
 		logger.Error(err, "unable to fetch node list")
 		os.Exit(1)
 	}
 
-	defaultPod := webhookcorev1.ApplyDefaults(kymaWorkerPoolName, omittedNamespaces)
-	if len(nodeList.Items) == 0 {
-		errMsg := fmt.Sprintf("worker.gardener.cloud/pool=%s not exist, switching to fallback",
-			kymaWorkerPoolName)
+	// the failure policy is relaxed while the Kyma worker pool doesn't exist
+	webhookPolicy := &controller.WebhookPolicy{
+		Client:       rtClient,
+		PoolPresent:  poolWatcher.PoolPresent,
+		FieldManager: patchFieldManagerName,
+		Recorder:     recorder,
+		EventTarget:  podReference(configNamespace),
+	}
+	applyConfig := []controller.ApplyFunc{
+		// the configuration is live once it is stored, before it is applied
+		func(_ context.Context, cfg config.Config) error {
+			mtr.SetConfigHash(cfg.Hash())
+			return nil
+		},
+	}
+	if auditLogger != nil && auditSigningSecret != "" {
+		// the records are signed with the rotated key once it is reloaded, an
+		// invalid key keeps the last one
+		applyConfig = append(applyConfig, func(context.Context, config.Config) error {
+			signer, err := audit.SignerFromSecret(store.Get().Secrets)
+			if err != nil {
+				return fmt.Errorf("unable to sign audit records: %w", err)
+			}
+			auditLogger.SetSigner(signer)
+			return nil
+		})
+	}
+
+	applySharedConfig := []controller.ApplyFunc{webhookPolicy.Apply}
+	if rolloutOnConfigChange {
+		rolloutOrchestrator := &controller.RolloutOrchestrator{
+			Client:        rtClient,
+			Namespaces:    mgr.GetCache(),
+			Config:        store.Config,
+			Recorder:      recorder,
+			EventTarget:   podReference(configNamespace),
+			MaxConcurrent: rolloutMaxConcurrent,
+			Interval:      rolloutCheckInterval,
+		}
+		if err := mgr.Add(rolloutOrchestrator); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "rollout-orchestrator")
+			os.Exit(1)
+		}
+		// only the leader tracks the placement, so a replica elected later takes
+		// the placement it was elected with as baseline, instead of rolling out
+		// a change the previous leader rolled out already
+		applySharedConfig = append(applySharedConfig, rolloutOrchestrator.Apply)
+	}
+
+	configReconciler := &controller.ConfigReconciler{
+		Loader:            loader,
+		Store:             store,
+		Gate:              featuregate.DefaultFeatureGate,
+		Namespace:         configNamespace,
+		ConfigMapName:     configMapName,
+		SecretCaches:      configSecretCaches,
+		ResyncPeriod:      resyncPeriod,
+		WebhookConfigName: cfg.WebhookConfigName,
+		Apply:             applyConfig,
+		ApplyShared:       applySharedConfig,
+		Elected:           mgr.Elected(),
+		Subsystem:         subsystems.Subsystem(health.SubsystemConfig),
+
+		WithoutSnatchConfigs: !snatchConfigs,
+		Options:              reconcilerOptions,
+	}
+	if err := configReconciler.SetupWithManager(mgr); err != nil {
+		logger.Error(err, "unable to create controller", "controller", "config")
+		os.Exit(1)
+	}
+	// the leader relaxes or restores the failure policy with the reload, not
+	// within the reconciliation of the nodes
+	poolWatcher.OnPoolPresence = append(poolWatcher.OnPoolPresence, configReconciler.Trigger)
+
+	// a certificate without Secret is published from the mounted files only,
+	// the reconciler also removes the replaced CAs after the rollover
+	if certificateSecretName != "" {
+		if err := (&controller.CABundleReconciler{
+			Client:            mgr.GetClient(),
+			Secret:            certificateSecret,
+			SecretCache:       certificateSecretCache,
+			WebhookConfigName: cfg.WebhookConfigName,
+			Publish: func(ctx context.Context, caBundle []byte) error {
+				return updateCABundles(ctx, rtClient, cfg.WebhookConfigName, caBundle, caRolloverGracePeriod)
+			},
+			RolloverGrace: caRolloverGracePeriod,
+			Options:       reconcilerOptions,
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error(err, "unable to create controller", "controller", "ca-bundle")
+			os.Exit(1)
+		}
+	}
+
+	if kymaModuleDefaults && !snatchConfigs {
+		logger.Error(errInvalidArgument, "the default SnatchConfig is not generated without the SnatchConfig CRD",
+			"crd", snatchConfigCRDName)
+	} else if kymaModuleDefaults {
+		planProfiles, err := controller.ParsePlanProfiles(kymaPlanProfiles)
+		if err != nil {
+			logger.Error(err, "invalid plan profiles")
+			os.Exit(1)
+		}
+
+		if err := (&controller.KymaDefaultsReconciler{
+			Client:       mgr.GetClient(),
+			Namespace:    configNamespace,
+			KymaName:     kymaName,
+			PlanProfiles: mergePlanProfiles(controller.DefaultPlanProfiles, planProfiles),
+			FieldManager: patchFieldManagerName,
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error(err, "unable to create controller", "controller", "kyma-defaults")
+			os.Exit(1)
+		}
+	}
+
+	if err := mgr.Add(&controller.ConfigDriftReporter{
+		Loader:      loader,
+		Store:       store,
+		Gate:        featuregate.DefaultFeatureGate,
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: podReference(configNamespace),
+		Interval:    configDriftInterval,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "config-drift")
+		os.Exit(1)
+	}
+
+	if gardenKubeconfig != "" && shootName != "" {
+		// the Garden cluster is only read, it may be unreachable at times
+		gardenClient, err := garden.NewClient(garden.Options{
+			Kubeconfig: gardenKubeconfig,
+			TokenFile:  gardenTokenFile,
+		}, scheme, mtr)
+		if err != nil {
+			logger.Error(err, "unable to create garden client")
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(&controller.ShootPoolChecker{
+			Garden:      gardenClient,
+			Client:      mgr.GetClient(),
+			Store:       store,
+			ShootKey:    client.ObjectKey{Namespace: shootNamespace, Name: shootName},
+			Recorder:    recorder,
+			EventTarget: podReference(configNamespace),
+			Interval:    shootCheckInterval,
+		}); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "shoot-pool-checker")
+			os.Exit(1)
+		}
+	}
+
+	capacityMonitor := &controller.CapacityMonitor{
+		Reader:      rtClient,
+		Config:      store.Config,
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: podReference(configNamespace),
+		Interval:    capacityCheckInterval,
+	}
+	if err := mgr.Add(capacityMonitor); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "capacity-monitor")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controller.PoolLabelChecker{
+		Reader:      rtClient,
+		Config:      store.Config,
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: podReference(configNamespace),
+		Interval:    poolLabelCheckInterval,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "pool-label-checker")
+		os.Exit(1)
+	}
+
+	if err := (&controller.PendingPodReconciler{
+		Client:      mgr.GetClient(),
+		Pods:        mgr.GetCache(),
+		Store:       store,
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: podReference(configNamespace),
+	}).SetupWithManager(mgr); err != nil {
+		logger.Error(err, "unable to create controller", "controller", "pending-pods")
+		os.Exit(1)
+	}
+
+	saturationMonitor := &controller.SaturationMonitor{
+		Reader:      rtClient,
+		Nodes:       poolWatcher.Nodes,
+		Config:      store.Config,
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: podReference(configNamespace),
+		Interval:    saturationCheckInterval,
+	}
+	if err := mgr.Add(saturationMonitor); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "saturation-monitor")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controller.SelfPlacementChecker{
+		Client:          rtClient,
+		Config:          store.Config,
+		Metrics:         mtr,
+		Recorder:        recorder,
+		ActivePool:      poolWatcher.ActivePool,
+		EventTarget:     podReference(configNamespace),
+		PatchDeployment: patchSelfPlacement,
+		Interval:        selfPlacementCheckInterval,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "self-placement-checker")
+		os.Exit(1)
+	}
+
+	if webhookPathMigrationInterval > 0 {
+		if err := mgr.Add(&controller.WebhookPathMigration{
+			Client:       rtClient,
+			Config:       store.Config,
+			Paths:        webhookcorev1.PodWebhookPaths,
+			FieldManager: patchFieldManagerName,
+			Recorder:     recorder,
+			EventTarget:  podReference(configNamespace),
+			Interval:     webhookPathMigrationInterval,
+		}); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "webhook-path-migration")
+			os.Exit(1)
+		}
+	}
+
+	if networkPolicyInterval > 0 {
+		if err := mgr.Add(&controller.NetworkPolicyReconciler{
+			Client:       rtClient,
+			Config:       store.Config,
+			Namespace:    configNamespace,
+			Name:         networkPolicyName,
+			PodSelector:  map[string]string{"app.kubernetes.io/component": "kim-snatch"},
+			WebhookPort:  webhookPort,
+			MetricsPort:  metricsPort,
+			FieldManager: patchFieldManagerName,
+			Interval:     networkPolicyInterval,
+		}); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "network-policy-reconciler")
+			os.Exit(1)
+		}
+	}
+
+	if err := mgr.Add(&controller.DistributionMonitor{
+		Namespaces: mgr.GetCache(),
+		Pods:       rtClient,
+		IsMember:   poolWatcher.IsMember,
+		Synced:     poolWatcher.Synced,
+		Config:     store.Config,
+		Metrics:    mtr,
+		Interval:   distributionCheckInterval,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "distribution-monitor")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controller.PlacementReporter{
+		Client:           rtClient,
+		Namespaces:       mgr.GetCache(),
+		Store:            store,
+		IsMember:         poolWatcher.IsMember,
+		Synced:           poolWatcher.Synced,
+		ResolvePlacement: webhookcorev1.NamespacePlacementResolver(mgr.GetCache()),
+		ActivePool:       poolWatcher.ActivePool,
+		Interval:         placementReportInterval,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "placement-reporter")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controller.Descheduler{
+		Namespaces:   mgr.GetCache(),
+		Client:       rtClient,
+		IsMember:     poolWatcher.IsMember,
+		Synced:       poolWatcher.Synced,
+		PoolReady:    poolWatcher.PoolReady,
+		Saturated:    saturationMonitor.Saturated,
+		Config:       store.Config,
+		Gate:         featuregate.DefaultFeatureGate,
+		Metrics:      mtr,
+		Recorder:     recorder,
+		EventTarget:  podReference(configNamespace),
+		MaxEvictions: deschedulingMaxEvictions,
+		Interval:     deschedulingInterval,
+
+		MaxDisruptionsPerNamespace: deschedulingMaxDisruptions,
+		WorkloadCooldown:           deschedulingCooldown,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "descheduler")
+		os.Exit(1)
+	}
+
+	if remediateWorkloads {
+		// the remediation patches many workloads at once, an own rate limit keeps
+		// it from starving the rest of kim-snatch or from overloading the API server
+		remediationClient := rtClient
+		if remediationKubeAPIQPS != 0 || remediationKubeAPIBurst != 0 {
+			remediationConfig := rest.CopyConfig(restConfig)
+			remediationConfig.QPS = cmp.Or(float32(remediationKubeAPIQPS), restConfig.QPS)
+			remediationConfig.Burst = cmp.Or(remediationKubeAPIBurst, restConfig.Burst)
+			if remediationClient, err = client.New(remediationConfig, client.Options{Scheme: scheme}); err != nil {
+				logger.Error(err, "unable to create client", "client", "remediation")
+				os.Exit(1)
+			}
+		}
+		remediator := &controller.WorkloadRemediator{
+			Client:           remediationClient,
+			Namespaces:       mgr.GetCache(),
+			Config:           store.Config,
+			Gate:             featuregate.DefaultFeatureGate,
+			ResolvePlacement: webhookcorev1.NamespacePlacementResolver(mgr.GetCache()),
+			Rules: func() *rules.Rules {
+				return store.Get().Rules
+			},
+			ActivePool:  poolWatcher.ActivePool,
+			Metrics:     mtr,
+			Recorder:    recorder,
+			EventTarget: podReference(configNamespace),
+			Interval:    remediationInterval,
+
+			Backoff:          remediationBackoff,
+			MaxBackoff:       remediationMaxBackoff,
+			FailureThreshold: remediationFailureThreshold,
+			Subsystem:        subsystems.Subsystem(health.SubsystemRemediation).LeaderElected(mgr.Elected()),
+			Options:          reconcilerOptions,
+		}
+		if err := mgr.Add(remediator); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "workload-remediator")
+			os.Exit(1)
+		}
+		if err := remediator.SetupWithManager(mgr); err != nil {
+			logger.Error(err, "unable to create controller", "controller", "workload-drift")
+			os.Exit(1)
+		}
+		poolWatcher.OnPoolChange = append(poolWatcher.OnPoolChange, remediator.Trigger)
+	}
+
+	if certificates != nil {
+		certificates.Elected = mgr.Elected()
+		if err := mgr.Add(certificates); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "certificate-manager")
+			os.Exit(1)
+		}
+	}
+	if requester != nil {
+		if err := mgr.Add(requester); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "certificate-requester")
+			os.Exit(1)
+		}
+	}
+
+	if certificateSecretName != "" {
+		if err := mgr.Add(&controller.CertificateMonitor{
+			Reader:        certificateSecretCache,
+			Secret:        certificateSecret,
+			Metrics:       mtr,
+			Recorder:      recorder,
+			EventTarget:   podReference(configNamespace),
+			RenewalWindow: certificateRenewalWindow,
+			Interval:      certificateCheckInterval,
+		}); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "certificate-monitor")
+			os.Exit(1)
+		}
+	}
+
+	if err := mgr.Add(&controller.WorkloadReverter{
+		Client:      rtClient,
+		Config:      store.Config,
+		Recorder:    recorder,
+		EventTarget: podReference(configNamespace),
+		Interval:    remediationInterval,
+	}); err != nil {
+		logger.Error(err, "unable to add runnable", "runnable", "workload-reverter")
+		os.Exit(1)
+	}
+
+	poolWatcher.Reader = mgr.GetCache()
+	poolWatcher.Metrics = mtr
+	poolWatcher.Recorder = recorder
+	poolWatcher.Breaker = breaker.New(breaker.Options{
+		Dependency: breaker.DependencyNodes,
+		Failures:   breakerFailures,
+		Cooldown:   breakerCooldown,
+		Synced:     poolWatcher.Synced,
+		Metrics:    mtr,
+	})
+	if err := poolWatcher.SetupWithManager(mgr); err != nil {
+		logger.Error(err, "unable to create controller", "controller", "pool")
+		os.Exit(1)
+	}
+
+	// the namespaces are looked up on every admission request, the informer is
+	// started with the cache instead of on the first request
+	namespaceInformer, err := mgr.GetCache().GetInformer(context.Background(), webhookcorev1.NamespaceMetadata(),
+		cache.BlockUntilSynced(false))
+	if err != nil {
+		logger.Error(err, "unable to create namespace informer")
+		os.Exit(1)
+	}
+
+	defaultPod := webhookcorev1.ApplyDefaults(webhookcorev1.ApplyDefaultsOpts{
+		Config:           store.Config,
+		ResolvePlacement: webhookcorev1.NamespacePlacementResolver(mgr.GetCache()),
+		Rules: func() *rules.Rules {
+			return store.Get().Rules
+		},
+		PreferOnly:  capacityMonitor.PreferOnly,
+		OutageZones: poolWatcher.OutageZones,
+		ActivePool:  poolWatcher.ActivePool,
+		PoolPresent: poolWatcher.PoolPresent,
+		PoolReady:   poolWatcher.PoolReady,
+		Saturated:   saturationMonitor.Saturated,
+		Taints:      poolWatcher.Taints,
+
+		NamespaceBreaker: breaker.New(breaker.Options{
+			Dependency: breaker.DependencyNamespaces,
+			Failures:   breakerFailures,
+			Cooldown:   breakerCooldown,
+			Synced:     namespaceInformer.HasSynced,
+			Metrics:    mtr,
+		}),
+		NodeBreaker: poolWatcher.Breaker,
+		BreakerMode: breakerMode,
+	})
+	// the webhook path keeps no state of its own, every replica decides from its
+	// caches whether to fall back, so the replicas can be scaled with the Pod
+	// churn; the fallback is reported once per change instead of per Pod
+	reportShoot := func(fallback bool) {
+		if !fallback {
+			mtr.SetDefaultShoot()
+			return
+		}
+		cfg := store.Config()
+		errMsg := fmt.Sprintf("%s=%s not exist, switching to fallback until the pool has nodes",
+			cfg.PoolLabelKey, cfg.KymaWorkerPoolName)
 		mtr.SetFallbackShoot()
 		logger.Error(errInvalidArgument, errMsg)
-		defaultPod = webhookcorev1.ApplyDefaultsFallback(kymaWorkerPoolName)
-	} else {
-		mtr.SetDefaultShoot()
 	}
+	var shootFallback atomic.Bool
+	shootFallback.Store(len(nodeList.Items) == 0)
+	reportShoot(shootFallback.Load())
+	poolWatcher.OnPoolPresence = append(poolWatcher.OnPoolPresence, func(_ context.Context, present bool) {
+		if shootFallback.Swap(!present) != !present {
+			reportShoot(!present)
+		}
+	})
+
+	var admissionNamespaces *metrics.LabelGuard
+	if admissionMetricsNamespaces > 0 {
+		admissionNamespaces = metrics.NewLabelGuard(admissionMetricsNamespaces)
+	}
+	if err = webhookcorev1.SetupPodWebhookWithManager(mgr, defaultPod, webhookcorev1.PodCustomDefaulterOpts{
+		Metrics:       mtr,
+		Namespaces:    admissionNamespaces,
+		Recorder:      recorder,
+		EventInterval: admissionEventInterval,
+		Audit:         auditLogger,
 
-	if err = webhookcorev1.SetupPodWebhookWithManager(mgr, defaultPod); err != nil {
+		Decisions:       decisionLog,
+		DecisionEvents:  admissionDecisionEvents,
+		SkipLogInterval: admissionSkipLogInterval,
+		SLOLatency:      admissionSLOLatency,
+	}); err != nil {
 		logger.Error(err, "unable to create webhook", "webhook", "Pod")
 		os.Exit(1)
 	}
+	if err = webhooksnatchv1alpha1.SetupSnatchConfigWebhookWithManager(mgr); err != nil {
+		logger.Error(err, "unable to create webhook", "webhook", "SnatchConfig")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -229,6 +1500,108 @@ func main() {
 		logger.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	var watchdog *controller.APIServerWatchdog
+	if apiServerProbeInterval > 0 {
+		probe, err := apiServerProbe(restConfig)
+		if err != nil {
+			logger.Error(err, "unable to create API server probe")
+			os.Exit(1)
+		}
+		watchdog = &controller.APIServerWatchdog{
+			Probe:      probe,
+			Metrics:    mtr,
+			Interval:   apiServerProbeInterval,
+			MaxBackoff: apiServerProbeMaxBackoff,
+		}
+		if err := mgr.Add(watchdog); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "apiserver-watchdog")
+			os.Exit(1)
+		}
+	}
+	// the self-test has no client certificate the webhook server accepts
+	if webhookSelfTestInterval > 0 && webhookClientCAFile == "" {
+		selfTest := &controller.WebhookSelfTest{
+			Reader:      rtClient,
+			Config:      store.Config,
+			Namespace:   configNamespace,
+			WebhookPort: webhookPort,
+			Host:        selfTestHost(webhookHost),
+			Metrics:     mtr,
+			Interval:    webhookSelfTestInterval,
+
+			APIServerUnreachable: watchdog.Unreachable,
+		}
+		if err := mgr.Add(selfTest); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "webhook-self-test")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("webhook-self-test", selfTest.ReadyCheck); err != nil {
+			logger.Error(err, "unable to set up ready check")
+			os.Exit(1)
+		}
+	}
+	if webhookRegistrationInterval > 0 {
+		registration := &controller.WebhookRegistrationCheck{
+			Reader:           rtClient,
+			Config:           store.Config,
+			ServiceName:      webhookServiceName,
+			ServiceNamespace: configNamespace,
+			WebhookPort:      webhookPort,
+			GetCertificate:   webhookServer.GetCertificate,
+			Interval:         webhookRegistrationInterval,
+
+			APIServerUnreachable: watchdog.Unreachable,
+		}
+		if err := mgr.Add(registration); err != nil {
+			logger.Error(err, "unable to add runnable", "runnable", "webhook-registration")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("webhook-registration", registration.ReadyCheck); err != nil {
+			logger.Error(err, "unable to set up ready check")
+			os.Exit(1)
+		}
+	}
+	if err := mgr.AddReadyzCheck("certificate",
+		certificate.ReadyChecker(webhookServer.GetCertificate, certificateDNSNames[0])); err != nil {
+		logger.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+
+	// the webhook isn't called before the namespaces, the nodes, and the
+	// configuration sources watched for reloads are cached
+	configInformers := map[client.Object]cache.Informers{
+		&corev1.ConfigMap{}: mgr.GetCache(),
+	}
+	for _, secrets := range configSecretCaches {
+		configInformers[&corev1.Secret{}] = secrets
+	}
+	if snatchConfigs {
+		configInformers[&snatchv1alpha1.SnatchConfig{}] = mgr.GetCache()
+	}
+	configSynced := make([]func() bool, 0, len(configInformers))
+	for obj, informers := range configInformers {
+		informer, err := informers.GetInformer(context.Background(), obj, cache.BlockUntilSynced(false))
+		if err != nil {
+			logger.Error(err, "unable to create configuration informer")
+			os.Exit(1)
+		}
+		configSynced = append(configSynced, informer.HasSynced)
+	}
+	if err := mgr.AddReadyzCheck("caches", health.CachesSyncedChecker(map[string]func() bool{
+		health.CacheNamespaces: namespaceInformer.HasSynced,
+		health.CacheNodes:      poolWatcher.Synced,
+		health.CacheConfig: func() bool {
+			for _, synced := range configSynced {
+				if !synced() {
+					return false
+				}
+			}
+			return true
+		},
+	})); err != nil {
+		logger.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
 
 	logger.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -236,3 +1609,323 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newRemoteClient creates the client of the cluster the kubeconfig points to,
+// the in-cluster client is used if no kubeconfig is given.
+func newRemoteClient(kubeconfig string, inCluster client.Client) (client.Client, error) {
+	if kubeconfig == "" {
+		return inCluster, nil
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(restConfig, client.Options{})
+}
+
+func mergePlanProfiles(defaults, overrides map[string]string) map[string]string {
+	result := maps.Clone(defaults)
+	maps.Copy(result, overrides)
+	return result
+}
+
+// updateCABundles patches the CA bundle into the MutatingWebhookConfiguration
+// and the conversion webhook of the SnatchConfig CustomResourceDefinition.
+func updateCABundles(ctx context.Context, c client.Client, webhookConfigName string, caBundle []byte,
+	rolloverGrace time.Duration) error {
+	updateCABundle := callback.BuildUpdateCABundle(ctx, c, callback.BuildUpdateCABundleOpts{
+		Name:          webhookConfigName,
+		CABundle:      caBundle,
+		FieldManager:  patchFieldManagerName,
+		RolloverGrace: rolloverGrace,
+	})
+	if err := callback.RetryUpdate(ctx, callback.DefaultBackoff, updateCABundle); err != nil {
+		return fmt.Errorf("unable to patch mutating webhook configuration: %w", err)
+	}
+
+	updateConversionCABundle := callback.BuildUpdateConversionCABundle(ctx, c, callback.BuildUpdateConversionCABundleOpts{
+		Name:          snatchConfigCRDName,
+		CABundle:      caBundle,
+		RolloverGrace: rolloverGrace,
+	})
+	// the SnatchConfig CRD is optional
+	if err := callback.RetryUpdate(ctx, callback.DefaultBackoff, updateConversionCABundle); err != nil &&
+		!apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to patch custom resource definition: %w", err)
+	}
+	return nil
+}
+
+func envOrDefault(name, defaultValue string) string {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// splitList splits a comma separated flag value, empty entries are dropped.
+func splitList(value string) []string {
+	var result []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// newAuditSink returns the audit sink of the value of --audit-sink, nil if empty.
+func newAuditSink(value string) (audit.Sink, error) {
+	switch value {
+	case "":
+		return nil, nil
+	case "stdout":
+		return audit.NewWriterSink(os.Stdout), nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("must be stdout or a http(s) URL")
+	}
+	return &audit.HTTPSink{URL: value, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// metricsOptions returns the options of the metrics server. Secure metrics are
+// served via HTTPS with a self-signed certificate, and every path requires a
+// token authorized for it, e.g. by the metrics-reader role.
+func metricsOptions(bindAddress string, secure bool, tlsOpts []func(*tls.Config)) metricsserver.Options {
+	opts := metricsserver.Options{
+		BindAddress:   bindAddress,
+		SecureServing: secure,
+		TLSOpts:       tlsOpts,
+	}
+	if secure {
+		opts.FilterProvider = filters.WithAuthenticationAndAuthorization
+	}
+	return opts
+}
+
+// diagnosticsHandlers returns the pprof and expvar handlers per path.
+func diagnosticsHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		"/debug/pprof/":        http.HandlerFunc(pprof.Index),
+		"/debug/pprof/cmdline": http.HandlerFunc(pprof.Cmdline),
+		"/debug/pprof/profile": http.HandlerFunc(pprof.Profile),
+		"/debug/pprof/symbol":  http.HandlerFunc(pprof.Symbol),
+		"/debug/pprof/trace":   http.HandlerFunc(pprof.Trace),
+		"/debug/vars":          expvar.Handler(),
+	}
+}
+
+// apiServerProbe returns a probe of the /readyz endpoint of the API server.
+func apiServerProbe(restConfig *rest.Config) (func(ctx context.Context) error, error) {
+	discoveryClient, err := clientdiscovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return discoveryClient.RESTClient().Get().AbsPath("/readyz").Do(ctx).Error()
+	}, nil
+}
+
+// authorizedHandler protects the handler with the authentication and
+// authorization filter of the metrics server.
+func authorizedHandler(restConfig *rest.Config, handler http.Handler) (http.Handler, error) {
+	httpClient, err := rest.HTTPClientFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := filters.WithAuthenticationAndAuthorization(restConfig, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return filter(ctrl.Log.WithName("config-handler"), handler)
+}
+
+// checkPermissions logs the permissions the Roles of the subsystems lack, and
+// records them in an event on the target if any are missing.
+func checkPermissions(ctx context.Context, c client.Client, recorder record.EventRecorder,
+	target *corev1.ObjectReference, namespace string, subsystems []string) {
+	missing, err := rbac.Missing(ctx, c, namespace, subsystems...)
+	if err != nil {
+		// the permissions are not verified without the reviews
+		logger.Error(err, "unable to verify permissions")
+		return
+	}
+	var roles []string
+	for _, subsystem := range subsystems {
+		if len(missing[subsystem]) == 0 {
+			continue
+		}
+		permissions := make([]string, 0, len(missing[subsystem]))
+		for _, permission := range missing[subsystem] {
+			permissions = append(permissions, permission.String())
+		}
+		role := subsystem + "-role"
+		logger.Error(errors.New("missing permissions"), "role lacks permissions", "subsystem", subsystem,
+			"role", role, "permissions", permissions)
+		roles = append(roles, fmt.Sprintf("%s: %s", role, strings.Join(permissions, ", ")))
+	}
+	if len(roles) > 0 && target != nil {
+		recorder.Event(target, corev1.EventTypeWarning, eventReasonPermissionsMissing,
+			"permissions missing, "+strings.Join(roles, "; "))
+	}
+}
+
+// checkPodSecurity warns if kim-snatch runs in violation of the restricted
+// profile of the Pod Security Standards, it starts anyway.
+func checkPodSecurity(ctx context.Context, c client.Reader, recorder record.EventRecorder,
+	target *corev1.ObjectReference) {
+	violations := podsecurity.ProcessViolations()
+	if target != nil {
+		var pod corev1.Pod
+		if err := c.Get(ctx, client.ObjectKey{Namespace: target.Namespace, Name: target.Name}, &pod); err != nil {
+			logger.Error(err, "unable to verify pod security", "pod", target.Name)
+		} else {
+			violations = append(violations, podsecurity.Violations(&pod)...)
+		}
+	}
+	if len(violations) == 0 {
+		return
+	}
+	logger.Error(errors.New("pod security violations"), "pod violates the restricted Pod Security Standard",
+		"violations", violations)
+	if target != nil {
+		recorder.Event(target, corev1.EventTypeWarning, eventReasonPodSecurityViolated,
+			"pod violates the restricted Pod Security Standard: "+strings.Join(violations, "; "))
+	}
+}
+
+// parseBindAddress splits the host:port address a server binds to, the port is
+// required.
+func parseBindAddress(address string) (string, int, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, err
+	}
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 1 || number > 65535 {
+		return "", 0, fmt.Errorf("invalid port %q", port)
+	}
+	return host, number, nil
+}
+
+// loopbackHost returns true if the host of a bind address is localhost or a
+// loopback IP, an empty host binds to all addresses.
+func loopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// selfTestHost returns the host the self-test reaches the webhook server at,
+// localhost if the server binds to all addresses.
+func selfTestHost(host string) string {
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		return ""
+	}
+	return host
+}
+
+// podReference returns the reference of the Pod kim-snatch runs in, or nil if
+// the Pod name is not known.
+func podReference(namespace string) *corev1.ObjectReference {
+	name := os.Getenv("POD_NAME")
+	if name == "" {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  namespace,
+		Name:       name,
+	}
+}
+
+// cacheOptions restricts the cache of the manager to the objects kim-snatch
+// actually watches, and to the fields it reads: the managed fields are dropped
+// from all objects. The namespaces are cached as metadata only, the kinds
+// kim-snatch only writes, such as events, aren't cached at all, as the client
+// of the manager reads from the API server. Secrets are never cached by the
+// manager, each Secret kim-snatch watches has a cache of its own, see secretCache.
+func cacheOptions(configNamespace, webhookConfigName, poolLabelKey string, snatchConfigs bool) cache.Options {
+	poolNodes, err := labels.Parse(poolLabelKey)
+	if err != nil {
+		// the label key is validated on startup
+		panic(err)
+	}
+
+	opts := cache.Options{
+		DefaultTransform: cache.TransformStripManagedFields(),
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.ConfigMap{}: {
+				Namespaces: map[string]cache.Config{configNamespace: {}},
+			},
+			// thousands of nodes are cached on large clusters, they are trimmed
+			&corev1.Node{}: {
+				Label:     poolNodes,
+				Transform: pool.TrimNode,
+			},
+			// only the remediated workloads are watched for drift
+			&appsv1.Deployment{}: {
+				Label: labels.SelectorFromSet(labels.Set{controller.LabelRemediated: "true"}),
+			},
+			&appsv1.StatefulSet{}: {
+				Label: labels.SelectorFromSet(labels.Set{controller.LabelRemediated: "true"}),
+			},
+			// only pending pods are evaluated for placement feedback
+			&corev1.Pod{}: {
+				Field: fields.OneTermEqualSelector("status.phase", string(corev1.PodPending)),
+			},
+			&admissionregistration.MutatingWebhookConfiguration{}: {
+				Field: fields.OneTermEqualSelector("metadata.name", webhookConfigName),
+			},
+		},
+	}
+	// the cache can't be created with a kind the API server doesn't serve
+	if snatchConfigs {
+		opts.ByObject[&snatchv1alpha1.SnatchConfig{}] = cache.ByObject{
+			Namespaces: map[string]cache.Config{configNamespace: {}},
+		}
+	}
+	return opts
+}
+
+// secretCache adds a cache holding the Secret only to the manager, it lists and
+// watches the Secret by name, so kim-snatch neither reads nor keeps the data of
+// the other Secrets of the namespace.
+func secretCache(mgr ctrl.Manager, key client.ObjectKey) (cache.Cache, error) {
+	secrets, err := cache.New(mgr.GetConfig(), cache.Options{
+		HTTPClient:       mgr.GetHTTPClient(),
+		Scheme:           mgr.GetScheme(),
+		Mapper:           mgr.GetRESTMapper(),
+		DefaultTransform: cache.TransformStripManagedFields(),
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Secret{}: {
+				Namespaces: map[string]cache.Config{key.Namespace: {}},
+				Field:      fields.OneTermEqualSelector("metadata.name", key.Name),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secrets, mgr.Add(secrets)
+}
+
+// snatchConfigsInstalled returns false if the API server doesn't serve the
+// SnatchConfigs, e.g. in a minimal installation without their CRD.
+func snatchConfigsInstalled(c client.Client) (bool, error) {
+	_, err := c.RESTMapper().RESTMapping(snatchv1alpha1.GroupVersion.WithKind("SnatchConfig").GroupKind(),
+		snatchv1alpha1.GroupVersion.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
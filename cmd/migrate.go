@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/rules"
+	webhookcorev1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// migrateCommand is the first argument running kim-snatch as a one-shot
+// migration instead of the webhook server.
+const migrateCommand = "migrate"
+
+// runMigrate adds the node affinity of the Kyma worker pool to the existing
+// workloads of the Kyma namespaces once and optionally restarts them, for the
+// adoption of kim-snatch on existing clusters.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet(migrateCommand, flag.ContinueOnError)
+	kubeconfig := fs.String("kubeconfig", "",
+		"The kubeconfig of the cluster to migrate, the in-cluster configuration is used if empty.")
+	configNamespace := fs.String("config-namespace", envOrDefault("POD_NAMESPACE", defaultConfigNamespace),
+		"The namespace of the configuration ConfigMap and SnatchConfigs.")
+	configMapName := fs.String("config-map-name", "kim-snatch-config",
+		"The name of the ConfigMap the configuration is read from.")
+	restart := fs.Bool("restart", false,
+		"If set, the workloads of the Kyma namespaces are restarted one after another after they were patched.")
+	maxConcurrent := fs.Int("rollout-max-concurrent", 1,
+		"The maximum number of workloads restarted at the same time.")
+	checkInterval := fs.Duration("rollout-check-interval", 15*time.Second,
+		"The interval in which the progress of the restarted workloads is checked.")
+	timeout := fs.Duration("timeout", time.Hour,
+		"The time after which the migration is aborted.")
+	config.BindFlags(fs)
+	fs.Var(featuregate.DefaultFeatureGate, flagFeatureGates, "A set of key=value pairs that describe feature gates "+
+		"for experimental features, e.g. Enforcement=true overrides the pools selected by the workload owners.")
+	opts := zap.Options{Development: true}
+	opts.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		return fmt.Errorf("unable to create rest configuration: %w", err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctrl.SetupSignalHandler(), *timeout)
+	defer cancel()
+
+	// the SnatchConfig CRD is optional like for the webhook server
+	snatchConfigs, err := snatchConfigsInstalled(c)
+	if err != nil {
+		return fmt.Errorf("unable to discover the SnatchConfig API: %w", err)
+	}
+	sources := []config.LoaderSource{
+		config.WithSource(config.ConfigMapSource(c, client.ObjectKey{Namespace: *configNamespace, Name: *configMapName})),
+	}
+	if snatchConfigs {
+		sources = append(sources, config.WithCompositeSource(config.SnatchConfigsSource(c, *configNamespace)))
+	}
+	sources = append(sources,
+		config.WithSource(config.EnvSource()),
+		config.WithSource(config.FlagSource(fs)),
+	)
+	effective, err := config.NewLoader(sources...).Load(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if errs := config.Validate(effective.Config, featuregate.DefaultFeatureGate); len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errs.ToAggregate())
+	}
+	cfg := effective.Config
+
+	logger.Info("migrating workloads", "pool", cfg.KymaWorkerPoolName, "mode", cfg.AffinityMode, "restart", *restart)
+	if err := (&controller.WorkloadRemediator{
+		Client:           c,
+		Namespaces:       c,
+		Config:           func() config.Config { return cfg },
+		Gate:             featuregate.DefaultFeatureGate,
+		ResolvePlacement: webhookcorev1.NamespacePlacementResolver(c),
+		Rules: func() *rules.Rules {
+			return effective.Rules
+		},
+	}).Remediate(ctx); err != nil {
+		return fmt.Errorf("migration of workloads not completed: %w", err)
+	}
+
+	if !*restart {
+		return nil
+	}
+
+	orchestrator := &controller.RolloutOrchestrator{
+		Client:        c,
+		Namespaces:    c,
+		Config:        func() config.Config { return cfg },
+		MaxConcurrent: *maxConcurrent,
+	}
+	if err := orchestrator.Apply(ctx, cfg); err != nil {
+		return err
+	}
+	orchestrator.Schedule()
+	if err := wait.PollUntilContextCancel(ctx, *checkInterval, true, func(ctx context.Context) (bool, error) {
+		orchestrator.Check(ctx)
+		return orchestrator.Done(), nil
+	}); err != nil {
+		return fmt.Errorf("restart of workloads not completed: %w", err)
+	}
+	if orchestrator.Stalled() {
+		return errors.New("restart of workloads aborted, a rollout exceeded its progress deadline")
+	}
+	if err := orchestrator.Err(); err != nil {
+		return fmt.Errorf("restart of workloads not completed: %w", err)
+	}
+	return nil
+}
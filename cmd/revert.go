@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// revertCommand is the first argument running kim-snatch as a one-shot removal
+// of the node affinity it added to the workloads instead of the webhook server.
+const revertCommand = "revert"
+
+// runRevert removes the node affinity kim-snatch added to the pod templates of
+// the workloads once, e.g. on the uninstall of the module.
+func runRevert(args []string) error {
+	fs := flag.NewFlagSet(revertCommand, flag.ContinueOnError)
+	kubeconfig := fs.String("kubeconfig", "",
+		"The kubeconfig of the cluster to revert, the in-cluster configuration is used if empty.")
+	timeout := fs.Duration("timeout", 10*time.Minute,
+		"The time after which the revert is aborted.")
+	opts := zap.Options{Development: true}
+	opts.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		return fmt.Errorf("unable to create rest configuration: %w", err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctrl.SetupSignalHandler(), *timeout)
+	defer cancel()
+
+	reverted, err := (&controller.WorkloadReverter{Client: c}).Revert(ctx)
+	logger.Info("workloads reverted", "workloads", reverted)
+	return err
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// prometheusrule writes the PrometheusRule of the default alerts of kim-snatch
+// to stdout.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+func main() {
+	opts := metrics.DefaultAlertOptions()
+	flag.Float64Var(&opts.ErrorRatio, "error-ratio", opts.ErrorRatio,
+		"The ratio of failed admission requests of Pods alerted on.")
+	flag.DurationVar(&opts.CertificateExpiry, "certificate-expiry", opts.CertificateExpiry,
+		"The remaining validity of a certificate of the webhook server alerted on.")
+	flag.IntVar(&opts.UtilizationPercent, "utilization-percent", opts.UtilizationPercent,
+		"The utilization of the kyma worker pool in percent alerted on.")
+	flag.DurationVar(&opts.For, "for", opts.For, "The time a condition must hold until it is alerted on.")
+	flag.Float64Var(&opts.SLOObjective, "slo-objective", opts.SLOObjective,
+		"The ratio of admission requests of Pods kim-snatch must mutate or pass within the SLO latency.")
+	flag.DurationVar(&opts.SLOLatency, "slo-latency", opts.SLOLatency,
+		"The latency of the SLO, it must match the --admission-slo-latency of kim-snatch.")
+	flag.Parse()
+
+	if err := metrics.WritePrometheusRule(os.Stdout, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
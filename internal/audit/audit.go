@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultBufferSize is the default number of records buffered until they are written
+	DefaultBufferSize = 1000
+	// DefaultBatchSize is the default number of records written at once
+	DefaultBatchSize = 100
+	// DefaultFlushInterval is the default interval the buffered records are written in
+	DefaultFlushInterval = time.Second
+
+	// flushTimeout bounds the time the remaining records are written on shutdown
+	flushTimeout = 5 * time.Second
+)
+
+// Record is the audit record of an admission request of a pod.
+type Record struct {
+	// Sequence is the position of the record since kim-snatch started
+	Sequence uint64 `json:"sequence"`
+	// Time the admission request was handled at
+	Time time.Time `json:"time"`
+	// UID of the admission request
+	UID types.UID `json:"uid"`
+	// Namespace of the pod
+	Namespace string `json:"namespace"`
+	// Pod is the name of the pod, or its generateName if it has no name yet
+	Pod string `json:"pod"`
+	// Result is one of the admission results of the metrics package
+	Result string `json:"result"`
+	// Reason is the affinity mode of mutated pods or the reason of skipped ones or of the failure
+	Reason string `json:"reason,omitempty"`
+	// Pool is the worker pool the node affinity of mutated pods selects
+	Pool string `json:"pool,omitempty"`
+	// Patch summarizes the patch of the pod as operation and path, e.g. add /spec/affinity
+	Patch []string `json:"patch,omitempty"`
+	// LatencySeconds is the time the admission request took
+	LatencySeconds float64 `json:"latencySeconds"`
+	// ConfigVersion is the hash of the configuration the request was handled with
+	ConfigVersion string `json:"configVersion,omitempty"`
+	// Previous is the hash of the previous record, empty for the first one
+	Previous string `json:"previous"`
+	// Hash is the SHA-256 of the record without the hash and the signature, it
+	// chains the records
+	Hash string `json:"hash,omitempty"`
+	// SignatureAlgorithm is the algorithm of the signature, empty if the record isn't signed
+	SignatureAlgorithm string `json:"signatureAlgorithm,omitempty"`
+	// Signature is the base64 encoded signature of the hash
+	Signature string `json:"signature,omitempty"`
+}
+
+// hash returns the hash of the record without its hash and its signature.
+func (r Record) hash() string {
+	r.Hash, r.Signature = "", ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		// Record consists of plain values only, it can always be marshalled
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify returns an error if the records are not an unmodified and complete
+// part of the chain of records.
+func Verify(records []Record) error {
+	for i, r := range records {
+		if r.Hash != r.hash() {
+			return fmt.Errorf("record %d modified", r.Sequence)
+		}
+		if i > 0 && (r.Previous != records[i-1].Hash || r.Sequence != records[i-1].Sequence+1) {
+			return fmt.Errorf("record %d doesn't follow record %d", r.Sequence, records[i-1].Sequence)
+		}
+	}
+	return nil
+}
+
+// Sink writes batches of records.
+type Sink interface {
+	Write(ctx context.Context, records []Record) error
+}
+
+// Options are the options of the Logger.
+type Options struct {
+	// BufferSize is the number of records buffered until they are written,
+	// records are dropped while the buffer is full
+	BufferSize int
+	// BatchSize is the number of records written at once
+	BatchSize int
+	// FlushInterval is the interval the buffered records are written in
+	FlushInterval time.Duration
+	// ConfigVersion returns the version of the current configuration, optional
+	ConfigVersion func() string
+	// Metrics counts the written and dropped records, optional
+	Metrics metrics.Metrics
+	// Signer signs the records, optional, it is replaced with SetSigner
+	Signer Signer
+}
+
+// Logger writes the audit records to the sink in the background. Records are
+// never blocking the admission requests, they are dropped while the sink can't
+// keep up. The records are chained by their hashes, so removed or modified
+// records are detected, and signed if a Signer is set, so the records are
+// known to be written by kim-snatch.
+type Logger struct {
+	sink    Sink
+	opts    Options
+	records chan Record
+	signer  atomic.Pointer[Signer]
+
+	// sequence and last are only accessed by Start
+	sequence uint64
+	last     string
+}
+
+// NewLogger returns a Logger writing the records to the sink.
+func NewLogger(sink Sink, opts Options) *Logger {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	l := &Logger{sink: sink, opts: opts, records: make(chan Record, opts.BufferSize)}
+	l.SetSigner(opts.Signer)
+	return l
+}
+
+// SetSigner replaces the signer of the records, e.g. after the key was
+// rotated. The records chained afterwards are signed by the signer, nil stops
+// signing the records.
+func (l *Logger) SetSigner(signer Signer) {
+	if signer == nil {
+		l.signer.Store(nil)
+		return
+	}
+	l.signer.Store(&signer)
+}
+
+// Record buffers the record, the record is dropped if the buffer is full.
+func (l *Logger) Record(r Record) {
+	if l.opts.ConfigVersion != nil {
+		r.ConfigVersion = l.opts.ConfigVersion()
+	}
+	select {
+	case l.records <- r:
+	default:
+		l.count(metrics.AuditResultDropped, 1)
+	}
+}
+
+// Start writes the buffered records until the context is done, the remaining
+// records are written before it returns.
+func (l *Logger) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("audit")
+	ticker := time.NewTicker(l.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []Record
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.sink.Write(ctx, batch); err != nil {
+			// the batch is kept and written again, the buffer fills up meanwhile
+			logger.Error(err, "unable to write audit records", "records", len(batch))
+			return
+		}
+		l.count(metrics.AuditResultWritten, len(batch))
+		batch = nil
+	}
+
+	for {
+		// no records are taken from the buffer while a full batch can't be written
+		records := l.records
+		if len(batch) >= l.opts.BatchSize {
+			records = nil
+		}
+
+		select {
+		case <-ctx.Done():
+		drain:
+			for len(batch) < l.opts.BufferSize+l.opts.BatchSize {
+				select {
+				case r := <-l.records:
+					batch = append(batch, l.chain(r))
+				default:
+					break drain
+				}
+			}
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+			flush(flushCtx)
+			l.count(metrics.AuditResultDropped, len(batch))
+			return nil
+		case r := <-records:
+			batch = append(batch, l.chain(r))
+			if len(batch) >= l.opts.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every replica
+// writes the records of the admission requests it handled.
+func (l *Logger) NeedLeaderElection() bool {
+	return false
+}
+
+// chain links the record to the previous one.
+func (l *Logger) chain(r Record) Record {
+	l.sequence++
+	r.Sequence = l.sequence
+	r.Previous = l.last
+	signer := l.signer.Load()
+	if signer != nil {
+		// the algorithm is part of the hash, it can't be replaced
+		r.SignatureAlgorithm = (*signer).Algorithm()
+	}
+	r.Hash = r.hash()
+	if signer != nil {
+		r.Signature = base64.StdEncoding.EncodeToString((*signer).Sign(r.Hash))
+	}
+	l.last = r.Hash
+	return r
+}
+
+func (l *Logger) count(result string, records int) {
+	if l.opts.Metrics != nil && records > 0 {
+		l.opts.Metrics.AddAuditRecords(result, records)
+	}
+}
@@ -0,0 +1,185 @@
+package audit_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/audit"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testSink collects the written records, it fails while failing is set.
+type testSink struct {
+	mu       sync.Mutex
+	records  []audit.Record
+	failing  bool
+	attempts int
+}
+
+func (s *testSink) Write(_ context.Context, records []audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.failing {
+		return errors.New("test")
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *testSink) written() []audit.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]audit.Record(nil), s.records...)
+}
+
+func (s *testSink) attempted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts > 0
+}
+
+func (s *testSink) setFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+func testRecord(pod string) audit.Record {
+	return audit.Record{Namespace: "test", Pod: pod, Result: metrics.AdmissionResultMutated}
+}
+
+func startLogger(t *testing.T, logger *audit.Logger) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, logger.Start(ctx))
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func Test_Logger(t *testing.T) {
+	sink := &testSink{}
+	logger := audit.NewLogger(sink, audit.Options{
+		BatchSize:     2,
+		FlushInterval: 10 * time.Millisecond,
+		ConfigVersion: func() string { return "v1" },
+	})
+	stop := startLogger(t, logger)
+
+	logger.Record(testRecord("a"))
+	logger.Record(testRecord("b"))
+	logger.Record(testRecord("c"))
+	stop()
+
+	records := sink.written()
+	require.Len(t, records, 3)
+	for i, r := range records {
+		assert.Equal(t, uint64(i+1), r.Sequence)
+		assert.Equal(t, "v1", r.ConfigVersion)
+	}
+	assert.Empty(t, records[0].Previous)
+	require.NoError(t, audit.Verify(records))
+
+	modified := append([]audit.Record(nil), records...)
+	modified[1].Pool = "other"
+	assert.EqualError(t, audit.Verify(modified), "record 2 modified")
+	assert.EqualError(t, audit.Verify([]audit.Record{records[0], records[2]}), "record 3 doesn't follow record 1")
+}
+
+func Test_Logger_backpressure(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("AddAuditRecords", metrics.AuditResultDropped, 1).Once()
+	mtr.On("AddAuditRecords", metrics.AuditResultWritten, mock.AnythingOfType("int"))
+
+	sink := &testSink{failing: true}
+	logger := audit.NewLogger(sink, audit.Options{
+		BufferSize:    1,
+		BatchSize:     1,
+		FlushInterval: 10 * time.Millisecond,
+		Metrics:       mtr,
+	})
+	stop := startLogger(t, logger)
+
+	// the first record is taken into the batch that can't be written, the
+	// second one fills the buffer and the third one is dropped
+	logger.Record(testRecord("a"))
+	require.Eventually(t, sink.attempted, time.Second, time.Millisecond)
+	logger.Record(testRecord("b"))
+	logger.Record(testRecord("c"))
+
+	// the records are written again once the sink recovered
+	sink.setFailing(false)
+	require.Eventually(t, func() bool { return len(sink.written()) == 2 }, time.Second, 10*time.Millisecond)
+	stop()
+
+	records := sink.written()
+	assert.Equal(t, "a", records[0].Pod)
+	assert.Equal(t, "b", records[1].Pod)
+	require.NoError(t, audit.Verify(records))
+}
+
+func Test_WriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, audit.NewWriterSink(&buf).Write(context.Background(),
+		[]audit.Record{testRecord("a"), testRecord("b")}))
+
+	var lines []audit.Record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r audit.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		lines = append(lines, r)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "b", lines[1].Pod)
+}
+
+func Test_HTTPSink(t *testing.T) {
+	var status = http.StatusNoContent
+	var received []audit.Record
+	token := "s3cr3t"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer "+token, r.Header.Get("Authorization"))
+		decoder := json.NewDecoder(r.Body)
+		for decoder.More() {
+			var record audit.Record
+			assert.NoError(t, decoder.Decode(&record))
+			received = append(received, record)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := &audit.HTTPSink{URL: server.URL, Token: func() string { return token }}
+	require.NoError(t, sink.Write(context.Background(), []audit.Record{testRecord("a"), testRecord("b")}))
+	require.Len(t, received, 2)
+	assert.Equal(t, "a", received[0].Pod)
+
+	// the rotated token is sent with the next batch
+	token = "r0tat3d"
+	require.NoError(t, sink.Write(context.Background(), []audit.Record{testRecord("c")}))
+	require.Len(t, received, 3)
+
+	status = http.StatusServiceUnavailable
+	assert.EqualError(t, sink.Write(context.Background(), []audit.Record{testRecord("c")}),
+		"audit sink responded with 503 Service Unavailable")
+}
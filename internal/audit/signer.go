@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Signature algorithms of the records.
+const (
+	// AlgorithmHMACSHA256 signs the records with a key shared with the verifiers
+	AlgorithmHMACSHA256 = "hmac-sha256"
+	// AlgorithmEd25519 signs the records with a private key, the verifiers only
+	// need the public key
+	AlgorithmEd25519 = "ed25519"
+)
+
+// Sensitive settings holding the signing key, one of them must be set.
+const (
+	// SecretKeyHMAC holds the key of AlgorithmHMACSHA256
+	SecretKeyHMAC = "audit-signing-hmac-key"
+	// SecretKeyEd25519 holds the PEM encoded PKCS #8 private key of AlgorithmEd25519
+	SecretKeyEd25519 = "audit-signing-ed25519-key"
+)
+
+// minHMACKeySize is the size of the output of SHA-256, shorter keys weaken the HMAC.
+const minHMACKeySize = sha256.Size
+
+// Signer signs the hashes of the records.
+type Signer interface {
+	// Algorithm is the name of the signature algorithm
+	Algorithm() string
+	// Sign returns the signature of the hash of a record
+	Sign(hash string) []byte
+}
+
+// VerifyFunc returns true if the signature of the hash of a record is valid.
+type VerifyFunc func(hash string, signature []byte) bool
+
+type hmacSigner struct {
+	key []byte
+}
+
+// NewHMACSigner returns a Signer signing with HMAC-SHA256.
+func NewHMACSigner(key []byte) Signer {
+	return &hmacSigner{key: key}
+}
+
+func (s *hmacSigner) Algorithm() string {
+	return AlgorithmHMACSHA256
+}
+
+func (s *hmacSigner) Sign(hash string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(hash))
+	return mac.Sum(nil)
+}
+
+// HMACVerifier returns the VerifyFunc of the records signed by NewHMACSigner.
+func HMACVerifier(key []byte) VerifyFunc {
+	signer := NewHMACSigner(key)
+	return func(hash string, signature []byte) bool {
+		return hmac.Equal(signer.Sign(hash), signature)
+	}
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewEd25519Signer returns a Signer signing with Ed25519.
+func NewEd25519Signer(key ed25519.PrivateKey) Signer {
+	return &ed25519Signer{key: key}
+}
+
+func (s *ed25519Signer) Algorithm() string {
+	return AlgorithmEd25519
+}
+
+func (s *ed25519Signer) Sign(hash string) []byte {
+	return ed25519.Sign(s.key, []byte(hash))
+}
+
+// Ed25519Verifier returns the VerifyFunc of the records signed by NewEd25519Signer.
+func Ed25519Verifier(key ed25519.PublicKey) VerifyFunc {
+	return func(hash string, signature []byte) bool {
+		return ed25519.Verify(key, []byte(hash), signature)
+	}
+}
+
+// SignerFromSecret returns the Signer of the key in the sensitive settings, the
+// Ed25519 key takes precedence.
+func SignerFromSecret(data map[string][]byte) (Signer, error) {
+	if data := data[SecretKeyEd25519]; len(data) > 0 {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s holds no PEM encoded key", SecretKeyEd25519)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", SecretKeyEd25519, err)
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s holds a %T, not an Ed25519 key", SecretKeyEd25519, key)
+		}
+		return NewEd25519Signer(privateKey), nil
+	}
+	if key := data[SecretKeyHMAC]; len(key) > 0 {
+		if len(key) < minHMACKeySize {
+			return nil, fmt.Errorf("%s must hold at least %d bytes, got %d", SecretKeyHMAC, minHMACKeySize, len(key))
+		}
+		return NewHMACSigner(key), nil
+	}
+	return nil, fmt.Errorf("neither %s nor %s set", SecretKeyEd25519, SecretKeyHMAC)
+}
+
+// VerifySignatures returns an error if the records are not an unmodified and
+// complete part of the chain of records, see Verify, or if a record isn't
+// signed with the algorithm or its signature is invalid.
+func VerifySignatures(records []Record, algorithm string, verify VerifyFunc) error {
+	if err := Verify(records); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if r.SignatureAlgorithm != algorithm {
+			return fmt.Errorf("record %d signed with %q", r.Sequence, r.SignatureAlgorithm)
+		}
+		signature, err := base64.StdEncoding.DecodeString(r.Signature)
+		if err != nil || !verify(r.Hash, signature) {
+			return errors.Join(fmt.Errorf("record %d signature invalid", r.Sequence), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package audit_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signedRecords(t *testing.T, signer audit.Signer) []audit.Record {
+	sink := &testSink{}
+	logger := audit.NewLogger(sink, audit.Options{FlushInterval: 10 * time.Millisecond, Signer: signer})
+	stop := startLogger(t, logger)
+	logger.Record(testRecord("a"))
+	logger.Record(testRecord("b"))
+	stop()
+
+	records := sink.written()
+	require.Len(t, records, 2)
+	return records
+}
+
+func Test_Logger_HMAC(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	records := signedRecords(t, audit.NewHMACSigner(key))
+	assert.Equal(t, audit.AlgorithmHMACSHA256, records[0].SignatureAlgorithm)
+	require.NoError(t, audit.VerifySignatures(records, audit.AlgorithmHMACSHA256, audit.HMACVerifier(key)))
+
+	other := bytes.Repeat([]byte("o"), 32)
+	assert.EqualError(t, audit.VerifySignatures(records, audit.AlgorithmHMACSHA256, audit.HMACVerifier(other)),
+		"record 1 signature invalid")
+	assert.EqualError(t, audit.VerifySignatures(records, audit.AlgorithmEd25519, audit.HMACVerifier(key)),
+		`record 1 signed with "hmac-sha256"`)
+}
+
+func Test_Logger_Ed25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	records := signedRecords(t, audit.NewEd25519Signer(private))
+	require.NoError(t, audit.VerifySignatures(records, audit.AlgorithmEd25519, audit.Ed25519Verifier(public)))
+
+	modified := append([]audit.Record(nil), records...)
+	modified[1].Pool = "other"
+	assert.EqualError(t, audit.VerifySignatures(modified, audit.AlgorithmEd25519, audit.Ed25519Verifier(public)),
+		"record 2 modified")
+
+	// records chained the same way, but signed with another key
+	_, other, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	forged := signedRecords(t, audit.NewEd25519Signer(other))
+	assert.EqualError(t, audit.VerifySignatures(forged, audit.AlgorithmEd25519, audit.Ed25519Verifier(public)),
+		"record 1 signature invalid")
+}
+
+func Test_Logger_SetSigner(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte("o"), 32), bytes.Repeat([]byte("n"), 32)
+	sink := &testSink{}
+	logger := audit.NewLogger(sink, audit.Options{BatchSize: 1, Signer: audit.NewHMACSigner(oldKey)})
+	stop := startLogger(t, logger)
+	logger.Record(testRecord("a"))
+	require.Eventually(t, func() bool { return len(sink.written()) == 1 }, time.Second, time.Millisecond)
+
+	// the records chained after the rotation are signed with the new key
+	logger.SetSigner(audit.NewHMACSigner(newKey))
+	logger.Record(testRecord("b"))
+	stop()
+
+	records := sink.written()
+	require.Len(t, records, 2)
+	require.NoError(t, audit.Verify(records))
+	assert.NoError(t, audit.VerifySignatures(records[:1], audit.AlgorithmHMACSHA256, audit.HMACVerifier(oldKey)))
+	assert.NoError(t, audit.VerifySignatures(records[1:], audit.AlgorithmHMACSHA256, audit.HMACVerifier(newKey)))
+}
+
+func Test_SignerFromSecret(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	ed25519PEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	signer, err := audit.SignerFromSecret(map[string][]byte{
+		audit.SecretKeyEd25519: ed25519PEM,
+		audit.SecretKeyHMAC:    bytes.Repeat([]byte("k"), 32),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, audit.AlgorithmEd25519, signer.Algorithm())
+
+	signer, err = audit.SignerFromSecret(map[string][]byte{audit.SecretKeyHMAC: bytes.Repeat([]byte("k"), 32)})
+	require.NoError(t, err)
+	assert.Equal(t, audit.AlgorithmHMACSHA256, signer.Algorithm())
+
+	_, err = audit.SignerFromSecret(map[string][]byte{audit.SecretKeyHMAC: []byte("short")})
+	assert.EqualError(t, err, "audit-signing-hmac-key must hold at least 32 bytes, got 5")
+	_, err = audit.SignerFromSecret(map[string][]byte{audit.SecretKeyEd25519: []byte("garbage")})
+	assert.EqualError(t, err, "audit-signing-ed25519-key holds no PEM encoded key")
+	_, err = audit.SignerFromSecret(nil)
+	assert.EqualError(t, err, "neither audit-signing-ed25519-key nor audit-signing-hmac-key set")
+}
@@ -0,0 +1,145 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+const (
+	// DefaultFailures is the default number of consecutive failures tripping a Breaker
+	DefaultFailures = 5
+	// DefaultCooldown is the default time a Breaker stays open until it lets a probe pass
+	DefaultCooldown = 30 * time.Second
+)
+
+// Names of the dependencies of the admission requests.
+const (
+	// DependencyNamespaces is the namespace cache the placement overrides are read from
+	DependencyNamespaces = "namespaces"
+	// DependencyNodes is the node cache the state of the kyma worker pool is derived from
+	DependencyNodes = "nodes"
+)
+
+// Options are the options of the Breaker.
+type Options struct {
+	// Dependency is the name of the dependency the Breaker protects
+	Dependency string
+	// Failures is the number of consecutive failures tripping the Breaker, the
+	// Breaker never trips if not positive
+	Failures int
+	// Cooldown is the time the Breaker stays open until it lets a probe pass
+	Cooldown time.Duration
+	// Synced returns false while the cache of the dependency hasn't synced, the
+	// Breaker is open meanwhile, optional
+	Synced func() bool
+	// Metrics exposes the state of the Breaker, optional
+	Metrics metrics.Metrics
+}
+
+// Breaker stops the lookups of a dependency that is unsynced or consistently
+// failing, so the admission requests don't wait on a degraded dependency. It
+// trips after the configured number of consecutive failures and stays open for
+// the cooldown. Then it lets a single probe pass, whose result closes the
+// Breaker or opens it for another cooldown. A nil Breaker is always closed.
+type Breaker struct {
+	opts Options
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+	// unsynced is set while the Breaker is open because the cache hasn't synced
+	unsynced bool
+}
+
+// New returns a closed Breaker.
+func New(opts Options) *Breaker {
+	b := &Breaker{opts: opts}
+	b.setState(metrics.BreakerStateClosed)
+	return b
+}
+
+// Allow returns true if the dependency may be looked up, the caller reports
+// the result of the lookup.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.opts.Synced != nil && !b.opts.Synced() {
+		b.unsynced = true
+		b.setState(metrics.BreakerStateOpen)
+		return false
+	}
+	if b.unsynced {
+		// the synced cache is healthy
+		b.unsynced = false
+		b.failures = 0
+		b.setState(metrics.BreakerStateClosed)
+	}
+	switch b.state {
+	case metrics.BreakerStateOpen:
+		if time.Since(b.openedAt) < b.opts.Cooldown {
+			return false
+		}
+		b.setState(metrics.BreakerStateHalfOpen)
+		b.probing = true
+		return true
+	case metrics.BreakerStateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Report records the result of a lookup of the dependency.
+func (b *Breaker) Report(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.setState(metrics.BreakerStateClosed)
+		return
+	}
+	b.failures++
+	if b.opts.Failures <= 0 || b.unsynced {
+		return
+	}
+	if b.state == metrics.BreakerStateHalfOpen || b.failures >= b.opts.Failures {
+		b.setState(metrics.BreakerStateOpen)
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the state of the Breaker, one of the breaker states of the
+// metrics package.
+func (b *Breaker) State() string {
+	if b == nil {
+		return metrics.BreakerStateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.opts.Metrics != nil {
+		b.opts.Metrics.SetDependencyBreaker(b.opts.Dependency, state)
+	}
+}
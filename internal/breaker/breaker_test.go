@@ -0,0 +1,80 @@
+package breaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/breaker"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Breaker(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetDependencyBreaker", breaker.DependencyNamespaces, metrics.BreakerStateClosed).Twice()
+	mtr.On("SetDependencyBreaker", breaker.DependencyNamespaces, metrics.BreakerStateOpen).Twice()
+	mtr.On("SetDependencyBreaker", breaker.DependencyNamespaces, metrics.BreakerStateHalfOpen).Twice()
+
+	errLookup := errors.New("unable to get namespace")
+	b := breaker.New(breaker.Options{
+		Dependency: breaker.DependencyNamespaces,
+		Failures:   2,
+		Cooldown:   20 * time.Millisecond,
+		Metrics:    mtr,
+	})
+
+	// a single failure doesn't trip the breaker
+	assert.True(t, b.Allow())
+	b.Report(errLookup)
+	assert.True(t, b.Allow())
+	b.Report(errLookup)
+	assert.Equal(t, metrics.BreakerStateOpen, b.State())
+	assert.False(t, b.Allow())
+
+	// a single probe passes after the cooldown, its failure opens the breaker again
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, b.Allow())
+	assert.Equal(t, metrics.BreakerStateHalfOpen, b.State())
+	assert.False(t, b.Allow())
+	b.Report(errLookup)
+	assert.False(t, b.Allow())
+
+	// a successful probe closes it
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, b.Allow())
+	b.Report(nil)
+	assert.Equal(t, metrics.BreakerStateClosed, b.State())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+}
+
+func Test_Breaker_synced(t *testing.T) {
+	synced := false
+	b := breaker.New(breaker.Options{
+		Dependency: breaker.DependencyNodes,
+		Cooldown:   time.Hour,
+		Synced:     func() bool { return synced },
+	})
+
+	// the breaker is open until the cache synced, without waiting for the cooldown
+	assert.False(t, b.Allow())
+	assert.Equal(t, metrics.BreakerStateOpen, b.State())
+	synced = true
+	assert.True(t, b.Allow())
+	assert.Equal(t, metrics.BreakerStateClosed, b.State())
+
+	// without failures the breaker never trips
+	for range 10 {
+		b.Report(errors.New("unable to list nodes"))
+	}
+	assert.True(t, b.Allow())
+}
+
+func Test_Breaker_nil(t *testing.T) {
+	var b *breaker.Breaker
+	assert.True(t, b.Allow())
+	b.Report(errors.New("unable to list nodes"))
+	assert.Equal(t, metrics.BreakerStateClosed, b.State())
+}
@@ -0,0 +1,86 @@
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// statusDataKey is the data entry of the status ConfigMap holding the status.
+const statusDataKey = "status"
+
+// AutoscalerStatusKey is the ConfigMap the cluster-autoscaler reports its status in.
+var AutoscalerStatusKey = client.ObjectKey{Namespace: "kube-system", Name: "cluster-autoscaler-status"}
+
+// autoscalerStatus is the part of the structured cluster-autoscaler status
+// kim-snatch relies on.
+type autoscalerStatus struct {
+	NodeGroups []nodeGroupStatus `json:"nodeGroups"`
+}
+
+type nodeGroupStatus struct {
+	Name   string `json:"name"`
+	Health struct {
+		CloudProviderTarget int `json:"cloudProviderTarget"`
+		MaxSize             int `json:"maxSize"`
+	} `json:"health"`
+}
+
+// PoolStatus is the capacity of a worker pool as seen by the cluster-autoscaler.
+type PoolStatus struct {
+	// Known is false if the cluster-autoscaler reports no node group of the pool
+	Known bool
+	// AtMaxSize is true if all node groups of the pool are scaled to their maximum
+	AtMaxSize bool
+}
+
+// nodeGroupPattern matches the node groups of a Gardener worker pool, they are
+// named after the machine deployments: <shoot-namespace>-<pool>-z<zone>. The
+// shoot namespace may contain dashes, so a pool named like the suffix of another
+// pool, e.g. worker-0 and cpu-worker-0, matches the node groups of both.
+func nodeGroupPattern(pool string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|-)` + regexp.QuoteMeta(pool) + `(-z[0-9]+)?$`)
+}
+
+// AutoscalerPoolStatus reads the capacity of the worker pool from the status
+// ConfigMap of the cluster-autoscaler. A missing ConfigMap results in an
+// unknown status.
+func AutoscalerPoolStatus(ctx context.Context, reader client.Reader, pool string) (PoolStatus, error) {
+	var cm corev1.ConfigMap
+	if err := reader.Get(ctx, AutoscalerStatusKey, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return PoolStatus{}, nil
+		}
+		return PoolStatus{}, fmt.Errorf("unable to get cluster-autoscaler status: %w", err)
+	}
+	return parsePoolStatus(cm.Data[statusDataKey], pool)
+}
+
+func parsePoolStatus(data, pool string) (PoolStatus, error) {
+	var status autoscalerStatus
+	if err := yaml.Unmarshal([]byte(data), &status); err != nil {
+		return PoolStatus{}, fmt.Errorf("unable to parse cluster-autoscaler status: %w", err)
+	}
+
+	pattern := nodeGroupPattern(pool)
+	result := PoolStatus{AtMaxSize: true}
+	for _, group := range status.NodeGroups {
+		if !pattern.MatchString(group.Name) {
+			continue
+		}
+		result.Known = true
+		if group.Health.CloudProviderTarget < group.Health.MaxSize {
+			result.AtMaxSize = false
+		}
+	}
+
+	if !result.Known {
+		return PoolStatus{}, nil
+	}
+	return result, nil
+}
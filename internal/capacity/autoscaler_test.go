@@ -0,0 +1,67 @@
+package capacity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStatus = `
+time: "2025-01-01 00:00:00.000000000 +0000 UTC"
+autoscalerStatus: Running
+nodeGroups:
+- name: shoot--kyma--test-cpu-worker-0-z1
+  health:
+    status: Healthy
+    cloudProviderTarget: 3
+    minSize: 1
+    maxSize: 3
+- name: shoot--kyma--test-cpu-worker-0-z2
+  health:
+    status: Healthy
+    cloudProviderTarget: %d
+    minSize: 1
+    maxSize: 3
+- name: shoot--kyma--test-other-z1
+  health:
+    status: Healthy
+    cloudProviderTarget: 1
+    minSize: 1
+    maxSize: 3
+`
+
+func Test_parsePoolStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     string
+		pool     string
+		expected PoolStatus
+	}{
+		{
+			name:     "at max size",
+			data:     fmt.Sprintf(testStatus, 3),
+			pool:     "cpu-worker-0",
+			expected: PoolStatus{Known: true, AtMaxSize: true},
+		},
+		{
+			name:     "zone below max size",
+			data:     fmt.Sprintf(testStatus, 2),
+			pool:     "cpu-worker-0",
+			expected: PoolStatus{Known: true},
+		},
+		{
+			name: "unknown pool",
+			data: fmt.Sprintf(testStatus, 3),
+			pool: "missing",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := parsePoolStatus(tc.data, tc.pool)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, status)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package capacity
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Resources the utilization of a worker pool is computed for.
+var Resources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// PodRequests returns the resources requested by the pod. Like the scheduler,
+// it takes the maximum of the sum of the containers and of every init container,
+// and adds the pod overhead.
+func PodRequests(pod *corev1.Pod) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResources(result, container.Resources.Requests)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := result[name]; !ok || quantity.Cmp(current) > 0 {
+				result[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	addResources(result, pod.Spec.Overhead)
+	return result
+}
+
+// Utilization returns the percentage of the allocatable resources that is requested.
+func Utilization(requested, allocatable corev1.ResourceList) map[corev1.ResourceName]float64 {
+	result := map[corev1.ResourceName]float64{}
+	for _, name := range Resources {
+		total, ok := allocatable[name]
+		if !ok || total.IsZero() {
+			continue
+		}
+		used := requested[name]
+		result[name] = float64(used.MilliValue()) / float64(total.MilliValue()) * 100
+	}
+	return result
+}
+
+func addResources(total, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		sum, ok := total[name]
+		if !ok {
+			sum = resource.Quantity{}
+		}
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
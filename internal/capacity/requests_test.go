@@ -0,0 +1,35 @@
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func requests(cpu string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}
+}
+
+func Test_PodRequests(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Containers:     []corev1.Container{{Resources: requests("100m")}, {Resources: requests("200m")}},
+		InitContainers: []corev1.Container{{Resources: requests("250m")}},
+		Overhead:       corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+	}}
+	result := PodRequests(pod)
+	assert.Equal(t, "350m", result.Cpu().String())
+
+	pod.Spec.InitContainers[0].Resources = requests("1")
+	result = PodRequests(pod)
+	assert.Equal(t, "1050m", result.Cpu().String())
+}
+
+func Test_Utilization(t *testing.T) {
+	utilization := Utilization(
+		corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+	)
+	assert.Equal(t, map[corev1.ResourceName]float64{corev1.ResourceCPU: 25}, utilization)
+}
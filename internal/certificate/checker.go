@@ -0,0 +1,45 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// ReadyChecker returns a healthz.Checker failing until the served certificate
+// is valid and issued for the DNS name of the webhook service, the error tells
+// why it isn't. It is a readiness check only, a Pod that isn't ready doesn't
+// receive webhook calls but isn't restarted.
+func ReadyChecker(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error), dnsName string) healthz.Checker {
+	return func(*http.Request) error {
+		cert, err := getCertificate(&tls.ClientHelloInfo{ServerName: dnsName})
+		if err != nil {
+			return fmt.Errorf("no serving certificate: %w", err)
+		}
+		leaf := cert.Leaf
+		if leaf == nil {
+			if len(cert.Certificate) == 0 {
+				return fmt.Errorf("empty serving certificate")
+			}
+			if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+				return fmt.Errorf("invalid serving certificate: %w", err)
+			}
+		}
+
+		now := time.Now()
+		if now.Before(leaf.NotBefore) {
+			return fmt.Errorf("serving certificate not valid before %s", leaf.NotBefore.UTC().Format(time.RFC3339))
+		}
+		if now.After(leaf.NotAfter) {
+			return fmt.Errorf("serving certificate expired at %s", leaf.NotAfter.UTC().Format(time.RFC3339))
+		}
+		if err := leaf.VerifyHostname(dnsName); err != nil {
+			return fmt.Errorf("serving certificate not valid for the webhook service: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package certificate_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServingCertificate(t *testing.T, dnsName string, notBefore, notAfter time.Time) *tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{dnsName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func Test_ReadyChecker(t *testing.T) {
+	const dnsName = "kim-snatch-webhook-service.kyma-system.svc"
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		dnsName             string
+		notBefore, notAfter time.Duration
+		err                 string
+	}{
+		"valid":        {dnsName: dnsName, notBefore: -time.Hour, notAfter: time.Hour},
+		"missing":      {err: "no serving certificate"},
+		"expired":      {dnsName: dnsName, notBefore: -2 * time.Hour, notAfter: -time.Hour, err: "expired"},
+		"not yet":      {dnsName: dnsName, notBefore: time.Hour, notAfter: 2 * time.Hour, err: "not valid before"},
+		"san mismatch": {dnsName: "other.kyma-system.svc", notBefore: -time.Hour, notAfter: time.Hour, err: "webhook service"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			checker := certificate.ReadyChecker(func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				if tc.dnsName == "" {
+					return nil, errors.New("webhook server has not been started yet")
+				}
+				return testServingCertificate(t, tc.dnsName, now.Add(tc.notBefore), now.Add(tc.notAfter)), nil
+			}, dnsName)
+
+			err := checker(nil)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package certificate
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ProviderCertManager requests the certificate from an issuer of cert-manager
+	ProviderCertManager = "cert-manager"
+	// ProviderGardener requests the certificate from an issuer of Gardener cert-management
+	ProviderGardener = "gardener"
+
+	// fieldManager is the field manager the certificate resources are applied with
+	fieldManager = "kim-snatch"
+)
+
+// Request is the serving certificate requested from the issuer of a provider.
+type Request struct {
+	// Secret the issued certificate is stored in, the certificate resource is
+	// named like it
+	Secret client.ObjectKey
+	// DNSNames are the names of the webhook service the certificate is issued for
+	DNSNames []string
+	// IssuerName is the name of the issuer in the namespace of the Secret
+	IssuerName string
+	// IssuerKind is the kind of the issuer, only cert-manager distinguishes
+	// Issuers and ClusterIssuers
+	IssuerKind string
+	// Parameters of the certificate, unset ones are left to the provider
+	Parameters Parameters
+}
+
+// Issuer renders the certificate resource of a certificate provider.
+type Issuer interface {
+	Certificate(request Request) *unstructured.Unstructured
+}
+
+// Issuers are the issuers of the providers kim-snatch requests the certificate from.
+var Issuers = map[string]Issuer{
+	ProviderCertManager: certManagerIssuer{},
+	ProviderGardener:    gardenerIssuer{},
+}
+
+type certManagerIssuer struct{}
+
+func (certManagerIssuer) Certificate(request Request) *unstructured.Unstructured {
+	return certificateResource(
+		schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+		request, parameters(request.Parameters, true, map[string]any{
+			"commonName": request.DNSNames[0],
+			"dnsNames":   toAny(request.DNSNames),
+			"secretName": request.Secret.Name,
+			"issuerRef": map[string]any{
+				"name": request.IssuerName,
+				"kind": request.IssuerKind,
+			},
+		}))
+}
+
+type gardenerIssuer struct{}
+
+func (gardenerIssuer) Certificate(request Request) *unstructured.Unstructured {
+	return certificateResource(
+		schema.GroupVersionKind{Group: "cert.gardener.cloud", Version: "v1alpha1", Kind: "Certificate"},
+		request, parameters(request.Parameters, false, map[string]any{
+			"commonName": request.DNSNames[0],
+			"dnsNames":   toAny(request.DNSNames),
+			// the self-signed issuers of Gardener only issue CA certificates, the
+			// serving certificate is its own CA
+			"isCA": true,
+			"secretRef": map[string]any{
+				"name":      request.Secret.Name,
+				"namespace": request.Secret.Namespace,
+			},
+			"issuerRef": map[string]any{
+				"name":      request.IssuerName,
+				"namespace": request.Secret.Namespace,
+			},
+		}))
+}
+
+// parameters adds the set parameters to the spec of a certificate resource, the
+// fields are named alike by cert-manager and Gardener cert-management. Only
+// cert-manager supports renewBefore.
+func parameters(p Parameters, renewBefore bool, spec map[string]any) map[string]any {
+	if p.Duration > 0 {
+		spec["duration"] = p.Duration.String()
+	}
+	if p.RenewBefore > 0 && renewBefore {
+		spec["renewBefore"] = p.RenewBefore.String()
+	}
+	if p.KeyAlgorithm != "" || p.KeySize != 0 {
+		privateKey := map[string]any{}
+		if p.KeyAlgorithm != "" {
+			privateKey["algorithm"] = p.KeyAlgorithm
+		}
+		if p.KeySize != 0 {
+			privateKey["size"] = int64(p.KeySize)
+		}
+		spec["privateKey"] = privateKey
+	}
+	return spec
+}
+
+func certificateResource(gvk schema.GroupVersionKind, request Request, spec map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(request.Secret.Namespace)
+	u.SetName(request.Secret.Name)
+	u.SetLabels(map[string]string{"app.kubernetes.io/managed-by": fieldManager})
+	return u
+}
+
+func toAny(values []string) []any {
+	result := make([]any, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
+
+// Requester requests the serving certificate from the issuer of a provider by
+// applying its certificate resource, the provider stores the issued
+// certificate in the Secret mounted into the Pod. The resource is applied
+// again in an interval, e.g. if it was deleted or changed.
+type Requester struct {
+	Client  client.Client
+	Issuer  Issuer
+	Request Request
+	// Interval in which the certificate resource is applied
+	Interval time.Duration
+}
+
+// Start applies the certificate resource until the context is cancelled.
+func (r *Requester) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("certificate-requester")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Apply(ctx); err != nil {
+			logger.Error(err, "unable to apply certificate resource")
+		}
+	}, r.Interval)
+	return nil
+}
+
+// Apply applies the certificate resource once.
+func (r *Requester) Apply(ctx context.Context) error {
+	certificate := r.Issuer.Certificate(r.Request)
+	return r.Client.Apply(ctx, client.ApplyConfigurationFromUnstructured(certificate),
+		client.FieldOwner(fieldManager), client.ForceOwnership)
+}
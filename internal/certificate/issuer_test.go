@@ -0,0 +1,76 @@
+package certificate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_Requester(t *testing.T) {
+	request := certificate.Request{
+		Secret:     testSecret,
+		DNSNames:   []string{"kim-snatch-webhook-service.kyma-system.svc"},
+		IssuerName: "kim-snatch-kyma",
+		IssuerKind: "Issuer",
+		Parameters: certificate.Parameters{
+			Duration:     48 * time.Hour,
+			RenewBefore:  12 * time.Hour,
+			KeyAlgorithm: certificate.KeyAlgorithmRSA,
+			KeySize:      3072,
+		},
+	}
+
+	for provider, expected := range map[string]struct {
+		gvk        schema.GroupVersionKind
+		secretPath []string
+		issuerPath []string
+		// Gardener cert-management renews with its own window
+		renewBefore string
+	}{
+		certificate.ProviderCertManager: {
+			gvk:         schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			secretPath:  []string{"spec", "secretName"},
+			issuerPath:  []string{"spec", "issuerRef", "kind"},
+			renewBefore: "12h0m0s",
+		},
+		certificate.ProviderGardener: {
+			gvk:        schema.GroupVersionKind{Group: "cert.gardener.cloud", Version: "v1alpha1", Kind: "Certificate"},
+			secretPath: []string{"spec", "secretRef", "name"},
+			issuerPath: []string{"spec", "issuerRef", "namespace"},
+		},
+	} {
+		t.Run(provider, func(t *testing.T) {
+			c := fake.NewClientBuilder().Build()
+			r := &certificate.Requester{Client: c, Issuer: certificate.Issuers[provider], Request: request}
+			require.NoError(t, r.Apply(context.Background()))
+			// the applied resource is left as it is
+			require.NoError(t, r.Apply(context.Background()))
+
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(expected.gvk)
+			require.NoError(t, c.Get(context.Background(), client.ObjectKey(testSecret), u))
+			secretName, _, _ := unstructured.NestedString(u.Object, expected.secretPath...)
+			assert.Equal(t, testSecret.Name, secretName)
+			issuer, _, _ := unstructured.NestedString(u.Object, expected.issuerPath...)
+			assert.NotEmpty(t, issuer)
+			dnsNames, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "dnsNames")
+			assert.Equal(t, request.DNSNames, dnsNames)
+			duration, _, _ := unstructured.NestedString(u.Object, "spec", "duration")
+			assert.Equal(t, "48h0m0s", duration)
+			renewBefore, _, _ := unstructured.NestedString(u.Object, "spec", "renewBefore")
+			assert.Equal(t, expected.renewBefore, renewBefore)
+			algorithm, _, _ := unstructured.NestedString(u.Object, "spec", "privateKey", "algorithm")
+			assert.Equal(t, "RSA", algorithm)
+			size, _, _ := unstructured.NestedInt64(u.Object, "spec", "privateKey", "size")
+			assert.Equal(t, int64(3072), size)
+		})
+	}
+}
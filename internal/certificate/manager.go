@@ -0,0 +1,355 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ProviderMounted reads the certificate from the files of the Secret, the
+	// certificate is requested by the manifests of kim-snatch
+	ProviderMounted = "mounted"
+	// ProviderSelfSigned provisions the certificate with the Manager
+	ProviderSelfSigned = "self-signed"
+
+	// the entries of the Secret are named like the ones of cert-manager, the CA
+	// key is kept to renew the serving certificate with the same CA
+	CAName    = "ca.crt"
+	CAKeyName = "ca.key"
+	CertName  = "tls.crt"
+	KeyName   = "tls.key"
+
+	// CAValidity is the validity of the generated CA
+	CAValidity = 3 * 365 * 24 * time.Hour
+	// CARenewBefore is the time before its expiry the CA is renewed
+	CARenewBefore = 30 * 24 * time.Hour
+)
+
+// Providers are the supported certificate providers.
+var Providers = []string{ProviderMounted, ProviderCertManager, ProviderGardener, ProviderSelfSigned}
+
+var (
+	errNoCertificate = errors.New("no serving certificate loaded")
+	errNoSecret      = errors.New("certificate secret not created by the leader yet")
+)
+
+// Manager provisions the CA and the serving certificate of the webhook server
+// without cert-manager. The certificates are stored in a Secret shared by all
+// replicas and renewed before they expire; the serving certificate is renewed
+// with the same CA, so the caBundle only changes with a new CA.
+type Manager struct {
+	Client client.Client
+	// Secret stores the CA and the serving certificate
+	Secret client.ObjectKey
+	// DNSNames are the names of the webhook service the serving certificate is issued for
+	DNSNames []string
+	// Parameters of the serving certificate, the CA is always an ECDSA P-256 key
+	Parameters Parameters
+	// OnRotate is called with the CA bundle whenever another CA was loaded, e.g.
+	// to patch the caBundle of the webhook configuration
+	OnRotate func(ctx context.Context, caBundle []byte) error
+	// Interval in which the certificates are checked for renewal
+	Interval time.Duration
+	// Elected is closed once the replica is elected leader, only the leader
+	// renews the certificates and calls OnRotate then, the other replicas load
+	// them from the Secret. Every replica renews them if nil, e.g. on start,
+	// conflicting renewals are retried with the Secret of the other replica.
+	Elected <-chan struct{}
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	// caBundle is the CA bundle OnRotate succeeded for
+	caBundle []byte
+}
+
+// Start renews and reloads the certificates until the context is cancelled.
+func (m *Manager) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("certificate-manager")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := m.Ensure(ctx); err != nil {
+			logger.Error(err, "unable to ensure webhook certificate")
+		}
+	}, m.Interval)
+	return nil
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface, every
+// replica serves the webhook with the certificate of the Secret, the renewal
+// is left to the leader with Elected.
+func (m *Manager) NeedLeaderElection() bool {
+	return false
+}
+
+// GetCertificate returns the loaded serving certificate, it is the
+// GetCertificate of the tls.Config of the webhook server.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil {
+		return nil, errNoCertificate
+	}
+	return m.cert, nil
+}
+
+// Ensure creates or renews the certificates of the Secret if needed and loads
+// them. A Secret changed by another replica in the meantime is read again.
+func (m *Manager) Ensure(ctx context.Context) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		return m.ensure(ctx)
+	})
+}
+
+func (m *Manager) ensure(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("certificate-manager")
+
+	secret := &corev1.Secret{}
+	exists := true
+	if err := m.Client.Get(ctx, m.Secret, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("unable to get certificate secret: %w", err)
+		}
+		exists = false
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: m.Secret.Namespace, Name: m.Secret.Name},
+			Type:       corev1.SecretTypeTLS,
+		}
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	now := time.Now()
+	ca, caKey, reason := parseCA(secret.Data, now)
+	if reason == "" {
+		reason = m.renewal(secret.Data, ca, now)
+	}
+	if reason != "" && !m.leading() {
+		logger.V(1).Info("certificates are renewed by the leader", "reason", reason)
+		if !exists {
+			return errNoSecret
+		}
+		return m.load(ctx, secret.Data)
+	}
+	if ca == nil {
+		var err error
+		if ca, caKey, err = newCA(secret.Data, now); err != nil {
+			return err
+		}
+		logger.Info("ca renewed", "reason", reason, "notAfter", ca.NotAfter)
+	}
+	if reason != "" {
+		if err := m.issue(secret, ca, caKey, now); err != nil {
+			return err
+		}
+		if exists {
+			if err := m.Client.Update(ctx, secret); err != nil {
+				return fmt.Errorf("unable to update certificate secret: %w", err)
+			}
+		} else if err := m.Client.Create(ctx, secret); err != nil {
+			return fmt.Errorf("unable to create certificate secret: %w", err)
+		}
+		logger.Info("serving certificate renewed", "reason", reason, "dnsNames", m.DNSNames)
+	}
+	return m.load(ctx, secret.Data)
+}
+
+// renewal returns why the serving certificate of the Secret needs to be
+// renewed, or an empty string if it is still valid.
+func (m *Manager) renewal(data map[string][]byte, ca *x509.Certificate, now time.Time) string {
+	if _, err := tls.X509KeyPair(data[CertName], data[KeyName]); err != nil {
+		return "missing or invalid serving certificate"
+	}
+	cert, err := parseCertificate(data[CertName])
+	if err != nil {
+		return "missing or invalid serving certificate"
+	}
+	if cert.CheckSignatureFrom(ca) != nil {
+		return "serving certificate not issued by the ca"
+	}
+	if now.After(cert.NotAfter.Add(-m.Parameters.withDefaults().RenewBefore)) {
+		return "serving certificate expiring"
+	}
+	if !slices.Equal(cert.DNSNames, m.DNSNames) {
+		return "dns names changed"
+	}
+	if !m.Parameters.keyMatches(cert.PublicKey) {
+		return "key algorithm changed"
+	}
+	return ""
+}
+
+// issue adds a new serving certificate issued by the CA to the Secret.
+func (m *Manager) issue(secret *corev1.Secret, ca *x509.Certificate, caKey crypto.Signer, now time.Time) error {
+	if len(m.DNSNames) == 0 {
+		return errors.New("no dns names for the serving certificate")
+	}
+	key, err := m.Parameters.generateKey()
+	if err != nil {
+		return fmt.Errorf("unable to generate serving certificate key: %w", err)
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: m.DNSNames[0]},
+		DNSNames:    m.DNSNames,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	certPEM, keyPEM, err := signWith(template, ca, key, caKey, now, m.Parameters.withDefaults().Duration)
+	if err != nil {
+		return fmt.Errorf("unable to issue serving certificate: %w", err)
+	}
+	secret.Data[CertName] = certPEM
+	secret.Data[KeyName] = keyPEM
+	return nil
+}
+
+// load serves the certificate of the Secret and calls OnRotate if the CA
+// bundle changed, a failed OnRotate is retried with the next load.
+func (m *Manager) load(ctx context.Context, data map[string][]byte) error {
+	cert, err := tls.X509KeyPair(data[CertName], data[KeyName])
+	if err != nil {
+		return fmt.Errorf("unable to load serving certificate: %w", err)
+	}
+	m.mu.Lock()
+	m.cert = &cert
+	rotated := !bytes.Equal(m.caBundle, data[CAName])
+	m.mu.Unlock()
+
+	if !rotated || m.OnRotate == nil || !m.leading() {
+		return nil
+	}
+	if err := m.OnRotate(ctx, data[CAName]); err != nil {
+		return fmt.Errorf("unable to publish ca bundle: %w", err)
+	}
+	m.mu.Lock()
+	m.caBundle = data[CAName]
+	m.mu.Unlock()
+	return nil
+}
+
+// leading returns true if the replica renews the certificates.
+func (m *Manager) leading() bool {
+	if m.Elected == nil {
+		return true
+	}
+	select {
+	case <-m.Elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseCA returns the CA of the Secret, or why a new CA is needed.
+func parseCA(data map[string][]byte, now time.Time) (*x509.Certificate, crypto.Signer, string) {
+	pair, err := tls.X509KeyPair(data[CAName], data[CAKeyName])
+	if err != nil {
+		return nil, nil, "missing or invalid ca"
+	}
+	ca, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || !ca.IsCA {
+		return nil, nil, "missing or invalid ca"
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, "missing or invalid ca"
+	}
+	if now.After(ca.NotAfter.Add(-CARenewBefore)) {
+		return nil, nil, "ca expiring"
+	}
+	return ca, key, ""
+}
+
+// newCA adds a new self-signed CA to the data of the Secret.
+func newCA(data map[string][]byte, now time.Time) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate ca key: %w", err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "kim-snatch-ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	certPEM, keyPEM, err := signWith(template, template, key, key, now, CAValidity)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate ca: %w", err)
+	}
+	ca, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the data of the Secret is replaced, the serving certificate is issued anew
+	clear(data)
+	data[CAName] = certPEM
+	data[CAKeyName] = keyPEM
+	return ca, key, nil
+}
+
+func signWith(template, parent *x509.Certificate, key, parentKey crypto.Signer, now time.Time,
+	validity time.Duration) ([]byte, []byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	// the clocks of the API server and kim-snatch may be skewed
+	template.NotBefore = now.Add(-time.Hour)
+	template.NotAfter = now.Add(validity)
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no pem encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,151 @@
+package certificate_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testSecret = client.ObjectKey{Namespace: "kyma-system", Name: "kim-snatch-certificates"}
+
+func testManager(objs ...client.Object) (*certificate.Manager, client.Client, *[][]byte) {
+	c := fake.NewClientBuilder().WithObjects(objs...).Build()
+	var rotations [][]byte
+	return &certificate.Manager{
+		Client:   c,
+		Secret:   testSecret,
+		DNSNames: []string{"kim-snatch-webhook-service.kyma-system.svc"},
+		OnRotate: func(_ context.Context, caBundle []byte) error {
+			rotations = append(rotations, caBundle)
+			return nil
+		},
+	}, c, &rotations
+}
+
+func testVerify(t *testing.T, m *certificate.Manager, caBundle []byte, dnsName string) {
+	t.Helper()
+
+	cert, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caBundle))
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: dnsName})
+	assert.NoError(t, err)
+}
+
+func Test_Manager(t *testing.T) {
+	m, c, rotations := testManager()
+	_, err := m.GetCertificate(nil)
+	require.Error(t, err)
+
+	require.NoError(t, m.Ensure(context.Background()))
+	var secret corev1.Secret
+	require.NoError(t, c.Get(context.Background(), testSecret, &secret))
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	require.Len(t, *rotations, 1)
+	assert.Equal(t, secret.Data[certificate.CAName], (*rotations)[0])
+	testVerify(t, m, secret.Data[certificate.CAName], "kim-snatch-webhook-service.kyma-system.svc")
+
+	// a valid secret is left untouched
+	require.NoError(t, m.Ensure(context.Background()))
+	var unchanged corev1.Secret
+	require.NoError(t, c.Get(context.Background(), testSecret, &unchanged))
+	assert.Equal(t, secret.ResourceVersion, unchanged.ResourceVersion)
+	assert.Len(t, *rotations, 1)
+
+	// the serving certificate is renewed with the same ca
+	m.DNSNames = []string{"kim-snatch.kyma-system.svc"}
+	require.NoError(t, m.Ensure(context.Background()))
+	require.NoError(t, c.Get(context.Background(), testSecret, &unchanged))
+	assert.NotEqual(t, secret.Data[certificate.CertName], unchanged.Data[certificate.CertName])
+	assert.Equal(t, secret.Data[certificate.CAName], unchanged.Data[certificate.CAName])
+	assert.Len(t, *rotations, 1)
+	testVerify(t, m, secret.Data[certificate.CAName], "kim-snatch.kyma-system.svc")
+}
+
+func Test_Manager_elected(t *testing.T) {
+	leader, c, _ := testManager()
+	follower, _, rotations := testManager()
+	follower.Client = c
+	follower.Elected = make(chan struct{})
+
+	// the secret is only created by the leader
+	require.Error(t, follower.Ensure(context.Background()))
+	var secret corev1.Secret
+	require.Error(t, c.Get(context.Background(), testSecret, &secret))
+
+	require.NoError(t, leader.Ensure(context.Background()))
+	require.NoError(t, c.Get(context.Background(), testSecret, &secret))
+
+	// the follower loads the certificate without renewing it nor publishing the ca
+	follower.DNSNames = []string{"kim-snatch.kyma-system.svc"}
+	require.NoError(t, follower.Ensure(context.Background()))
+	var unchanged corev1.Secret
+	require.NoError(t, c.Get(context.Background(), testSecret, &unchanged))
+	assert.Equal(t, secret.ResourceVersion, unchanged.ResourceVersion)
+	assert.Empty(t, *rotations)
+	testVerify(t, follower, secret.Data[certificate.CAName], "kim-snatch-webhook-service.kyma-system.svc")
+}
+
+func Test_Manager_invalid_secret(t *testing.T) {
+	m, c, rotations := testManager(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testSecret.Namespace, Name: testSecret.Name},
+		Data: map[string][]byte{
+			certificate.CAName:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")}),
+			certificate.CertName: []byte("invalid"),
+		},
+	})
+
+	// the ca and the serving certificate are replaced
+	require.NoError(t, m.Ensure(context.Background()))
+	var secret corev1.Secret
+	require.NoError(t, c.Get(context.Background(), testSecret, &secret))
+	assert.NotEmpty(t, secret.Data[certificate.CAKeyName])
+	require.Len(t, *rotations, 1)
+	testVerify(t, m, secret.Data[certificate.CAName], "kim-snatch-webhook-service.kyma-system.svc")
+}
+
+func Test_Manager_parameters(t *testing.T) {
+	m, c, rotations := testManager()
+	require.NoError(t, m.Ensure(context.Background()))
+	var secret corev1.Secret
+	require.NoError(t, c.Get(context.Background(), testSecret, &secret))
+
+	// a changed key algorithm renews the serving certificate with the same ca
+	m.Parameters = certificate.Parameters{
+		Duration:     48 * time.Hour,
+		RenewBefore:  12 * time.Hour,
+		KeyAlgorithm: certificate.KeyAlgorithmRSA,
+		KeySize:      2048,
+	}
+	require.NoError(t, m.Ensure(context.Background()))
+	var renewed corev1.Secret
+	require.NoError(t, c.Get(context.Background(), testSecret, &renewed))
+	assert.NotEqual(t, secret.Data[certificate.CertName], renewed.Data[certificate.CertName])
+	assert.Len(t, *rotations, 1)
+	testVerify(t, m, renewed.Data[certificate.CAName], "kim-snatch-webhook-service.kyma-system.svc")
+
+	cert, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, x509.RSA, leaf.PublicKeyAlgorithm)
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), leaf.NotAfter, time.Minute)
+
+	// the renewed secret is left untouched
+	require.NoError(t, m.Ensure(context.Background()))
+	require.NoError(t, c.Get(context.Background(), testSecret, &secret))
+	assert.Equal(t, renewed.ResourceVersion, secret.ResourceVersion)
+}
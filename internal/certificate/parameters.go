@@ -0,0 +1,125 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	KeyAlgorithmECDSA = "ECDSA"
+	KeyAlgorithmRSA   = "RSA"
+
+	// DefaultDuration is the default validity of the serving certificate
+	DefaultDuration = 90 * 24 * time.Hour
+	// DefaultRenewBefore is the default time before its expiry the serving
+	// certificate is renewed
+	DefaultRenewBefore = 30 * 24 * time.Hour
+	// DefaultKeySize is the default size of ECDSA keys
+	DefaultKeySize = 256
+)
+
+// KeyAlgorithms are the supported key algorithms.
+var KeyAlgorithms = []string{KeyAlgorithmECDSA, KeyAlgorithmRSA}
+
+// keySizes are the key sizes supported by all providers.
+var keySizes = map[string][]int{
+	KeyAlgorithmECDSA: {256, 384},
+	KeyAlgorithmRSA:   {2048, 3072, 4096},
+}
+
+// Parameters of the serving certificate, they are applied by the Manager and
+// passed to the issuer of the provider. Unset parameters are left to the
+// defaults of the provider.
+type Parameters struct {
+	// Duration is the validity of the serving certificate
+	Duration time.Duration
+	// RenewBefore is the time before its expiry the serving certificate is
+	// renewed, Gardener cert-management renews all certificates with its own
+	// renewal window
+	RenewBefore time.Duration
+	// KeyAlgorithm of the private key, ECDSA or RSA
+	KeyAlgorithm string
+	// KeySize is the size of RSA keys in bits or the curve size of ECDSA keys
+	KeySize int
+}
+
+// Validate returns the invalid parameters, the field names are prefixed with the path.
+func (p Parameters) Validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if p.Duration < 0 {
+		errs = append(errs, field.Invalid(path.Child("duration"), p.Duration.String(), "must not be negative"))
+	}
+	if p.RenewBefore < 0 {
+		errs = append(errs, field.Invalid(path.Child("renewBefore"), p.RenewBefore.String(), "must not be negative"))
+	}
+	if p := p.withDefaults(); p.RenewBefore >= p.Duration {
+		errs = append(errs, field.Invalid(path.Child("renewBefore"), p.RenewBefore.String(),
+			"must be shorter than the duration "+p.Duration.String()))
+	}
+	if p.KeyAlgorithm != "" && !slices.Contains(KeyAlgorithms, p.KeyAlgorithm) {
+		errs = append(errs, field.NotSupported(path.Child("keyAlgorithm"), p.KeyAlgorithm, KeyAlgorithms))
+		return errs
+	}
+	if sizes := keySizes[p.withDefaults().KeyAlgorithm]; p.KeySize != 0 && !slices.Contains(sizes, p.KeySize) {
+		errs = append(errs, field.Invalid(path.Child("keySize"), p.KeySize,
+			fmt.Sprintf("must be one of %v for %s keys", sizes, p.withDefaults().KeyAlgorithm)))
+	}
+	return errs
+}
+
+// withDefaults returns the parameters with the defaults of the Manager for the
+// unset ones.
+func (p Parameters) withDefaults() Parameters {
+	if p.Duration == 0 {
+		p.Duration = DefaultDuration
+	}
+	if p.RenewBefore == 0 {
+		p.RenewBefore = min(DefaultRenewBefore, p.Duration/3)
+	}
+	if p.KeyAlgorithm == "" {
+		p.KeyAlgorithm = KeyAlgorithmECDSA
+	}
+	if p.KeySize == 0 {
+		p.KeySize = map[string]int{KeyAlgorithmECDSA: DefaultKeySize, KeyAlgorithmRSA: 2048}[p.KeyAlgorithm]
+	}
+	return p
+}
+
+// generateKey generates a private key with the algorithm and size of the parameters.
+func (p Parameters) generateKey() (crypto.Signer, error) {
+	p = p.withDefaults()
+	switch p.KeyAlgorithm {
+	case KeyAlgorithmRSA:
+		return rsa.GenerateKey(rand.Reader, p.KeySize)
+	case KeyAlgorithmECDSA:
+		if curve, ok := curves[p.KeySize]; ok {
+			return ecdsa.GenerateKey(curve, rand.Reader)
+		}
+	}
+	return nil, fmt.Errorf("unsupported key %s %d", p.KeyAlgorithm, p.KeySize)
+}
+
+// keyMatches returns true if the public key has the algorithm and size of the parameters.
+func (p Parameters) keyMatches(key crypto.PublicKey) bool {
+	p = p.withDefaults()
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return p.KeyAlgorithm == KeyAlgorithmRSA && key.N.BitLen() == p.KeySize
+	case *ecdsa.PublicKey:
+		return p.KeyAlgorithm == KeyAlgorithmECDSA && key.Curve == curves[p.KeySize]
+	}
+	return false
+}
+
+var curves = map[int]elliptic.Curve{
+	256: elliptic.P256(),
+	384: elliptic.P384(),
+}
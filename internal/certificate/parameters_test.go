@@ -0,0 +1,50 @@
+package certificate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/certificate"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_Parameters_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		parameters certificate.Parameters
+		fields     []string
+	}{
+		"defaults": {},
+		"valid": {
+			parameters: certificate.Parameters{Duration: 24 * time.Hour, RenewBefore: 8 * time.Hour,
+				KeyAlgorithm: certificate.KeyAlgorithmRSA, KeySize: 4096},
+		},
+		"short duration with default renewal": {
+			parameters: certificate.Parameters{Duration: time.Hour},
+		},
+		"renewal after expiry": {
+			parameters: certificate.Parameters{Duration: 24 * time.Hour, RenewBefore: 24 * time.Hour},
+			fields:     []string{"certificate.renewBefore"},
+		},
+		"negative duration": {
+			parameters: certificate.Parameters{Duration: -time.Hour, RenewBefore: time.Hour},
+			fields:     []string{"certificate.duration", "certificate.renewBefore"},
+		},
+		"unsupported algorithm": {
+			parameters: certificate.Parameters{KeyAlgorithm: "Ed25519", KeySize: 256},
+			fields:     []string{"certificate.keyAlgorithm"},
+		},
+		"ecdsa size of rsa": {
+			parameters: certificate.Parameters{KeySize: 2048},
+			fields:     []string{"certificate.keySize"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var fields []string
+			for _, err := range tc.parameters.Validate(field.NewPath("certificate")) {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tc.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,447 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kyma-project/kim-snatch/internal/provider"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/utils/ptr"
+)
+
+// Keys of the configuration settings, shared by all configuration sources. Flags
+// use the key as their name, environment variables use the upper-cased key with
+// EnvPrefix and dashes replaced with underscores, ConfigMaps use the key as the
+// data entry name.
+const (
+	KeyWebhookConfigName   = "webhook-cfg-name"
+	KeyKymaWorkerPoolName  = "kyma-worker-pool-name"
+	KeyKymaWorkerPoolZones = "kyma-worker-pool-zones"
+	KeyPoolSuccessors      = "pool-successors"
+	KeyOmittedNamespaces   = "omitted-namespaces"
+	KeyAffinityWeight      = "affinity-weight"
+	KeyAffinityMode        = "affinity-mode"
+	KeyPoolLabelKey        = "pool-label-key"
+	KeyProvider            = "provider"
+	KeyFailurePolicy       = "webhook-failure-policy"
+	KeyTimeoutSeconds      = "webhook-timeout-seconds"
+	KeyProfile             = "profile"
+	KeyExcludeRules        = "exclude-rules"
+	KeyDegradeAtMaxSize    = "degrade-at-pool-max-size"
+	KeyIncludeRules        = "include-rules"
+	KeySaturationThreshold = "pool-saturation-threshold"
+	KeySaturationPriority  = "pool-saturation-min-priority"
+	KeyTolerationAllowList = "toleration-allow-list"
+	KeyDeschedulingAllow   = "descheduling-allow-list"
+	KeyDeschedulingPlan    = "descheduling-plan-only"
+	KeyCleanup             = "cleanup"
+	KeyNaturalRestartKinds = "natural-restart-kinds"
+	KeyAPIServerCIDRs      = "apiserver-cidrs"
+	KeyMonitoringNamespace = "monitoring-namespace"
+)
+
+// Kinds of the workloads the node affinity is added to.
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+)
+
+// DefaultPoolLabelKey is the node label Gardener sets to the name of the worker pool.
+const DefaultPoolLabelKey = "worker.gardener.cloud/pool"
+
+// Affinity modes
+const (
+	// ModePreferred injects the node affinity as preferredDuringSchedulingIgnoredDuringExecution
+	ModePreferred = "preferred"
+	// ModeRequired injects the node affinity as requiredDuringSchedulingIgnoredDuringExecution
+	ModeRequired = "required"
+)
+
+// Config is the effective configuration of kim-snatch.
+type Config struct {
+	// WebhookConfigName is the name of the mutating webhook configuration to be updated
+	WebhookConfigName string `json:"webhookConfigName"`
+	// KymaWorkerPoolName is the name of the worker pool the kyma components will be scheduled on
+	KymaWorkerPoolName string `json:"kymaWorkerPoolName"`
+	// KymaWorkerPoolZones are the zones the kyma worker pool is provisioned in
+	KymaWorkerPoolZones []string `json:"kymaWorkerPoolZones,omitempty"`
+	// PoolSuccessors maps the names of worker pools to the names of the pools they were recreated as
+	PoolSuccessors map[string]string `json:"poolSuccessors,omitempty"`
+	// OmittedNamespaces is the list of namespaces the webhook will never mutate pods in
+	OmittedNamespaces []string `json:"omittedNamespaces"`
+	// AffinityWeight is the weight of the injected preferred scheduling term
+	AffinityWeight int32 `json:"affinityWeight"`
+	// AffinityMode is the way the node affinity is injected, one of ModePreferred, ModeRequired
+	AffinityMode string `json:"affinityMode"`
+	// PoolLabelKey is the key of the node label holding the name of the worker pool
+	PoolLabelKey string `json:"poolLabelKey"`
+	// Provider is the distribution the worker pools are provided by, it defines the default PoolLabelKey
+	Provider string `json:"provider"`
+	// FailurePolicy is the failurePolicy of the webhooks, one of Ignore, Fail
+	FailurePolicy string `json:"failurePolicy"`
+	// TimeoutSeconds is the timeoutSeconds of the webhooks
+	TimeoutSeconds int32 `json:"timeoutSeconds"`
+	// Profile is the name of the profile the settings are based on
+	Profile string `json:"profile,omitempty"`
+	// ExcludeRules are CEL expressions over the pod, matching pods are not mutated
+	ExcludeRules []string `json:"excludeRules,omitempty"`
+	// IncludeRules are CEL expressions over the pod, if set only matching pods are mutated
+	IncludeRules []string `json:"includeRules,omitempty"`
+	// DegradeAtMaxSize injects the required node affinity as preferred while the kyma worker pool is at its maximum size
+	DegradeAtMaxSize bool `json:"degradeAtMaxSize"`
+	// SaturationThreshold is the percentage of the allocatable resources of the kyma worker pool
+	// requested by pods above which the pool is saturated
+	SaturationThreshold int32 `json:"saturationThreshold"`
+	// SaturationMinPriority is the priority below which pods are not steered to the saturated pool, unset disables it
+	SaturationMinPriority *int32 `json:"saturationMinPriority,omitempty"`
+	// TolerationAllowList are the keys of the taints of the kyma worker pool nodes mutated pods tolerate
+	TolerationAllowList []string `json:"tolerationAllowList,omitempty"`
+	// DeschedulingAllowList are the namespaces, or namespace/component pairs, of the pods evicted from outside of the kyma worker pool
+	DeschedulingAllowList []string `json:"deschedulingAllowList,omitempty"`
+	// DeschedulingPlanOnly reports the pods that would be evicted from outside of the kyma worker pool without evicting them
+	DeschedulingPlanOnly bool `json:"deschedulingPlanOnly"`
+	// NaturalRestartKinds are the kinds of the workloads that are never restarted to get the node affinity
+	NaturalRestartKinds []string `json:"naturalRestartKinds,omitempty"`
+	// Cleanup stops the injection and removes the node affinity kim-snatch added to the pod templates of the workloads
+	Cleanup bool `json:"cleanup"`
+	// APIServerCIDRs are the IP ranges the API server calls the webhooks from, all sources are allowed if empty
+	APIServerCIDRs []string `json:"apiServerCIDRs,omitempty"`
+	// MonitoringNamespace is the namespace the metrics are scraped from, unset denies the scraping from a namespace
+	MonitoringNamespace string `json:"monitoringNamespace,omitempty"`
+}
+
+// Default returns the configuration used if no source sets a value.
+func Default() Config {
+	return Config{
+		OmittedNamespaces: []string{"kube-system"},
+		AffinityWeight:    10,
+		AffinityMode:      ModePreferred,
+		PoolLabelKey:      DefaultPoolLabelKey,
+		Provider:          provider.Gardener,
+		FailurePolicy:     string(admissionregistrationv1.Ignore),
+		TimeoutSeconds:    10,
+		// the pool is saturated before the scheduler fails to place pods
+		SaturationThreshold: 90,
+	}
+}
+
+type setting struct {
+	usage string
+	set   func(*Config, string) error
+}
+
+var settings = map[string]setting{
+	KeyWebhookConfigName: {
+		usage: "The name of the mutating webhook configuration to be updated.",
+		set: func(c *Config, v string) error {
+			c.WebhookConfigName = v
+			return nil
+		},
+	},
+	KeyKymaWorkerPoolName: {
+		usage: "The name of the workerpool the kyma components will be scheduled on.",
+		set: func(c *Config, v string) error {
+			c.KymaWorkerPoolName = v
+			return nil
+		},
+	},
+	KeyKymaWorkerPoolZones: {
+		usage: "Comma separated list of zones the kyma worker pool is provisioned in.",
+		set: func(c *Config, v string) error {
+			c.KymaWorkerPoolZones = splitList(v)
+			return nil
+		},
+	},
+	KeyPoolSuccessors: {
+		usage: "Comma separated list of old=new pairs of worker pools recreated under a new name.",
+		set: func(c *Config, v string) error {
+			successors, err := ParsePairs(v)
+			if err != nil {
+				return err
+			}
+			c.PoolSuccessors = successors
+			return nil
+		},
+	},
+	KeyOmittedNamespaces: {
+		usage: "Comma separated list of namespaces the webhook will never mutate pods in.",
+		set: func(c *Config, v string) error {
+			c.OmittedNamespaces = splitList(v)
+			return nil
+		},
+	},
+	KeyAffinityWeight: {
+		usage: "The weight (1-100) of the injected preferred node affinity.",
+		set: func(c *Config, v string) error {
+			weight, err := ParseWeight(v)
+			if err != nil {
+				return err
+			}
+			c.AffinityWeight = weight
+			return nil
+		},
+	},
+	KeyAffinityMode: {
+		usage: "The way the node affinity is injected, one of: preferred, required.",
+		set: func(c *Config, v string) error {
+			mode, err := ParseMode(v)
+			if err != nil {
+				return err
+			}
+			c.AffinityMode = mode
+			return nil
+		},
+	},
+	KeyPoolLabelKey: {
+		usage: "The key of the node label holding the name of the worker pool.",
+		set: func(c *Config, v string) error {
+			c.PoolLabelKey = strings.TrimSpace(v)
+			return nil
+		},
+	},
+	KeyProvider: {
+		usage: "The distribution the worker pools are provided by, one of: aks, eks, gardener, generic, gke.",
+		set: func(c *Config, v string) error {
+			p, err := provider.Get(v)
+			if err != nil {
+				return err
+			}
+			c.Provider = p.Name()
+			return nil
+		},
+	},
+	KeyFailurePolicy: {
+		usage: "The failurePolicy of the mutating webhooks, one of: Ignore, Fail.",
+		set: func(c *Config, v string) error {
+			switch policy := admissionregistrationv1.FailurePolicyType(strings.TrimSpace(v)); policy {
+			case admissionregistrationv1.Ignore, admissionregistrationv1.Fail:
+				c.FailurePolicy = string(policy)
+				return nil
+			default:
+				return fmt.Errorf("unsupported failure policy %q", v)
+			}
+		},
+	},
+	KeyTimeoutSeconds: {
+		usage: "The timeoutSeconds (1-30) of the mutating webhooks.",
+		set: func(c *Config, v string) error {
+			timeout, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				return err
+			}
+			c.TimeoutSeconds = int32(timeout)
+			return nil
+		},
+	},
+	KeyProfile: {
+		usage: "The profile the settings are based on, one of: evaluation, production, strict-isolation.",
+		set: func(c *Config, v string) error {
+			profile, err := ParseProfile(v)
+			if err != nil {
+				return err
+			}
+			c.Profile = profile
+			return nil
+		},
+	},
+	KeyExcludeRules: {
+		usage: "Newline separated list of CEL expressions over the pod (object), matching pods are not mutated.",
+		set: func(c *Config, v string) error {
+			c.ExcludeRules = splitLines(v)
+			return nil
+		},
+	},
+	KeyIncludeRules: {
+		usage: "Newline separated list of CEL expressions over the pod (object), if set only matching pods are mutated.",
+		set: func(c *Config, v string) error {
+			c.IncludeRules = splitLines(v)
+			return nil
+		},
+	},
+	KeyDegradeAtMaxSize: {
+		usage: "If true, the required node affinity is injected as preferred while the kyma worker pool is at its maximum size.",
+		set: func(c *Config, v string) error {
+			degrade, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+			c.DegradeAtMaxSize = degrade
+			return nil
+		},
+	},
+	KeySaturationThreshold: {
+		usage: "The percentage (1-100) of the allocatable resources of the kyma worker pool requested by pods above which the pool is saturated.",
+		set: func(c *Config, v string) error {
+			threshold, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				return err
+			}
+			c.SaturationThreshold = int32(threshold)
+			return nil
+		},
+	},
+	KeySaturationPriority: {
+		usage: "Pods with a priority below this value are not steered to the kyma worker pool while it is saturated, disabled if empty.",
+		set: func(c *Config, v string) error {
+			if v = strings.TrimSpace(v); v == "" {
+				c.SaturationMinPriority = nil
+				return nil
+			}
+			priority, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return err
+			}
+			c.SaturationMinPriority = ptr.To(int32(priority))
+			return nil
+		},
+	},
+	KeyTolerationAllowList: {
+		usage: "Comma separated list of taint keys of the kyma worker pool nodes the mutated pods get tolerations for.",
+		set: func(c *Config, v string) error {
+			c.TolerationAllowList = splitList(v)
+			return nil
+		},
+	},
+	KeyDeschedulingAllow: {
+		usage: "Comma separated list of namespaces or namespace/component pairs the pods running outside of the kyma worker pool are evicted from.",
+		set: func(c *Config, v string) error {
+			c.DeschedulingAllowList = splitList(v)
+			return nil
+		},
+	},
+	KeyDeschedulingPlan: {
+		usage: "If true, the pods running outside of the kyma worker pool that would be evicted are only reported.",
+		set: func(c *Config, v string) error {
+			planOnly, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+			c.DeschedulingPlanOnly = planOnly
+			return nil
+		},
+	},
+	KeyNaturalRestartKinds: {
+		usage: "Comma separated list of workload kinds (Deployment, StatefulSet) that only get the node affinity on their next natural restart.",
+		set: func(c *Config, v string) error {
+			c.NaturalRestartKinds = splitList(v)
+			return nil
+		},
+	},
+	KeyCleanup: {
+		usage: "If true, no node affinity is injected and the node affinity added to the pod templates of the workloads is removed.",
+		set: func(c *Config, v string) error {
+			cleanup, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+			c.Cleanup = cleanup
+			return nil
+		},
+	},
+	KeyAPIServerCIDRs: {
+		usage: "Comma separated list of IP ranges the API server calls the webhooks from, the ingress to the webhook port is allowed from all sources if empty.",
+		set: func(c *Config, v string) error {
+			c.APIServerCIDRs = splitList(v)
+			return nil
+		},
+	},
+	KeyMonitoringNamespace: {
+		usage: "The namespace the ingress to the metrics port is allowed from.",
+		set: func(c *Config, v string) error {
+			c.MonitoringNamespace = strings.TrimSpace(v)
+			return nil
+		},
+	},
+}
+
+// ParseWeight parses the weight of a preferred scheduling term.
+func ParseWeight(v string) (int32, error) {
+	weight, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if weight < 1 || weight > 100 {
+		return 0, fmt.Errorf("weight must be in range 1-100, got %d", weight)
+	}
+	return int32(weight), nil
+}
+
+// ParseMode parses the affinity mode.
+func ParseMode(v string) (string, error) {
+	switch mode := strings.TrimSpace(v); mode {
+	case ModePreferred, ModeRequired:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported affinity mode %q", v)
+	}
+}
+
+// Hash returns a stable hash of the configuration.
+func (c Config) Hash() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		// Config consists of plain values only, it can always be marshalled
+		panic(err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// Set applies a single setting on the configuration.
+func (c *Config) Set(key, value string) error {
+	s, ok := settings[key]
+	if !ok {
+		return fmt.Errorf("unknown configuration key: %s", key)
+	}
+
+	if err := s.set(c, value); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", key, err)
+	}
+	return nil
+}
+
+// BindFlags registers a flag for every configuration setting. The flags have no
+// defaults, only explicitly set flags are considered by FlagSource.
+func BindFlags(fs *flag.FlagSet) {
+	for key, s := range settings {
+		fs.String(key, "", s.usage)
+	}
+}
+
+// ParsePairs parses a comma separated list of key=value pairs.
+func ParsePairs(v string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range splitList(v) {
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected key=value", pair)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// splitLines splits a list of expressions, expressions may contain commas.
+func splitLines(v string) []string {
+	result := []string{}
+	for _, item := range strings.Split(v, "\n") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func splitList(v string) []string {
+	result := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package config_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Handler(t *testing.T) {
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "test-me"
+	store := config.NewStore(config.Effective{
+		Config:  cfg,
+		Origins: map[string]string{config.KeyKymaWorkerPoolName: "flags"},
+	})
+
+	rec := httptest.NewRecorder()
+	config.Handler(store).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var effective config.Effective
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &effective))
+	assert.Equal(t, store.Get(), effective)
+}
+
+func Test_Handler_method_not_allowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	config.Handler(config.NewStore(config.Effective{})).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/config", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
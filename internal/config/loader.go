@@ -0,0 +1,157 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/kyma-project/kim-snatch/internal/provider"
+	"github.com/kyma-project/kim-snatch/internal/rules"
+)
+
+const originDefault = "default"
+
+// Effective is the configuration resolved from all sources together with the
+// origin of every setting.
+type Effective struct {
+	Config Config `json:"config"`
+	// Origins maps the configuration keys to the name of the source the value was taken from
+	Origins map[string]string `json:"origins"`
+	// Conflicts are the conflicts detected between sources of the same precedence
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	// Rules are the compiled exclusion and inclusion rules of the configuration
+	Rules *rules.Rules `json:"-"`
+	// Secrets are the sensitive settings, they are never exported
+	Secrets Secrets `json:"-"`
+}
+
+// LoaderSource is a source of the Loader, created by WithSource,
+// WithCompositeSource, or WithSecretSource. The zero value provides no settings.
+type LoaderSource struct {
+	source    Source
+	composite CompositeSource
+	secrets   SecretSource
+}
+
+// WithSource returns the LoaderSource of the settings of a Source.
+func WithSource(src Source) LoaderSource {
+	return LoaderSource{source: src}
+}
+
+// WithCompositeSource returns the LoaderSource of the settings of the sources
+// a CompositeSource expands to.
+func WithCompositeSource(src CompositeSource) LoaderSource {
+	return LoaderSource{composite: src}
+}
+
+// WithSecretSource returns the LoaderSource of the sensitive settings of a SecretSource.
+func WithSecretSource(src SecretSource) LoaderSource {
+	return LoaderSource{secrets: src}
+}
+
+// Loader merges the configuration from multiple sources.
+type Loader struct {
+	sources []LoaderSource
+}
+
+// NewLoader creates a loader for the given sources ordered by ascending
+// precedence, i.e. settings of later sources override the ones of earlier sources.
+func NewLoader(sources ...LoaderSource) *Loader {
+	return &Loader{sources: sources}
+}
+
+// Load resolves the effective configuration.
+func (l *Loader) Load(ctx context.Context) (Effective, error) {
+	result := Effective{
+		Config:  Default(),
+		Origins: map[string]string{},
+	}
+
+	for key := range settings {
+		result.Origins[key] = originDefault
+	}
+
+	var sources []Source
+	for _, src := range l.sources {
+		if secrets := src.secrets; secrets != nil {
+			values, err := secrets.LoadSecrets(ctx)
+			if err != nil {
+				return Effective{}, fmt.Errorf("unable to load configuration from %s: %w", secrets.Name(), err)
+			}
+			if result.Secrets == nil {
+				result.Secrets = Secrets{}
+			}
+			maps.Copy(result.Secrets, values)
+			continue
+		}
+
+		composite := src.composite
+		if composite == nil {
+			// a zero LoaderSource provides no settings
+			if src.source != nil {
+				sources = append(sources, src.source)
+			}
+			continue
+		}
+
+		expanded, conflicts, err := composite.Expand(ctx)
+		if err != nil {
+			return Effective{}, fmt.Errorf("unable to load configuration from %s: %w", composite.Name(), err)
+		}
+		sources = append(sources, expanded...)
+		result.Conflicts = append(result.Conflicts, conflicts...)
+	}
+
+	loaded := make([]map[string]string, len(sources))
+	profile := ""
+	for i, src := range sources {
+		values, err := src.Load(ctx)
+		if err != nil {
+			return Effective{}, fmt.Errorf("unable to load configuration from %s: %w", src.Name(), err)
+		}
+		loaded[i] = values
+
+		if value, ok := values[KeyProfile]; ok {
+			if profile, err = ParseProfile(value); err != nil {
+				return Effective{}, fmt.Errorf("%s: invalid value of %s: %w", src.Name(), KeyProfile, err)
+			}
+		}
+	}
+
+	// the profile is applied first, so that explicitly set values take precedence
+	for key, value := range profiles[profile] {
+		if err := result.Config.Set(key, value); err != nil {
+			return Effective{}, fmt.Errorf("%s: %w", profileOrigin(profile), err)
+		}
+		result.Origins[key] = profileOrigin(profile)
+	}
+
+	for i, src := range sources {
+		for key, value := range loaded[i] {
+			if err := result.Config.Set(key, value); err != nil {
+				return Effective{}, fmt.Errorf("%s: %w", src.Name(), err)
+			}
+			result.Origins[key] = src.Name()
+		}
+	}
+
+	// the pool label key of the provider is used unless it is set explicitly
+	if origin := result.Origins[KeyPoolLabelKey]; origin == originDefault {
+		p, err := provider.Get(result.Config.Provider)
+		if err != nil {
+			return Effective{}, err
+		}
+		if p.Name() != provider.Gardener {
+			result.Config.PoolLabelKey = p.PoolLabelKey()
+			result.Origins[KeyPoolLabelKey] = "provider/" + p.Name()
+		}
+	}
+
+	compiled, err := rules.Compile(result.Config.ExcludeRules, result.Config.IncludeRules)
+	if err != nil {
+		return Effective{}, fmt.Errorf("invalid rules: %w", err)
+	}
+	result.Rules = compiled
+
+	return result, nil
+}
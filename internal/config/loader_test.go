@@ -0,0 +1,253 @@
+package config_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "kyma-system"
+
+var testConfigMapKey = client.ObjectKey{Namespace: testNamespace, Name: "kim-snatch-config"}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, snatchv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func testLoader(t *testing.T, fs *flag.FlagSet, objs ...client.Object) *config.Loader {
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(objs...).
+		Build()
+
+	return config.NewLoader(
+		config.WithSource(config.ConfigMapSource(fakeClient, testConfigMapKey)),
+		config.WithCompositeSource(config.SnatchConfigsSource(fakeClient, testNamespace)),
+		config.WithSource(config.EnvSource()),
+		config.WithSource(config.FlagSource(fs)),
+	)
+}
+
+func testFlagSet(t *testing.T, args ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.BindFlags(fs)
+	require.NoError(t, fs.Parse(args))
+	return fs
+}
+
+func Test_Loader_defaults(t *testing.T) {
+	effective, err := testLoader(t, testFlagSet(t)).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), effective.Config)
+	assert.Equal(t, "default", effective.Origins[config.KeyKymaWorkerPoolName])
+}
+
+func Test_Loader_precedence(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data: map[string]string{
+			config.KeyWebhookConfigName:  "from-configmap",
+			config.KeyKymaWorkerPoolName: "from-configmap",
+			config.KeyOmittedNamespaces:  "from-configmap",
+		},
+	}
+	snatchCfg := &snatchv1alpha1.SnatchConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch"},
+		Spec: snatchv1alpha1.SnatchConfigSpec{
+			KymaWorkerPoolName: "from-crd",
+			OmittedNamespaces:  []string{"from-crd", "kube-system"},
+		},
+	}
+	t.Setenv(config.EnvName(config.KeyKymaWorkerPoolName), "from-env")
+
+	fs := testFlagSet(t, "--"+config.KeyWebhookConfigName+"=from-flags")
+	effective, err := testLoader(t, fs, cm, snatchCfg).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-flags", effective.Config.WebhookConfigName)
+	assert.Equal(t, "from-env", effective.Config.KymaWorkerPoolName)
+	assert.Equal(t, []string{"from-crd", "kube-system"}, effective.Config.OmittedNamespaces)
+
+	assert.Equal(t, "flags", effective.Origins[config.KeyWebhookConfigName])
+	assert.Equal(t, "env", effective.Origins[config.KeyKymaWorkerPoolName])
+	assert.Equal(t, "snatchconfig kyma-system/kim-snatch", effective.Origins[config.KeyOmittedNamespaces])
+}
+
+func Test_Loader_invalid_value(t *testing.T) {
+	t.Setenv(config.EnvName(config.KeyAffinityWeight), "101")
+
+	_, err := testLoader(t, testFlagSet(t)).Load(context.Background())
+
+	assert.ErrorContains(t, err, "invalid value of affinity-weight")
+}
+
+func Test_Loader_unknown_configmap_key(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data:       map[string]string{"unknown": "value"},
+	}
+
+	_, err := testLoader(t, testFlagSet(t), cm).Load(context.Background())
+
+	assert.ErrorContains(t, err, "unknown configuration key")
+}
+
+func Test_EnvName(t *testing.T) {
+	assert.Equal(t, "KIM_SNATCH_KYMA_WORKER_POOL_NAME", config.EnvName(config.KeyKymaWorkerPoolName))
+}
+
+func testSnatchConfig(name string, priority int32, pool string) *snatchv1alpha1.SnatchConfig {
+	return &snatchv1alpha1.SnatchConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name},
+		Spec: snatchv1alpha1.SnatchConfigSpec{
+			Priority:           priority,
+			KymaWorkerPoolName: pool,
+		},
+	}
+}
+
+func Test_Loader_snatchconfig_priority(t *testing.T) {
+	loader := testLoader(t, testFlagSet(t),
+		testSnatchConfig("high", 10, "from-high"),
+		testSnatchConfig("low", 0, "from-low"),
+	)
+
+	effective, err := loader.Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-high", effective.Config.KymaWorkerPoolName)
+	assert.Equal(t, "snatchconfig kyma-system/high", effective.Origins[config.KeyKymaWorkerPoolName])
+	assert.Empty(t, effective.Conflicts)
+}
+
+func Test_Loader_snatchconfig_conflict(t *testing.T) {
+	loader := testLoader(t, testFlagSet(t),
+		testSnatchConfig("team-b", 5, "from-b"),
+		testSnatchConfig("team-a", 5, "from-a"),
+		testSnatchConfig("team-c", 5, "from-a"),
+	)
+
+	effective, err := loader.Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-a", effective.Config.KymaWorkerPoolName)
+	assert.Equal(t, "snatchconfig kyma-system/team-a", effective.Origins[config.KeyKymaWorkerPoolName])
+	assert.Equal(t, []config.Conflict{{
+		Key:      config.KeyKymaWorkerPoolName,
+		Priority: 5,
+		Sources:  []string{"team-a", "team-b", "team-c"},
+		Winner:   "team-a",
+	}}, effective.Conflicts)
+}
+
+func Test_Loader_profile(t *testing.T) {
+	snatchCfg := &snatchv1alpha1.SnatchConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch"},
+		Spec:       snatchv1alpha1.SnatchConfigSpec{Profile: config.ProfileStrictIsolation},
+	}
+
+	// explicitly set settings override the profile, regardless of the source precedence
+	fs := testFlagSet(t, "--"+config.KeyTimeoutSeconds+"=20")
+	effective, err := testLoader(t, fs, snatchCfg).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, config.ProfileStrictIsolation, effective.Config.Profile)
+	assert.Equal(t, config.ModeRequired, effective.Config.AffinityMode)
+	assert.Equal(t, "Fail", effective.Config.FailurePolicy)
+	assert.Equal(t, int32(20), effective.Config.TimeoutSeconds)
+	assert.Equal(t, "profile/strict-isolation", effective.Origins[config.KeyAffinityMode])
+	assert.Equal(t, "flags", effective.Origins[config.KeyTimeoutSeconds])
+}
+
+func Test_Loader_unknown_profile(t *testing.T) {
+	_, err := testLoader(t, testFlagSet(t, "--"+config.KeyProfile+"=unknown")).Load(context.Background())
+
+	assert.ErrorContains(t, err, "unknown profile")
+}
+
+func Test_Loader_rules(t *testing.T) {
+	fs := testFlagSet(t, "--"+config.KeyExcludeRules+"=object.metadata.labels['app'] in ['nats', 'eventing']\n'x' == 'y'")
+	effective, err := testLoader(t, fs).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"object.metadata.labels['app'] in ['nats', 'eventing']", "'x' == 'y'"},
+		effective.Config.ExcludeRules)
+	assert.NotNil(t, effective.Rules)
+}
+
+func Test_Loader_invalid_rules(t *testing.T) {
+	fs := testFlagSet(t, "--"+config.KeyIncludeRules+"=object.metadata.name ==")
+	_, err := testLoader(t, fs).Load(context.Background())
+
+	assert.ErrorContains(t, err, "invalid rules")
+}
+
+func Test_Loader_secrets(t *testing.T) {
+	secretKey := client.ObjectKey{Namespace: testNamespace, Name: "kim-snatch-secrets"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretKey.Namespace, Name: secretKey.Name},
+		Data:       map[string][]byte{config.SecretKeyAuditSinkToken: []byte("s3cr3t")},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(secret).Build()
+
+	effective, err := config.NewLoader(config.WithSecretSource(config.SecretObjectSource(fakeClient, secretKey))).Load(context.Background())
+
+	require.NoError(t, err)
+	token, ok := effective.Secrets.Get(config.SecretKeyAuditSinkToken)
+	assert.True(t, ok)
+	assert.Equal(t, "s3cr3t", token)
+
+	data, err := json.Marshal(effective)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+}
+
+func Test_Loader_zero_source(t *testing.T) {
+	effective, err := config.NewLoader(config.LoaderSource{}, config.WithSource(nil)).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), effective.Config)
+}
+
+func Test_Loader_missing_secret(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme(t)).Build()
+
+	effective, err := config.NewLoader(
+		config.WithSecretSource(
+			config.SecretObjectSource(fakeClient, client.ObjectKey{Namespace: testNamespace, Name: "missing"})),
+	).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, effective.Secrets)
+}
+
+func Test_Loader_provider(t *testing.T) {
+	effective, err := testLoader(t, testFlagSet(t, "--"+config.KeyProvider+"=gke")).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "cloud.google.com/gke-nodepool", effective.Config.PoolLabelKey)
+	assert.Equal(t, "provider/gke", effective.Origins[config.KeyPoolLabelKey])
+}
+
+func Test_Loader_provider_explicit_pool_label_key(t *testing.T) {
+	fs := testFlagSet(t, "--"+config.KeyProvider+"=generic", "--"+config.KeyPoolLabelKey+"=example.com/pool")
+	effective, err := testLoader(t, fs).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/pool", effective.Config.PoolLabelKey)
+}
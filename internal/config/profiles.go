@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+// Names of the configuration profiles.
+const (
+	// ProfileEvaluation is meant for evaluation and development clusters, the
+	// webhook stays out of the way if it is not available
+	ProfileEvaluation = "evaluation"
+	// ProfileProduction prefers the Kyma worker pool strongly but never blocks
+	// the creation of pods
+	ProfileProduction = "production"
+	// ProfileStrictIsolation schedules the Kyma components on the Kyma worker
+	// pool only, it needs the RequiredMode feature gate
+	ProfileStrictIsolation = "strict-isolation"
+)
+
+// profiles are preset bundles of settings. A profile overrides the built-in
+// defaults, every setting explicitly set by a source overrides the profile.
+var profiles = map[string]map[string]string{
+	ProfileEvaluation: {
+		KeyAffinityMode:   ModePreferred,
+		KeyAffinityWeight: "10",
+		KeyFailurePolicy:  string(admissionregistrationv1.Ignore),
+		KeyTimeoutSeconds: "5",
+	},
+	ProfileProduction: {
+		KeyAffinityMode:   ModePreferred,
+		KeyAffinityWeight: "100",
+		KeyFailurePolicy:  string(admissionregistrationv1.Ignore),
+		KeyTimeoutSeconds: "10",
+	},
+	ProfileStrictIsolation: {
+		KeyAffinityMode:   ModeRequired,
+		KeyAffinityWeight: "100",
+		KeyFailurePolicy:  string(admissionregistrationv1.Fail),
+		KeyTimeoutSeconds: "10",
+	},
+}
+
+// Profiles returns the sorted names of the configuration profiles.
+func Profiles() []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseProfile parses the name of a configuration profile.
+func ParseProfile(v string) (string, error) {
+	name := strings.TrimSpace(v)
+	if _, ok := profiles[name]; !ok && name != "" {
+		return "", fmt.Errorf("unknown profile %q, must be one of: %s", v, strings.Join(Profiles(), ", "))
+	}
+	return name, nil
+}
+
+func profileOrigin(name string) string {
+	return "profile/" + name
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretKeyAuditSinkToken is the bearer token the records are posted to the
+// HTTP audit sink with.
+const SecretKeyAuditSinkToken = "audit-sink-token"
+
+// Secrets are sensitive settings, e.g. credentials of integrations. They are
+// never part of the Config, so they are neither printed nor exported.
+type Secrets map[string][]byte
+
+// Get returns the value of the sensitive setting.
+func (s Secrets) Get(key string) (string, bool) {
+	value, ok := s[key]
+	return string(value), ok
+}
+
+// SecretSource provides sensitive settings.
+type SecretSource interface {
+	// Name describes the source
+	Name() string
+	// LoadSecrets returns the sensitive settings provided by the source
+	LoadSecrets(ctx context.Context) (Secrets, error)
+}
+
+type secretSource struct {
+	reader client.Reader
+	key    client.ObjectKey
+}
+
+// SecretObjectSource provides the data of the given Secret as sensitive
+// settings. A missing Secret provides no settings.
+func SecretObjectSource(reader client.Reader, key client.ObjectKey) SecretSource {
+	return &secretSource{reader: reader, key: key}
+}
+
+func (s *secretSource) Name() string {
+	return fmt.Sprintf("secret %s", s.key)
+}
+
+func (s *secretSource) LoadSecrets(ctx context.Context) (Secrets, error) {
+	var secret corev1.Secret
+	if err := s.reader.Get(ctx, s.key, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get secret: %w", err)
+	}
+	return Secrets(secret.Data), nil
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"iter"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EnvPrefix is the prefix of the environment variables considered by EnvSource.
+const EnvPrefix = "KIM_SNATCH_"
+
+const originSnatchConfig = "snatchconfig "
+
+// Source provides raw configuration settings keyed by the configuration keys.
+type Source interface {
+	// Name describes the source, it is used to report the origin of a setting
+	Name() string
+	// Load returns the settings provided by the source
+	Load(ctx context.Context) (map[string]string, error)
+}
+
+// CompositeSource is made of multiple sources, resolved every time the
+// configuration is loaded.
+type CompositeSource interface {
+	// Name describes the source
+	Name() string
+	// Expand returns the sources ordered by ascending precedence and the
+	// conflicts detected between them
+	Expand(ctx context.Context) ([]Source, []Conflict, error)
+}
+
+// Conflict describes a key set to different values by sources of the same precedence.
+type Conflict struct {
+	Key      string   `json:"key"`
+	Priority int32    `json:"priority"`
+	Sources  []string `json:"sources"`
+	Winner   string   `json:"winner"`
+}
+
+type flagSource struct {
+	fs *flag.FlagSet
+}
+
+// FlagSource provides the settings explicitly set on the command line.
+func FlagSource(fs *flag.FlagSet) Source {
+	return &flagSource{fs: fs}
+}
+
+func (s *flagSource) Name() string {
+	return "flags"
+}
+
+func (s *flagSource) Load(_ context.Context) (map[string]string, error) {
+	result := map[string]string{}
+	s.fs.Visit(func(f *flag.Flag) {
+		if _, ok := settings[f.Name]; ok {
+			result[f.Name] = f.Value.String()
+		}
+	})
+	return result, nil
+}
+
+type envSource struct {
+	lookup func(string) (string, bool)
+}
+
+// EnvSource provides the settings set as environment variables.
+func EnvSource() Source {
+	return &envSource{lookup: os.LookupEnv}
+}
+
+func (s *envSource) Name() string {
+	return "env"
+}
+
+func (s *envSource) Load(_ context.Context) (map[string]string, error) {
+	result := map[string]string{}
+	for key := range settings {
+		if value, ok := s.lookup(EnvName(key)); ok {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// EnvName returns the name of the environment variable for the given key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+type configMapSource struct {
+	reader client.Reader
+	key    client.ObjectKey
+}
+
+// ConfigMapSource provides the settings stored in the data of the given ConfigMap.
+// A missing ConfigMap provides no settings.
+func ConfigMapSource(reader client.Reader, key client.ObjectKey) Source {
+	return &configMapSource{reader: reader, key: key}
+}
+
+func (s *configMapSource) Name() string {
+	return fmt.Sprintf("configmap %s", s.key)
+}
+
+func (s *configMapSource) Load(ctx context.Context) (map[string]string, error) {
+	var cm corev1.ConfigMap
+	if err := s.reader.Get(ctx, s.key, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get config map: %w", err)
+	}
+
+	result := map[string]string{}
+	for key, value := range cm.Data {
+		if _, ok := settings[key]; !ok {
+			return nil, fmt.Errorf("unknown configuration key %q in config map %s", key, s.key)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+type snatchConfigsSource struct {
+	reader    client.Reader
+	namespace string
+}
+
+// SnatchConfigsSource provides the settings of all SnatchConfig objects in the
+// namespace. Settings of configurations with higher spec.priority override the
+// ones with lower priority. If configurations of the same priority set the same
+// key, the configuration with the alphabetically first name wins and the conflict
+// is reported.
+func SnatchConfigsSource(reader client.Reader, namespace string) CompositeSource {
+	return &snatchConfigsSource{reader: reader, namespace: namespace}
+}
+
+func (s *snatchConfigsSource) Name() string {
+	return fmt.Sprintf("snatchconfigs %s", s.namespace)
+}
+
+func (s *snatchConfigsSource) Expand(ctx context.Context) ([]Source, []Conflict, error) {
+	var list snatchv1alpha1.SnatchConfigList
+	if err := s.reader.List(ctx, &list, client.InNamespace(s.namespace)); err != nil {
+		return nil, nil, fmt.Errorf("unable to list snatch configs: %w", err)
+	}
+
+	items := list.Items
+	// ascending precedence: lower priority first, equal priority in reverse name order
+	slices.SortFunc(items, func(a, b snatchv1alpha1.SnatchConfig) int {
+		if c := cmp.Compare(a.Spec.Priority, b.Spec.Priority); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Name, a.Name)
+	})
+
+	sources := make([]Source, 0, len(items))
+	for _, item := range items {
+		sources = append(sources, &snatchConfigSource{
+			name:     fmt.Sprintf("%s%s/%s", originSnatchConfig, item.Namespace, item.Name),
+			settings: SpecSettings(item.Spec),
+		})
+	}
+
+	return sources, detectConflicts(items), nil
+}
+
+// SnatchConfigOrigin returns the SnatchConfig a setting was taken from, false
+// if the origin is not a SnatchConfig.
+func SnatchConfigOrigin(origin string) (client.ObjectKey, bool) {
+	namespacedName, ok := strings.CutPrefix(origin, originSnatchConfig)
+	if !ok {
+		return client.ObjectKey{}, false
+	}
+
+	namespace, name, ok := strings.Cut(namespacedName, "/")
+	if !ok {
+		return client.ObjectKey{}, false
+	}
+	return client.ObjectKey{Namespace: namespace, Name: name}, true
+}
+
+// detectConflicts returns the keys set to different values by configurations of
+// the same priority, items are expected to be sorted by priority.
+func detectConflicts(items []snatchv1alpha1.SnatchConfig) []Conflict {
+	var conflicts []Conflict
+	for group := range chunkByPriority(items) {
+		values := map[string]map[string]string{}
+		for _, item := range group {
+			for key, value := range SpecSettings(item.Spec) {
+				if values[key] == nil {
+					values[key] = map[string]string{}
+				}
+				values[key][item.Name] = value
+			}
+		}
+
+		for key, byName := range values {
+			if len(byName) < 2 || len(slices.Compact(slices.Sorted(maps.Values(byName)))) < 2 {
+				continue
+			}
+			names := slices.Sorted(maps.Keys(byName))
+			conflicts = append(conflicts, Conflict{
+				Key:      key,
+				Priority: group[0].Spec.Priority,
+				Sources:  names,
+				Winner:   names[0],
+			})
+		}
+	}
+
+	slices.SortFunc(conflicts, func(a, b Conflict) int {
+		return cmp.Or(cmp.Compare(a.Priority, b.Priority), cmp.Compare(a.Key, b.Key))
+	})
+	return conflicts
+}
+
+func chunkByPriority(items []snatchv1alpha1.SnatchConfig) iter.Seq[[]snatchv1alpha1.SnatchConfig] {
+	return func(yield func([]snatchv1alpha1.SnatchConfig) bool) {
+		for start := 0; start < len(items); {
+			end := start + 1
+			for end < len(items) && items[end].Spec.Priority == items[start].Spec.Priority {
+				end++
+			}
+			if !yield(items[start:end]) {
+				return
+			}
+			start = end
+		}
+	}
+}
+
+type snatchConfigSource struct {
+	name     string
+	settings map[string]string
+}
+
+func (s *snatchConfigSource) Name() string {
+	return s.name
+}
+
+func (s *snatchConfigSource) Load(_ context.Context) (map[string]string, error) {
+	return s.settings, nil
+}
+
+// SpecSettings converts the SnatchConfig spec into configuration settings.
+func SpecSettings(spec snatchv1alpha1.SnatchConfigSpec) map[string]string {
+	result := map[string]string{}
+	if spec.KymaWorkerPoolName != "" {
+		result[KeyKymaWorkerPoolName] = spec.KymaWorkerPoolName
+	}
+	if spec.OmittedNamespaces != nil {
+		result[KeyOmittedNamespaces] = strings.Join(spec.OmittedNamespaces, ",")
+	}
+	if spec.Weight != nil {
+		result[KeyAffinityWeight] = strconv.Itoa(int(*spec.Weight))
+	}
+	if spec.Mode != "" {
+		result[KeyAffinityMode] = spec.Mode
+	}
+	if spec.FailurePolicy != "" {
+		result[KeyFailurePolicy] = spec.FailurePolicy
+	}
+	if spec.TimeoutSeconds != nil {
+		result[KeyTimeoutSeconds] = strconv.Itoa(int(*spec.TimeoutSeconds))
+	}
+	if spec.Profile != "" {
+		result[KeyProfile] = spec.Profile
+	}
+	if spec.ExcludeRules != nil {
+		result[KeyExcludeRules] = strings.Join(spec.ExcludeRules, "\n")
+	}
+	if spec.IncludeRules != nil {
+		result[KeyIncludeRules] = strings.Join(spec.IncludeRules, "\n")
+	}
+	if spec.Cleanup {
+		result[KeyCleanup] = strconv.FormatBool(spec.Cleanup)
+	}
+	return result
+}
+
+type sourceFunc struct {
+	name string
+	load func(context.Context) (map[string]string, error)
+}
+
+// SourceFunc creates a source from a function.
+func SourceFunc(name string, load func(context.Context) (map[string]string, error)) Source {
+	return &sourceFunc{name: name, load: load}
+}
+
+func (s *sourceFunc) Name() string {
+	return s.name
+}
+
+func (s *sourceFunc) Load(ctx context.Context) (map[string]string, error) {
+	return s.load(ctx)
+}
@@ -0,0 +1,45 @@
+package config
+
+import "sync/atomic"
+
+// Store holds the effective configuration, it is safe for concurrent use.
+type Store struct {
+	current atomic.Pointer[Effective]
+	applied atomic.Bool
+}
+
+// NewStore creates a store holding the initial configuration.
+func NewStore(initial Effective) *Store {
+	s := &Store{}
+	s.Set(initial)
+	return s
+}
+
+// Get returns the effective configuration.
+func (s *Store) Get() Effective {
+	return *s.current.Load()
+}
+
+// Config returns the configuration.
+func (s *Store) Config() Config {
+	return s.current.Load().Config
+}
+
+// Set replaces the effective configuration, the configuration is considered not
+// applied on the dependent resources until MarkApplied is called.
+func (s *Store) Set(effective Effective) {
+	s.current.Store(&effective)
+	s.applied.Store(false)
+}
+
+// MarkApplied records that the current configuration was applied on all
+// dependent resources.
+func (s *Store) MarkApplied() {
+	s.applied.Store(true)
+}
+
+// Applied returns true if the current configuration was applied on all
+// dependent resources.
+func (s *Store) Applied() bool {
+	return s.applied.Load()
+}
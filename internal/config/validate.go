@@ -0,0 +1,139 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate checks the complete configuration and reports all problems found
+// instead of only the first one.
+func Validate(cfg Config, gate *featuregate.FeatureGate) field.ErrorList {
+	var errs field.ErrorList
+
+	if cfg.WebhookConfigName == "" {
+		errs = append(errs, field.Required(field.NewPath(KeyWebhookConfigName), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(cfg.WebhookConfigName) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyWebhookConfigName), cfg.WebhookConfigName, msg))
+		}
+	}
+
+	if cfg.PoolLabelKey == "" {
+		errs = append(errs, field.Required(field.NewPath(KeyPoolLabelKey),
+			"must be set for the "+cfg.Provider+" provider"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(cfg.PoolLabelKey) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyPoolLabelKey), cfg.PoolLabelKey, msg))
+		}
+	}
+
+	if cfg.KymaWorkerPoolName == "" {
+		errs = append(errs, field.Required(field.NewPath(KeyKymaWorkerPoolName), ""))
+	} else {
+		for _, msg := range validation.IsValidLabelValue(cfg.KymaWorkerPoolName) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyKymaWorkerPoolName), cfg.KymaWorkerPoolName, msg))
+		}
+	}
+
+	for i, zone := range cfg.KymaWorkerPoolZones {
+		for _, msg := range validation.IsValidLabelValue(zone) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyKymaWorkerPoolZones).Index(i), zone, msg))
+		}
+	}
+
+	for _, old := range slices.Sorted(maps.Keys(cfg.PoolSuccessors)) {
+		successor := cfg.PoolSuccessors[old]
+		for _, msg := range validation.IsValidLabelValue(successor) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyPoolSuccessors).Key(old), successor, msg))
+		}
+	}
+
+	for i, ns := range cfg.OmittedNamespaces {
+		for _, msg := range validation.IsDNS1123Label(ns) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyOmittedNamespaces).Index(i), ns, msg))
+		}
+	}
+
+	if cfg.AffinityWeight < 1 || cfg.AffinityWeight > 100 {
+		errs = append(errs, field.Invalid(field.NewPath(KeyAffinityWeight), cfg.AffinityWeight, "must be in range 1-100"))
+	}
+
+	if _, err := ParseMode(cfg.AffinityMode); err != nil {
+		errs = append(errs, field.NotSupported(field.NewPath(KeyAffinityMode), cfg.AffinityMode,
+			[]string{ModePreferred, ModeRequired}))
+	} else if cfg.AffinityMode == ModeRequired && !gate.Enabled(featuregate.RequiredMode) {
+		errs = append(errs, field.Forbidden(field.NewPath(KeyAffinityMode),
+			"required mode needs the "+string(featuregate.RequiredMode)+" feature gate"))
+	}
+
+	if cfg.FailurePolicy == "" {
+		errs = append(errs, field.Required(field.NewPath(KeyFailurePolicy), ""))
+	}
+
+	if cfg.TimeoutSeconds < 1 || cfg.TimeoutSeconds > 30 {
+		errs = append(errs, field.Invalid(field.NewPath(KeyTimeoutSeconds), cfg.TimeoutSeconds, "must be in range 1-30"))
+	}
+
+	if cfg.SaturationThreshold < 1 || cfg.SaturationThreshold > 100 {
+		errs = append(errs, field.Invalid(field.NewPath(KeySaturationThreshold), cfg.SaturationThreshold,
+			"must be in range 1-100"))
+	}
+
+	for i, key := range cfg.TolerationAllowList {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyTolerationAllowList).Index(i), key, msg))
+		}
+	}
+
+	for i, entry := range cfg.DeschedulingAllowList {
+		namespace, component, _ := strings.Cut(entry, "/")
+		for _, msg := range validation.IsDNS1123Label(namespace) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyDeschedulingAllow).Index(i), entry, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(component) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyDeschedulingAllow).Index(i), entry, msg))
+		}
+	}
+	for i, kind := range cfg.NaturalRestartKinds {
+		if kind != KindDeployment && kind != KindStatefulSet {
+			errs = append(errs, field.NotSupported(field.NewPath(KeyNaturalRestartKinds).Index(i), kind,
+				[]string{KindDeployment, KindStatefulSet}))
+		}
+	}
+	for i, cidr := range cfg.APIServerCIDRs {
+		errs = append(errs, validation.IsValidCIDR(field.NewPath(KeyAPIServerCIDRs).Index(i), cidr)...)
+	}
+	if cfg.MonitoringNamespace != "" {
+		for _, msg := range validation.IsDNS1123Label(cfg.MonitoringNamespace) {
+			errs = append(errs, field.Invalid(field.NewPath(KeyMonitoringNamespace), cfg.MonitoringNamespace, msg))
+		}
+	}
+	if len(cfg.DeschedulingAllowList) > 0 && !gate.Enabled(featuregate.Descheduling) {
+		errs = append(errs, field.Forbidden(field.NewPath(KeyDeschedulingAllow),
+			"descheduling needs the "+string(featuregate.Descheduling)+" feature gate"))
+	}
+
+	return errs
+}
+
+// ValidateFiles checks that the given files exist in the directory and are readable.
+func ValidateFiles(path *field.Path, dir string, names ...string) field.ErrorList {
+	var errs field.ErrorList
+	for _, name := range names {
+		filePath := filepath.Join(dir, name)
+		f, err := os.Open(filePath)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Child(name), filePath, err.Error()))
+			continue
+		}
+		_ = f.Close()
+	}
+	return errs
+}
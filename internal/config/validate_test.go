@@ -0,0 +1,93 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func testGate() *featuregate.FeatureGate {
+	return featuregate.New(map[featuregate.Feature]featuregate.FeatureSpec{
+		featuregate.RequiredMode: {Default: false, Stage: featuregate.Alpha},
+	})
+}
+
+func validConfig() config.Config {
+	cfg := config.Default()
+	cfg.WebhookConfigName = "kim-snatch-mutating-webhook-configuration"
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	return cfg
+}
+
+func Test_Validate_valid(t *testing.T) {
+	assert.Empty(t, config.Validate(validConfig(), testGate()))
+}
+
+func Test_Validate_reports_all_errors(t *testing.T) {
+	cfg := config.Default()
+	cfg.PoolLabelKey = "not a/label/key"
+	cfg.OmittedNamespaces = []string{"kube-system", "Invalid_NS"}
+	cfg.AffinityMode = config.ModeRequired
+	cfg.SaturationThreshold = 0
+	cfg.TolerationAllowList = []string{"dedicated", "not a key"}
+	cfg.DeschedulingAllowList = []string{"kyma-system/api-gateway", "Invalid_NS"}
+	cfg.NaturalRestartKinds = []string{config.KindStatefulSet, "DaemonSet"}
+	cfg.APIServerCIDRs = []string{"10.250.0.0/16", "10.250.0.1"}
+	cfg.MonitoringNamespace = "Monitoring"
+
+	errs := config.Validate(cfg, testGate())
+
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	assert.ElementsMatch(t, []string{
+		config.KeyWebhookConfigName,
+		config.KeyPoolLabelKey,
+		config.KeyKymaWorkerPoolName,
+		config.KeyOmittedNamespaces + "[1]",
+		config.KeyAffinityMode,
+		config.KeySaturationThreshold,
+		config.KeyTolerationAllowList + "[1]",
+		config.KeyDeschedulingAllow + "[1]",
+		config.KeyDeschedulingAllow,
+		config.KeyNaturalRestartKinds + "[1]",
+		config.KeyAPIServerCIDRs + "[1]",
+		config.KeyMonitoringNamespace,
+	}, fields)
+}
+
+func Test_Validate_required_mode_with_feature_gate(t *testing.T) {
+	gate := testGate()
+	require.NoError(t, gate.Set("RequiredMode=true"))
+
+	cfg := validConfig()
+	cfg.AffinityMode = config.ModeRequired
+
+	assert.Empty(t, config.Validate(cfg, gate))
+}
+
+func Test_ValidateFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), []byte("test"), 0o600))
+
+	errs := config.ValidateFiles(field.NewPath("tls"), dir, "tls.crt", "tls.key")
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, "tls.tls.key", errs[0].Field)
+}
+
+func Test_ParsePairs(t *testing.T) {
+	pairs, err := config.ParsePairs("old=new, cpu-worker-0=cpu-worker-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"old": "new", "cpu-worker-0": "cpu-worker-1"}, pairs)
+
+	_, err = config.ParsePairs("old")
+	assert.Error(t, err)
+}
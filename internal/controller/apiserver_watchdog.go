@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// apiServerProbeTimeout is the time a probe of the API server may take
+const apiServerProbeTimeout = 5 * time.Second
+
+// APIServerWatchdog periodically probes whether the API server is reachable.
+// While the probes fail, they back off exponentially from the interval up to
+// the maximum backoff. The admission requests are served from the caches
+// meanwhile: the checks reading from the API server keep their last result
+// instead of taking the replica out of the webhook service. A single warning
+// is logged when the API server becomes unreachable, and a summary of the
+// outage once it's reachable again.
+type APIServerWatchdog struct {
+	// Probe checks once if the API server is reachable, e.g. with its /readyz endpoint
+	Probe   func(ctx context.Context) error
+	Metrics metrics.Metrics
+	// Interval between two probes while the API server is reachable
+	Interval time.Duration
+	// MaxBackoff is the longest time between two probes while the API server
+	// is unreachable
+	MaxBackoff time.Duration
+
+	mu sync.Mutex
+	// failures is the number of consecutive failed probes since failingSince
+	failures     int
+	failingSince time.Time
+}
+
+// Start probes the API server until the context is cancelled.
+func (w *APIServerWatchdog) Start(ctx context.Context) error {
+	for {
+		delay := w.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// NeedLeaderElection returns false, every replica serves admission requests.
+func (w *APIServerWatchdog) NeedLeaderElection() bool {
+	return false
+}
+
+// Unreachable returns true while the last probe of the API server failed.
+func (w *APIServerWatchdog) Unreachable() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.failures > 0
+}
+
+// Check probes the API server once and returns the time until the next probe.
+func (w *APIServerWatchdog) Check(ctx context.Context) time.Duration {
+	logger := logf.FromContext(ctx).WithName("apiserver-watchdog")
+
+	probeCtx, cancel := context.WithTimeout(ctx, apiServerProbeTimeout)
+	err := w.Probe(probeCtx)
+	cancel()
+	if err != nil && ctx.Err() != nil {
+		// shutting down
+		return w.Interval
+	}
+
+	w.mu.Lock()
+	failures, failingSince := w.failures, w.failingSince
+	if err != nil {
+		if w.failures == 0 {
+			w.failingSince = time.Now()
+		}
+		w.failures++
+	} else {
+		w.failures = 0
+	}
+	delay := w.delay()
+	w.mu.Unlock()
+
+	switch {
+	case err != nil && failures == 0:
+		logger.Info("API server unreachable, serving admission requests from the caches", "error", err.Error())
+	case err == nil && failures > 0:
+		logger.Info("API server reachable again", "outage", time.Since(failingSince).Round(time.Second),
+			"failedProbes", failures)
+	}
+	if w.Metrics != nil {
+		w.Metrics.SetAPIServerUnreachable(err != nil)
+	}
+	return delay
+}
+
+// delay returns the time until the next probe, doubled with every failed probe.
+func (w *APIServerWatchdog) delay() time.Duration {
+	delay := w.Interval
+	for i := 1; i < w.failures && delay < w.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, max(w.MaxBackoff, w.Interval))
+}
@@ -0,0 +1,43 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_APIServerWatchdog(t *testing.T) {
+	var probeErr error
+	m := mocks.NewMetrics(t)
+	m.On("SetAPIServerUnreachable", false).Twice()
+	m.On("SetAPIServerUnreachable", true).Times(4)
+
+	watchdog := &controller.APIServerWatchdog{
+		Probe:      func(context.Context) error { return probeErr },
+		Metrics:    m,
+		Interval:   10 * time.Second,
+		MaxBackoff: time.Minute,
+	}
+	assert.Equal(t, 10*time.Second, watchdog.Check(t.Context()))
+	assert.False(t, watchdog.Unreachable())
+
+	probeErr = errors.New("connection refused")
+	for _, delay := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute} {
+		assert.Equal(t, delay, watchdog.Check(t.Context()))
+		assert.True(t, watchdog.Unreachable())
+	}
+
+	probeErr = nil
+	assert.Equal(t, 10*time.Second, watchdog.Check(t.Context()))
+	assert.False(t, watchdog.Unreachable())
+}
+
+func Test_APIServerWatchdog_nil(t *testing.T) {
+	var watchdog *controller.APIServerWatchdog
+	assert.False(t, watchdog.Unreachable())
+}
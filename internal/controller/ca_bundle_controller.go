@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// caBundleRequest is the only request the CA bundle reconciler works on.
+var caBundleRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "ca-bundle"}}
+
+// caBundleKey is the entry of the certificate Secret holding the CA bundle.
+const caBundleKey = "ca.crt"
+
+// CABundleReconciler patches the CA bundle of the certificate Secret into the
+// MutatingWebhookConfiguration as soon as the Secret is renewed, without
+// waiting for the kubelet to update the mounted files or for the cainjector of
+// cert-manager. A caBundle changed by someone else is patched back as well.
+type CABundleReconciler struct {
+	client.Client
+
+	// Secret is the certificate Secret, it is provisioned by cert-manager or
+	// Gardener cert-management
+	Secret client.ObjectKey
+	// SecretCache holds the Secret only, the cache of the manager doesn't hold
+	// Secrets
+	SecretCache cache.Cache
+	// WebhookConfigName is the name of the mutating webhook configuration
+	WebhookConfigName string
+	// Publish patches the CA bundle, e.g. into the webhook configuration and the
+	// conversion webhook of the SnatchConfig CustomResourceDefinition
+	Publish func(ctx context.Context, caBundle []byte) error
+	// RolloverGrace is the grace period the CAs replaced by the CA bundle are
+	// advertised for, the bundle is published again once it elapsed
+	RolloverGrace time.Duration
+	// Options tune the work queue of the reconciler
+	Options ReconcilerOptions
+}
+
+func (r *CABundleReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, r.Secret, &secret); err != nil {
+		// the Secret is watched, the bundle is patched once it is issued
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	caBundle := secret.Data[caBundleKey]
+	if len(caBundle) == 0 {
+		logger.Info("certificate secret holds no ca bundle", "secret", r.Secret, "key", caBundleKey)
+		return ctrl.Result{}, nil
+	}
+
+	var webhookConfig admissionregistration.MutatingWebhookConfiguration
+	if err := r.Get(ctx, client.ObjectKey{Name: r.WebhookConfigName}, &webhookConfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to get mutating webhook configuration: %w", err)
+	}
+	applied, until := r.caBundleApplied(&webhookConfig, caBundle, time.Now())
+	if !applied {
+		if err := r.Publish(ctx, caBundle); err != nil {
+			return ctrl.Result{}, fmt.Errorf("unable to update ca bundle: %w", err)
+		}
+		logger.Info("ca bundle updated", "secret", r.Secret, "webhookConfig", r.WebhookConfigName)
+	}
+	if !until.IsZero() {
+		// the replaced CAs are removed from the bundle after the grace period
+		return ctrl.Result{RequeueAfter: time.Until(until)}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// caBundleApplied returns true if all webhooks of the configuration advertise
+// the CA bundle, and the end of the grace period of the CAs it replaced.
+func (r *CABundleReconciler) caBundleApplied(webhookConfig *admissionregistration.MutatingWebhookConfiguration,
+	caBundle []byte, now time.Time) (bool, time.Time) {
+	applied := true
+	var until time.Time
+	for _, webhook := range webhookConfig.Webhooks {
+		expected, end := callback.RolloverCABundle(webhook.ClientConfig.CABundle, caBundle, r.RolloverGrace, now)
+		if !bytes.Equal(webhook.ClientConfig.CABundle, expected) {
+			applied = false
+		}
+		if end.After(until) {
+			until = end
+		}
+	}
+	return applied, until
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CABundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{caBundleRequest}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("ca-bundle").
+		WithOptions(r.Options.controllerOptions()).
+		WatchesRawSource(source.Kind[client.Object](r.SecretCache, &corev1.Secret{}, enqueue,
+			predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return client.ObjectKeyFromObject(obj) == r.Secret
+			}))).
+		Watches(&admissionregistration.MutatingWebhookConfiguration{}, enqueue, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetName() == r.WebhookConfigName
+			}))).
+		Complete(r)
+}
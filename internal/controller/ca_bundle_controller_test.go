@@ -0,0 +1,91 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testCABundleReconciler(objs ...client.Object) (*controller.CABundleReconciler, *[][]byte) {
+	var published [][]byte
+	return &controller.CABundleReconciler{
+		Client:            fake.NewClientBuilder().WithObjects(objs...).Build(),
+		Secret:            client.ObjectKey{Namespace: testNamespace, Name: "kim-snatch-certificates"},
+		WebhookConfigName: "kim-snatch",
+		Publish: func(_ context.Context, caBundle []byte) error {
+			published = append(published, caBundle)
+			return nil
+		},
+	}, &published
+}
+
+func testWebhookConfig(caBundle string) *admissionregistration.MutatingWebhookConfiguration {
+	return &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kim-snatch"},
+		Webhooks: []admissionregistration.MutatingWebhook{{
+			Name:         "pods.kim-snatch.kyma-project.io",
+			ClientConfig: admissionregistration.WebhookClientConfig{CABundle: []byte(caBundle)},
+		}},
+	}
+}
+
+func Test_CABundleReconciler(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch-certificates"},
+		Data:       map[string][]byte{"ca.crt": []byte("new-ca")},
+	}
+
+	t.Run("stale bundle", func(t *testing.T) {
+		r, published := testCABundleReconciler(secret, testWebhookConfig("old-ca"))
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("new-ca")}, *published)
+	})
+
+	t.Run("applied bundle", func(t *testing.T) {
+		r, published := testCABundleReconciler(secret, testWebhookConfig("new-ca"))
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.NoError(t, err)
+		assert.Empty(t, *published)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		r, published := testCABundleReconciler(testWebhookConfig("old-ca"))
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.NoError(t, err)
+		assert.Empty(t, *published)
+	})
+
+	t.Run("failed update", func(t *testing.T) {
+		r, _ := testCABundleReconciler(secret, testWebhookConfig("old-ca"))
+		r.Publish = func(context.Context, []byte) error {
+			return errors.New("conflict")
+		}
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.Error(t, err)
+	})
+
+	t.Run("rollover", func(t *testing.T) {
+		oldCA, newCA := testCertificatePEM(t, 1, time.Hour), testCertificatePEM(t, 2, time.Hour)
+		rotated := secret.DeepCopy()
+		rotated.Data["ca.crt"] = newCA
+		r, published := testCABundleReconciler(rotated, testWebhookConfig(string(oldCA)))
+		r.RolloverGrace = 2 * time.Hour
+		result, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{newCA}, *published)
+		// the new CA became valid an hour ago, the old CA is dropped in an hour
+		assert.InDelta(t, time.Hour, result.RequeueAfter, float64(time.Minute))
+	})
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/capacity"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonPoolAtMaxSize     = "PoolAtMaxSize"
+	EventReasonPoolBelowMaxSize  = "PoolBelowMaxSize"
+	EventReasonPreferOnlyEnabled = "PreferOnlyMode"
+)
+
+// CapacityMonitor periodically checks if the Kyma worker pool is scaled to its
+// maximum size by the cluster-autoscaler and reports it via metric and events.
+type CapacityMonitor struct {
+	Reader   client.Reader
+	Config   func() config.Config
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks
+	Interval time.Duration
+
+	atMaxSize atomic.Bool
+}
+
+// Start runs the monitor until the context is cancelled.
+func (m *CapacityMonitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, m.Check, m.Interval)
+	return nil
+}
+
+// Check reads the capacity of the Kyma worker pool once.
+func (m *CapacityMonitor) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("capacity-monitor")
+
+	cfg := m.Config()
+	status, err := capacity.AutoscalerPoolStatus(ctx, m.Reader, cfg.KymaWorkerPoolName)
+	if err != nil {
+		// keep the last known state
+		logger.Error(err, "unable to check capacity of kyma worker pool")
+		return
+	}
+
+	if m.Metrics != nil {
+		m.Metrics.SetPoolAtMaxSize(status.AtMaxSize)
+	}
+
+	if m.atMaxSize.Swap(status.AtMaxSize) == status.AtMaxSize {
+		return
+	}
+
+	if status.AtMaxSize {
+		logger.Info("kyma worker pool reached its maximum size", "pool", cfg.KymaWorkerPoolName)
+		m.event(corev1.EventTypeWarning, EventReasonPoolAtMaxSize, "kyma worker pool "+cfg.KymaWorkerPoolName+" reached its maximum size")
+		if cfg.DegradeAtMaxSize {
+			m.event(corev1.EventTypeWarning, EventReasonPreferOnlyEnabled, "required node affinity is injected as preferred until the pool can scale up")
+		}
+		return
+	}
+
+	logger.Info("kyma worker pool is below its maximum size", "pool", cfg.KymaWorkerPoolName)
+	m.event(corev1.EventTypeNormal, EventReasonPoolBelowMaxSize, "kyma worker pool "+cfg.KymaWorkerPoolName+" can scale up again")
+}
+
+func (m *CapacityMonitor) event(eventType, reason, message string) {
+	if m.Recorder != nil && m.EventTarget != nil {
+		m.Recorder.Event(m.EventTarget, eventType, reason, message)
+	}
+}
+
+// PreferOnly returns true if the Kyma worker pool is at its maximum size and
+// the configuration allows degrading the required mode.
+func (m *CapacityMonitor) PreferOnly() bool {
+	return m.Config().DegradeAtMaxSize && m.atMaxSize.Load()
+}
+
+// NeedLeaderElection returns false, every replica serves admission requests.
+func (m *CapacityMonitor) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,57 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/capacity"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testAutoscalerStatus = `
+nodeGroups:
+- name: shoot--kyma--test-cpu-worker-0-z1
+  health:
+    cloudProviderTarget: 3
+    maxSize: 3
+`
+
+func Test_CapacityMonitor(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: capacity.AutoscalerStatusKey.Namespace,
+			Name:      capacity.AutoscalerStatusKey.Name,
+		},
+		Data: map[string]string{"status": testAutoscalerStatus},
+	}
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	cfg.DegradeAtMaxSize = true
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPoolAtMaxSize", true).Twice()
+	recorder := record.NewFakeRecorder(10)
+
+	m := &controller.CapacityMonitor{
+		Reader:      fake.NewClientBuilder().WithObjects(cm).Build(),
+		Config:      func() config.Config { return cfg },
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	m.Check(context.Background())
+	m.Check(context.Background())
+
+	assert.True(t, m.PreferOnly())
+	// events are recorded only when the state changes
+	assert.Len(t, recorder.Events, 2)
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonCertificateExpiring = "CertificateExpiring"
+
+	// servingCertificateKey is the entry of the certificate Secret holding the
+	// serving certificate
+	servingCertificateKey = "tls.crt"
+)
+
+// CertificateMonitor periodically reads the serving certificate and the CA of
+// the certificate Secret and reports the time until they expire via metric. A
+// Warning event is recorded once a certificate is within the renewal window,
+// its renewal is overdue.
+type CertificateMonitor struct {
+	Reader client.Reader
+	// Secret is the certificate Secret
+	Secret   client.ObjectKey
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// RenewalWindow is the time before their expiry the certificates are
+	// expected to be renewed
+	RenewalWindow time.Duration
+	// Interval between two checks
+	Interval time.Duration
+
+	// expiring holds the serial number of the expiring certificates an event was
+	// recorded for, a renewed certificate is reported again
+	expiring map[string]string
+}
+
+// Start runs the monitor until the context is cancelled.
+func (m *CertificateMonitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, m.Check, m.Interval)
+	return nil
+}
+
+// Check reads the certificates once.
+func (m *CertificateMonitor) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("certificate-monitor")
+
+	var secret corev1.Secret
+	if err := m.Reader.Get(ctx, m.Secret, &secret); err != nil {
+		logger.Error(err, "unable to get certificate secret", "secret", m.Secret)
+		return
+	}
+
+	now := time.Now()
+	for _, entry := range []struct{ certificate, key string }{
+		{metrics.CertificateServing, servingCertificateKey},
+		{metrics.CertificateCA, caBundleKey},
+	} {
+		// the first certificate of a bundle is the one in use
+		cert, err := firstCertificate(secret.Data[entry.key])
+		if err != nil {
+			logger.Error(err, "unable to parse certificate", "secret", m.Secret, "key", entry.key)
+			continue
+		}
+
+		remaining := cert.NotAfter.Sub(now)
+		if m.Metrics != nil {
+			m.Metrics.SetCertificateExpiry(entry.certificate, remaining.Seconds())
+		}
+		if remaining > m.RenewalWindow {
+			delete(m.expiring, entry.certificate)
+			continue
+		}
+
+		serial := cert.SerialNumber.String()
+		if m.expiring[entry.certificate] == serial {
+			continue
+		}
+		if m.expiring == nil {
+			m.expiring = map[string]string{}
+		}
+		m.expiring[entry.certificate] = serial
+		logger.Info("certificate not renewed", "certificate", entry.certificate, "notAfter", cert.NotAfter)
+		m.event(corev1.EventTypeWarning, EventReasonCertificateExpiring,
+			fmt.Sprintf("%s certificate of the webhook server expires at %s and was not renewed yet, check its issuer",
+				entry.certificate, cert.NotAfter.UTC().Format(time.RFC3339)))
+	}
+}
+
+func (m *CertificateMonitor) event(eventType, reason, message string) {
+	if m.Recorder != nil && m.EventTarget != nil {
+		m.Recorder.Event(m.EventTarget, eventType, reason, message)
+	}
+}
+
+func firstCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no pem encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,83 @@
+package controller_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testCertificatePEM(t *testing.T, serial int64, validity time.Duration) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func Test_CertificateMonitor(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch-certificates"},
+		Data: map[string][]byte{
+			"tls.crt": testCertificatePEM(t, 1, 48*time.Hour),
+			"ca.crt":  testCertificatePEM(t, 2, 365*24*time.Hour),
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+	mtr := &mocks.Metrics{}
+	mtr.On("SetCertificateExpiry", metrics.CertificateServing, mock.MatchedBy(func(seconds float64) bool {
+		return seconds > 0 && seconds <= 48*3600
+	}))
+	mtr.On("SetCertificateExpiry", metrics.CertificateCA, mock.Anything)
+
+	recorder := record.NewFakeRecorder(10)
+	m := &controller.CertificateMonitor{
+		Reader:        c,
+		Secret:        client.ObjectKeyFromObject(secret),
+		Metrics:       mtr,
+		Recorder:      recorder,
+		EventTarget:   &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+		RenewalWindow: 7 * 24 * time.Hour,
+	}
+
+	m.Check(context.Background())
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, controller.EventReasonCertificateExpiring)
+	assert.Contains(t, event, "serving certificate")
+
+	// the expiring certificate is reported once
+	m.Check(context.Background())
+	assert.Empty(t, recorder.Events)
+
+	// a renewed certificate still within the window is reported again
+	secret.Data["tls.crt"] = testCertificatePEM(t, 3, 24*time.Hour)
+	require.NoError(t, c.Update(context.Background(), secret))
+	m.Check(context.Background())
+	assert.Len(t, recorder.Events, 1)
+	mtr.AssertExpectations(t)
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/health"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// configRequest is the only request the config reconciler works on, all watched
+// objects contribute to the single effective configuration.
+var configRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "effective-config"}}
+
+// ApplyFunc applies the effective configuration on a dependent resource.
+type ApplyFunc = func(ctx context.Context, cfg config.Config) error
+
+// ConfigReconciler reloads the configuration whenever one of its sources or the
+// mutating webhook configuration changes, e.g. when a Secret is rotated, and applies it on the dependent resources.
+// Every replica reloads the configuration it serves, the resources shared by
+// the replicas are only updated by the leader.
+type ConfigReconciler struct {
+	Loader *config.Loader
+	Store  *config.Store
+	Gate   *featuregate.FeatureGate
+
+	// Namespace of the configuration sources
+	Namespace string
+	// ConfigMapName is the name of the configuration ConfigMap
+	ConfigMapName string
+	// SecretCaches hold the Secrets of the sensitive settings, each cache holds
+	// a single Secret only, the cache of the manager doesn't hold Secrets
+	SecretCaches []cache.Cache
+	// WebhookConfigName is the name of the mutating webhook configuration
+	WebhookConfigName string
+
+	// Apply is called with the new configuration after every successful reload
+	Apply []ApplyFunc
+	// ApplyShared is called after Apply by the leader only, e.g. to update the
+	// mutating webhook configuration
+	ApplyShared []ApplyFunc
+	// Elected is closed once the replica is elected leader, the configuration is
+	// reloaded then to apply it on the shared resources. Every replica is the
+	// leader if nil.
+	Elected <-chan struct{}
+	// ResyncPeriod forces a periodic reload for sources that can not be watched, optional
+	ResyncPeriod time.Duration
+	// Subsystem tracks whether the last reload succeeded, optional
+	Subsystem *health.Subsystem
+	// WithoutSnatchConfigs is set if the SnatchConfig CRD isn't installed, the
+	// SnatchConfigs aren't watched then
+	WithoutSnatchConfigs bool
+	// Options tune the work queue of the reconciler
+	Options ReconcilerOptions
+
+	once    sync.Once
+	trigger chan struct{}
+}
+
+// Trigger reloads the configuration without a change of its sources, e.g. to
+// relax the failure policy when the Kyma worker pool disappears or returns, it
+// is a pool.PoolPresenceFunc. The reload runs in the work queue of the
+// reconciler, so the caller isn't blocked by the updates of the shared resources.
+func (r *ConfigReconciler) Trigger(_ context.Context, _ bool) {
+	select {
+	case r.triggered() <- struct{}{}:
+	default:
+		// a reload is already pending
+	}
+}
+
+func (r *ConfigReconciler) triggered() chan struct{} {
+	r.once.Do(func() {
+		r.trigger = make(chan struct{}, 1)
+	})
+	return r.trigger
+}
+
+func (r *ConfigReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	effective, err := r.Loader.Load(ctx)
+	if err != nil {
+		err = fmt.Errorf("unable to reload configuration: %w", err)
+		r.Subsystem.Report(err)
+		return ctrl.Result{}, err
+	}
+
+	if errs := config.Validate(effective.Config, r.Gate); len(errs) > 0 {
+		// keep serving with the last valid configuration
+		logger.Error(errs.ToAggregate(), "ignoring invalid configuration")
+		r.Subsystem.Report(fmt.Errorf("ignoring invalid configuration: %w", errs.ToAggregate()))
+		return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+	}
+
+	r.Store.Set(effective)
+
+	applyFuncs := r.Apply
+	if leading(r.Elected) {
+		applyFuncs = append(slices.Clone(applyFuncs), r.ApplyShared...)
+	}
+	for _, apply := range applyFuncs {
+		if err := apply(ctx, effective.Config); err != nil {
+			err = fmt.Errorf("unable to apply configuration: %w", err)
+			r.Subsystem.Report(err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	r.Store.MarkApplied()
+	r.Subsystem.Report(nil)
+	logger.Info("configuration reloaded")
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
+
+// leading returns true once elected is closed, i.e. the manager of the replica
+// was elected leader, or if there is no election.
+func leading(elected <-chan struct{}) bool {
+	if elected == nil {
+		return true
+	}
+	select {
+	case <-elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager, the controller runs
+// on every replica.
+func (r *ConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{configRequest}
+	})
+
+	inNamespace := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.Namespace
+	})
+
+	elected := source.Func(func(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+		if r.Elected == nil {
+			return nil
+		}
+		go func() {
+			select {
+			case <-r.Elected:
+				queue.Add(configRequest)
+			case <-ctx.Done():
+			}
+		}()
+		return nil
+	})
+
+	triggered := source.Func(func(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+		go func() {
+			for {
+				select {
+				case <-r.triggered():
+					queue.Add(configRequest)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return nil
+	})
+
+	options := r.Options.controllerOptions()
+	options.NeedLeaderElection = ptr.To(false)
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("config").
+		WithOptions(options).
+		WatchesRawSource(elected).
+		WatchesRawSource(triggered).
+		Watches(&corev1.ConfigMap{}, enqueue, builder.WithPredicates(
+			inNamespace,
+			predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetName() == r.ConfigMapName
+			})))
+	for _, secrets := range r.SecretCaches {
+		b = b.WatchesRawSource(source.Kind[client.Object](secrets, &corev1.Secret{}, enqueue, inNamespace))
+	}
+	if !r.WithoutSnatchConfigs {
+		b = b.Watches(&snatchv1alpha1.SnatchConfig{}, enqueue, builder.WithPredicates(
+			inNamespace,
+			predicate.GenerationChangedPredicate{}))
+	}
+	return b.
+		Watches(&admissionregistration.MutatingWebhookConfiguration{}, enqueue, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetName() == r.WebhookConfigName
+			}))).
+		Complete(r)
+}
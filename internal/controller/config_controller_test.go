@@ -0,0 +1,152 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "kyma-system"
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, snatchv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func testSnatchConfig(pool string) *snatchv1alpha1.SnatchConfig {
+	return &snatchv1alpha1.SnatchConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch"},
+		Spec:       snatchv1alpha1.SnatchConfigSpec{KymaWorkerPoolName: pool},
+	}
+}
+
+func testReconciler(t *testing.T, objs ...client.Object) *controller.ConfigReconciler {
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(objs...).
+		Build()
+
+	initial := config.Default()
+	initial.WebhookConfigName = "test-me"
+	initial.KymaWorkerPoolName = "initial"
+
+	return &controller.ConfigReconciler{
+		Loader: config.NewLoader(
+			config.WithCompositeSource(config.SnatchConfigsSource(fakeClient, testNamespace)),
+			config.WithSource(config.SourceFunc("test", func(context.Context) (map[string]string, error) {
+				return map[string]string{config.KeyWebhookConfigName: "test-me"}, nil
+			})),
+		),
+		Store:     config.NewStore(config.Effective{Config: initial}),
+		Gate:      featuregate.New(nil),
+		Namespace: testNamespace,
+	}
+}
+
+func Test_ConfigReconciler_reload(t *testing.T) {
+	r := testReconciler(t, testSnatchConfig("reloaded"))
+
+	var applied config.Config
+	r.Apply = []controller.ApplyFunc{func(_ context.Context, cfg config.Config) error {
+		applied = cfg
+		return nil
+	}}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "reloaded", r.Store.Config().KymaWorkerPoolName)
+	assert.Equal(t, "reloaded", applied.KymaWorkerPoolName)
+	assert.True(t, r.Store.Applied())
+}
+
+func Test_ConfigReconciler_invalid_configuration(t *testing.T) {
+	r := testReconciler(t, testSnatchConfig("not a valid pool"))
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "initial", r.Store.Config().KymaWorkerPoolName)
+}
+
+func Test_ConfigReconciler_apply_error(t *testing.T) {
+	r := testReconciler(t, testSnatchConfig("reloaded"))
+	r.Apply = []controller.ApplyFunc{func(context.Context, config.Config) error {
+		return errors.New("test error")
+	}}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+
+	assert.ErrorContains(t, err, "unable to apply configuration")
+	assert.False(t, r.Store.Applied())
+}
+
+func Test_ConfigReconciler_apply_shared(t *testing.T) {
+	r := testReconciler(t, testSnatchConfig("reloaded"))
+	var applied, shared int
+	r.Apply = []controller.ApplyFunc{func(context.Context, config.Config) error {
+		applied++
+		return nil
+	}}
+	r.ApplyShared = []controller.ApplyFunc{func(context.Context, config.Config) error {
+		shared++
+		return nil
+	}}
+	elected := make(chan struct{})
+	r.Elected = elected
+
+	// the shared resources are left to the leader
+	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "reloaded", r.Store.Config().KymaWorkerPoolName)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, 0, shared)
+	assert.True(t, r.Store.Applied())
+
+	close(elected)
+	_, err = r.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied)
+	assert.Equal(t, 1, shared)
+}
+
+func Test_ConfigReconciler_rollout_after_election(t *testing.T) {
+	pool := "initial"
+	r := testReconciler(t)
+	load := func(context.Context) (map[string]string, error) {
+		return map[string]string{config.KeyWebhookConfigName: "test-me", config.KeyKymaWorkerPoolName: pool}, nil
+	}
+	r.Loader = config.NewLoader(config.WithSource(config.SourceFunc("test", load)))
+	orchestrator := &controller.RolloutOrchestrator{}
+	r.ApplyShared = []controller.ApplyFunc{orchestrator.Apply}
+	elected := make(chan struct{})
+	r.Elected = elected
+
+	// the placement changes the standby replica reloads are rolled out by the leader
+	for _, pool = range []string{"initial", "renamed"} {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.NoError(t, err)
+	}
+	close(elected)
+	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.True(t, orchestrator.Done())
+
+	pool = "other"
+	_, err = r.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.False(t, orchestrator.Done())
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DriftReasonSource reports that the effective configuration differs from
+	// its sources, the last reload failed or was rejected.
+	DriftReasonSource = "source"
+	// DriftReasonApply reports that the effective configuration was not
+	// applied on all dependent resources.
+	DriftReasonApply = "apply"
+
+	EventReasonConfigDrift = "ConfigDrift"
+)
+
+var driftReasons = []string{DriftReasonSource, DriftReasonApply}
+
+// ConfigDriftReporter periodically compares the effective configuration with
+// its sources and reports drift via the config_drift metric and an event.
+type ConfigDriftReporter struct {
+	Loader   *config.Loader
+	Store    *config.Store
+	Gate     *featuregate.FeatureGate
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object drift events are recorded for, events are
+	// not recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks
+	Interval time.Duration
+
+	drifted map[string]bool
+}
+
+// Start runs the reporter until the context is cancelled.
+func (r *ConfigDriftReporter) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, r.Check, r.Interval)
+	return nil
+}
+
+// Check compares the effective configuration with its sources once.
+func (r *ConfigDriftReporter) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("config-drift")
+
+	if r.drifted == nil {
+		r.drifted = map[string]bool{}
+	}
+
+	current := map[string]string{}
+
+	effective, err := r.Loader.Load(ctx)
+	switch {
+	case err != nil:
+		current[DriftReasonSource] = "unable to load configuration: " + err.Error()
+	case len(config.Validate(effective.Config, r.Gate)) > 0:
+		current[DriftReasonSource] = "configuration sources are invalid, last valid configuration is used"
+	case effective.Config.Hash() != r.Store.Config().Hash():
+		current[DriftReasonSource] = "configuration sources changed but were not reloaded"
+	}
+
+	if !r.Store.Applied() {
+		current[DriftReasonApply] = "configuration was not applied on all dependent resources"
+	}
+
+	for _, reason := range driftReasons {
+		message, drifted := current[reason]
+		if r.Metrics != nil {
+			r.Metrics.SetConfigDrift(reason, drifted)
+		}
+
+		if drifted && !r.drifted[reason] {
+			logger.Info("configuration drift detected", "reason", reason, "message", message)
+			if r.Recorder != nil && r.EventTarget != nil {
+				r.Recorder.Event(r.EventTarget, corev1.EventTypeWarning, EventReasonConfigDrift, message)
+			}
+		}
+		r.drifted[reason] = drifted
+	}
+}
+
+// NeedLeaderElection returns false, every replica serves its own configuration.
+func (r *ConfigDriftReporter) NeedLeaderElection() bool {
+	return false
+}
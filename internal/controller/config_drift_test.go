@@ -0,0 +1,87 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func testDriftReporter(t *testing.T, load func(context.Context) (map[string]string, error)) (*controller.ConfigDriftReporter, *mocks.Metrics, *record.FakeRecorder) {
+	initial := config.Default()
+	initial.WebhookConfigName = "test-me"
+	initial.KymaWorkerPoolName = "initial"
+
+	mtr := mocks.NewMetrics(t)
+	recorder := record.NewFakeRecorder(10)
+
+	return &controller.ConfigDriftReporter{
+		Loader:      config.NewLoader(config.WithSource(config.SourceFunc("test", load))),
+		Store:       config.NewStore(config.Effective{Config: initial}),
+		Gate:        featuregate.New(nil),
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}, mtr, recorder
+}
+
+func testSources(pool string) func(context.Context) (map[string]string, error) {
+	return func(context.Context) (map[string]string, error) {
+		return map[string]string{
+			config.KeyWebhookConfigName:  "test-me",
+			config.KeyKymaWorkerPoolName: pool,
+		}, nil
+	}
+}
+
+func Test_ConfigDriftReporter_no_drift(t *testing.T) {
+	r, mtr, recorder := testDriftReporter(t, testSources("initial"))
+	r.Store.MarkApplied()
+	mtr.On("SetConfigDrift", controller.DriftReasonSource, false).Once()
+	mtr.On("SetConfigDrift", controller.DriftReasonApply, false).Once()
+
+	r.Check(context.Background())
+
+	assert.Empty(t, recorder.Events)
+}
+
+func Test_ConfigDriftReporter_source_drift(t *testing.T) {
+	for name, load := range map[string]func(context.Context) (map[string]string, error){
+		"changed": testSources("changed"),
+		"invalid": testSources("not a valid pool"),
+		"failed": func(context.Context) (map[string]string, error) {
+			return nil, errors.New("test error")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, mtr, recorder := testDriftReporter(t, load)
+			r.Store.MarkApplied()
+			mtr.On("SetConfigDrift", controller.DriftReasonSource, true).Twice()
+			mtr.On("SetConfigDrift", controller.DriftReasonApply, false).Twice()
+
+			r.Check(context.Background())
+			r.Check(context.Background())
+
+			// the event is recorded only once per drift
+			assert.Len(t, recorder.Events, 1)
+		})
+	}
+}
+
+func Test_ConfigDriftReporter_apply_drift(t *testing.T) {
+	r, mtr, recorder := testDriftReporter(t, testSources("initial"))
+	mtr.On("SetConfigDrift", controller.DriftReasonSource, false).Once()
+	mtr.On("SetConfigDrift", controller.DriftReasonApply, true).Once()
+
+	r.Check(context.Background())
+
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonConfigDrift)
+}
@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonPodsDescheduled  = "PodsDescheduled"
+	EventReasonDeschedulingPlan = "DeschedulingPlanned"
+
+	// LabelComponent is the label of the pods matched by the namespace/component
+	// entries of the descheduling allow-list
+	LabelComponent = "app.kubernetes.io/component"
+)
+
+// Descheduler periodically evicts the pods of the Kyma namespaces running
+// outside of the Kyma worker pool, so that their replacements are steered to
+// the pool by the webhook. Only the pods matching the descheduling allow-list
+// are evicted, and only while the Descheduling feature gate is enabled.
+type Descheduler struct {
+	// Namespaces lists the Kyma namespaces
+	Namespaces client.Reader
+	// Client lists and evicts the pods of the Kyma namespaces, the cache of the
+	// manager only holds the pending ones
+	Client client.Client
+	// IsMember returns true if the node belongs to the Kyma worker pool
+	IsMember func(node string) bool
+	// Synced returns false until the nodes of the pools were listed, IsMember
+	// and PoolReady can't be trusted before, optional
+	Synced func() bool
+	// PoolReady returns false while the Kyma worker pool can't take the evicted pods, optional
+	PoolReady func() bool
+	// Saturated returns true while the Kyma worker pool is saturated, optional
+	Saturated func() bool
+	Config    func() config.Config
+	Gate      *featuregate.FeatureGate
+	Metrics   metrics.Metrics
+	Recorder  record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// MaxEvictions limits the number of pods evicted per interval
+	MaxEvictions int
+	// MaxDisruptionsPerNamespace limits the number of disrupted pods per namespace,
+	// pods being deleted or not ready count as disrupted, unlimited if zero
+	MaxDisruptionsPerNamespace int
+	// WorkloadCooldown is the time after an eviction before the next pod of the
+	// same workload is evicted
+	WorkloadCooldown time.Duration
+	// Interval between two runs
+	Interval time.Duration
+
+	// plan are the candidates of the last run, the plan is only reported when it changed
+	plan string
+	// evictedAt is the time of the last eviction per workload
+	evictedAt map[string]time.Time
+}
+
+// Start runs the descheduler until the context is cancelled.
+func (d *Descheduler) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, d.Check, d.Interval)
+	return nil
+}
+
+// Check plans the eviction of the pods running outside of the Kyma worker pool
+// and evicts up to MaxEvictions of them once, unless only the plan is configured.
+func (d *Descheduler) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("descheduler")
+	cfg := d.Config()
+	if !d.Gate.Enabled(featuregate.Descheduling) || len(cfg.DeschedulingAllowList) == 0 {
+		return
+	}
+	// the placement is being removed by the WorkloadReverter
+	if cfg.Cleanup {
+		return
+	}
+	// before the nodes are listed, every pod would be taken as off the pool
+	if d.Synced != nil && !d.Synced() {
+		return
+	}
+	// the replacements of the evicted pods would not be steered to the pool
+	if d.PoolReady != nil && !d.PoolReady() {
+		return
+	}
+	if d.Saturated != nil && d.Saturated() {
+		return
+	}
+
+	var namespaces metav1.PartialObjectMetadataList
+	namespaces.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	if err := d.Namespaces.List(ctx, &namespaces, client.MatchingLabels{
+		LabelKymaManagedBy: kymaManagedByValue,
+	}); err != nil {
+		logger.Error(err, "unable to list kyma namespaces")
+		return
+	}
+
+	var candidates []*corev1.Pod
+	disrupted := map[string]int{}
+	for _, namespace := range namespaces.Items {
+		if slices.Contains(cfg.OmittedNamespaces, namespace.Name) ||
+			!slices.ContainsFunc(cfg.DeschedulingAllowList, func(entry string) bool {
+				allowed, _, _ := strings.Cut(entry, "/")
+				return allowed == namespace.Name
+			}) {
+			continue
+		}
+
+		var pods corev1.PodList
+		if err := d.Client.List(ctx, &pods, client.InNamespace(namespace.Name)); err != nil {
+			logger.Error(err, "unable to list pods of kyma namespace", "namespace", namespace.Name)
+			continue
+		}
+		for i := range pods.Items {
+			if isDisrupted(&pods.Items[i]) {
+				disrupted[namespace.Name]++
+			}
+			if d.evictable(&pods.Items[i], cfg) {
+				candidates = append(candidates, &pods.Items[i])
+			}
+		}
+	}
+	d.report(ctx, cfg, candidates)
+	if cfg.DeschedulingPlanOnly {
+		return
+	}
+
+	if d.evictedAt == nil {
+		d.evictedAt = map[string]time.Time{}
+	}
+	var evicted []string
+	for _, pod := range candidates {
+		if len(evicted) >= d.MaxEvictions {
+			break
+		}
+		// the components of a namespace are sensitive to simultaneous restarts
+		if d.MaxDisruptionsPerNamespace > 0 && disrupted[pod.Namespace] >= d.MaxDisruptionsPerNamespace {
+			continue
+		}
+		kind, name := podWorkload(pod)
+		workload := pod.Namespace + "/" + kind + "/" + name
+		if last, ok := d.evictedAt[workload]; ok && time.Since(last) < d.WorkloadCooldown {
+			continue
+		}
+
+		ok, err := d.evict(ctx, pod)
+		if err != nil {
+			logger.Error(err, "unable to evict pod", "namespace", pod.Namespace, "name", pod.Name)
+			continue
+		}
+		if ok {
+			evicted = append(evicted, pod.Namespace+"/"+pod.Name)
+			disrupted[pod.Namespace]++
+			d.evictedAt[workload] = time.Now()
+		}
+	}
+
+	if len(evicted) == 0 {
+		return
+	}
+	logger.Info("pods outside of kyma worker pool evicted", "pool", cfg.KymaWorkerPoolName, "pods", evicted)
+	d.event(corev1.EventTypeNormal, EventReasonPodsDescheduled,
+		fmt.Sprintf("%d pods evicted from outside of kyma worker pool %s: %s", len(evicted),
+			cfg.KymaWorkerPoolName, reportedNames(evicted)))
+}
+
+// report exposes the number of eviction candidates and logs and records the
+// plan if it changed since the last run, so that the impact can be reviewed
+// before the pods are evicted.
+func (d *Descheduler) report(ctx context.Context, cfg config.Config, candidates []*corev1.Pod) {
+	if d.Metrics != nil {
+		d.Metrics.SetDeschedulingCandidates(len(candidates))
+	}
+
+	names := make([]string, 0, len(candidates))
+	for _, pod := range candidates {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	plan := strings.Join(names, ",")
+	if plan == d.plan {
+		return
+	}
+	d.plan = plan
+	if len(candidates) == 0 {
+		return
+	}
+
+	logf.FromContext(ctx).WithName("descheduler").Info("eviction of pods outside of kyma worker pool planned",
+		"pool", cfg.KymaWorkerPoolName, "planOnly", cfg.DeschedulingPlanOnly, "candidates", len(candidates),
+		"maxEvictions", d.MaxEvictions, "pods", names)
+	message := fmt.Sprintf("%d pods outside of kyma worker pool %s are evicted, at most %d per run: %s",
+		len(candidates), cfg.KymaWorkerPoolName, d.MaxEvictions, reportedNames(names))
+	if cfg.DeschedulingPlanOnly {
+		message = fmt.Sprintf("%d pods outside of kyma worker pool %s would be evicted, only the plan is configured: %s",
+			len(candidates), cfg.KymaWorkerPoolName, reportedNames(names))
+	}
+	d.event(corev1.EventTypeNormal, EventReasonDeschedulingPlan, message)
+}
+
+// evictable returns true if the pod runs outside of the Kyma worker pool, is
+// matched by the allow-list, is recreated by its controller once evicted, and
+// its workload may be restarted.
+func (d *Descheduler) evictable(pod *corev1.Pod, cfg config.Config) bool {
+	if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	if d.IsMember(pod.Spec.NodeName) {
+		return false
+	}
+
+	// bare pods are not recreated, daemon set pods are bound to their node
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind == "DaemonSet" {
+		return false
+	}
+	// the labels of the pod template are the labels of the pod
+	if kind, _ := podWorkload(pod); naturalRestartOnly(cfg, kind, pod.Labels) {
+		return false
+	}
+
+	return slices.ContainsFunc(cfg.DeschedulingAllowList, func(entry string) bool {
+		namespace, component, found := strings.Cut(entry, "/")
+		return namespace == pod.Namespace && (!found || pod.Labels[LabelComponent] == component)
+	})
+}
+
+// isDisrupted returns true if the pod is being deleted or not ready, e.g. the
+// replacement of an evicted pod that is still starting.
+func isDisrupted(pod *corev1.Pod) bool {
+	if isTerminated(pod) {
+		return false
+	}
+	if pod.DeletionTimestamp != nil {
+		return true
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status != corev1.ConditionTrue
+		}
+	}
+	return true
+}
+
+// evict evicts the pod with the eviction API, which rejects the eviction if it
+// would violate a PodDisruptionBudget, true is returned if the pod was evicted.
+func (d *Descheduler) evict(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	err := d.Client.SubResource("eviction").Create(ctx, pod, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+	})
+	switch {
+	case err == nil:
+		d.incEvictions(metrics.EvictionResultEvicted)
+		return true, nil
+	case apierrors.IsTooManyRequests(err):
+		// the pod is evicted on one of the next runs, once the budget allows it
+		d.incEvictions(metrics.EvictionResultBlocked)
+		logf.FromContext(ctx).V(1).Info("eviction blocked by pod disruption budget",
+			"namespace", pod.Namespace, "name", pod.Name)
+		return false, nil
+	case apierrors.IsNotFound(err):
+		return false, nil
+	default:
+		d.incEvictions(metrics.EvictionResultFailed)
+		return false, err
+	}
+}
+
+func (d *Descheduler) incEvictions(result string) {
+	if d.Metrics != nil {
+		d.Metrics.IncEvictions(result)
+	}
+}
+
+func (d *Descheduler) event(eventType, reason, message string) {
+	if d.Recorder != nil && d.EventTarget != nil {
+		d.Recorder.Event(d.EventTarget, eventType, reason, message)
+	}
+}
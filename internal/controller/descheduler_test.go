@@ -0,0 +1,226 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func testOwnedPod(name, node, kind string, labels map[string]string) *corev1.Pod {
+	pod := testScheduledPod(testNamespace, name, node, corev1.PodRunning)
+	pod.Labels = labels
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if kind != "" {
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       kind,
+			Name:       name,
+			UID:        "owner",
+			Controller: ptr.To(true),
+		}}
+	}
+	return pod
+}
+
+func testDescheduler(t *testing.T, c client.Client, allowList ...string) *controller.Descheduler {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	cfg.DeschedulingAllowList = allowList
+
+	return &controller.Descheduler{
+		Namespaces: c,
+		Client:     c,
+		IsMember:   func(node string) bool { return node == "pool-node" },
+		Config:     func() config.Config { return cfg },
+		Gate: featuregate.New(map[featuregate.Feature]featuregate.FeatureSpec{
+			featuregate.Descheduling: {Default: true, Stage: featuregate.Alpha},
+		}),
+		MaxEvictions: 5,
+	}
+}
+
+func testKymaNamespace() *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   testNamespace,
+		Labels: map[string]string{controller.LabelKymaManagedBy: "kyma"},
+	}}
+}
+
+func Test_Descheduler(t *testing.T) {
+	component := map[string]string{controller.LabelComponent: "api-gateway"}
+	c := fake.NewClientBuilder().WithObjects(testKymaNamespace(),
+		testOwnedPod("off-pool", "other-node", "ReplicaSet", component),
+		testOwnedPod("other-component", "other-node", "ReplicaSet", map[string]string{controller.LabelComponent: "istio"}),
+		testOwnedPod("on-pool", "pool-node", "ReplicaSet", component),
+		testOwnedPod("bare", "other-node", "", component),
+		testOwnedPod("daemon", "other-node", "DaemonSet", component),
+		testOwnedPod("natural-restart", "other-node", "ReplicaSet", map[string]string{
+			controller.LabelComponent:      "api-gateway",
+			controller.LabelNaturalRestart: "true",
+		}),
+	).Build()
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetDeschedulingCandidates", 1).Once()
+	mtr.On("IncEvictions", metrics.EvictionResultEvicted).Once()
+	recorder := record.NewFakeRecorder(10)
+
+	d := testDescheduler(t, c, testNamespace+"/api-gateway")
+	d.Metrics = mtr
+	d.Recorder = recorder
+	d.EventTarget = &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"}
+	d.Check(context.Background())
+
+	var pods corev1.PodList
+	require.NoError(t, c.List(context.Background(), &pods))
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+	assert.ElementsMatch(t, []string{"other-component", "on-pool", "bare", "daemon", "natural-restart"}, names)
+
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonDeschedulingPlan)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonPodsDescheduled)
+}
+
+func Test_Descheduler_max_evictions(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(testKymaNamespace(),
+		testOwnedPod("off-pool-1", "other-node", "ReplicaSet", nil),
+		testOwnedPod("off-pool-2", "other-node", "ReplicaSet", nil),
+		testOwnedPod("off-pool-3", "other-node", "ReplicaSet", nil),
+	).Build()
+
+	d := testDescheduler(t, c, testNamespace)
+	d.MaxEvictions = 2
+	d.Check(context.Background())
+
+	var pods corev1.PodList
+	require.NoError(t, c.List(context.Background(), &pods))
+	assert.Len(t, pods.Items, 1)
+}
+
+func Test_Descheduler_pacing(t *testing.T) {
+	// the replacement of a pod evicted before is still starting
+	starting := testOwnedPod("starting", "pool-node", "ReplicaSet", nil)
+	starting.Status.Conditions = nil
+	first := testOwnedPod("api-1", "other-node", "ReplicaSet", nil)
+	second := testOwnedPod("api-2", "other-node", "ReplicaSet", nil)
+	second.OwnerReferences[0].Name = first.OwnerReferences[0].Name
+	third := testOwnedPod("database-0", "other-node", "StatefulSet", nil)
+	c := fake.NewClientBuilder().WithObjects(testKymaNamespace(), starting, first, second, third).Build()
+
+	d := testDescheduler(t, c, testNamespace)
+	d.MaxDisruptionsPerNamespace = 2
+	d.WorkloadCooldown = time.Hour
+	d.Check(context.Background())
+
+	// one more pod is disrupted, the second pod of the workload waits for the cooldown
+	assert.True(t, apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(first), &corev1.Pod{})))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(second), &corev1.Pod{}))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(third), &corev1.Pod{}))
+
+	require.NoError(t, c.Delete(context.Background(), starting))
+	d.Check(context.Background())
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(second), &corev1.Pod{}))
+	assert.True(t, apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(third), &corev1.Pod{})))
+}
+
+func Test_Descheduler_pod_disruption_budget(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(testKymaNamespace(),
+		testOwnedPod("off-pool", "other-node", "ReplicaSet", nil),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		SubResourceCreate: func(_ context.Context, _ client.Client, _ string, _ client.Object,
+			_ client.Object, _ ...client.SubResourceCreateOption) error {
+			return apierrors.NewTooManyRequests("cannot evict pod as it would violate the pod's disruption budget", 0)
+		},
+	}).Build()
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetDeschedulingCandidates", 1).Twice()
+	mtr.On("IncEvictions", metrics.EvictionResultBlocked).Twice()
+	recorder := record.NewFakeRecorder(10)
+
+	d := testDescheduler(t, c, testNamespace)
+	d.Metrics = mtr
+	d.Recorder = recorder
+	d.EventTarget = &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"}
+	d.Check(context.Background())
+
+	// only the plan is recorded, and it is not recorded again while it is unchanged
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonDeschedulingPlan)
+	d.Check(context.Background())
+	assert.Empty(t, recorder.Events)
+}
+
+func Test_Descheduler_plan_only(t *testing.T) {
+	pod := testOwnedPod("off-pool", "other-node", "ReplicaSet", nil)
+	c := fake.NewClientBuilder().WithObjects(testKymaNamespace(), pod,
+		testOwnedPod("on-pool", "pool-node", "ReplicaSet", nil),
+	).Build()
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetDeschedulingCandidates", 1).Once()
+	recorder := record.NewFakeRecorder(10)
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	cfg.DeschedulingAllowList = []string{testNamespace}
+	cfg.DeschedulingPlanOnly = true
+	d := testDescheduler(t, c)
+	d.Config = func() config.Config { return cfg }
+	d.Metrics = mtr
+	d.Recorder = recorder
+	d.EventTarget = &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"}
+	d.Check(context.Background())
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(pod), &corev1.Pod{}))
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, controller.EventReasonDeschedulingPlan)
+	assert.Contains(t, event, testNamespace+"/off-pool")
+}
+
+func Test_Descheduler_skipped(t *testing.T) {
+	for name, modify := range map[string]func(d *controller.Descheduler){
+		"feature gate disabled": func(d *controller.Descheduler) { d.Gate = featuregate.New(nil) },
+		"pool unsynced":         func(d *controller.Descheduler) { d.Synced = func() bool { return false } },
+		"pool not ready":        func(d *controller.Descheduler) { d.PoolReady = func() bool { return false } },
+		"pool saturated":        func(d *controller.Descheduler) { d.Saturated = func() bool { return true } },
+		"cleanup": func(d *controller.Descheduler) {
+			cfg := d.Config()
+			cfg.Cleanup = true
+			d.Config = func() config.Config { return cfg }
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pod := testOwnedPod("off-pool", "other-node", "ReplicaSet", nil)
+			c := fake.NewClientBuilder().WithObjects(testKymaNamespace(), pod).Build()
+
+			d := testDescheduler(t, c, testNamespace)
+			modify(d)
+			d.Check(context.Background())
+
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(pod), &corev1.Pod{}))
+		})
+	}
+}
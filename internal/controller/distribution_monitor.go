@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// LabelKymaManagedBy marks the namespaces the webhook mutates the pods of
+	LabelKymaManagedBy = "operator.kyma-project.io/managed-by"
+	// kymaManagedByValue is the value of LabelKymaManagedBy of the Kyma namespaces
+	kymaManagedByValue = "kyma"
+)
+
+// DistributionMonitor periodically counts the scheduled pods of the Kyma
+// namespaces running on and off the Kyma worker pool.
+type DistributionMonitor struct {
+	// Namespaces lists the Kyma namespaces
+	Namespaces client.Reader
+	// Pods lists the pods of the Kyma namespaces, the cache of the manager only
+	// holds the pending ones
+	Pods client.Reader
+	// IsMember returns true if the node belongs to the Kyma worker pool
+	IsMember func(node string) bool
+	// Synced returns false until the nodes of the pools were listed, IsMember
+	// can't be trusted before, optional
+	Synced  func() bool
+	Config  func() config.Config
+	Metrics metrics.Metrics
+
+	// Interval between two checks
+	Interval time.Duration
+}
+
+// Start runs the monitor until the context is cancelled.
+func (m *DistributionMonitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, m.Check, m.Interval)
+	return nil
+}
+
+// Check counts the pods once.
+func (m *DistributionMonitor) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("distribution-monitor")
+	cfg := m.Config()
+	// before the nodes are listed, every pod would be counted as off the pool
+	if m.Synced != nil && !m.Synced() {
+		return
+	}
+
+	// the namespaces are cached as metadata only, see the pod webhook
+	var namespaces metav1.PartialObjectMetadataList
+	namespaces.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	if err := m.Namespaces.List(ctx, &namespaces, client.MatchingLabels{
+		LabelKymaManagedBy: kymaManagedByValue,
+	}); err != nil {
+		logger.Error(err, "unable to list kyma namespaces")
+		return
+	}
+
+	var onPool, offPool int
+	for _, namespace := range namespaces.Items {
+		if slices.Contains(cfg.OmittedNamespaces, namespace.Name) {
+			continue
+		}
+
+		var pods corev1.PodList
+		if err := m.Pods.List(ctx, &pods, client.InNamespace(namespace.Name)); err != nil {
+			// keep the last known distribution
+			logger.Error(err, "unable to list pods of kyma namespace", "namespace", namespace.Name)
+			return
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Spec.NodeName == "" || isTerminated(pod) {
+				continue
+			}
+			if m.IsMember(pod.Spec.NodeName) {
+				onPool++
+				continue
+			}
+			offPool++
+		}
+	}
+
+	if m.Metrics != nil {
+		m.Metrics.SetPodDistribution(onPool, offPool)
+	}
+}
+
+// NeedLeaderElection returns false, every replica reports its own view.
+func (m *DistributionMonitor) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,60 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testScheduledPod(namespace, name, node string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func Test_DistributionMonitor(t *testing.T) {
+	kyma := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "kyma-system",
+		Labels: map[string]string{controller.LabelKymaManagedBy: "kyma"},
+	}}
+	omitted := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "kube-system",
+		Labels: map[string]string{controller.LabelKymaManagedBy: "kyma"},
+	}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+
+	c := fake.NewClientBuilder().WithObjects(kyma, omitted, other,
+		testScheduledPod("kyma-system", "on-pool", "pool-node", corev1.PodRunning),
+		testScheduledPod("kyma-system", "off-pool", "other-node", corev1.PodRunning),
+		testScheduledPod("kyma-system", "completed", "other-node", corev1.PodSucceeded),
+		testScheduledPod("kyma-system", "pending", "", corev1.PodPending),
+		testScheduledPod("kube-system", "omitted", "other-node", corev1.PodRunning),
+		testScheduledPod("default", "other", "other-node", corev1.PodRunning),
+	).Build()
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPodDistribution", 1, 1).Once()
+
+	synced := false
+
+	m := &controller.DistributionMonitor{
+		Namespaces: c,
+		Pods:       c,
+		IsMember:   func(node string) bool { return node == "pool-node" },
+		Synced:     func() bool { return synced },
+		Config:     config.Default,
+		Metrics:    mtr,
+	}
+	// the pods are only counted once the nodes were listed
+	m.Check(context.Background())
+	synced = true
+	m.Check(context.Background())
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// FleetSnatchConfigName is the name of the SnatchConfig applied to the
+	// managed clusters in fleet mode
+	FleetSnatchConfigName = "kim-snatch-fleet"
+	// DefaultKubeconfigLabel is the label of the kubeconfig Secrets of the
+	// managed clusters stored in KCP
+	DefaultKubeconfigLabel = "kyma-project.io/runtime-id"
+	// DefaultKubeconfigKey is the key of the kubeconfig in the Secrets
+	DefaultKubeconfigKey = "config"
+)
+
+// NewClientFunc creates the client of the cluster the kubeconfig points to.
+type NewClientFunc func(kubeconfig []byte) (client.Client, error)
+
+// KubeconfigClient returns a NewClientFunc creating clients with the given scheme.
+func KubeconfigClient(scheme *runtime.Scheme) NewClientFunc {
+	return func(kubeconfig []byte) (client.Client, error) {
+		restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return client.New(restConfig, client.Options{Scheme: scheme})
+	}
+}
+
+// FleetReconciler distributes the fleet SnatchConfig of KCP to all managed
+// clusters whose kubeconfig Secrets are stored in KCP.
+type FleetReconciler struct {
+	client.Client
+
+	// Namespace of the kubeconfig Secrets and the fleet SnatchConfig
+	Namespace string
+	// TemplateName is the name of the SnatchConfig applied to the managed clusters
+	TemplateName string
+	// KubeconfigLabel selects the kubeconfig Secrets
+	KubeconfigLabel string
+	// KubeconfigKey is the key of the kubeconfig in the Secrets
+	KubeconfigKey string
+	// TargetNamespace is the configuration namespace in the managed clusters
+	TargetNamespace string
+	// FieldManager is the name of the field manager of the apply operations
+	FieldManager string
+	// ResyncPeriod is the interval in which every managed cluster is reconciled again
+	ResyncPeriod time.Duration
+	// NewClient creates the clients of the managed clusters
+	NewClient NewClientFunc
+
+	mu sync.Mutex
+	// clients are the clients of the managed clusters per kubeconfig Secret
+	clients map[types.NamespacedName]fleetClient
+}
+
+// fleetClient is the client of a managed cluster created from the given
+// resource version of its kubeconfig Secret.
+type fleetClient struct {
+	resourceVersion string
+	client          client.Client
+}
+
+func (r *FleetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the managed cluster was removed from the fleet
+			r.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var template snatchv1alpha1.SnatchConfig
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: r.TemplateName}, &template); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("fleet snatch config not found, nothing to distribute", "name", r.TemplateName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to get fleet snatch config: %w", err)
+	}
+
+	kubeconfig, ok := secret.Data[r.KubeconfigKey]
+	if !ok {
+		logger.Info("kubeconfig secret without kubeconfig, skipping", "key", r.KubeconfigKey)
+		return ctrl.Result{}, nil
+	}
+
+	target, err := r.targetClient(&secret, kubeconfig)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to create client of managed cluster: %w", err)
+	}
+
+	snatchCfg := &snatchv1alpha1.SnatchConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: snatchv1alpha1.GroupVersion.String(),
+			Kind:       "SnatchConfig",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: r.TargetNamespace,
+			Name:      FleetSnatchConfigName,
+			Labels:    map[string]string{labelManagedBy: r.FieldManager},
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+
+	if err := target.Patch(ctx, snatchCfg, client.Apply, &client.PatchOptions{
+		FieldManager: r.FieldManager,
+		Force:        ptr.To(true),
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to apply fleet snatch config: %w", err)
+	}
+
+	// the webhook settings are left to kim-snatch of the managed cluster, it
+	// applies them with the fleet snatch config like with any other source
+	logger.Info("fleet configuration applied")
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
+
+// targetClient returns the client of the managed cluster of the kubeconfig
+// Secret, the client is only created again once the Secret changed.
+func (r *FleetReconciler) targetClient(secret *corev1.Secret, kubeconfig []byte) (client.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := client.ObjectKeyFromObject(secret)
+	if cached, ok := r.clients[key]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+	target, err := r.NewClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if r.clients == nil {
+		r.clients = map[types.NamespacedName]fleetClient{}
+	}
+	r.clients[key] = fleetClient{resourceVersion: secret.ResourceVersion, client: target}
+	return target, nil
+}
+
+// forget drops the client of the managed cluster of the deleted kubeconfig Secret.
+func (r *FleetReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, key)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *FleetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	inNamespace := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.Namespace
+	})
+	isKubeconfig := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[r.KubeconfigLabel]
+		return ok
+	})
+	isTemplate := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.TemplateName
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("fleet").
+		For(&corev1.Secret{}, builder.WithPredicates(inNamespace, isKubeconfig)).
+		Watches(&snatchv1alpha1.SnatchConfig{},
+			handler.EnqueueRequestsFromMapFunc(r.kubeconfigRequests),
+			builder.WithPredicates(inNamespace, isTemplate, predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// kubeconfigRequests enqueues all managed clusters when the fleet SnatchConfig changes.
+func (r *FleetReconciler) kubeconfigRequests(ctx context.Context, _ client.Object) []reconcile.Request {
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, client.InNamespace(r.Namespace), client.HasLabels{r.KubeconfigLabel}); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list kubeconfig secrets")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: secret.Namespace,
+			Name:      secret.Name,
+		}})
+	}
+	return requests
+}
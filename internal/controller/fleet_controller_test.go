@@ -0,0 +1,137 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testKCPNamespace = "kcp-system"
+
+func testFleetReconciler(t *testing.T, target client.Client, objs ...client.Object) *controller.FleetReconciler {
+	scheme := testScheme(t)
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	return &controller.FleetReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objs...).
+			Build(),
+		Namespace:       testKCPNamespace,
+		TemplateName:    "fleet",
+		KubeconfigLabel: controller.DefaultKubeconfigLabel,
+		KubeconfigKey:   controller.DefaultKubeconfigKey,
+		TargetNamespace: testNamespace,
+		FieldManager:    "snatch",
+		NewClient: func(kubeconfig []byte) (client.Client, error) {
+			assert.Equal(t, "test-kubeconfig", string(kubeconfig))
+			return target, nil
+		},
+	}
+}
+
+func testKubeconfigSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testKCPNamespace,
+			Name:      "kubeconfig-test",
+			Labels:    map[string]string{controller.DefaultKubeconfigLabel: "test"},
+		},
+		Data: map[string][]byte{controller.DefaultKubeconfigKey: []byte("test-kubeconfig")},
+	}
+}
+
+func Test_FleetReconciler(t *testing.T) {
+	scheme := testScheme(t)
+	require.NoError(t, admissionregistration.AddToScheme(scheme))
+
+	target := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&admissionregistration.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-me"},
+			Webhooks:   []admissionregistration.MutatingWebhook{{Name: "pods.kim-snatch.kyma-project.io"}},
+		}).
+		Build()
+
+	template := &snatchv1alpha1.SnatchConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testKCPNamespace, Name: "fleet"},
+		Spec: snatchv1alpha1.SnatchConfigSpec{
+			KymaWorkerPoolName: "fleet-pool",
+			FailurePolicy:      "Fail",
+			TimeoutSeconds:     ptr.To(int32(5)),
+		},
+	}
+	secret := testKubeconfigSecret()
+	r := testFleetReconciler(t, target, template, secret)
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+	require.NoError(t, err)
+
+	var snatchCfg snatchv1alpha1.SnatchConfig
+	require.NoError(t, target.Get(context.Background(), client.ObjectKey{
+		Namespace: testNamespace,
+		Name:      controller.FleetSnatchConfigName,
+	}, &snatchCfg))
+	assert.Equal(t, "fleet-pool", snatchCfg.Spec.KymaWorkerPoolName)
+
+	assert.Equal(t, "Fail", snatchCfg.Spec.FailurePolicy)
+
+	// the webhook settings are left to kim-snatch of the managed cluster
+	var mwc admissionregistration.MutatingWebhookConfiguration
+	require.NoError(t, target.Get(context.Background(), client.ObjectKey{Name: "test-me"}, &mwc))
+	require.Len(t, mwc.Webhooks, 1)
+	assert.Nil(t, mwc.Webhooks[0].FailurePolicy)
+	assert.Nil(t, mwc.Webhooks[0].TimeoutSeconds)
+}
+
+func Test_FleetReconciler_client_cache(t *testing.T) {
+	target := fake.NewClientBuilder().WithScheme(testScheme(t)).Build()
+	template := &snatchv1alpha1.SnatchConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testKCPNamespace, Name: "fleet"},
+	}
+	secret := testKubeconfigSecret()
+	r := testFleetReconciler(t, target, template, secret)
+	created := 0
+	r.NewClient = func([]byte) (client.Client, error) {
+		created++
+		return target, nil
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)}
+
+	// the client is reused until the kubeconfig Secret changes
+	for range 2 {
+		_, err := r.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, created)
+
+	require.NoError(t, r.Get(context.Background(), req.NamespacedName, secret))
+	secret.Data[controller.DefaultKubeconfigKey] = []byte("rotated-kubeconfig")
+	require.NoError(t, r.Update(context.Background(), secret))
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+}
+
+func Test_FleetReconciler_WithoutTemplate(t *testing.T) {
+	secret := testKubeconfigSecret()
+	r := testFleetReconciler(t, nil, secret)
+	r.NewClient = func([]byte) (client.Client, error) {
+		t.Fatal("managed cluster must not be contacted without fleet snatch config")
+		return nil, nil
+	}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// DefaultSnatchConfigName is the name of the generated SnatchConfig
+	DefaultSnatchConfigName = "kim-snatch-default"
+	// DefaultSnatchConfigPriority is the priority of the generated SnatchConfig,
+	// every SnatchConfig created by the users overrides its settings
+	DefaultSnatchConfigPriority = int32(-100)
+
+	// LabelBrokerPlanName is the label of the Kyma CR holding the name of the plan
+	LabelBrokerPlanName = "kyma-project.io/broker-plan-name"
+	labelManagedBy      = "app.kubernetes.io/managed-by"
+)
+
+// KymaGroupVersionKind is the kind of the Kyma CR managed by the lifecycle-manager.
+var KymaGroupVersionKind = schema.GroupVersionKind{
+	Group:   "operator.kyma-project.io",
+	Version: "v1beta2",
+	Kind:    "Kyma",
+}
+
+// DefaultPlanProfiles maps the plans of managed Kyma installations to the
+// configuration profiles, plans not listed use the production profile.
+var DefaultPlanProfiles = map[string]string{
+	"trial": config.ProfileEvaluation,
+	"free":  config.ProfileEvaluation,
+}
+
+// KymaDefaultsReconciler generates the default SnatchConfig of a managed Kyma
+// installation from its Kyma CR.
+type KymaDefaultsReconciler struct {
+	client.Client
+
+	// Namespace of the Kyma CR and the generated SnatchConfig
+	Namespace string
+	// KymaName is the name of the Kyma CR
+	KymaName string
+	// PlanProfiles maps plan names to profiles
+	PlanProfiles map[string]string
+	// FieldManager is the name of the field manager of the apply operation
+	FieldManager string
+}
+
+func newKyma() *unstructured.Unstructured {
+	kyma := &unstructured.Unstructured{}
+	kyma.SetGroupVersionKind(KymaGroupVersionKind)
+	return kyma
+}
+
+// Profile returns the profile for the given plan.
+func (r *KymaDefaultsReconciler) Profile(plan string) string {
+	if profile, ok := r.PlanProfiles[plan]; ok {
+		return profile
+	}
+	return config.ProfileProduction
+}
+
+func (r *KymaDefaultsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	kyma := newKyma()
+	if err := r.Get(ctx, req.NamespacedName, kyma); err != nil {
+		// the generated SnatchConfig is garbage collected together with the Kyma CR
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	plan := kyma.GetLabels()[LabelBrokerPlanName]
+	snatchCfg := &snatchv1alpha1.SnatchConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: snatchv1alpha1.GroupVersion.String(),
+			Kind:       "SnatchConfig",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: kyma.GetNamespace(),
+			Name:      DefaultSnatchConfigName,
+			Labels:    map[string]string{labelManagedBy: r.FieldManager},
+		},
+		Spec: snatchv1alpha1.SnatchConfigSpec{
+			Priority: DefaultSnatchConfigPriority,
+			Profile:  r.Profile(plan),
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(kyma, snatchCfg, r.Scheme()); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to set owner of default snatch config: %w", err)
+	}
+
+	if err := r.Patch(ctx, snatchCfg, client.Apply, &client.PatchOptions{
+		FieldManager: r.FieldManager,
+		Force:        ptr.To(true),
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to apply default snatch config: %w", err)
+	}
+
+	logger.Info("default snatch config applied", "plan", plan, "profile", snatchCfg.Spec.Profile)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KymaDefaultsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("kyma-defaults").
+		For(newKyma(), builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetNamespace() == r.Namespace && obj.GetName() == r.KymaName
+			}),
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Owns(&snatchv1alpha1.SnatchConfig{}).
+		Complete(r)
+}
+
+// ParsePlanProfiles parses a comma separated list of plan=profile pairs.
+func ParsePlanProfiles(v string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(v, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		plan, value, ok := strings.Cut(pair, "=")
+		if !ok || plan == "" {
+			return nil, fmt.Errorf("missing plan name in %q, expected plan=profile", pair)
+		}
+
+		profile, err := config.ParseProfile(value)
+		if err != nil {
+			return nil, err
+		}
+		result[strings.TrimSpace(plan)] = profile
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testKyma(plan string) *unstructured.Unstructured {
+	kyma := &unstructured.Unstructured{}
+	kyma.SetGroupVersionKind(controller.KymaGroupVersionKind)
+	kyma.SetNamespace(testNamespace)
+	kyma.SetName("default")
+	kyma.SetUID("test-uid")
+	kyma.SetLabels(map[string]string{controller.LabelBrokerPlanName: plan})
+	return kyma
+}
+
+func Test_KymaDefaultsReconciler(t *testing.T) {
+	for plan, profile := range map[string]string{
+		"trial": config.ProfileEvaluation,
+		"azure": config.ProfileProduction,
+	} {
+		t.Run(plan, func(t *testing.T) {
+			kyma := testKyma(plan)
+			r := &controller.KymaDefaultsReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(testScheme(t)).
+					WithObjects(kyma).
+					Build(),
+				Namespace:    testNamespace,
+				KymaName:     kyma.GetName(),
+				PlanProfiles: controller.DefaultPlanProfiles,
+				FieldManager: "snatch",
+			}
+
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(kyma)})
+			require.NoError(t, err)
+
+			var snatchCfg snatchv1alpha1.SnatchConfig
+			require.NoError(t, r.Get(context.Background(), client.ObjectKey{
+				Namespace: testNamespace,
+				Name:      controller.DefaultSnatchConfigName,
+			}, &snatchCfg))
+			assert.Equal(t, profile, snatchCfg.Spec.Profile)
+			assert.Equal(t, controller.DefaultSnatchConfigPriority, snatchCfg.Spec.Priority)
+			require.Len(t, snatchCfg.OwnerReferences, 1)
+			assert.Equal(t, "Kyma", snatchCfg.OwnerReferences[0].Kind)
+		})
+	}
+}
+
+func Test_ParsePlanProfiles(t *testing.T) {
+	profiles, err := controller.ParsePlanProfiles("trial=evaluation, azure=strict-isolation")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"trial": config.ProfileEvaluation,
+		"azure": config.ProfileStrictIsolation,
+	}, profiles)
+
+	_, err = controller.ParsePlanProfiles("trial=unknown")
+	assert.Error(t, err)
+}
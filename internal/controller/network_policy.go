@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// namespaceNameLabel is set by the API server on every namespace.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// NetworkPolicyReconciler maintains the NetworkPolicy restricting the ingress
+// to the pods of kim-snatch. The webhook port is reachable from the API server
+// CIDRs of the configuration, the metrics port from its monitoring namespace,
+// all other ingress is denied by the policy. The policy is applied again with
+// every check, so changes of the configuration and manual edits are reverted.
+type NetworkPolicyReconciler struct {
+	Client    client.Client
+	Config    func() config.Config
+	Namespace string
+	Name      string
+	// PodSelector selects the pods of kim-snatch
+	PodSelector map[string]string
+	WebhookPort int
+	// MetricsPort is not part of the policy if 0, the metrics server is disabled
+	MetricsPort  int
+	FieldManager string
+	Interval     time.Duration
+
+	applied *networkingv1.NetworkPolicySpec
+}
+
+// Start runs the reconciliation until the context is cancelled.
+func (r *NetworkPolicyReconciler) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, r.Check, r.Interval)
+	return nil
+}
+
+// NeedLeaderElection returns true, only the leader applies the policy.
+func (r *NetworkPolicyReconciler) NeedLeaderElection() bool {
+	return true
+}
+
+// Check applies the NetworkPolicy derived from the current configuration.
+func (r *NetworkPolicyReconciler) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("network-policy-reconciler")
+
+	policy := r.networkPolicy(r.Config())
+	if err := r.Client.Patch(ctx, policy, client.Apply, &client.PatchOptions{
+		FieldManager: r.FieldManager,
+		Force:        ptr.To(true),
+	}); err != nil {
+		logger.Error(err, "unable to apply network policy", "name", r.Name)
+		return
+	}
+	if r.applied == nil || !reflect.DeepEqual(*r.applied, policy.Spec) {
+		logger.Info("network policy applied", "name", r.Name, "ingress", len(policy.Spec.Ingress))
+		r.applied = policy.Spec.DeepCopy()
+	}
+}
+
+func (r *NetworkPolicyReconciler) networkPolicy(cfg config.Config) *networkingv1.NetworkPolicy {
+	webhookRule := networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{tcpPort(r.WebhookPort)},
+	}
+	// no peers allow the ingress from all sources
+	for _, cidr := range cfg.APIServerCIDRs {
+		webhookRule.From = append(webhookRule.From, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+		})
+	}
+	ingress := []networkingv1.NetworkPolicyIngressRule{webhookRule}
+
+	if r.MetricsPort > 0 && cfg.MonitoringNamespace != "" {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{tcpPort(r.MetricsPort)},
+			From: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{namespaceNameLabel: cfg.MonitoringNamespace},
+				},
+			}},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+			Kind:       "NetworkPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: r.Namespace,
+			Name:      r.Name,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "kim-snatch",
+				"app.kubernetes.io/managed-by": "kim-snatch",
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: r.PodSelector},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}
+}
+
+func tcpPort(port int) networkingv1.NetworkPolicyPort {
+	return networkingv1.NetworkPolicyPort{
+		Protocol: ptr.To(corev1.ProtocolTCP),
+		Port:     ptr.To(intstr.FromInt32(int32(port))),
+	}
+}
@@ -0,0 +1,60 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_NetworkPolicyReconciler(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	cfg := config.Config{}
+	reconciler := &controller.NetworkPolicyReconciler{
+		Client:       c,
+		Config:       func() config.Config { return cfg },
+		Namespace:    testNamespace,
+		Name:         "kim-snatch-allow-ingress",
+		PodSelector:  map[string]string{"app.kubernetes.io/component": "kim-snatch"},
+		WebhookPort:  9443,
+		MetricsPort:  8443,
+		FieldManager: "snatch",
+	}
+	policy := func() networkingv1.NetworkPolicySpec {
+		var current networkingv1.NetworkPolicy
+		require.NoError(t, c.Get(context.Background(),
+			client.ObjectKey{Namespace: testNamespace, Name: "kim-snatch-allow-ingress"}, &current))
+		return current.Spec
+	}
+
+	reconciler.Check(context.Background())
+	spec := policy()
+	assert.Equal(t, map[string]string{"app.kubernetes.io/component": "kim-snatch"}, spec.PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, spec.PolicyTypes)
+	require.Len(t, spec.Ingress, 1, "the metrics port is closed without a monitoring namespace")
+	assert.Equal(t, intstr.FromInt32(9443), *spec.Ingress[0].Ports[0].Port)
+	assert.Empty(t, spec.Ingress[0].From, "the webhook port is open without API server CIDRs")
+
+	cfg.APIServerCIDRs = []string{"10.250.0.0/16", "100.64.0.0/10"}
+	cfg.MonitoringNamespace = "monitoring"
+	reconciler.Check(context.Background())
+	spec = policy()
+	require.Len(t, spec.Ingress, 2)
+	require.Len(t, spec.Ingress[0].From, 2)
+	assert.Equal(t, "10.250.0.0/16", spec.Ingress[0].From[0].IPBlock.CIDR)
+	assert.Equal(t, "100.64.0.0/10", spec.Ingress[0].From[1].IPBlock.CIDR)
+	assert.Equal(t, intstr.FromInt32(8443), *spec.Ingress[1].Ports[0].Port)
+	assert.Equal(t, map[string]string{"kubernetes.io/metadata.name": "monitoring"},
+		spec.Ingress[1].From[0].NamespaceSelector.MatchLabels)
+
+	reconciler.MetricsPort = 0
+	reconciler.Check(context.Background())
+	assert.Len(t, policy().Ingress, 1, "the metrics port is not part of the policy if the metrics server is disabled")
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ConditionPodsScheduled reports if pods are pending because of the injected placement
+	ConditionPodsScheduled = "PodsScheduled"
+
+	ReasonPodsScheduled         = "PodsScheduled"
+	ReasonPendingDueToPlacement = "PendingDueToPlacement"
+
+	// Causes of the pods pending because of their placement.
+	PendingCauseCapacity = "capacity"
+	PendingCauseAffinity = "affinity"
+)
+
+// PendingCauses are all causes of pods pending because of their placement.
+var PendingCauses = []string{PendingCauseCapacity, PendingCauseAffinity}
+
+// pendingPodsRequest is the only request the reconciler works on, every pod
+// event results in all pending pods being evaluated again.
+var pendingPodsRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "pending-pods"}}
+
+// PendingPodReconciler detects pods that cannot be scheduled because of the
+// required node affinity injected for the Kyma worker pool.
+type PendingPodReconciler struct {
+	// Client updates the status of the SnatchConfig the pool is configured in
+	client.Client
+	// Pods reads the pending pods, usually the cache of the manager
+	Pods     client.Reader
+	Store    *config.Store
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object events are recorded for if the pool is not
+	// configured in a SnatchConfig, events are not recorded if not set
+	EventTarget *corev1.ObjectReference
+
+	pending map[types.NamespacedName]string
+}
+
+func (r *PendingPodReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	effective := r.Store.Get()
+	cfg := effective.Config
+
+	var pods corev1.PodList
+	if err := r.Pods.List(ctx, &pods); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to list pending pods: %w", err)
+	}
+
+	pending := map[types.NamespacedName]string{}
+	count := map[string]int{}
+	for _, cause := range PendingCauses {
+		count[cause] = 0
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if slices.Contains(cfg.OmittedNamespaces, pod.Namespace) {
+			continue
+		}
+		cause, message, ok := PendingDueToPlacement(pod, cfg.PoolLabelKey)
+		if !ok {
+			continue
+		}
+
+		key := client.ObjectKeyFromObject(pod)
+		pending[key] = cause
+		count[cause]++
+		if _, known := r.pending[key]; !known {
+			logger.Info("pod pending due to placement", "pod", key, "cause", cause, "message", message)
+			r.event(ctx, effective, corev1.EventTypeWarning, ReasonPendingDueToPlacement,
+				fmt.Sprintf("pod %s is pending due to its placement on kyma worker pool %s (%s): %s",
+					key, cfg.KymaWorkerPoolName, cause, message))
+		}
+	}
+	r.pending = pending
+
+	if r.Metrics != nil {
+		for cause, pods := range count {
+			r.Metrics.SetPendingDueToPlacement(cause, pods)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    ConditionPodsScheduled,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonPodsScheduled,
+		Message: "no pods are pending due to their placement",
+	}
+	if len(pending) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonPendingDueToPlacement
+		condition.Message = fmt.Sprintf("%d pods are pending due to their placement, capacity: %d, affinity: %d",
+			len(pending), count[PendingCauseCapacity], count[PendingCauseAffinity])
+	}
+
+	key, ok := config.SnatchConfigOrigin(effective.Origins[config.KeyKymaWorkerPoolName])
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+	if err := setSnatchConfigCondition(ctx, r.Client, key, condition); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to update snatch config status: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// event records the event on the SnatchConfig the pool is configured in, or
+// on the EventTarget.
+func (r *PendingPodReconciler) event(ctx context.Context, effective config.Effective, eventType, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+
+	var target runtime.Object
+	if key, ok := config.SnatchConfigOrigin(effective.Origins[config.KeyKymaWorkerPoolName]); ok {
+		var snatchCfg snatchv1alpha1.SnatchConfig
+		if err := r.Get(ctx, key, &snatchCfg); err == nil {
+			target = &snatchCfg
+		}
+	}
+	if target == nil {
+		if r.EventTarget == nil {
+			return
+		}
+		target = r.EventTarget
+	}
+	r.Recorder.Event(target, eventType, reason, message)
+}
+
+// PendingDueToPlacement returns the cause and the message of the scheduler if
+// the pod is unschedulable and the required node affinity selects the worker
+// pool label. The preferred node affinity never prevents scheduling.
+func PendingDueToPlacement(pod *corev1.Pod, labelKey string) (string, string, bool) {
+	if pod.Status.Phase != corev1.PodPending || !requiresPool(pod, labelKey) {
+		return "", "", false
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse ||
+			condition.Reason != corev1.PodReasonUnschedulable {
+			continue
+		}
+
+		// the nodes of the pool exist but have no room left, otherwise no
+		// node matches the affinity at all
+		if strings.Contains(condition.Message, "Insufficient") || strings.Contains(condition.Message, "Too many pods") {
+			return PendingCauseCapacity, condition.Message, true
+		}
+		return PendingCauseAffinity, condition.Message, true
+	}
+	return "", "", false
+}
+
+func requiresPool(pod *corev1.Pod, labelKey string) bool {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return false
+	}
+
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == labelKey && expression.Operator == corev1.NodeSelectorOpIn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager. The cache of the
+// manager is expected to hold pending pods only.
+func (r *PendingPodReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pending-pods").
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(
+			func(context.Context, client.Object) []reconcile.Request {
+				return []reconcile.Request{pendingPodsRequest}
+			})).
+		Complete(r)
+}
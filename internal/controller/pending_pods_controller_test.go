@@ -0,0 +1,115 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testPendingPod(name, message string, required bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kyma-system", Name: name},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: message,
+			}},
+		},
+	}
+	if required {
+		pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      config.DefaultPoolLabelKey,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"cpu-worker-0"},
+				}}}},
+			},
+		}}
+	}
+	return pod
+}
+
+func Test_PendingPodReconciler(t *testing.T) {
+	scheme := testScheme(t)
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	snatchCfg := testSnatchConfig("cpu-worker-0")
+	cluster := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(snatchCfg,
+			testPendingPod("capacity", "0/3 nodes are available: 3 Insufficient cpu.", true),
+			testPendingPod("affinity", "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.", true),
+			testPendingPod("preferred", "0/3 nodes are available: 3 Insufficient memory.", false),
+		).
+		WithStatusSubresource(snatchCfg).
+		Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPendingDueToPlacement", controller.PendingCauseCapacity, 1).Twice()
+	mtr.On("SetPendingDueToPlacement", controller.PendingCauseAffinity, 1).Twice()
+	recorder := record.NewFakeRecorder(10)
+
+	r := &controller.PendingPodReconciler{
+		Client: cluster,
+		Pods:   cluster,
+		Store: config.NewStore(config.Effective{
+			Config: cfg,
+			Origins: map[string]string{
+				config.KeyKymaWorkerPoolName: "snatchconfig " + testNamespace + "/" + snatchCfg.Name,
+			},
+		}),
+		Metrics:  mtr,
+		Recorder: recorder,
+	}
+
+	for range 2 {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		require.NoError(t, err)
+	}
+
+	// every pod is reported once
+	assert.Len(t, recorder.Events, 2)
+
+	var updated snatchv1alpha1.SnatchConfig
+	require.NoError(t, cluster.Get(context.Background(), client.ObjectKeyFromObject(snatchCfg), &updated))
+	condition := meta.FindStatusCondition(updated.Status.Conditions, controller.ConditionPodsScheduled)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, controller.ReasonPendingDueToPlacement, condition.Reason)
+}
+
+func Test_PendingDueToPlacement(t *testing.T) {
+	cause, _, ok := controller.PendingDueToPlacement(
+		testPendingPod("test", "0/3 nodes are available: 3 Too many pods.", true), config.DefaultPoolLabelKey)
+	assert.True(t, ok)
+	assert.Equal(t, controller.PendingCauseCapacity, cause)
+
+	_, _, ok = controller.PendingDueToPlacement(
+		testPendingPod("test", "0/3 nodes are available: 3 Insufficient cpu.", false), config.DefaultPoolLabelKey)
+	assert.False(t, ok)
+
+	scheduled := testPendingPod("test", "", true)
+	scheduled.Status.Conditions = nil
+	_, _, ok = controller.PendingDueToPlacement(scheduled, config.DefaultPoolLabelKey)
+	assert.False(t, ok)
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// maxReportedViolations limits the number of workloads listed in the placement report
+const maxReportedViolations = 50
+
+// PlacementReporter periodically lists the workloads of the Kyma namespaces
+// with pods running outside of the Kyma worker pool in the status of the
+// SnatchConfig the pool is configured in.
+type PlacementReporter struct {
+	// Client lists the pods of the Kyma namespaces and updates the status of the
+	// SnatchConfig, the cache of the manager only holds the pending pods
+	Client client.Client
+	// Namespaces lists the Kyma namespaces
+	Namespaces client.Reader
+	Store      *config.Store
+	// IsMember returns true if the node belongs to the Kyma worker pool
+	IsMember func(node string) bool
+	// Synced returns false until the nodes of the pools were listed, IsMember
+	// can't be trusted before, optional
+	Synced func() bool
+	// ResolvePlacement resolves the placement for the namespace of the pods, optional
+	ResolvePlacement webhookv1.PlacementResolver
+	// ActivePool returns the successor of the kyma worker pool if it was recreated, optional
+	ActivePool func() string
+
+	// Interval between two reports
+	Interval time.Duration
+}
+
+// Start runs the reporter until the context is cancelled.
+func (r *PlacementReporter) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, r.Check, r.Interval)
+	return nil
+}
+
+// Check refreshes the report once.
+func (r *PlacementReporter) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("placement-reporter")
+	effective := r.Store.Get()
+	key, ok := config.SnatchConfigOrigin(effective.Origins[config.KeyKymaWorkerPoolName])
+	if !ok {
+		// the report is only kept in the status of a SnatchConfig
+		return
+	}
+	// before the nodes are listed, every workload would be reported as off the pool
+	if r.Synced != nil && !r.Synced() {
+		return
+	}
+
+	report, err := r.report(ctx, effective.Config)
+	if err != nil {
+		logger.Error(err, "unable to create placement report")
+		return
+	}
+
+	var snatchCfg snatchv1alpha1.SnatchConfig
+	if err := r.Client.Get(ctx, key, &snatchCfg); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "unable to get snatch config", "snatchConfig", key)
+		}
+		return
+	}
+	snatchCfg.Status.PlacementReport = report
+	if err := r.Client.Status().Update(ctx, &snatchCfg); err != nil {
+		logger.Error(err, "unable to update placement report", "snatchConfig", key)
+	}
+}
+
+func (r *PlacementReporter) report(ctx context.Context, cfg config.Config) (*snatchv1alpha1.PlacementReport, error) {
+	logger := logf.FromContext(ctx).WithName("placement-reporter")
+
+	var namespaces metav1.PartialObjectMetadataList
+	namespaces.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	if err := r.Namespaces.List(ctx, &namespaces, client.MatchingLabels{
+		LabelKymaManagedBy: kymaManagedByValue,
+	}); err != nil {
+		return nil, err
+	}
+
+	report := &snatchv1alpha1.PlacementReport{
+		LastUpdateTime: metav1.Now(),
+		Pool:           cfg.KymaWorkerPoolName,
+	}
+	if r.ActivePool != nil {
+		if active := r.ActivePool(); active != "" {
+			report.Pool = active
+		}
+	}
+
+	violations := map[snatchv1alpha1.PlacementViolation]int32{}
+	for _, namespace := range namespaces.Items {
+		if slices.Contains(cfg.OmittedNamespaces, namespace.Name) {
+			continue
+		}
+		placement, err := namespacePlacement(ctx, cfg, namespace.Name, r.ResolvePlacement, r.ActivePool)
+		if err != nil {
+			logger.Error(err, "unable to resolve namespace placement, skipping namespace", "namespace", namespace.Name)
+			continue
+		}
+		// only the nodes of the kyma worker pool are known
+		if placement.Pool != report.Pool {
+			continue
+		}
+
+		var pods corev1.PodList
+		if err := r.Client.List(ctx, &pods, client.InNamespace(namespace.Name)); err != nil {
+			return nil, err
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Spec.NodeName == "" || isTerminated(pod) || r.IsMember(pod.Spec.NodeName) {
+				continue
+			}
+
+			kind, name := podWorkload(pod)
+			violations[snatchv1alpha1.PlacementViolation{
+				Namespace: pod.Namespace,
+				Kind:      kind,
+				Name:      name,
+				Reason:    placementReason(pod, placement),
+			}]++
+			report.ViolatingPods++
+		}
+	}
+
+	for violation, pods := range violations {
+		violation.Pods = pods
+		report.Violations = append(report.Violations, violation)
+	}
+	slices.SortFunc(report.Violations, func(a, b snatchv1alpha1.PlacementViolation) int {
+		return cmp.Or(
+			cmp.Compare(b.Pods, a.Pods),
+			strings.Compare(a.Namespace, b.Namespace),
+			strings.Compare(a.Kind, b.Kind),
+			strings.Compare(a.Name, b.Name),
+			strings.Compare(a.Reason, b.Reason),
+		)
+	})
+	report.ViolatingWorkloads = int32(len(report.Violations))
+	if len(report.Violations) > maxReportedViolations {
+		report.Violations = report.Violations[:maxReportedViolations]
+	}
+	return report, nil
+}
+
+// placementReason returns why the pod runs outside of the pool of the placement.
+func placementReason(pod *corev1.Pod, placement webhookv1.Placement) string {
+	pools := webhookv1.SelectedPools(&pod.Spec, placement)
+	switch {
+	case len(pools) == 0:
+		return snatchv1alpha1.PlacementReasonMutationSkipped
+	case !slices.Contains(pools, placement.Pool):
+		return snatchv1alpha1.PlacementReasonStalePod
+	default:
+		// only the preferred node affinity lets the scheduler fall back to other pools
+		return snatchv1alpha1.PlacementReasonCapacity
+	}
+}
+
+// podWorkload returns the kind and the name of the workload owning the pod,
+// pods of a ReplicaSet are reported with their Deployment.
+func podWorkload(pod *corev1.Pod) (string, string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
+	}
+	if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; owner.Kind == "ReplicaSet" && hash != "" {
+		return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+	}
+	return owner.Kind, owner.Name
+}
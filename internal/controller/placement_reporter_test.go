@@ -0,0 +1,77 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_PlacementReporter(t *testing.T) {
+	scheme := testScheme(t)
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	stale := testOwnedPod("api-5d8b7-abcde", "other-node", "ReplicaSet",
+		map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d8b7"})
+	stale.OwnerReferences[0].Name = "api-5d8b7"
+	stale.Spec.Affinity = testPoolAffinity("cpu-worker-old")
+	staleReplica := stale.DeepCopy()
+	staleReplica.Name = "api-5d8b7-fghij"
+	capacity := testOwnedPod("database-0", "other-node", "StatefulSet", nil)
+	capacity.OwnerReferences[0].Name = "database"
+	capacity.Spec.Affinity = testPoolAffinity("cpu-worker-0")
+
+	snatchCfg := testSnatchConfig("cpu-worker-0")
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(snatchCfg, testKymaNamespace(), stale, staleReplica, capacity,
+			testOwnedPod("bare", "other-node", "", nil),
+			testOwnedPod("on-pool", "pool-node", "ReplicaSet", nil),
+		).
+		WithStatusSubresource(snatchCfg).
+		Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	synced := false
+	r := &controller.PlacementReporter{
+		Client:     c,
+		Namespaces: c,
+		Store: config.NewStore(config.Effective{
+			Config: cfg,
+			Origins: map[string]string{
+				config.KeyKymaWorkerPoolName: "snatchconfig " + testNamespace + "/" + snatchCfg.Name,
+			},
+		}),
+		IsMember: func(node string) bool { return node == "pool-node" },
+		Synced:   func() bool { return synced },
+	}
+
+	// nothing is reported before the nodes were listed
+	r.Check(context.Background())
+	var updated snatchv1alpha1.SnatchConfig
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(snatchCfg), &updated))
+	assert.Nil(t, updated.Status.PlacementReport)
+
+	synced = true
+	r.Check(context.Background())
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(snatchCfg), &updated))
+	report := updated.Status.PlacementReport
+	require.NotNil(t, report)
+	assert.Equal(t, "cpu-worker-0", report.Pool)
+	assert.Equal(t, int32(4), report.ViolatingPods)
+	assert.Equal(t, int32(3), report.ViolatingWorkloads)
+	assert.Equal(t, []snatchv1alpha1.PlacementViolation{
+		{Namespace: testNamespace, Kind: "Deployment", Name: "api", Pods: 2, Reason: snatchv1alpha1.PlacementReasonStalePod},
+		{Namespace: testNamespace, Kind: "Pod", Name: "bare", Pods: 1, Reason: snatchv1alpha1.PlacementReasonMutationSkipped},
+		{Namespace: testNamespace, Kind: "StatefulSet", Name: "database", Pods: 1, Reason: snatchv1alpha1.PlacementReasonCapacity},
+	}, report.Violations)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/provider"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonPoolLabelMismatch    = "PoolLabelMismatch"
+	EventReasonPoolLabelsPropagated = "PoolLabelsPropagated"
+
+	// maxReportedNames limits the number of nodes or workloads listed in the events
+	maxReportedNames = 5
+)
+
+// PoolLabelChecker periodically verifies that the nodes created for the Kyma
+// worker pool carry the pool label, the injected node affinity has no effect
+// if Gardener fails to propagate it.
+type PoolLabelChecker struct {
+	// Reader lists all nodes, the cache of the manager only holds the labeled ones
+	Reader   client.Reader
+	Config   func() config.Config
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks
+	Interval time.Duration
+
+	mismatched int
+}
+
+// Start runs the checker until the context is cancelled.
+func (c *PoolLabelChecker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, c.Check, c.Interval)
+	return nil
+}
+
+// Check verifies the labels of the nodes once.
+func (c *PoolLabelChecker) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("pool-label-checker")
+
+	cfg := c.Config()
+	if cfg.Provider != provider.Gardener {
+		// the nodes of the pool are only known from the Gardener machine names
+		return
+	}
+
+	// the metadata is sufficient, the labels and names of the nodes are compared
+	var nodes metav1.PartialObjectMetadataList
+	nodes.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NodeList"))
+	if err := c.Reader.List(ctx, &nodes); err != nil {
+		logger.Error(err, "unable to verify pool labels of nodes")
+		return
+	}
+
+	var mismatched []string
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		label := node.Labels[cfg.PoolLabelKey]
+		if label == cfg.KymaWorkerPoolName || !garden.MachineOfPool(node, cfg.KymaWorkerPoolName) {
+			continue
+		}
+		// machines of pools whose names end with the name of the Kyma worker
+		// pool match as well, they are consistent with their own label
+		if garden.MachineOfPool(node, label) {
+			continue
+		}
+		mismatched = append(mismatched, node.Name)
+	}
+
+	if c.Metrics != nil {
+		c.Metrics.SetPoolLabelMismatch(len(mismatched))
+	}
+
+	previous := c.mismatched
+	c.mismatched = len(mismatched)
+	switch {
+	case len(mismatched) > 0 && previous == 0:
+		logger.Info("nodes of kyma worker pool without pool label", "pool", cfg.KymaWorkerPoolName,
+			"label", cfg.PoolLabelKey, "nodes", mismatched)
+		c.event(corev1.EventTypeWarning, EventReasonPoolLabelMismatch,
+			fmt.Sprintf("%d nodes of kyma worker pool %s are not labeled %s=%s: %s", len(mismatched),
+				cfg.KymaWorkerPoolName, cfg.PoolLabelKey, cfg.KymaWorkerPoolName, reportedNames(mismatched)))
+	case len(mismatched) == 0 && previous > 0:
+		logger.Info("pool labels of kyma worker pool propagated", "pool", cfg.KymaWorkerPoolName)
+		c.event(corev1.EventTypeNormal, EventReasonPoolLabelsPropagated,
+			fmt.Sprintf("all nodes of kyma worker pool %s are labeled %s", cfg.KymaWorkerPoolName, cfg.PoolLabelKey))
+	}
+}
+
+func (c *PoolLabelChecker) event(eventType, reason, message string) {
+	if c.Recorder != nil && c.EventTarget != nil {
+		c.Recorder.Event(c.EventTarget, eventType, reason, message)
+	}
+}
+
+// NeedLeaderElection returns false, every replica reports its own view.
+func (c *PoolLabelChecker) NeedLeaderElection() bool {
+	return false
+}
+
+func reportedNames(names []string) string {
+	if len(names) <= maxReportedNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxReportedNames], ", "), len(names)-maxReportedNames)
+}
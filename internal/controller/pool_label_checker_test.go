@@ -0,0 +1,88 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testMachineNode(name, machine string, labels map[string]string) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+	for k, v := range labels {
+		node.Labels[k] = v
+	}
+	if machine != "" {
+		node.Labels[garden.LabelMachineName] = machine
+	}
+	return node
+}
+
+func Test_PoolLabelChecker(t *testing.T) {
+	labeled := testMachineNode("ip-10-250-0-1", "shoot--kyma--test-cpu-worker-0-z1-5d8b7-abcde",
+		map[string]string{config.DefaultPoolLabelKey: "cpu-worker-0"})
+	unlabeled := testMachineNode("ip-10-250-0-2", "shoot--kyma--test-cpu-worker-0-z2-5d8b7-fghij", nil)
+	otherPool := testMachineNode("ip-10-250-0-3", "shoot--kyma--test-gpu-z1-6c9a8-klmno",
+		map[string]string{config.DefaultPoolLabelKey: "gpu"})
+	suffixPool := testMachineNode("ip-10-250-0-4", "shoot--kyma--test-big-cpu-worker-0-z1-7d0b9-pqrst",
+		map[string]string{config.DefaultPoolLabelKey: "big-cpu-worker-0"})
+
+	reader := fake.NewClientBuilder().WithObjects(labeled, unlabeled, otherPool, suffixPool).Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPoolLabelMismatch", 1).Twice()
+	mtr.On("SetPoolLabelMismatch", 0).Once()
+	recorder := record.NewFakeRecorder(10)
+
+	c := &controller.PoolLabelChecker{
+		Reader:      reader,
+		Config:      func() config.Config { return cfg },
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	c.Check(context.Background())
+	c.Check(context.Background())
+
+	// events are recorded only when the state changes
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, controller.EventReasonPoolLabelMismatch)
+	assert.Contains(t, event, unlabeled.Name)
+
+	unlabeled.Labels[config.DefaultPoolLabelKey] = "cpu-worker-0"
+	require.NoError(t, reader.Update(context.Background(), unlabeled))
+	c.Check(context.Background())
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonPoolLabelsPropagated)
+}
+
+func Test_PoolLabelChecker_other_provider(t *testing.T) {
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	cfg.Provider = "gke"
+
+	c := &controller.PoolLabelChecker{
+		Reader: fake.NewClientBuilder().WithObjects(
+			testMachineNode("shoot--kyma--test-cpu-worker-0-z1-5d8b7-abcde", "", nil)).Build(),
+		Config:  func() config.Config { return cfg },
+		Metrics: mocks.NewMetrics(t),
+	}
+
+	// the metrics mock fails on any call
+	c.Check(context.Background())
+}
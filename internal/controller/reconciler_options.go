@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The overall retry limit of the reconcilers, the one of the default rate
+// limiter of controller-runtime.
+const (
+	retryQPS   = 10
+	retryBurst = 100
+)
+
+// ReconcilerOptions tune the work queue of a reconciler, the defaults of
+// controller-runtime apply to the zero values. The depth, the latency, and the
+// retries of the queue are exported by controller-runtime with the name of the
+// reconciler, e.g. workqueue_depth{name="ca-bundle"}.
+type ReconcilerOptions struct {
+	// MaxConcurrentReconciles is the number of requests reconciled in parallel
+	MaxConcurrentReconciles int
+	// BaseDelay is the delay of the first retry of a failed request, it doubles
+	// with every further failure up to MaxDelay, the retries of all requests
+	// are limited to 10 per second with a burst of 100 like by default
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// controllerOptions returns the options of the controller of the reconciler.
+func (o ReconcilerOptions) controllerOptions() controller.Options {
+	options := controller.Options{MaxConcurrentReconciles: o.MaxConcurrentReconciles}
+	if o.BaseDelay > 0 && o.MaxDelay > 0 {
+		options.RateLimiter = workqueue.NewTypedMaxOfRateLimiter(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
+				o.BaseDelay, max(o.MaxDelay, o.BaseDelay)),
+			&workqueue.TypedBucketRateLimiter[reconcile.Request]{
+				Limiter: rate.NewLimiter(rate.Limit(retryQPS), retryBurst),
+			},
+		)
+	}
+	return options
+}
@@ -0,0 +1,331 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonRolloutStarted   = "RolloutStarted"
+	EventReasonRolloutCompleted = "RolloutCompleted"
+	EventReasonRolloutStalled   = "RolloutStalled"
+
+	// AnnotationRestartedAt is the pod template annotation kubectl rollout restart sets
+	AnnotationRestartedAt = "kubectl.kubernetes.io/restartedAt"
+	// selfComponent is the value of LabelComponent of the pods of kim-snatch
+	selfComponent = "kim-snatch"
+)
+
+// rolloutTarget is a workload restarted by the RolloutOrchestrator.
+type rolloutTarget struct {
+	key         client.ObjectKey
+	statefulSet bool
+}
+
+func (t rolloutTarget) String() string {
+	return t.key.String()
+}
+
+func (t rolloutTarget) object() client.Object {
+	if t.statefulSet {
+		return &appsv1.StatefulSet{}
+	}
+	return &appsv1.Deployment{}
+}
+
+// RolloutOrchestrator restarts the Deployments and StatefulSets of the Kyma
+// namespaces when the Kyma worker pool or the affinity mode changes, so that
+// the pods are recreated with the new placement. The workloads are restarted
+// one after another, the next workload is only restarted once the rollouts in
+// flight completed; Deployments are restarted before StatefulSets.
+type RolloutOrchestrator struct {
+	// Client reads and restarts the workloads, the cache of the manager holds none of them
+	Client client.Client
+	// Namespaces lists the Kyma namespaces
+	Namespaces client.Reader
+	Config     func() config.Config
+	Recorder   record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// MaxConcurrent limits the number of workloads rolled out at the same time
+	MaxConcurrent int
+	// Interval in which the progress of the rollouts is checked
+	Interval time.Duration
+
+	mu sync.Mutex
+	// placement is the pool and mode of the last applied configuration
+	placement string
+	pending   bool
+	stalled   bool
+	// failure is the last error restarting a workload of the rollout
+	failure  error
+	queue    []rolloutTarget
+	inFlight []rolloutTarget
+}
+
+// Apply schedules the rollout if the Kyma worker pool or the affinity mode of
+// the configuration changed, the first configuration is the baseline. It is an
+// ApplyShared func of the ConfigReconciler, so the baseline is the
+// configuration the replica was elected with.
+func (o *RolloutOrchestrator) Apply(_ context.Context, cfg config.Config) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	placement := cfg.KymaWorkerPoolName + "/" + cfg.AffinityMode
+	if o.placement != "" && o.placement != placement {
+		o.pending = true
+	}
+	o.placement = placement
+	return nil
+}
+
+// Schedule restarts the workloads with the next check regardless of the
+// configuration, e.g. for the migration of an existing cluster.
+func (o *RolloutOrchestrator) Schedule() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending = true
+}
+
+// Done returns true if no rollout is scheduled or in progress.
+func (o *RolloutOrchestrator) Done() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return !o.pending && len(o.queue) == 0 && len(o.inFlight) == 0
+}
+
+// Stalled returns true if the last rollout was aborted because a workload
+// exceeded its progress deadline.
+func (o *RolloutOrchestrator) Stalled() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stalled
+}
+
+// Err returns the last error restarting a workload of the last rollout, the
+// workload was skipped then.
+func (o *RolloutOrchestrator) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.failure
+}
+
+// Start runs the orchestrator until the context is cancelled.
+func (o *RolloutOrchestrator) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, o.Check, o.Interval)
+	return nil
+}
+
+// Check starts a scheduled rollout and restarts the next workloads once the
+// rollouts in flight completed.
+func (o *RolloutOrchestrator) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("rollout-orchestrator")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.pending {
+		targets, err := o.targets(ctx)
+		if err != nil {
+			// the rollout is started again with the next check
+			logger.Error(err, "unable to list workloads of kyma namespaces")
+			return
+		}
+		o.pending = false
+		// a new configuration restarts the workloads from the beginning
+		o.queue, o.inFlight, o.stalled, o.failure = targets, nil, false, nil
+		logger.Info("rollout of kyma workloads started", "placement", o.placement, "workloads", len(targets))
+		o.event(corev1.EventTypeNormal, EventReasonRolloutStarted,
+			fmt.Sprintf("restarting %d workloads after the placement changed to %s", len(targets), o.placement))
+	}
+	if len(o.queue) == 0 && len(o.inFlight) == 0 {
+		return
+	}
+
+	inFlight := o.inFlight[:0]
+	for _, target := range o.inFlight {
+		completed, stalled, err := o.progress(ctx, target)
+		switch {
+		case err != nil:
+			logger.Error(err, "unable to get rollout progress", "workload", target)
+			inFlight = append(inFlight, target)
+		case stalled:
+			logger.Info("rollout of kyma workload stalled, rollout aborted", "workload", target)
+			o.event(corev1.EventTypeWarning, EventReasonRolloutStalled,
+				fmt.Sprintf("rollout of %s exceeded its progress deadline, %d workloads are not restarted",
+					target, len(o.queue)))
+			o.queue, o.inFlight, o.stalled = nil, nil, true
+			return
+		case !completed:
+			inFlight = append(inFlight, target)
+		}
+	}
+	o.inFlight = inFlight
+
+	for len(o.inFlight) < max(o.MaxConcurrent, 1) && len(o.queue) > 0 {
+		target := o.queue[0]
+		o.queue = o.queue[1:]
+		if err := o.restart(ctx, target); err != nil {
+			logger.Error(err, "unable to restart workload, skipping workload", "workload", target)
+			o.failure = fmt.Errorf("unable to restart %s: %w", target, err)
+			continue
+		}
+		o.inFlight = append(o.inFlight, target)
+	}
+
+	if len(o.queue) == 0 && len(o.inFlight) == 0 {
+		logger.Info("rollout of kyma workloads completed", "placement", o.placement)
+		o.event(corev1.EventTypeNormal, EventReasonRolloutCompleted,
+			fmt.Sprintf("workloads restarted after the placement changed to %s", o.placement))
+	}
+}
+
+// targets returns the workloads of the Kyma namespaces with pods to restart,
+// Deployments first.
+func (o *RolloutOrchestrator) targets(ctx context.Context) ([]rolloutTarget, error) {
+	var namespaces metav1.PartialObjectMetadataList
+	namespaces.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	if err := o.Namespaces.List(ctx, &namespaces, client.MatchingLabels{
+		LabelKymaManagedBy: kymaManagedByValue,
+	}); err != nil {
+		return nil, err
+	}
+
+	cfg := o.Config()
+	var deployments, statefulSets []rolloutTarget
+	for _, namespace := range namespaces.Items {
+		if slices.Contains(cfg.OmittedNamespaces, namespace.Name) {
+			continue
+		}
+
+		var deploymentList appsv1.DeploymentList
+		if err := o.Client.List(ctx, &deploymentList, client.InNamespace(namespace.Name)); err != nil {
+			return nil, err
+		}
+		for _, deployment := range deploymentList.Items {
+			if ptr.Deref(deployment.Spec.Replicas, 1) > 0 && !isSelf(&deployment.Spec.Template) &&
+				!naturalRestartOnly(cfg, config.KindDeployment, deployment.Labels, deployment.Spec.Template.Labels) {
+				deployments = append(deployments, rolloutTarget{key: client.ObjectKeyFromObject(&deployment)})
+			}
+		}
+
+		var statefulSetList appsv1.StatefulSetList
+		if err := o.Client.List(ctx, &statefulSetList, client.InNamespace(namespace.Name)); err != nil {
+			return nil, err
+		}
+		for _, statefulSet := range statefulSetList.Items {
+			if ptr.Deref(statefulSet.Spec.Replicas, 1) > 0 && !isSelf(&statefulSet.Spec.Template) &&
+				replacesAllPods(&statefulSet) &&
+				!naturalRestartOnly(cfg, config.KindStatefulSet, statefulSet.Labels, statefulSet.Spec.Template.Labels) {
+				statefulSets = append(statefulSets, rolloutTarget{
+					key:         client.ObjectKeyFromObject(&statefulSet),
+					statefulSet: true,
+				})
+			}
+		}
+	}
+	return slices.Concat(deployments, statefulSets), nil
+}
+
+// restart patches the restart annotation into the pod template of the workload,
+// like kubectl rollout restart. The workload replaces its pods according to its
+// update strategy, e.g. respecting maxUnavailable.
+func (o *RolloutOrchestrator) restart(ctx context.Context, target rolloutTarget) error {
+	obj := target.object()
+	if err := o.Client.Get(ctx, target.key, obj); err != nil {
+		return err
+	}
+
+	original := obj.DeepCopyObject().(client.Object)
+	template := podTemplate(obj)
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[AnnotationRestartedAt] = time.Now().Format(time.RFC3339)
+	return o.Client.Patch(ctx, obj, client.MergeFrom(original))
+}
+
+// progress returns whether the rollout of the workload completed, or stalled
+// because it exceeded its progress deadline.
+func (o *RolloutOrchestrator) progress(ctx context.Context, target rolloutTarget) (bool, bool, error) {
+	obj := target.object()
+	if err := o.Client.Get(ctx, target.key, obj); err != nil {
+		// a deleted workload has nothing left to roll out
+		return apierrors.IsNotFound(err), false, client.IgnoreNotFound(err)
+	}
+
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		for _, condition := range workload.Status.Conditions {
+			if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+				condition.Reason == "ProgressDeadlineExceeded" {
+				return false, true, nil
+			}
+		}
+		replicas := ptr.Deref(workload.Spec.Replicas, 1)
+		return workload.Status.ObservedGeneration >= workload.Generation &&
+			workload.Status.Replicas == replicas &&
+			workload.Status.UpdatedReplicas == replicas &&
+			workload.Status.AvailableReplicas == replicas, false, nil
+	case *appsv1.StatefulSet:
+		if !replacesAllPods(workload) {
+			// partitioned since the restart, the rollout never reaches all pods
+			return true, false, nil
+		}
+		replicas := ptr.Deref(workload.Spec.Replicas, 1)
+		return workload.Status.ObservedGeneration >= workload.Generation &&
+			workload.Status.UpdatedReplicas == replicas &&
+			workload.Status.ReadyReplicas == replicas &&
+			workload.Status.CurrentRevision == workload.Status.UpdateRevision, false, nil
+	}
+	return true, false, nil
+}
+
+// replacesAllPods returns false if a restart doesn't replace all pods of the
+// stateful set: the pods of OnDelete stateful sets are not replaced at all, the
+// pods below the partition of a partitioned rolling update are kept.
+func replacesAllPods(statefulSet *appsv1.StatefulSet) bool {
+	strategy := statefulSet.Spec.UpdateStrategy
+	if strategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return false
+	}
+	return strategy.RollingUpdate == nil || ptr.Deref(strategy.RollingUpdate.Partition, 0) == 0
+}
+
+func (o *RolloutOrchestrator) event(eventType, reason, message string) {
+	if o.Recorder != nil && o.EventTarget != nil {
+		o.Recorder.Event(o.EventTarget, eventType, reason, message)
+	}
+}
+
+// isSelf returns true for the pod template of kim-snatch, restarting itself
+// would abort the rollout.
+func isSelf(template *corev1.PodTemplateSpec) bool {
+	return template.Labels[LabelComponent] == selfComponent
+}
+
+// podTemplate returns the pod template of the workload.
+func podTemplate(obj client.Object) *corev1.PodTemplateSpec {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template
+	}
+	return nil
+}
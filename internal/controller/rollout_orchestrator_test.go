@@ -0,0 +1,228 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func testRolloutOrchestrator(t *testing.T, objs ...client.Object) (*controller.RolloutOrchestrator, client.Client, *record.FakeRecorder) {
+	t.Helper()
+
+	c := fake.NewClientBuilder().
+		WithObjects(append(objs, testKymaNamespace())...).
+		WithStatusSubresource(&appsv1.Deployment{}, &appsv1.StatefulSet{}).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	o := &controller.RolloutOrchestrator{
+		Client:        c,
+		Namespaces:    c,
+		Config:        config.Default,
+		Recorder:      recorder,
+		EventTarget:   &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+		MaxConcurrent: 1,
+	}
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	require.NoError(t, o.Apply(context.Background(), cfg))
+	return o, c, recorder
+}
+
+func testRestartedAt(t *testing.T, c client.Client, obj client.Object) string {
+	t.Helper()
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(obj), obj))
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return workload.Spec.Template.Annotations[controller.AnnotationRestartedAt]
+	case *appsv1.StatefulSet:
+		return workload.Spec.Template.Annotations[controller.AnnotationRestartedAt]
+	}
+	return ""
+}
+
+func testCompleteRollout(t *testing.T, c client.Client, deployment *appsv1.Deployment) {
+	t.Helper()
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), deployment))
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: deployment.Generation,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		AvailableReplicas:  1,
+	}
+	require.NoError(t, c.Status().Update(context.Background(), deployment))
+}
+
+func testPartitionedStatefulSet(name string, partition int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name},
+		Spec: appsv1.StatefulSetSpec{UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+			Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: ptr.To(partition)},
+		}},
+	}
+}
+
+func Test_RolloutOrchestrator(t *testing.T) {
+	first := testDeployment("first", nil)
+	second := testDeployment("second", nil)
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "database"}}
+	scaledDown := testDeployment("scaled-down", nil)
+	scaledDown.Spec.Replicas = ptr.To(int32(0))
+	self := testDeployment("kim-snatch", nil)
+	self.Spec.Template.Labels = map[string]string{controller.LabelComponent: "kim-snatch"}
+	naturalRestart := testDeployment("nats", nil)
+	naturalRestart.Labels = map[string]string{controller.LabelNaturalRestart: "true"}
+	partitioned := testPartitionedStatefulSet("canary", 2)
+
+	o, c, recorder := testRolloutOrchestrator(t, first, second, statefulSet, scaledDown, self, naturalRestart,
+		partitioned)
+
+	// the baseline configuration doesn't restart anything
+	o.Check(context.Background())
+	assert.Empty(t, recorder.Events)
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-1"
+	require.NoError(t, o.Apply(context.Background(), cfg))
+
+	o.Check(context.Background())
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonRolloutStarted)
+	assert.NotEmpty(t, testRestartedAt(t, c, first))
+	assert.Empty(t, testRestartedAt(t, c, second))
+
+	// the next workload is only restarted once the previous rollout completed
+	o.Check(context.Background())
+	assert.Empty(t, testRestartedAt(t, c, second))
+
+	testCompleteRollout(t, c, first)
+	o.Check(context.Background())
+	assert.NotEmpty(t, testRestartedAt(t, c, second))
+	assert.Empty(t, testRestartedAt(t, c, statefulSet))
+
+	testCompleteRollout(t, c, second)
+	o.Check(context.Background())
+	assert.NotEmpty(t, testRestartedAt(t, c, statefulSet))
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(statefulSet), statefulSet))
+	statefulSet.Status = appsv1.StatefulSetStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1}
+	require.NoError(t, c.Status().Update(context.Background(), statefulSet))
+	o.Check(context.Background())
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonRolloutCompleted)
+	assert.Empty(t, testRestartedAt(t, c, scaledDown))
+	assert.Empty(t, testRestartedAt(t, c, self))
+	assert.Empty(t, testRestartedAt(t, c, naturalRestart))
+	assert.Empty(t, testRestartedAt(t, c, partitioned))
+}
+
+func Test_RolloutOrchestrator_partitioned(t *testing.T) {
+	statefulSet := testPartitionedStatefulSet("database", 0)
+	o, c, _ := testRolloutOrchestrator(t, statefulSet)
+
+	o.Schedule()
+	o.Check(context.Background())
+	assert.NotEmpty(t, testRestartedAt(t, c, statefulSet))
+	assert.False(t, o.Done())
+
+	// the rollout doesn't wait for the pods kept by the partition
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(statefulSet), statefulSet))
+	statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = ptr.To(int32(1))
+	require.NoError(t, c.Update(context.Background(), statefulSet))
+	o.Check(context.Background())
+	assert.True(t, o.Done())
+	assert.False(t, o.Stalled())
+}
+
+func Test_RolloutOrchestrator_stalled(t *testing.T) {
+	first := testDeployment("first", nil)
+	second := testDeployment("second", nil)
+	o, c, recorder := testRolloutOrchestrator(t, first, second)
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	cfg.AffinityMode = config.ModeRequired
+	require.NoError(t, o.Apply(context.Background(), cfg))
+	o.Check(context.Background())
+	<-recorder.Events
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(first), first))
+	first.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	}}
+	require.NoError(t, c.Status().Update(context.Background(), first))
+	o.Check(context.Background())
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonRolloutStalled)
+	assert.Empty(t, testRestartedAt(t, c, second))
+	assert.True(t, o.Done())
+	assert.True(t, o.Stalled())
+}
+
+func Test_RolloutOrchestrator_schedule(t *testing.T) {
+	deployment := testDeployment("first", nil)
+	o, c, _ := testRolloutOrchestrator(t, deployment)
+	assert.True(t, o.Done())
+
+	// the workloads are restarted without a change of the configuration
+	o.Schedule()
+	assert.False(t, o.Done())
+	o.Check(context.Background())
+	assert.NotEmpty(t, testRestartedAt(t, c, deployment))
+
+	testCompleteRollout(t, c, deployment)
+	o.Check(context.Background())
+	assert.True(t, o.Done())
+	assert.False(t, o.Stalled())
+}
+
+func Test_RolloutOrchestrator_restart_failure(t *testing.T) {
+	failing := testDeployment("failing", nil)
+	healthy := testDeployment("healthy", nil)
+	o, c, _ := testRolloutOrchestrator(t, failing, healthy)
+	o.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if obj.GetName() == "failing" {
+				return apierrors.NewForbidden(appsv1.Resource("deployments"), "failing", errors.New("denied by policy"))
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	// the failing workload is skipped, the error is kept until the next rollout
+	o.Schedule()
+	o.Check(context.Background())
+	assert.NotEmpty(t, testRestartedAt(t, c, healthy))
+	testCompleteRollout(t, c, healthy)
+	o.Check(context.Background())
+	assert.True(t, o.Done())
+	assert.False(t, o.Stalled())
+	require.Error(t, o.Err())
+	assert.Contains(t, o.Err().Error(), "failing")
+
+	o.Client = c
+	o.Schedule()
+	o.Check(context.Background())
+	assert.NoError(t, o.Err())
+}
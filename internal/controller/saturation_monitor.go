@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/capacity"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonPoolSaturated   = "PoolSaturated"
+	EventReasonPoolUnsaturated = "PoolUnsaturated"
+
+	// FieldNodeName selects the pods bound to a node
+	FieldNodeName = "spec.nodeName"
+)
+
+// SaturationMonitor periodically compares the resources requested by the pods
+// running on the Kyma worker pool with the allocatable resources of its
+// available nodes, and reports the saturation via metric and events.
+type SaturationMonitor struct {
+	// Reader lists the pods bound to the nodes of the pool
+	Reader client.Reader
+	// Nodes returns the nodes of the Kyma worker pool
+	Nodes    func() []pool.Node
+	Config   func() config.Config
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks
+	Interval time.Duration
+
+	saturated atomic.Bool
+}
+
+// Start runs the monitor until the context is cancelled.
+func (m *SaturationMonitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, m.Check, m.Interval)
+	return nil
+}
+
+// Check computes the utilization of the Kyma worker pool once.
+func (m *SaturationMonitor) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("saturation-monitor")
+	cfg := m.Config()
+
+	requested := corev1.ResourceList{}
+	allocatable := corev1.ResourceList{}
+	for _, node := range m.Nodes() {
+		// cordoned nodes take no new pods, their load is ignored as well
+		if !node.Available() {
+			continue
+		}
+
+		var pods corev1.PodList
+		if err := m.Reader.List(ctx, &pods, client.MatchingFields{FieldNodeName: node.Name}); err != nil {
+			// keep the last known state
+			logger.Error(err, "unable to list pods of node", "node", node.Name)
+			return
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if isTerminated(pod) || slices.Contains(cfg.OmittedNamespaces, pod.Namespace) {
+				continue
+			}
+			addResources(requested, capacity.PodRequests(pod))
+		}
+		addResources(allocatable, node.Allocatable)
+	}
+
+	utilization := capacity.Utilization(requested, allocatable)
+	saturated := false
+	for name, percent := range utilization {
+		if m.Metrics != nil {
+			m.Metrics.SetPoolUtilization(string(name), percent)
+		}
+		if percent >= float64(cfg.SaturationThreshold) {
+			saturated = true
+		}
+	}
+
+	if m.saturated.Swap(saturated) == saturated {
+		return
+	}
+
+	if saturated {
+		logger.Info("kyma worker pool saturated", "pool", cfg.KymaWorkerPoolName, "utilization", utilization)
+		m.event(corev1.EventTypeWarning, EventReasonPoolSaturated,
+			fmt.Sprintf("pods request more than %d%% of the allocatable resources of kyma worker pool %s: %s",
+				cfg.SaturationThreshold, cfg.KymaWorkerPoolName, formatUtilization(utilization)))
+		return
+	}
+
+	logger.Info("kyma worker pool no longer saturated", "pool", cfg.KymaWorkerPoolName, "utilization", utilization)
+	m.event(corev1.EventTypeNormal, EventReasonPoolUnsaturated,
+		fmt.Sprintf("kyma worker pool %s is no longer saturated: %s",
+			cfg.KymaWorkerPoolName, formatUtilization(utilization)))
+}
+
+func (m *SaturationMonitor) event(eventType, reason, message string) {
+	if m.Recorder != nil && m.EventTarget != nil {
+		m.Recorder.Event(m.EventTarget, eventType, reason, message)
+	}
+}
+
+// Saturated returns true if the pods request more than the configured share
+// of the allocatable resources of the Kyma worker pool.
+func (m *SaturationMonitor) Saturated() bool {
+	return m.saturated.Load()
+}
+
+// NeedLeaderElection returns false, every replica serves admission requests.
+func (m *SaturationMonitor) NeedLeaderElection() bool {
+	return false
+}
+
+func isTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+func addResources(total, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+func formatUtilization(utilization map[corev1.ResourceName]float64) string {
+	var result string
+	for _, name := range capacity.Resources {
+		percent, ok := utilization[name]
+		if !ok {
+			continue
+		}
+		if result != "" {
+			result += ", "
+		}
+		result += fmt.Sprintf("%s %.0f%%", name, percent)
+	}
+	return result
+}
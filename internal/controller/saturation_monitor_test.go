@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testBoundPod(namespace, name, node, cpu string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name: "test",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse(cpu),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func Test_SaturationMonitor(t *testing.T) {
+	reader := fake.NewClientBuilder().
+		WithObjects(
+			testBoundPod("kyma-system", "a", "node-a", "1500m"),
+			testBoundPod("kyma-system", "b", "node-b", "500m"),
+			testBoundPod("kube-system", "omitted", "node-a", "2"),
+			testBoundPod("kyma-system", "off-pool", "node-c", "2"),
+		).
+		WithIndex(&corev1.Pod{}, controller.FieldNodeName, func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.NodeName}
+		}).
+		Build()
+
+	nodes := []pool.Node{
+		{Name: "node-a", Ready: true, Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		}},
+		{Name: "node-b", Ready: true, Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		}},
+	}
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPoolUtilization", "cpu", float64(100)).Twice()
+	mtr.On("SetPoolUtilization", "memory", float64(0)).Twice()
+	recorder := record.NewFakeRecorder(10)
+
+	m := &controller.SaturationMonitor{
+		Reader:      reader,
+		Nodes:       func() []pool.Node { return nodes },
+		Config:      func() config.Config { return cfg },
+		Metrics:     mtr,
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	m.Check(context.Background())
+	m.Check(context.Background())
+
+	assert.True(t, m.Saturated())
+	// events are recorded only when the state changes
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonPoolSaturated)
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonSelfPlacementMismatch = "SelfPlacementMismatch"
+	EventReasonSelfPlaced            = "SelfPlaced"
+	EventReasonSelfPlacementPatched  = "SelfPlacementPatched"
+)
+
+// SelfPlacementChecker periodically verifies that kim-snatch itself runs on the
+// Kyma worker pool it steers the workloads to.
+type SelfPlacementChecker struct {
+	// Client reads the own pod and node, and patches the own Deployment, the
+	// cache of the manager holds neither of them
+	Client   client.Client
+	Config   func() config.Config
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+	// ActivePool returns the successor of the kyma worker pool if it was recreated, optional
+	ActivePool func() string
+
+	// EventTarget is the pod of kim-snatch, the check is skipped if not set
+	EventTarget *corev1.ObjectReference
+	// PatchDeployment adds the node affinity of the Kyma worker pool to the
+	// pod template of the own Deployment if the pod is misplaced
+	PatchDeployment bool
+	// Interval between two checks
+	Interval time.Duration
+
+	misplaced bool
+}
+
+// Start runs the checker until the context is cancelled.
+func (c *SelfPlacementChecker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, c.Check, c.Interval)
+	return nil
+}
+
+// Check verifies the placement of the own pod once.
+func (c *SelfPlacementChecker) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("self-placement-checker")
+	if c.EventTarget == nil {
+		return
+	}
+
+	cfg := c.Config()
+	// the webhook steers the pods to the successor of a recreated pool
+	if c.ActivePool != nil {
+		if active := c.ActivePool(); active != "" {
+			cfg.KymaWorkerPoolName = active
+		}
+	}
+	var pod corev1.Pod
+	if err := c.Client.Get(ctx, client.ObjectKey{
+		Namespace: c.EventTarget.Namespace,
+		Name:      c.EventTarget.Name,
+	}, &pod); err != nil {
+		logger.Error(err, "unable to get own pod")
+		return
+	}
+	if pod.Spec.NodeName == "" {
+		return
+	}
+
+	// the metadata is sufficient, only the pool label is compared
+	var node metav1.PartialObjectMetadata
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, &node); err != nil {
+		logger.Error(err, "unable to get node of own pod", "node", pod.Spec.NodeName)
+		return
+	}
+
+	misplaced := node.Labels[cfg.PoolLabelKey] != cfg.KymaWorkerPoolName
+	if c.Metrics != nil {
+		c.Metrics.SetSelfOnPool(!misplaced)
+	}
+
+	previous := c.misplaced
+	c.misplaced = misplaced
+	switch {
+	case misplaced && !previous:
+		logger.Info("kim-snatch runs outside of kyma worker pool", "pool", cfg.KymaWorkerPoolName,
+			"node", node.Name)
+		c.event(corev1.EventTypeWarning, EventReasonSelfPlacementMismatch,
+			fmt.Sprintf("kim-snatch runs on node %s outside of kyma worker pool %s", node.Name, cfg.KymaWorkerPoolName))
+	case !misplaced && previous:
+		logger.Info("kim-snatch runs on kyma worker pool", "pool", cfg.KymaWorkerPoolName, "node", node.Name)
+		c.event(corev1.EventTypeNormal, EventReasonSelfPlaced,
+			fmt.Sprintf("kim-snatch runs on node %s of kyma worker pool %s", node.Name, cfg.KymaWorkerPoolName))
+	}
+
+	if !misplaced || !c.PatchDeployment {
+		return
+	}
+
+	patched, err := c.patchDeployment(ctx, &pod, cfg)
+	if err != nil {
+		logger.Error(err, "unable to patch node affinity of own deployment")
+		return
+	}
+	if patched != "" {
+		logger.Info("node affinity of own deployment patched", "deployment", patched)
+		c.event(corev1.EventTypeNormal, EventReasonSelfPlacementPatched,
+			fmt.Sprintf("deployment %s patched to prefer kyma worker pool %s", patched, cfg.KymaWorkerPoolName))
+	}
+}
+
+// patchDeployment adds the node affinity of the Kyma worker pool to the
+// Deployment owning the pod, the name of the Deployment is returned if it was
+// patched. The affinity is preferred, kim-snatch must stay schedulable while
+// the pool is unavailable.
+func (c *SelfPlacementChecker) patchDeployment(ctx context.Context, pod *corev1.Pod, cfg config.Config) (string, error) {
+	deployment, err := owningDeployment(ctx, c.Client, pod)
+	if err != nil || deployment == nil {
+		return "", err
+	}
+
+	original := deployment.DeepCopy()
+	if !preferPool(&deployment.Spec.Template.Spec, cfg) {
+		return "", nil
+	}
+
+	if err := c.Client.Patch(ctx, deployment,
+		client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return "", err
+	}
+	return deployment.Name, nil
+}
+
+// owningDeployment returns the Deployment owning the pod through its
+// ReplicaSet, or nil if the pod isn't owned by a Deployment.
+func owningDeployment(ctx context.Context, c client.Reader, pod *corev1.Pod) (*appsv1.Deployment, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "ReplicaSet" {
+		return nil, nil
+	}
+
+	var replicaSet metav1.PartialObjectMetadata
+	replicaSet.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))
+	if err := c.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: owner.Name}, &replicaSet); err != nil {
+		return nil, fmt.Errorf("unable to get replica set: %w", err)
+	}
+
+	owner = metav1.GetControllerOf(&replicaSet)
+	if owner == nil || owner.Kind != "Deployment" {
+		return nil, nil
+	}
+
+	var deployment appsv1.Deployment
+	if err := c.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: owner.Name}, &deployment); err != nil {
+		return nil, fmt.Errorf("unable to get deployment: %w", err)
+	}
+	return &deployment, nil
+}
+
+func (c *SelfPlacementChecker) event(eventType, reason, message string) {
+	if c.Recorder != nil && c.EventTarget != nil {
+		c.Recorder.Event(c.EventTarget, eventType, reason, message)
+	}
+}
+
+// NeedLeaderElection returns false, every replica verifies its own placement.
+func (c *SelfPlacementChecker) NeedLeaderElection() bool {
+	return false
+}
+
+// preferPool adds the preferred scheduling term of the Kyma worker pool to the
+// pod spec and replaces the terms of other pools, false is returned if the
+// term is already present.
+func preferPool(spec *corev1.PodSpec, cfg config.Config) bool {
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      cfg.PoolLabelKey,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{cfg.KymaWorkerPoolName},
+	}
+
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := spec.Affinity.NodeAffinity
+
+	for _, term := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		for _, expression := range term.Preference.MatchExpressions {
+			if expression.Key == requirement.Key && expression.Operator == requirement.Operator &&
+				slices.Equal(expression.Values, requirement.Values) {
+				return false
+			}
+		}
+	}
+
+	// terms of a previous pool would compete with the new one
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = slices.DeleteFunc(
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		func(term corev1.PreferredSchedulingTerm) bool {
+			return slices.ContainsFunc(term.Preference.MatchExpressions, func(expression corev1.NodeSelectorRequirement) bool {
+				return expression.Key == requirement.Key
+			})
+		})
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.PreferredSchedulingTerm{
+			Weight:     cfg.AffinityWeight,
+			Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{requirement}},
+		})
+	return true
+}
@@ -0,0 +1,186 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testSelfPlacementObjects(nodeLabels map[string]string) (*corev1.Pod, *corev1.Node, *appsv1.ReplicaSet, *appsv1.Deployment) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch", UID: "deployment"},
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "kim-snatch-5d8b7",
+			UID:       "replicaset",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       deployment.Name,
+				UID:        deployment.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "kim-snatch-5d8b7-abcde",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       replicaSet.Name,
+				UID:        replicaSet.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: corev1.PodSpec{NodeName: "ip-10-250-0-1"},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ip-10-250-0-1", Labels: nodeLabels}}
+	return pod, node, replicaSet, deployment
+}
+
+func Test_SelfPlacementChecker(t *testing.T) {
+	pod, node, replicaSet, deployment := testSelfPlacementObjects(map[string]string{
+		config.DefaultPoolLabelKey: "other",
+	})
+	c := fake.NewClientBuilder().WithObjects(pod, node, replicaSet, deployment).Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetSelfOnPool", false).Twice()
+	mtr.On("SetSelfOnPool", true).Once()
+	recorder := record.NewFakeRecorder(10)
+
+	checker := &controller.SelfPlacementChecker{
+		Client:          c,
+		Config:          func() config.Config { return cfg },
+		Metrics:         mtr,
+		Recorder:        recorder,
+		EventTarget:     &corev1.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name},
+		PatchDeployment: true,
+	}
+
+	checker.Check(context.Background())
+	checker.Check(context.Background())
+
+	// the mismatch is reported once, the deployment is patched once
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonSelfPlacementMismatch)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonSelfPlacementPatched)
+
+	var patched appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), &patched))
+	require.NotNil(t, patched.Spec.Template.Spec.Affinity)
+	terms := patched.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	assert.Equal(t, cfg.AffinityWeight, terms[0].Weight)
+	assert.Equal(t, []corev1.NodeSelectorRequirement{{
+		Key:      config.DefaultPoolLabelKey,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"cpu-worker-0"},
+	}}, terms[0].Preference.MatchExpressions)
+	assert.Nil(t, patched.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+
+	node.Labels[config.DefaultPoolLabelKey] = "cpu-worker-0"
+	require.NoError(t, c.Update(context.Background(), node))
+	checker.Check(context.Background())
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonSelfPlaced)
+}
+
+func Test_SelfPlacementChecker_replaces_previous_pool(t *testing.T) {
+	pod, node, replicaSet, deployment := testSelfPlacementObjects(nil)
+	deployment.Spec.Template.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+			Weight: 10,
+			Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      config.DefaultPoolLabelKey,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"cpu-worker-0"},
+			}}},
+		}},
+	}}
+	c := fake.NewClientBuilder().WithObjects(pod, node, replicaSet, deployment).Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-1"
+
+	checker := &controller.SelfPlacementChecker{
+		Client:          c,
+		Config:          func() config.Config { return cfg },
+		EventTarget:     &corev1.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name},
+		PatchDeployment: true,
+	}
+	checker.Check(context.Background())
+
+	var patched appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), &patched))
+	terms := patched.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	assert.Equal(t, []string{"cpu-worker-1"}, terms[0].Preference.MatchExpressions[0].Values)
+}
+
+func Test_SelfPlacementChecker_without_patch(t *testing.T) {
+	pod, node, replicaSet, deployment := testSelfPlacementObjects(nil)
+	c := fake.NewClientBuilder().WithObjects(pod, node, replicaSet, deployment).Build()
+
+	checker := &controller.SelfPlacementChecker{
+		Client:      c,
+		Config:      config.Default,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name},
+	}
+	checker.Check(context.Background())
+
+	var unchanged appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), &unchanged))
+	assert.Nil(t, unchanged.Spec.Template.Spec.Affinity)
+}
+
+func Test_SelfPlacementChecker_active_pool(t *testing.T) {
+	pod, node, replicaSet, deployment := testSelfPlacementObjects(map[string]string{
+		config.DefaultPoolLabelKey: "cpu-worker-1",
+	})
+	c := fake.NewClientBuilder().WithObjects(pod, node, replicaSet, deployment).Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetSelfOnPool", true).Once()
+	recorder := record.NewFakeRecorder(10)
+
+	// the pool was recreated as cpu-worker-1, kim-snatch runs on the successor
+	checker := &controller.SelfPlacementChecker{
+		Client:          c,
+		Config:          func() config.Config { return cfg },
+		Metrics:         mtr,
+		Recorder:        recorder,
+		ActivePool:      func() string { return "cpu-worker-1" },
+		EventTarget:     &corev1.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name},
+		PatchDeployment: true,
+	}
+	checker.Check(context.Background())
+
+	assert.Empty(t, recorder.Events)
+	var unchanged appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), &unchanged))
+	assert.Nil(t, unchanged.Spec.Template.Spec.Affinity)
+}
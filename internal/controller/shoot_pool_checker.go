@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ConditionPoolAvailable reports if the configured worker pool is defined in the Shoot
+	ConditionPoolAvailable = "PoolAvailable"
+
+	ReasonPoolFound     = "PoolFound"
+	ReasonPoolMissing   = "PoolMissing"
+	ReasonShootDeleting = "ShootDeleting"
+
+	// ReasonGardenUnreachable is the reason of the event recorded when the
+	// Garden cluster cannot be reached, the last condition is kept meanwhile
+	ReasonGardenUnreachable = "GardenUnreachable"
+)
+
+// ShootPoolChecker periodically verifies that the configured Kyma worker pool
+// is defined in the spec of the Shoot in the Garden cluster.
+type ShootPoolChecker struct {
+	// Garden reads the Shoot from the Garden cluster
+	Garden client.Reader
+	// Client updates the status of the SnatchConfig the pool is configured in
+	Client client.Client
+	Store  *config.Store
+
+	// ShootKey is the namespace and name of the Shoot in the Garden cluster
+	ShootKey client.ObjectKey
+
+	Recorder record.EventRecorder
+	// EventTarget is the object events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks
+	Interval time.Duration
+
+	lastReason string
+}
+
+// Start runs the checker until the context is cancelled.
+func (c *ShootPoolChecker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, c.Check, c.Interval)
+	return nil
+}
+
+// Check verifies the configured Kyma worker pool once.
+func (c *ShootPoolChecker) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("shoot-pool-checker")
+	effective := c.Store.Get()
+	pool := effective.Config.KymaWorkerPoolName
+
+	workers, err := garden.GetShootWorkers(ctx, c.Garden, c.ShootKey)
+	if errors.Is(err, garden.ErrUnreachable) {
+		// degrade gracefully, the pool is verified again once the Garden cluster is back
+		if c.lastReason != ReasonGardenUnreachable {
+			logger.Info("garden cluster unreachable, keeping last verification result", "error", err.Error())
+			c.recordEvent(corev1.EventTypeWarning, ReasonGardenUnreachable,
+				fmt.Sprintf("garden cluster unreachable, shoot %s not verified", c.ShootKey))
+			c.lastReason = ReasonGardenUnreachable
+		}
+		return
+	}
+	if err != nil {
+		logger.Error(err, "unable to verify kyma worker pool")
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:    ConditionPoolAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonPoolFound,
+		Message: fmt.Sprintf("worker pool %s is defined in shoot %s", pool, c.ShootKey),
+	}
+	switch {
+	case workers.Deleting:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonShootDeleting
+		condition.Message = fmt.Sprintf("shoot %s is being deleted", c.ShootKey)
+	case !slices.Contains(workers.Pools, pool):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonPoolMissing
+		condition.Message = fmt.Sprintf("worker pool %s is not defined in shoot %s, defined pools: %v",
+			pool, c.ShootKey, workers.Pools)
+	}
+
+	if condition.Reason != c.lastReason {
+		eventType := corev1.EventTypeWarning
+		if condition.Status == metav1.ConditionTrue {
+			eventType = corev1.EventTypeNormal
+		}
+		logger.Info("kyma worker pool verified", "reason", condition.Reason, "message", condition.Message)
+		c.recordEvent(eventType, condition.Reason, condition.Message)
+		c.lastReason = condition.Reason
+	}
+
+	// the condition is reported on the SnatchConfig the pool is configured in
+	key, ok := config.SnatchConfigOrigin(effective.Origins[config.KeyKymaWorkerPoolName])
+	if !ok {
+		return
+	}
+	if err := setSnatchConfigCondition(ctx, c.Client, key, condition); err != nil {
+		logger.Error(err, "unable to update snatch config status", "name", key)
+	}
+}
+
+func (c *ShootPoolChecker) recordEvent(eventType, reason, message string) {
+	if c.Recorder != nil && c.EventTarget != nil {
+		c.Recorder.Event(c.EventTarget, eventType, reason, message)
+	}
+}
+
+// setSnatchConfigCondition sets the condition in the status of the SnatchConfig,
+// SnatchConfigs that don't exist anymore are ignored.
+func setSnatchConfigCondition(ctx context.Context, c client.Client, key client.ObjectKey, condition metav1.Condition) error {
+	var snatchCfg snatchv1alpha1.SnatchConfig
+	if err := c.Get(ctx, key, &snatchCfg); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	condition.ObservedGeneration = snatchCfg.Generation
+	if !meta.SetStatusCondition(&snatchCfg.Status.Conditions, condition) {
+		return nil
+	}
+	return c.Status().Update(ctx, &snatchCfg)
+}
@@ -0,0 +1,115 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	snatchv1alpha1 "github.com/kyma-project/kim-snatch/api/v1alpha1"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var testShootKey = client.ObjectKey{Namespace: "garden-kyma", Name: "test"}
+
+func testShoot(pools ...string) *unstructured.Unstructured {
+	var workers []any
+	for _, pool := range pools {
+		workers = append(workers, map[string]any{"name": pool})
+	}
+
+	shoot := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"provider": map[string]any{"workers": workers}},
+	}}
+	shoot.SetGroupVersionKind(garden.ShootGroupVersionKind)
+	shoot.SetNamespace(testShootKey.Namespace)
+	shoot.SetName(testShootKey.Name)
+	return shoot
+}
+
+func Test_ShootPoolChecker_pool_missing(t *testing.T) {
+	snatchCfg := testSnatchConfig("missing")
+	cluster := fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(snatchCfg).
+		WithStatusSubresource(snatchCfg).
+		Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "missing"
+	recorder := record.NewFakeRecorder(10)
+
+	c := &controller.ShootPoolChecker{
+		Garden: fake.NewClientBuilder().WithObjects(testShoot("cpu-worker-0")).Build(),
+		Client: cluster,
+		Store: config.NewStore(config.Effective{
+			Config: cfg,
+			Origins: map[string]string{
+				config.KeyKymaWorkerPoolName: "snatchconfig " + testNamespace + "/" + snatchCfg.Name,
+			},
+		}),
+		ShootKey:    testShootKey,
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	c.Check(context.Background())
+	c.Check(context.Background())
+
+	var updated snatchv1alpha1.SnatchConfig
+	require.NoError(t, cluster.Get(context.Background(), client.ObjectKeyFromObject(snatchCfg), &updated))
+	condition := meta.FindStatusCondition(updated.Status.Conditions, controller.ConditionPoolAvailable)
+	require.NotNil(t, condition)
+	assert.Equal(t, controller.ReasonPoolMissing, condition.Reason)
+	assert.Len(t, recorder.Events, 1)
+}
+
+func Test_ShootPoolChecker_garden_unreachable(t *testing.T) {
+	reachable := true
+	gardenReader := fake.NewClientBuilder().
+		WithObjects(testShoot("cpu-worker-0")).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if !reachable {
+					return errors.New("connection refused")
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+	recorder := record.NewFakeRecorder(10)
+
+	c := &controller.ShootPoolChecker{
+		Garden:      garden.NewClientFor(gardenReader, nil),
+		Client:      fake.NewClientBuilder().WithScheme(testScheme(t)).Build(),
+		Store:       config.NewStore(config.Effective{Config: cfg}),
+		ShootKey:    testShootKey,
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	c.Check(context.Background())
+	reachable = false
+	c.Check(context.Background())
+	c.Check(context.Background())
+	reachable = true
+	c.Check(context.Background())
+
+	require.Len(t, recorder.Events, 3)
+	assert.Contains(t, <-recorder.Events, controller.ReasonPoolFound)
+	assert.Contains(t, <-recorder.Events, controller.ReasonGardenUnreachable)
+	assert.Contains(t, <-recorder.Events, controller.ReasonPoolFound)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const EventReasonWebhookPathsMigrated = "WebhookPathsMigrated"
+
+// WebhookPathMigration moves the webhooks of the mutating webhook configuration
+// from the paths of the previous release to the ones of this release. The
+// release serves both paths, the webhooks are only moved once the rollout of
+// the own Deployment completed: all its replicas run this release and are
+// available. Until then, the API server keeps sending the admission requests
+// to the previous path, which the replicas of both releases serve, so there is
+// no window in which a replica answers with 404.
+type WebhookPathMigration struct {
+	// Client reads the own pod and Deployment, and patches the mutating webhook
+	// configuration, the cache of the manager holds none of them
+	Client client.Client
+	Config func() config.Config
+	// Paths maps the previous paths of the webhooks to the ones of this release
+	Paths        map[string]string
+	FieldManager string
+	Recorder     record.EventRecorder
+
+	// EventTarget is the pod of kim-snatch, the webhooks are moved without
+	// waiting for a rollout if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks, the manifests register the previous paths
+	// again with every upgrade
+	Interval time.Duration
+}
+
+// Start runs the migration until the context is cancelled.
+func (m *WebhookPathMigration) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, m.Check, m.Interval)
+	return nil
+}
+
+// NeedLeaderElection returns true, only the leader updates the webhooks.
+func (m *WebhookPathMigration) NeedLeaderElection() bool {
+	return true
+}
+
+// Check moves the webhooks once if they are registered with a previous path
+// and the rollout completed.
+func (m *WebhookPathMigration) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("webhook-path-migration")
+	webhookConfigName := m.Config().WebhookConfigName
+
+	var webhookConfig admissionregistration.MutatingWebhookConfiguration
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: webhookConfigName}, &webhookConfig); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.Error(err, "unable to get mutating webhook configuration", "name", webhookConfigName)
+		}
+		return
+	}
+	webhooks := callback.PreviousPaths(&webhookConfig, m.Paths)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	pending, err := m.rolloutPending(ctx)
+	if err != nil {
+		logger.Error(err, "unable to check rollout of own deployment")
+		return
+	}
+	if pending != "" {
+		logger.V(1).Info("webhooks kept on previous paths until rollout completed", "reason", pending)
+		return
+	}
+
+	if err := callback.RetryUpdate(ctx, callback.DefaultBackoff, callback.BuildUpdateWebhookPaths(ctx, m.Client,
+		callback.BuildUpdateWebhookPathsOpts{
+			Name:         webhookConfigName,
+			Paths:        m.Paths,
+			FieldManager: m.FieldManager,
+		})); err != nil {
+		logger.Error(err, "unable to move webhooks to current paths", "webhooks", webhooks)
+		return
+	}
+	logger.Info("webhooks moved to current paths", "webhooks", webhooks)
+	m.event(corev1.EventTypeNormal, EventReasonWebhookPathsMigrated,
+		fmt.Sprintf("webhooks %s moved to the paths of this release", strings.Join(webhooks, ", ")))
+}
+
+// rolloutPending returns why the rollout of the own Deployment hasn't
+// completed yet, or an empty string if it has.
+func (m *WebhookPathMigration) rolloutPending(ctx context.Context) (string, error) {
+	if m.EventTarget == nil {
+		return "", nil
+	}
+	var pod corev1.Pod
+	if err := m.Client.Get(ctx, client.ObjectKey{
+		Namespace: m.EventTarget.Namespace,
+		Name:      m.EventTarget.Name,
+	}, &pod); err != nil {
+		return "", fmt.Errorf("unable to get own pod: %w", err)
+	}
+	deployment, err := owningDeployment(ctx, m.Client, &pod)
+	if err != nil || deployment == nil {
+		return "", err
+	}
+	return rolloutPending(deployment), nil
+}
+
+// rolloutPending returns why the rollout of the Deployment hasn't completed
+// yet, or an empty string if all replicas are updated and available.
+func rolloutPending(deployment *appsv1.Deployment) string {
+	replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+	status := deployment.Status
+	switch {
+	case status.ObservedGeneration < deployment.Generation:
+		return "deployment change not observed yet"
+	case status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d of %d replicas updated", status.UpdatedReplicas, replicas)
+	case status.Replicas > status.UpdatedReplicas:
+		return fmt.Sprintf("%d previous replicas pending termination", status.Replicas-status.UpdatedReplicas)
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return fmt.Sprintf("%d of %d updated replicas available", status.AvailableReplicas, status.UpdatedReplicas)
+	}
+	return ""
+}
+
+func (m *WebhookPathMigration) event(eventType, reason, message string) {
+	if m.Recorder != nil && m.EventTarget != nil {
+		m.Recorder.Event(m.EventTarget, eventType, reason, message)
+	}
+}
@@ -0,0 +1,77 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_WebhookPathMigration(t *testing.T) {
+	pod, _, replicaSet, deployment := testSelfPlacementObjects(nil)
+	deployment.Generation = 2
+	deployment.Spec.Replicas = ptr.To(int32(2))
+	// the second replica of the previous release is still running
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           3,
+		UpdatedReplicas:    2,
+		AvailableReplicas:  3,
+	}
+	mwc := &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kim-snatch"},
+		Webhooks: []admissionregistration.MutatingWebhook{{
+			Name: "pods.kim-snatch.kyma-project.io",
+			ClientConfig: admissionregistration.WebhookClientConfig{
+				Service: &admissionregistration.ServiceReference{
+					Namespace: testNamespace,
+					Name:      "kim-snatch-webhook-service",
+					Path:      ptr.To("/mutate--v1-pod"),
+				},
+			},
+		}},
+	}
+	c := fake.NewClientBuilder().WithObjects(pod, replicaSet, deployment, mwc).
+		WithStatusSubresource(deployment).Build()
+
+	recorder := record.NewFakeRecorder(10)
+	migration := &controller.WebhookPathMigration{
+		Client:       c,
+		Config:       func() config.Config { return config.Config{WebhookConfigName: "kim-snatch"} },
+		Paths:        map[string]string{"/mutate--v1-pod": "/mutate--v1-pod-v2"},
+		FieldManager: "snatch",
+		Recorder:     recorder,
+		EventTarget:  &corev1.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name},
+	}
+	path := func() string {
+		var current admissionregistration.MutatingWebhookConfiguration
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(mwc), &current))
+		return ptr.Deref(current.Webhooks[0].ClientConfig.Service.Path, "")
+	}
+
+	migration.Check(context.Background())
+	assert.Equal(t, "/mutate--v1-pod", path(), "the previous path is kept during the rollout")
+	assert.Empty(t, recorder.Events)
+
+	deployment.Status.Replicas = 2
+	deployment.Status.AvailableReplicas = 2
+	require.NoError(t, c.Status().Update(context.Background(), deployment))
+	migration.Check(context.Background())
+	assert.Equal(t, "/mutate--v1-pod-v2", path())
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonWebhookPathsMigrated)
+
+	migration.Check(context.Background())
+	assert.Empty(t, recorder.Events, "moved webhooks are left unchanged")
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonFailurePolicyRelaxed  = "FailurePolicyRelaxed"
+	EventReasonFailurePolicyRestored = "FailurePolicyRestored"
+)
+
+// WebhookPolicy applies the failure policy and timeout of the webhooks on the
+// mutating webhook configuration. While the Kyma worker pool doesn't exist, e.g.
+// while it is recreated, the failure policy is relaxed to Ignore so that an
+// unavailable kim-snatch never blocks the creation of pods.
+type WebhookPolicy struct {
+	Client client.Client
+	// PoolPresent returns false while the Kyma worker pool has no nodes at all
+	PoolPresent  func() bool
+	FieldManager string
+	Recorder     record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+
+	mu sync.Mutex
+	// relaxed is set if the failure policy was last applied relaxed
+	relaxed bool
+}
+
+// FailurePolicy returns the failure policy the webhooks are configured with.
+func (p *WebhookPolicy) FailurePolicy(cfg config.Config) admissionregistration.FailurePolicyType {
+	if p.PoolPresent != nil && !p.PoolPresent() {
+		return admissionregistration.Ignore
+	}
+	return admissionregistration.FailurePolicyType(cfg.FailurePolicy)
+}
+
+// Apply updates the settings of the webhooks, it is an ApplyFunc of the
+// ConfigReconciler. The ConfigReconciler is triggered when the Kyma worker pool
+// disappears or returns, so that the failure policy is relaxed or restored.
+func (p *WebhookPolicy) Apply(ctx context.Context, cfg config.Config) error {
+	failurePolicy := p.FailurePolicy(cfg)
+	updateWebhookSettings := callback.BuildUpdateWebhookSettings(ctx, p.Client,
+		callback.BuildUpdateWebhookSettingsOpts{
+			Name:           cfg.WebhookConfigName,
+			FailurePolicy:  failurePolicy,
+			TimeoutSeconds: cfg.TimeoutSeconds,
+			FieldManager:   p.FieldManager,
+		})
+	if err := callback.RetryUpdate(ctx, callback.DefaultBackoff, updateWebhookSettings); err != nil {
+		return err
+	}
+
+	relaxed := p.PoolPresent != nil && !p.PoolPresent()
+	p.mu.Lock()
+	changed := relaxed != p.relaxed
+	p.relaxed = relaxed
+	p.mu.Unlock()
+	if !changed {
+		return nil
+	}
+
+	logger := logf.FromContext(ctx).WithName("webhook-policy")
+	if relaxed {
+		logger.Info("kyma worker pool has no nodes, failure policy relaxed", "pool", cfg.KymaWorkerPoolName)
+		p.event(corev1.EventTypeWarning, EventReasonFailurePolicyRelaxed,
+			fmt.Sprintf("kyma worker pool %s has no nodes, failure policy of webhooks set to %s",
+				cfg.KymaWorkerPoolName, failurePolicy))
+		return nil
+	}
+
+	logger.Info("kyma worker pool has nodes again, failure policy restored", "pool", cfg.KymaWorkerPoolName)
+	p.event(corev1.EventTypeNormal, EventReasonFailurePolicyRestored,
+		fmt.Sprintf("kyma worker pool %s has nodes again, failure policy of webhooks set to %s",
+			cfg.KymaWorkerPoolName, failurePolicy))
+	return nil
+}
+
+func (p *WebhookPolicy) event(eventType, reason, message string) {
+	if p.Recorder != nil && p.EventTarget != nil {
+		p.Recorder.Event(p.EventTarget, eventType, reason, message)
+	}
+}
@@ -0,0 +1,68 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_WebhookPolicy_pool_presence(t *testing.T) {
+	scheme := testScheme(t)
+	require.NoError(t, admissionregistration.AddToScheme(scheme))
+
+	cfg := config.Default()
+	cfg.WebhookConfigName = "test-me"
+	cfg.FailurePolicy = string(admissionregistration.Fail)
+
+	mwc := &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: cfg.WebhookConfigName},
+		Webhooks: []admissionregistration.MutatingWebhook{{
+			Name:          "pods.kim-snatch.kyma-project.io",
+			FailurePolicy: ptr.To(admissionregistration.Fail),
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mwc).Build()
+
+	present := false
+	recorder := record.NewFakeRecorder(10)
+	p := &controller.WebhookPolicy{
+		Client:       c,
+		PoolPresent:  func() bool { return present },
+		FieldManager: "snatch",
+		Recorder:     recorder,
+		EventTarget:  &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	failurePolicy := func() admissionregistration.FailurePolicyType {
+		var current admissionregistration.MutatingWebhookConfiguration
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(mwc), &current))
+		return ptr.Deref(current.Webhooks[0].FailurePolicy, "")
+	}
+
+	require.NoError(t, p.Apply(context.Background(), cfg))
+	assert.Equal(t, admissionregistration.Ignore, failurePolicy())
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonFailurePolicyRelaxed)
+
+	// further reloads keep the relaxed failure policy without another event
+	require.NoError(t, p.Apply(context.Background(), cfg))
+	assert.Equal(t, admissionregistration.Ignore, failurePolicy())
+	assert.Empty(t, recorder.Events)
+
+	present = true
+	require.NoError(t, p.Apply(context.Background(), cfg))
+	assert.Equal(t, admissionregistration.Fail, failurePolicy())
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonFailurePolicyRestored)
+}
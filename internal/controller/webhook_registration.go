@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var errRegistrationPending = errors.New("webhook registration has not been checked yet")
+
+// WebhookRegistrationCheck periodically verifies the MutatingWebhookConfiguration
+// still routes the admission requests to this webhook server: it exists, each
+// of its webhooks targets the webhook service and the port the webhook server
+// listens on, and its caBundle verifies the certificate currently served. The
+// replica is unready with the reason while the registration is broken, e.g.
+// after the configuration was deleted or its caBundle was overwritten.
+type WebhookRegistrationCheck struct {
+	// Reader reads the MutatingWebhookConfiguration and its Service
+	Reader client.Reader
+	Config func() config.Config
+	// ServiceName and ServiceNamespace are of the Service of the webhook server
+	ServiceName      string
+	ServiceNamespace string
+	// WebhookPort is the port the webhook server listens on
+	WebhookPort int
+	// GetCertificate returns the certificate the webhook server serves
+	GetCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	// Interval between two checks
+	Interval time.Duration
+	// APIServerUnreachable returns true while the API server can't be reached,
+	// the check is skipped then and keeps its last result, optional
+	APIServerUnreachable func() bool
+
+	mu sync.Mutex
+	// checked is set once the registration was checked, err is the result of the last check
+	checked bool
+	err     error
+}
+
+// Start checks the registration until the context is cancelled.
+func (c *WebhookRegistrationCheck) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		c.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false, every replica verifies it is reachable.
+func (c *WebhookRegistrationCheck) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadyCheck is a healthz.Checker failing until the registration was checked
+// and while the last check failed.
+func (c *WebhookRegistrationCheck) ReadyCheck(*http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if !c.checked {
+		return errRegistrationPending
+	}
+	return nil
+}
+
+// Check verifies the registration once.
+func (c *WebhookRegistrationCheck) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("webhook-registration")
+
+	if c.APIServerUnreachable != nil && c.APIServerUnreachable() {
+		return
+	}
+	err := c.check(ctx)
+
+	c.mu.Lock()
+	recovered := err == nil && c.err != nil
+	c.checked = true
+	c.err = nil
+	if err != nil {
+		c.err = fmt.Errorf("webhook registration broken: %w", err)
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		logger.Error(err, "webhook registration broken")
+	} else if recovered {
+		logger.Info("webhook registration recovered")
+	}
+}
+
+func (c *WebhookRegistrationCheck) check(ctx context.Context) error {
+	webhookConfigName := c.Config().WebhookConfigName
+	var webhookConfig admissionregistration.MutatingWebhookConfiguration
+	if err := c.Reader.Get(ctx, client.ObjectKey{Name: webhookConfigName}, &webhookConfig); err != nil {
+		return fmt.Errorf("unable to get mutating webhook configuration %s: %w", webhookConfigName, err)
+	}
+	if len(webhookConfig.Webhooks) == 0 {
+		return fmt.Errorf("mutating webhook configuration %s has no webhooks", webhookConfigName)
+	}
+
+	serverName := fmt.Sprintf("%s.%s.svc", c.ServiceName, c.ServiceNamespace)
+	cert, err := c.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	if err != nil {
+		return fmt.Errorf("no serving certificate: %w", err)
+	}
+	if len(cert.Certificate) == 0 {
+		return errors.New("empty serving certificate")
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("invalid serving certificate: %w", err)
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, der := range cert.Certificate[1:] {
+		if intermediate, err := x509.ParseCertificate(der); err == nil {
+			intermediates.AddCert(intermediate)
+		}
+	}
+
+	for _, wh := range webhookConfig.Webhooks {
+		ref := wh.ClientConfig.Service
+		if ref == nil {
+			return fmt.Errorf("webhook %s isn't served by a service", wh.Name)
+		}
+		if ref.Name != c.ServiceName || ref.Namespace != c.ServiceNamespace {
+			return fmt.Errorf("webhook %s targets service %s/%s instead of %s/%s",
+				wh.Name, ref.Namespace, ref.Name, c.ServiceNamespace, c.ServiceName)
+		}
+		if err := verifyServicePort(ctx, c.Reader, ref, c.WebhookPort); err != nil {
+			return fmt.Errorf("webhook %s: %w", wh.Name, err)
+		}
+
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(wh.ClientConfig.CABundle) {
+			return fmt.Errorf("webhook %s has no valid caBundle", wh.Name)
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         roots,
+			Intermediates: intermediates,
+		}); err != nil {
+			return fmt.Errorf("caBundle of webhook %s doesn't match the serving certificate: %w", wh.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package controller_test
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_WebhookRegistrationCheck(t *testing.T) {
+	server, caBundle := testWebhookServer(t, true)
+	served := &server.TLS.Certificates[0]
+
+	for name, tc := range map[string]struct {
+		missing     bool
+		serviceName string
+		targetPort  int32
+		caBundle    []byte
+		err         string
+	}{
+		"registered":      {},
+		"missing":         {missing: true, err: "unable to get mutating webhook configuration kim-snatch"},
+		"other service":   {serviceName: "other", err: "targets service kyma-system/other"},
+		"wrong target":    {targetPort: 8443, err: "forwards port 443 to 8443"},
+		"other ca":        {caBundle: testCertificatePEM(t, 3, time.Hour), err: "doesn't match the serving certificate"},
+		"invalid bundle":  {caBundle: []byte("invalid"), err: "no valid caBundle"},
+		"rotated ca kept": {caBundle: append(testCertificatePEM(t, 3, time.Hour), caBundle...)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			serviceName := "kim-snatch-webhook-service"
+			if tc.serviceName != "" {
+				serviceName = tc.serviceName
+			}
+			targetPort := int32(9443)
+			if tc.targetPort != 0 {
+				targetPort = tc.targetPort
+			}
+			bundle := caBundle
+			if tc.caBundle != nil {
+				bundle = tc.caBundle
+			}
+
+			objs := []client.Object{&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: serviceName},
+				Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{
+					Port:       443,
+					TargetPort: intstr.FromInt32(targetPort),
+				}}},
+			}}
+			if !tc.missing {
+				objs = append(objs, &admissionregistration.MutatingWebhookConfiguration{
+					ObjectMeta: metav1.ObjectMeta{Name: "kim-snatch"},
+					Webhooks: []admissionregistration.MutatingWebhook{{
+						Name: "pods.kim-snatch.kyma-project.io",
+						ClientConfig: admissionregistration.WebhookClientConfig{
+							Service: &admissionregistration.ServiceReference{
+								Namespace: testNamespace,
+								Name:      serviceName,
+							},
+							CABundle: bundle,
+						},
+					}},
+				})
+			}
+
+			check := &controller.WebhookRegistrationCheck{
+				Reader:           fake.NewClientBuilder().WithObjects(objs...).Build(),
+				Config:           func() config.Config { return config.Config{WebhookConfigName: "kim-snatch"} },
+				ServiceName:      "kim-snatch-webhook-service",
+				ServiceNamespace: testNamespace,
+				WebhookPort:      9443,
+				GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+					return served, nil
+				},
+			}
+			require.ErrorContains(t, check.ReadyCheck(nil), "not been checked yet")
+			check.Check(t.Context())
+			if tc.err == "" {
+				assert.NoError(t, check.ReadyCheck(nil))
+				return
+			}
+			assert.ErrorContains(t, check.ReadyCheck(nil), tc.err)
+		})
+	}
+}
+
+func Test_WebhookRegistrationCheck_apiServerUnreachable(t *testing.T) {
+	server, caBundle := testWebhookServer(t, true)
+	served := &server.TLS.Certificates[0]
+
+	webhookConfig := &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kim-snatch"},
+		Webhooks: []admissionregistration.MutatingWebhook{{
+			Name: "pods.kim-snatch.kyma-project.io",
+			ClientConfig: admissionregistration.WebhookClientConfig{
+				Service: &admissionregistration.ServiceReference{
+					Namespace: testNamespace,
+					Name:      "kim-snatch-webhook-service",
+				},
+				CABundle: caBundle,
+			},
+		}},
+	}
+	reader := fake.NewClientBuilder().WithObjects(webhookConfig, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch-webhook-service"},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{
+			Port:       443,
+			TargetPort: intstr.FromInt32(9443),
+		}}},
+	}).Build()
+
+	unreachable := false
+	check := &controller.WebhookRegistrationCheck{
+		Reader:           reader,
+		Config:           func() config.Config { return config.Config{WebhookConfigName: "kim-snatch"} },
+		ServiceName:      "kim-snatch-webhook-service",
+		ServiceNamespace: testNamespace,
+		WebhookPort:      9443,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return served, nil
+		},
+		APIServerUnreachable: func() bool { return unreachable },
+	}
+	check.Check(t.Context())
+	require.NoError(t, check.ReadyCheck(nil))
+
+	// the reads fail while the API server is unreachable
+	require.NoError(t, reader.Delete(t.Context(), webhookConfig))
+	unreachable = true
+	check.Check(t.Context())
+	assert.NoError(t, check.ReadyCheck(nil), "the last result is kept")
+
+	unreachable = false
+	check.Check(t.Context())
+	assert.ErrorContains(t, check.ReadyCheck(nil), "unable to get mutating webhook configuration")
+}
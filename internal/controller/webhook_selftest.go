@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// selfTestRetryInterval is the interval the self-test is repeated in until it succeeded
+	selfTestRetryInterval = 5 * time.Second
+	// selfTestTimeout is the timeout of the admission review sent to the own webhook server
+	selfTestTimeout = 10 * time.Second
+)
+
+var errSelfTestPending = errors.New("webhook self-test has not succeeded yet")
+
+// WebhookSelfTest periodically sends a dry-run admission review to the own
+// webhook server the way the API server does: for the service, the port, and
+// the path of the MutatingWebhookConfiguration, verified with its caBundle. The
+// review is sent to the local port the service forwards to instead of the
+// service, an unready replica doesn't receive traffic of the service. A broken
+// wiring of the service, the port, or the certificate is caught on startup
+// rather than on the first Pod creation.
+type WebhookSelfTest struct {
+	// Reader reads the MutatingWebhookConfiguration and its Service
+	Reader client.Reader
+	Config func() config.Config
+	// Namespace of the Pod of the admission review
+	Namespace string
+	// WebhookPort is the port the webhook server listens on
+	WebhookPort int
+	// Host the webhook server is reached at, defaults to localhost
+	Host    string
+	Metrics metrics.Metrics
+	// Interval between two self-tests, the self-test is repeated more often
+	// until it succeeded
+	Interval time.Duration
+	// APIServerUnreachable returns true while the API server can't be reached,
+	// the self-test is skipped then and keeps its last result, optional
+	APIServerUnreachable func() bool
+
+	mu sync.Mutex
+	// succeeded is set once a self-test succeeded, err is the error of the last one
+	succeeded bool
+	err       error
+}
+
+// Start runs the self-test until the context is cancelled.
+func (t *WebhookSelfTest) Start(ctx context.Context) error {
+	for {
+		t.Check(ctx)
+		interval := t.Interval
+		if t.ReadyCheck(nil) != nil {
+			interval = min(interval, selfTestRetryInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// NeedLeaderElection returns false, every replica tests its own webhook server.
+func (t *WebhookSelfTest) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadyCheck is a healthz.Checker failing until the self-test succeeded and
+// while the last self-test failed.
+func (t *WebhookSelfTest) ReadyCheck(*http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	if !t.succeeded {
+		return errSelfTestPending
+	}
+	return nil
+}
+
+// Check runs the self-test once.
+func (t *WebhookSelfTest) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("webhook-self-test")
+
+	if t.APIServerUnreachable != nil && t.APIServerUnreachable() {
+		return
+	}
+	err := t.test(ctx)
+	if t.Metrics != nil {
+		t.Metrics.SetWebhookSelfTest(err == nil)
+	}
+
+	t.mu.Lock()
+	recovered := err == nil && (!t.succeeded || t.err != nil)
+	t.err = nil
+	if err != nil {
+		t.err = fmt.Errorf("webhook self-test failed: %w", err)
+	} else {
+		t.succeeded = true
+	}
+	t.mu.Unlock()
+
+	if err != nil {
+		logger.Error(err, "webhook self-test failed")
+	} else if recovered {
+		logger.Info("webhook self-test succeeded")
+	}
+}
+
+func (t *WebhookSelfTest) test(ctx context.Context) error {
+	webhookConfigName := t.Config().WebhookConfigName
+	var webhookConfig admissionregistration.MutatingWebhookConfiguration
+	if err := t.Reader.Get(ctx, client.ObjectKey{Name: webhookConfigName}, &webhookConfig); err != nil {
+		return fmt.Errorf("unable to get mutating webhook configuration %s: %w", webhookConfigName, err)
+	}
+	var clientConfig *admissionregistration.WebhookClientConfig
+	for i := range webhookConfig.Webhooks {
+		if webhookConfig.Webhooks[i].ClientConfig.Service != nil {
+			clientConfig = &webhookConfig.Webhooks[i].ClientConfig
+			break
+		}
+	}
+	if clientConfig == nil {
+		return fmt.Errorf("mutating webhook configuration %s has no webhook served by a service", webhookConfigName)
+	}
+	ref := clientConfig.Service
+	if err := verifyServicePort(ctx, t.Reader, ref, t.WebhookPort); err != nil {
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(clientConfig.CABundle) {
+		return fmt.Errorf("mutating webhook configuration %s has no valid caBundle", webhookConfigName)
+	}
+	httpClient := &http.Client{
+		Timeout: selfTestTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:    roots,
+			ServerName: fmt.Sprintf("%s.%s.svc", ref.Name, ref.Namespace),
+			MinVersion: tls.VersionTLS12,
+		}},
+	}
+	defer httpClient.CloseIdleConnections()
+	return t.review(ctx, httpClient, ptr.Deref(ref.Path, "/"))
+}
+
+// review sends a dry-run admission review of a Pod and expects it allowed.
+func (t *WebhookSelfTest) review(ctx context.Context, httpClient *http.Client, path string) error {
+	pod, err := json.Marshal(&corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: t.Namespace, Name: "kim-snatch-self-test"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  "self-test",
+			Image: "kim-snatch-self-test",
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	request := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       uuid.NewUUID(),
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Namespace: t.Namespace,
+			Name:      "kim-snatch-self-test",
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: pod},
+			DryRun:    ptr.To(true),
+		},
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	host := t.Host
+	if host == "" {
+		host = "localhost"
+	}
+	url := "https://" + net.JoinHostPort(host, strconv.Itoa(t.WebhookPort)) + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send admission review: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("admission review returned status %d", resp.StatusCode)
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+		return fmt.Errorf("unable to decode admission review: %w", err)
+	}
+	switch {
+	case review.Response == nil || review.Response.UID != request.Request.UID:
+		return errors.New("admission review returned no response for the request")
+	case !review.Response.Allowed:
+		var message string
+		if review.Response.Result != nil {
+			message = review.Response.Result.Message
+		}
+		return fmt.Errorf("admission review denied: %s", message)
+	}
+	return nil
+}
+
+// verifyServicePort returns an error if the service of the reference doesn't
+// forward the port of the reference to the port of the webhook server.
+func verifyServicePort(ctx context.Context, reader client.Reader, ref *admissionregistration.ServiceReference,
+	webhookPort int) error {
+	var service corev1.Service
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, &service); err != nil {
+		return fmt.Errorf("unable to get webhook service %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	port := ptr.Deref(ref.Port, 443)
+	var servicePort *corev1.ServicePort
+	for i := range service.Spec.Ports {
+		if service.Spec.Ports[i].Port == port {
+			servicePort = &service.Spec.Ports[i]
+		}
+	}
+	if servicePort == nil {
+		return fmt.Errorf("webhook service %s/%s has no port %d", ref.Namespace, ref.Name, port)
+	}
+	targetPort := servicePort.TargetPort
+	if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+		targetPort = intstr.FromInt32(servicePort.Port)
+	}
+	// a named port is resolved by the container ports and not verified
+	if targetPort.Type == intstr.Int && int(targetPort.IntVal) != webhookPort {
+		return fmt.Errorf("webhook service %s/%s forwards port %d to %d, the webhook server listens on %d",
+			ref.Namespace, ref.Name, port, targetPort.IntVal, webhookPort)
+	}
+	return nil
+}
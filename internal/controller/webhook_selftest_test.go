@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// testWebhookServer serves admission reviews with a certificate of the webhook
+// service, it returns the server and the CA bundle.
+func testWebhookServer(t *testing.T, allowed bool) (*httptest.Server, []byte) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, caKey.Public(), caKey)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     []string{"kim-snatch-webhook-service.kyma-system.svc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leaf, ca, key.Public(), caKey)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mutate--v1-pod" {
+			http.NotFound(w, r)
+			return
+		}
+		var review admissionv1.AdmissionReview
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+		assert.True(t, *review.Request.DryRun)
+		review.Response = &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: allowed}
+		review.Request = nil
+		require.NoError(t, json.NewEncoder(w).Encode(&review))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{leafDER}, PrivateKey: key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+}
+
+func Test_WebhookSelfTest(t *testing.T) {
+	for name, tc := range map[string]struct {
+		allowed    bool
+		caBundle   []byte
+		path       string
+		targetPort *intstr.IntOrString
+		err        string
+	}{
+		"succeeded":    {allowed: true, path: "/mutate--v1-pod"},
+		"denied":       {path: "/mutate--v1-pod", err: "admission review denied"},
+		"wrong path":   {allowed: true, path: "/mutate", err: "status 404"},
+		"wrong ca":     {allowed: true, path: "/mutate--v1-pod", caBundle: testCertificatePEM(t, 3, time.Hour), err: "certificate"},
+		"wrong target": {allowed: true, path: "/mutate--v1-pod", targetPort: ptr.To(intstr.FromInt32(8443)), err: "forwards port 443 to 8443"},
+		"named target": {allowed: true, path: "/mutate--v1-pod", targetPort: ptr.To(intstr.FromString("webhook-server"))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, caBundle := testWebhookServer(t, tc.allowed)
+			if tc.caBundle != nil {
+				caBundle = tc.caBundle
+			}
+			_, portValue, err := net.SplitHostPort(server.Listener.Addr().String())
+			require.NoError(t, err)
+			port, err := strconv.Atoi(portValue)
+			require.NoError(t, err)
+			targetPort := intstr.FromInt(port)
+			if tc.targetPort != nil {
+				targetPort = *tc.targetPort
+			}
+
+			c := fake.NewClientBuilder().WithObjects(
+				&admissionregistration.MutatingWebhookConfiguration{
+					ObjectMeta: metav1.ObjectMeta{Name: "kim-snatch"},
+					Webhooks: []admissionregistration.MutatingWebhook{{
+						Name: "pods.kim-snatch.kyma-project.io",
+						ClientConfig: admissionregistration.WebhookClientConfig{
+							Service: &admissionregistration.ServiceReference{
+								Namespace: testNamespace,
+								Name:      "kim-snatch-webhook-service",
+								Path:      ptr.To(tc.path),
+							},
+							CABundle: caBundle,
+						},
+					}},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "kim-snatch-webhook-service"},
+					Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{
+						Port:       443,
+						TargetPort: targetPort,
+					}}},
+				},
+			).Build()
+			mtr := &mocks.Metrics{}
+			mtr.On("SetWebhookSelfTest", tc.err == "").Once()
+
+			selfTest := &controller.WebhookSelfTest{
+				Reader:      c,
+				Config:      func() config.Config { return config.Config{WebhookConfigName: "kim-snatch"} },
+				Namespace:   testNamespace,
+				WebhookPort: port,
+				Host:        "127.0.0.1",
+				Metrics:     mtr,
+			}
+			require.ErrorContains(t, selfTest.ReadyCheck(nil), "not succeeded yet")
+			selfTest.Check(t.Context())
+			mtr.AssertExpectations(t)
+			if tc.err == "" {
+				assert.NoError(t, selfTest.ReadyCheck(nil))
+				return
+			}
+			assert.ErrorContains(t, selfTest.ReadyCheck(nil), tc.err)
+		})
+	}
+}
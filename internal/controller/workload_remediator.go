@@ -0,0 +1,474 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/health"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/rules"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// remediationRequest is the single request all workload changes are mapped to,
+// the remediation covers all workloads
+var remediationRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "workloads"}}
+
+const (
+	EventReasonWorkloadsRemediated  = "WorkloadsRemediated"
+	EventReasonAffinityReapplied    = "AffinityReapplied"
+	EventReasonRemediationSuspended = "RemediationSuspended"
+
+	// AnnotationInjectedAffinity marks the pod templates the node affinity was
+	// added to, the value is the label key of the pool expressions
+	AnnotationInjectedAffinity = "kim-snatch.kyma-project.io/injected-affinity"
+	// LabelRemediated marks the workloads the node affinity was added to, the
+	// manager only watches the marked workloads
+	LabelRemediated = "kim-snatch.kyma-project.io/remediated"
+
+	// LabelNaturalRestart set to true on a workload or its pod template marks
+	// the workloads kim-snatch never restarts, e.g. databases
+	LabelNaturalRestart = "kim-snatch.kyma-project.io/natural-restart"
+
+	// fieldManager owns the fields kim-snatch applies to the workloads
+	fieldManager = "kim-snatch"
+)
+
+// WorkloadRemediator periodically adds the node affinity of the Kyma worker
+// pool to the pod templates of the Deployments and StatefulSets of the Kyma
+// namespaces, so that workloads created before kim-snatch was installed or
+// before their namespace was labeled converge as well.
+type WorkloadRemediator struct {
+	// Client lists and patches the workloads, the cache of the manager holds none of them
+	Client client.Client
+	// Namespaces lists the Kyma namespaces
+	Namespaces client.Reader
+	Config     func() config.Config
+	Gate       *featuregate.FeatureGate
+	// ResolvePlacement resolves the placement for the namespace of the workload, optional
+	ResolvePlacement webhookv1.PlacementResolver
+	// Rules returns the current exclusion and inclusion rules, optional
+	Rules func() *rules.Rules
+	// ActivePool returns the successor of the kyma worker pool if it was recreated, optional
+	ActivePool func() string
+	Metrics    metrics.Metrics
+	Recorder   record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two remediations
+	Interval time.Duration
+	// Backoff is the time a workload is skipped after its first failed
+	// remediation, it doubles with every further failure up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// FailureThreshold is the number of consecutive failures after which the
+	// remediation of a workload is suspended until the workload changes, the
+	// remediation is never suspended if zero
+	FailureThreshold int
+	// Subsystem tracks whether the last remediation ran without errors, optional
+	Subsystem *health.Subsystem
+	// Options tune the work queue of the reconciler
+	Options ReconcilerOptions
+
+	once    sync.Once
+	trigger chan struct{}
+	// failures are the failed remediations per workload
+	failures map[string]*remediationFailure
+}
+
+// remediationFailure tracks the consecutive failed remediations of a workload.
+type remediationFailure struct {
+	count int
+	// retryAt is the time before which the workload is skipped
+	retryAt time.Time
+	// generation of the workload when it failed last, the failures are
+	// forgotten once the workload changes
+	generation int64
+}
+
+// Start runs the remediator until the context is cancelled.
+func (r *WorkloadRemediator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-r.triggered():
+		}
+	}
+}
+
+// Trigger starts a remediation without waiting for the interval, e.g. when the
+// Kyma worker pool was recreated, it is a pool.PoolChangeFunc.
+func (r *WorkloadRemediator) Trigger(_ context.Context, _, _ string) {
+	select {
+	case r.triggered() <- struct{}{}:
+	default:
+		// a remediation is already pending
+	}
+}
+
+func (r *WorkloadRemediator) triggered() chan struct{} {
+	r.once.Do(func() {
+		r.trigger = make(chan struct{}, 1)
+	})
+	return r.trigger
+}
+
+// SetupWithManager triggers a remediation when a remediated workload changes,
+// so that node affinity removed by another manager, e.g. by a Helm upgrade, is
+// applied again right away. The cache of the manager is expected to hold the
+// workloads with LabelRemediated only.
+func (r *WorkloadRemediator) SetupWithManager(mgr ctrl.Manager) error {
+	trigger := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{remediationRequest}
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("workload-drift").
+		WithOptions(r.Options.controllerOptions()).
+		Watches(&appsv1.Deployment{}, trigger, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&appsv1.StatefulSet{}, trigger, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+			r.Trigger(ctx, "", "")
+			return reconcile.Result{}, nil
+		}))
+}
+
+// Check remediates the workloads of all Kyma namespaces once and reports the
+// result to the Subsystem.
+func (r *WorkloadRemediator) Check(ctx context.Context) {
+	r.Subsystem.Report(r.Remediate(ctx))
+}
+
+// Remediate remediates the workloads of all Kyma namespaces once. The
+// remediation continues with the next workload on failure, the last error is
+// returned.
+func (r *WorkloadRemediator) Remediate(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("workload-remediator")
+	var failure error
+
+	cfg := r.Config()
+	if cfg.Cleanup {
+		// the node affinity is being removed by the WorkloadReverter
+		return nil
+	}
+
+	var namespaces metav1.PartialObjectMetadataList
+	namespaces.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	if err := r.Namespaces.List(ctx, &namespaces, client.MatchingLabels{
+		LabelKymaManagedBy: kymaManagedByValue,
+	}); err != nil {
+		logger.Error(err, "unable to list kyma namespaces")
+		return err
+	}
+
+	var remediated, reapplied []string
+	for _, namespace := range namespaces.Items {
+		if slices.Contains(cfg.OmittedNamespaces, namespace.Name) {
+			continue
+		}
+
+		placement, err := namespacePlacement(ctx, cfg, namespace.Name, r.ResolvePlacement, r.ActivePool)
+		if err != nil {
+			failure = err
+			logger.Error(err, "unable to resolve namespace placement, skipping namespace", "namespace", namespace.Name)
+			continue
+		}
+
+		var deployments appsv1.DeploymentList
+		if err := r.Client.List(ctx, &deployments, client.InNamespace(namespace.Name)); err != nil {
+			failure = err
+			logger.Error(err, "unable to list deployments", "namespace", namespace.Name)
+			continue
+		}
+		for i := range deployments.Items {
+			deployment := &deployments.Items[i]
+			// patching the pod template rolls out the deployment
+			if naturalRestartOnly(cfg, config.KindDeployment, deployment.Labels, deployment.Spec.Template.Labels) {
+				continue
+			}
+			key := workloadKey(config.KindDeployment, deployment)
+			if r.skipped(key, deployment.Generation) {
+				continue
+			}
+			patched, drifted, err := r.remediate(ctx, cfg, placement, deployment, &deployment.Spec.Template)
+			r.record(ctx, key, deployment.Generation, err)
+			if patched {
+				remediated = append(remediated, namespace.Name+"/"+deployment.Name)
+			}
+			if patched && drifted {
+				r.incReapplied(deployment.Namespace, config.KindDeployment, deployment.Name)
+				reapplied = append(reapplied, namespace.Name+"/"+deployment.Name)
+			}
+			if err != nil {
+				failure = err
+				logger.Error(err, "unable to remediate deployment", "namespace", namespace.Name, "name", deployment.Name)
+			}
+		}
+
+		var statefulSets appsv1.StatefulSetList
+		if err := r.Client.List(ctx, &statefulSets, client.InNamespace(namespace.Name)); err != nil {
+			failure = err
+			logger.Error(err, "unable to list stateful sets", "namespace", namespace.Name)
+			continue
+		}
+		for i := range statefulSets.Items {
+			statefulSet := &statefulSets.Items[i]
+			// the pods of OnDelete stateful sets are not replaced when the pod template changes
+			if statefulSet.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType &&
+				naturalRestartOnly(cfg, config.KindStatefulSet, statefulSet.Labels, statefulSet.Spec.Template.Labels) {
+				continue
+			}
+			key := workloadKey(config.KindStatefulSet, statefulSet)
+			if r.skipped(key, statefulSet.Generation) {
+				continue
+			}
+			patched, drifted, err := r.remediate(ctx, cfg, placement, statefulSet, &statefulSet.Spec.Template)
+			r.record(ctx, key, statefulSet.Generation, err)
+			if patched {
+				remediated = append(remediated, namespace.Name+"/"+statefulSet.Name)
+			}
+			if patched && drifted {
+				r.incReapplied(statefulSet.Namespace, config.KindStatefulSet, statefulSet.Name)
+				reapplied = append(reapplied, namespace.Name+"/"+statefulSet.Name)
+			}
+			if err != nil {
+				failure = err
+				logger.Error(err, "unable to remediate stateful set", "namespace", namespace.Name, "name", statefulSet.Name)
+			}
+		}
+	}
+
+	if len(reapplied) > 0 {
+		// another manager, e.g. a Helm upgrade or a GitOps sync, removed the node affinity
+		logger.Info("node affinity of remediated workloads removed by another manager, node affinity applied again",
+			"workloads", reapplied)
+		r.event(corev1.EventTypeWarning, EventReasonAffinityReapplied,
+			fmt.Sprintf("node affinity removed by another manager applied again to %d workloads: %s",
+				len(reapplied), reportedNames(reapplied)))
+	}
+	if len(remediated) == 0 {
+		return failure
+	}
+	logger.Info("workloads remediated", "pool", cfg.KymaWorkerPoolName, "workloads", remediated)
+	r.event(corev1.EventTypeNormal, EventReasonWorkloadsRemediated,
+		fmt.Sprintf("node affinity added to %d workloads: %s", len(remediated), reportedNames(remediated)))
+	return failure
+}
+
+// namespacePlacement returns the placement of the pods of the namespace like
+// the webhook resolves it, the excluded zones are transient and never set.
+func namespacePlacement(ctx context.Context, cfg config.Config, namespace string,
+	resolve webhookv1.PlacementResolver, activePool func() string) (webhookv1.Placement, error) {
+	placement := webhookv1.PlacementFromConfig(cfg)
+	if resolve != nil {
+		resolved, err := resolve(ctx, namespace, placement)
+		if err != nil {
+			return placement, err
+		}
+		placement = resolved
+	}
+
+	if placement.Pool == cfg.KymaWorkerPoolName && activePool != nil {
+		if active := activePool(); active != "" {
+			placement.Pool = active
+		}
+	}
+	return placement, nil
+}
+
+// remediate patches the pod template of the workload if it doesn't select the
+// pool of the placement. It returns whether the workload was patched, and
+// whether it drifted because the node affinity added before was removed.
+func (r *WorkloadRemediator) remediate(ctx context.Context, cfg config.Config, placement webhookv1.Placement,
+	obj client.Object, template *corev1.PodTemplateSpec) (bool, bool, error) {
+	if r.Rules != nil {
+		// the rules are evaluated on pods, the template is what the pods are created from
+		pod := &corev1.Pod{ObjectMeta: *template.ObjectMeta.DeepCopy(), Spec: template.Spec}
+		pod.Namespace = obj.GetNamespace()
+		matched, _, err := r.Rules().Match(pod)
+		if err != nil {
+			return false, false, fmt.Errorf("unable to evaluate rules: %w", err)
+		}
+		if !matched {
+			return false, false, nil
+		}
+	}
+
+	pools := webhookv1.SelectedPools(&template.Spec, placement)
+	if slices.Contains(pools, placement.Pool) {
+		return false, false, nil
+	}
+	// a changed configuration replaces the pools, a drifted workload selects none
+	_, annotated := template.Annotations[AnnotationInjectedAffinity]
+	drifted := len(pools) == 0 && (annotated || obj.GetLabels()[LabelRemediated] == "true")
+	// pools selected by the workload owner are only overridden with enforcement,
+	// the configured pool is replaced by its successor
+	foreign := slices.ContainsFunc(pools, func(pool string) bool { return pool != cfg.KymaWorkerPoolName })
+	if foreign && !r.Gate.Enabled(featuregate.Enforcement) {
+		return false, false, nil
+	}
+
+	webhookv1.RemovePools(&template.Spec, placement)
+	webhookv1.InjectNodeAffinity(&template.Spec, placement)
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[AnnotationInjectedAffinity] = cfg.PoolLabelKey
+
+	if err := applyNodeAffinity(ctx, r.Client, obj, template); err != nil {
+		return false, false, err
+	}
+	return true, drifted, nil
+}
+
+// naturalRestartOnly returns true if kim-snatch must not restart the workload of
+// the kind, the pods of such workloads only get the node affinity from the
+// webhook when they are recreated for other reasons.
+func naturalRestartOnly(cfg config.Config, kind string, labels ...map[string]string) bool {
+	if slices.Contains(cfg.NaturalRestartKinds, kind) {
+		return true
+	}
+	return slices.ContainsFunc(labels, func(labels map[string]string) bool {
+		return labels[LabelNaturalRestart] == "true"
+	})
+}
+
+// applyNodeAffinity applies the node affinity and the AnnotationInjectedAffinity
+// annotation of the pod template, and the LabelRemediated label of the annotated
+// workloads, with server-side apply. Only these fields are
+// applied, so the fields owned by other managers, e.g. Helm, are left untouched,
+// and the fields kim-snatch applied before and omits now are removed. The lists
+// of the node affinity are atomic, kim-snatch takes over the lists it applies.
+func applyNodeAffinity(ctx context.Context, c client.Client, obj client.Object, template *corev1.PodTemplateSpec) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+
+	applied := map[string]any{}
+	if labelKey, ok := template.Annotations[AnnotationInjectedAffinity]; ok {
+		applied["metadata"] = map[string]any{
+			"annotations": map[string]any{AnnotationInjectedAffinity: labelKey},
+		}
+	}
+	if template.Spec.Affinity != nil && template.Spec.Affinity.NodeAffinity != nil {
+		nodeAffinity, err := runtime.DefaultUnstructuredConverter.ToUnstructured(template.Spec.Affinity.NodeAffinity)
+		if err != nil {
+			return err
+		}
+		if len(nodeAffinity) > 0 {
+			applied["spec"] = map[string]any{
+				"affinity": map[string]any{"nodeAffinity": nodeAffinity},
+			}
+		}
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(obj.GetNamespace())
+	u.SetName(obj.GetName())
+	if _, ok := template.Annotations[AnnotationInjectedAffinity]; ok {
+		u.SetLabels(map[string]string{LabelRemediated: "true"})
+	}
+	// the patch is rejected if the workload changed since it was read
+	u.SetResourceVersion(obj.GetResourceVersion())
+	if err := unstructured.SetNestedMap(u.Object, applied, "spec", "template"); err != nil {
+		return err
+	}
+	return c.Apply(ctx, client.ApplyConfigurationFromUnstructured(u),
+		client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// workloadKey identifies the workload of the kind in the failures.
+func workloadKey(kind string, obj client.Object) string {
+	return obj.GetNamespace() + "/" + kind + "/" + obj.GetName()
+}
+
+// skipped returns true while the workload backs off after a failure, or while
+// its remediation is suspended and the workload didn't change since.
+func (r *WorkloadRemediator) skipped(key string, generation int64) bool {
+	failure, ok := r.failures[key]
+	if !ok {
+		return false
+	}
+	if failure.generation != generation {
+		// the owner changed the workload, e.g. fixed what the patch conflicted with
+		delete(r.failures, key)
+		return false
+	}
+	if r.FailureThreshold > 0 && failure.count >= r.FailureThreshold {
+		return true
+	}
+	return time.Now().Before(failure.retryAt)
+}
+
+// record tracks the result of the remediation of the workload, a failure
+// doesn't stall the remediation of the other workloads.
+func (r *WorkloadRemediator) record(ctx context.Context, key string, generation int64, err error) {
+	if err == nil {
+		delete(r.failures, key)
+		return
+	}
+
+	if r.failures == nil {
+		r.failures = map[string]*remediationFailure{}
+	}
+	failure, ok := r.failures[key]
+	if !ok || failure.generation != generation {
+		failure = &remediationFailure{generation: generation}
+		r.failures[key] = failure
+	}
+	failure.count++
+	backoff := r.Backoff << min(failure.count-1, 30)
+	if r.MaxBackoff > 0 && (backoff > r.MaxBackoff || backoff < 0) {
+		backoff = r.MaxBackoff
+	}
+	failure.retryAt = time.Now().Add(backoff)
+
+	if r.FailureThreshold > 0 && failure.count == r.FailureThreshold {
+		logf.FromContext(ctx).WithName("workload-remediator").Info(
+			"remediation of workload suspended until the workload changes", "workload", key, "failures", failure.count)
+		r.event(corev1.EventTypeWarning, EventReasonRemediationSuspended,
+			fmt.Sprintf("remediation of %s suspended after %d failures until the workload changes: %v",
+				key, failure.count, err))
+	}
+}
+
+func (r *WorkloadRemediator) incReapplied(namespace, kind, name string) {
+	if r.Metrics != nil {
+		r.Metrics.IncWorkloadReapplied(namespace, kind, name)
+	}
+}
+
+func (r *WorkloadRemediator) event(eventType, reason, message string) {
+	if r.Recorder != nil && r.EventTarget != nil {
+		r.Recorder.Event(r.EventTarget, eventType, reason, message)
+	}
+}
@@ -0,0 +1,267 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func testPoolAffinity(pool string) *corev1.Affinity {
+	return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+			Weight: 10,
+			Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      config.DefaultPoolLabelKey,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{pool},
+			}}},
+		}},
+	}}
+}
+
+func testDeployment(name string, affinity *corev1.Affinity) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Affinity: affinity},
+		}},
+	}
+}
+
+func testRemediator(t *testing.T, enforcement bool, objs ...client.Object) (*controller.WorkloadRemediator, client.Client, *record.FakeRecorder) {
+	t.Helper()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   testNamespace,
+		Labels: map[string]string{controller.LabelKymaManagedBy: "kyma"},
+	}}
+	c := fake.NewClientBuilder().WithObjects(append(objs, namespace)...).WithReturnManagedFields().Build()
+
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = "cpu-worker-0"
+
+	recorder := record.NewFakeRecorder(10)
+	return &controller.WorkloadRemediator{
+		Client:     c,
+		Namespaces: c,
+		Config:     func() config.Config { return cfg },
+		Gate: featuregate.New(map[featuregate.Feature]featuregate.FeatureSpec{
+			featuregate.Enforcement: {Default: enforcement, Stage: featuregate.Alpha},
+		}),
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}, c, recorder
+}
+
+func testTemplatePools(t *testing.T, c client.Client, name string) []string {
+	t.Helper()
+
+	var deployment appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: testNamespace, Name: name}, &deployment))
+	if deployment.Spec.Template.Spec.Affinity == nil {
+		return nil
+	}
+
+	var pools []string
+	for _, term := range deployment.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		for _, expression := range term.Preference.MatchExpressions {
+			if expression.Key == config.DefaultPoolLabelKey {
+				pools = append(pools, expression.Values...)
+			}
+		}
+	}
+	return pools
+}
+
+func Test_WorkloadRemediator(t *testing.T) {
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "database"}}
+	r, c, recorder := testRemediator(t, false,
+		testDeployment("unplaced", nil),
+		testDeployment("placed", testPoolAffinity("cpu-worker-0")),
+		testDeployment("foreign", testPoolAffinity("gpu-worker")),
+		statefulSet,
+	)
+
+	r.Check(context.Background())
+
+	assert.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "unplaced"))
+	assert.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "placed"))
+	// the pool selected by the workload owner is kept without enforcement
+	assert.Equal(t, []string{"gpu-worker"}, testTemplatePools(t, c, "foreign"))
+
+	var patched appsv1.StatefulSet
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(statefulSet), &patched))
+	require.NotNil(t, patched.Spec.Template.Spec.Affinity)
+	assert.Equal(t, config.DefaultPoolLabelKey, patched.Spec.Template.Annotations[controller.AnnotationInjectedAffinity])
+
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, controller.EventReasonWorkloadsRemediated)
+	assert.Contains(t, event, "2 workloads")
+
+	// the remediated workloads are not patched again
+	r.Check(context.Background())
+	assert.Empty(t, recorder.Events)
+}
+
+func Test_WorkloadRemediator_enforcement(t *testing.T) {
+	r, c, _ := testRemediator(t, true, testDeployment("foreign", testPoolAffinity("gpu-worker")))
+
+	r.Check(context.Background())
+
+	assert.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "foreign"))
+}
+
+func Test_WorkloadRemediator_successor(t *testing.T) {
+	r, c, _ := testRemediator(t, false, testDeployment("placed", testPoolAffinity("cpu-worker-0")))
+	r.ActivePool = func() string { return "cpu-worker-1" }
+
+	r.Check(context.Background())
+
+	// the configured pool is replaced by its successor without enforcement
+	assert.Equal(t, []string{"cpu-worker-1"}, testTemplatePools(t, c, "placed"))
+}
+
+func Test_WorkloadRemediator_field_manager(t *testing.T) {
+	deployment := testDeployment("unplaced", nil)
+	deployment.Spec.Template.Annotations = map[string]string{"helm.sh/chart": "api-1.0.0"}
+	r, c, _ := testRemediator(t, false, deployment)
+
+	r.Check(context.Background())
+
+	// only the node affinity and the annotation of kim-snatch are applied
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), deployment))
+	assert.Equal(t, map[string]string{
+		"helm.sh/chart":                       "api-1.0.0",
+		controller.AnnotationInjectedAffinity: config.DefaultPoolLabelKey,
+	}, deployment.Spec.Template.Annotations)
+
+	var managers []string
+	for _, entry := range deployment.ManagedFields {
+		if entry.Operation == metav1.ManagedFieldsOperationApply {
+			managers = append(managers, entry.Manager)
+		}
+	}
+	assert.Equal(t, []string{"kim-snatch"}, managers)
+}
+
+func Test_WorkloadRemediator_natural_restart(t *testing.T) {
+	labeled := testDeployment("nats", nil)
+	labeled.Spec.Template.Labels = map[string]string{controller.LabelNaturalRestart: "true"}
+	rolling := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "redis"}}
+	onDelete := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "database"},
+		Spec: appsv1.StatefulSetSpec{UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.OnDeleteStatefulSetStrategyType,
+		}},
+	}
+	r, c, _ := testRemediator(t, false, labeled, rolling, onDelete)
+	cfg := r.Config()
+	cfg.NaturalRestartKinds = []string{config.KindStatefulSet}
+	r.Config = func() config.Config { return cfg }
+
+	r.Check(context.Background())
+
+	// patching the templates would restart the pods
+	assert.Empty(t, testTemplatePools(t, c, "nats"))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(rolling), rolling))
+	assert.Nil(t, rolling.Spec.Template.Spec.Affinity)
+	// the pods of OnDelete stateful sets get the node affinity when they are deleted
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(onDelete), onDelete))
+	assert.NotNil(t, onDelete.Spec.Template.Spec.Affinity)
+}
+
+func Test_WorkloadRemediator_drift(t *testing.T) {
+	r, c, recorder := testRemediator(t, false, testDeployment("api", nil), testDeployment("other", nil))
+	mtr := mocks.NewMetrics(t)
+	mtr.On("IncWorkloadReapplied", testNamespace, config.KindDeployment, "api").Once()
+	r.Metrics = mtr
+
+	r.Check(context.Background())
+	<-recorder.Events
+
+	var deployment appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: testNamespace, Name: "api"}, &deployment))
+	assert.Equal(t, "true", deployment.Labels[controller.LabelRemediated])
+
+	// a helm upgrade replaces the pod template
+	deployment.Spec.Template = corev1.PodTemplateSpec{}
+	require.NoError(t, c.Update(context.Background(), &deployment))
+
+	r.Check(context.Background())
+	assert.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "api"))
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonAffinityReapplied)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonWorkloadsRemediated)
+}
+
+func Test_WorkloadRemediator_failure_isolation(t *testing.T) {
+	failing := testDeployment("failing", nil)
+	r, c, recorder := testRemediator(t, false, failing, testDeployment("healthy", nil))
+	r.FailureThreshold = 2
+	attempts := 0
+	r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			if u, ok := obj.(interface{ GetName() string }); ok && u.GetName() == "failing" {
+				attempts++
+				return apierrors.NewForbidden(appsv1.Resource("deployments"), "failing", errors.New("denied by policy"))
+			}
+			return c.Apply(ctx, obj, opts...)
+		},
+	})
+
+	// the failing workload doesn't stop the others from being remediated
+	require.Error(t, r.Remediate(context.Background()))
+	assert.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "healthy"))
+	assert.Contains(t, <-recorder.Events, controller.EventReasonWorkloadsRemediated)
+
+	// the remediation is suspended after the second failure
+	r.Check(context.Background())
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, controller.EventReasonRemediationSuspended)
+	r.Check(context.Background())
+	assert.Equal(t, 2, attempts)
+
+	// the suspended workload is retried once it changed
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(failing), failing))
+	failing.Spec.Replicas = ptr.To(int32(2))
+	failing.Generation++
+	require.NoError(t, c.Update(context.Background(), failing))
+	r.Check(context.Background())
+	assert.Equal(t, 3, attempts)
+}
+
+func Test_WorkloadRemediator_backoff(t *testing.T) {
+	r, c, _ := testRemediator(t, false, testDeployment("api", nil))
+	r.Backoff = time.Hour
+	attempts := 0
+	r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Apply: func(context.Context, client.WithWatch, runtime.ApplyConfiguration, ...client.ApplyOption) error {
+			attempts++
+			return errors.New("webhook timeout")
+		},
+	})
+
+	// the failed workload is retried after the backoff only
+	r.Check(context.Background())
+	r.Check(context.Background())
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	EventReasonWorkloadsReverted = "WorkloadsReverted"
+)
+
+// WorkloadReverter removes the node affinity the WorkloadRemediator added to the
+// pod templates of the Deployments and StatefulSets while the cleanup is
+// configured, so that uninstalling kim-snatch leaves no placement behind.
+type WorkloadReverter struct {
+	// Client lists and patches the workloads, the cache of the manager holds none of them
+	Client   client.Client
+	Config   func() config.Config
+	Recorder record.EventRecorder
+
+	// EventTarget is the object the events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// Interval between two checks of the configuration
+	Interval time.Duration
+}
+
+// Start runs the reverter until the context is cancelled.
+func (r *WorkloadReverter) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, r.Check, r.Interval)
+	return nil
+}
+
+// Check reverts the workloads once if the cleanup is configured.
+func (r *WorkloadReverter) Check(ctx context.Context) {
+	logger := logf.FromContext(ctx).WithName("workload-reverter")
+	if !r.Config().Cleanup {
+		return
+	}
+
+	reverted, err := r.Revert(ctx)
+	if err != nil {
+		logger.Error(err, "unable to revert all workloads")
+	}
+	if len(reverted) == 0 {
+		return
+	}
+	logger.Info("workloads reverted", "workloads", reverted)
+	r.event(corev1.EventTypeNormal, EventReasonWorkloadsReverted,
+		fmt.Sprintf("node affinity removed from %d workloads: %s", len(reverted), reportedNames(reverted)))
+}
+
+// Revert removes the node affinity and the annotation kim-snatch added to the
+// pod templates of the workloads of all namespaces, the namespaces may have lost
+// their label since. It returns the reverted workloads, a failed workload
+// doesn't stop the others from being reverted.
+func (r *WorkloadReverter) Revert(ctx context.Context) ([]string, error) {
+	var deployments appsv1.DeploymentList
+	if err := r.Client.List(ctx, &deployments); err != nil {
+		return nil, fmt.Errorf("unable to list deployments: %w", err)
+	}
+	var statefulSets appsv1.StatefulSetList
+	if err := r.Client.List(ctx, &statefulSets); err != nil {
+		return nil, fmt.Errorf("unable to list stateful sets: %w", err)
+	}
+
+	var reverted []string
+	var errs []error
+	revert := func(obj client.Object, template *corev1.PodTemplateSpec) {
+		patched, err := r.revert(ctx, obj, template)
+		if patched {
+			reverted = append(reverted, obj.GetNamespace()+"/"+obj.GetName())
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to revert %s/%s: %w", obj.GetNamespace(), obj.GetName(), err))
+		}
+	}
+	for i := range deployments.Items {
+		revert(&deployments.Items[i], &deployments.Items[i].Spec.Template)
+	}
+	for i := range statefulSets.Items {
+		revert(&statefulSets.Items[i], &statefulSets.Items[i].Spec.Template)
+	}
+	return reverted, errors.Join(errs...)
+}
+
+// revert patches the pod template of the workload if kim-snatch added the node
+// affinity to it.
+func (r *WorkloadReverter) revert(ctx context.Context, obj client.Object, template *corev1.PodTemplateSpec) (bool, error) {
+	labelKey, ok := template.Annotations[AnnotationInjectedAffinity]
+	if !ok {
+		return false, nil
+	}
+
+	webhookv1.RemovePools(&template.Spec, webhookv1.Placement{LabelKey: labelKey})
+	// the affinity is removed entirely if kim-snatch created it
+	if affinity := template.Spec.Affinity; affinity != nil {
+		if nodeAffinity := affinity.NodeAffinity; nodeAffinity != nil &&
+			len(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution) == 0 &&
+			nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+			affinity.NodeAffinity = nil
+		}
+		if *affinity == (corev1.Affinity{}) {
+			template.Spec.Affinity = nil
+		}
+	}
+	delete(template.Annotations, AnnotationInjectedAffinity)
+
+	// the omitted fields are removed, they are owned by kim-snatch since it applied them
+	if err := applyNodeAffinity(ctx, r.Client, obj, template); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *WorkloadReverter) event(eventType, reason, message string) {
+	if r.Recorder != nil && r.EventTarget != nil {
+		r.Recorder.Event(r.EventTarget, eventType, reason, message)
+	}
+}
@@ -0,0 +1,79 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_WorkloadReverter(t *testing.T) {
+	// the zone term of the owner is kept
+	mixed := testDeployment("mixed", &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+			Weight: 1,
+			Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      corev1.LabelTopologyZone,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"zone-a"},
+			}}},
+		}},
+	}})
+	remediator, c, _ := testRemediator(t, false,
+		testDeployment("unplaced", nil),
+		mixed,
+		testDeployment("owned", testPoolAffinity("gpu-worker")),
+	)
+	remediator.Check(context.Background())
+	require.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "unplaced"))
+
+	recorder := record.NewFakeRecorder(10)
+	cleanup := false
+	r := &controller.WorkloadReverter{
+		Client: c,
+		Config: func() config.Config {
+			cfg := config.Default()
+			cfg.Cleanup = cleanup
+			return cfg
+		},
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: testNamespace, Name: "kim-snatch"},
+	}
+
+	// nothing is reverted without the cleanup
+	r.Check(context.Background())
+	assert.Equal(t, []string{"cpu-worker-0"}, testTemplatePools(t, c, "unplaced"))
+	assert.Empty(t, recorder.Events)
+
+	cleanup = true
+	r.Check(context.Background())
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, controller.EventReasonWorkloadsReverted)
+	assert.Contains(t, event, "2 workloads")
+
+	var deployment appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: testNamespace, Name: "unplaced"}, &deployment))
+	assert.Nil(t, deployment.Spec.Template.Spec.Affinity)
+	assert.NotContains(t, deployment.Spec.Template.Annotations, controller.AnnotationInjectedAffinity)
+	assert.NotContains(t, deployment.Labels, controller.LabelRemediated)
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(mixed), &deployment))
+	require.NotNil(t, deployment.Spec.Template.Spec.Affinity)
+	terms := deployment.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	assert.Equal(t, corev1.LabelTopologyZone, terms[0].Preference.MatchExpressions[0].Key)
+
+	assert.Equal(t, []string{"gpu-worker"}, testTemplatePools(t, c, "owned"))
+
+	// the reverted workloads are left untouched afterwards
+	r.Check(context.Background())
+	assert.Empty(t, recorder.Events)
+}
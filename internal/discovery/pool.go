@@ -0,0 +1,92 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationKymaPool on the shoot-info ConfigMap designates the Kyma worker pool
+	AnnotationKymaPool = "kim-snatch.kyma-project.io/kyma-pool"
+	// DefaultKymaPoolName is the name of the worker pool Kyma provisions by convention
+	DefaultKymaPoolName = "cpu-worker-0"
+)
+
+// ShootInfoKey is the ConfigMap Gardener maintains with the details of the shoot.
+var ShootInfoKey = client.ObjectKey{Namespace: "kube-system", Name: "shoot-info"}
+
+type poolSource struct {
+	reader     client.Reader
+	labelKey   string
+	convention string
+}
+
+// PoolSource discovers the Kyma worker pool. The candidates are the worker
+// pools of the nodes, the one designated by the shoot-info annotation wins,
+// otherwise the one named by convention, otherwise the only candidate.
+// No setting is provided if the Kyma worker pool can not be determined.
+func PoolSource(reader client.Reader, labelKey, convention string) config.Source {
+	return &poolSource{reader: reader, labelKey: labelKey, convention: convention}
+}
+
+func (s *poolSource) Name() string {
+	return "pool discovery"
+}
+
+func (s *poolSource) Load(ctx context.Context) (map[string]string, error) {
+	candidates, err := s.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	designated, err := s.designated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case designated != "":
+		if !slices.Contains(candidates, designated) {
+			return nil, fmt.Errorf("designated worker pool %q has no nodes", designated)
+		}
+		return map[string]string{config.KeyKymaWorkerPoolName: designated}, nil
+	case slices.Contains(candidates, s.convention):
+		return map[string]string{config.KeyKymaWorkerPoolName: s.convention}, nil
+	case len(candidates) == 1:
+		return map[string]string{config.KeyKymaWorkerPoolName: candidates[0]}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// candidates returns the sorted names of the worker pools of all nodes.
+func (s *poolSource) candidates(ctx context.Context) ([]string, error) {
+	var nodes corev1.NodeList
+	if err := s.reader.List(ctx, &nodes, client.HasLabels{s.labelKey}); err != nil {
+		return nil, fmt.Errorf("unable to list nodes: %w", err)
+	}
+
+	var result []string
+	for _, node := range nodes.Items {
+		result = append(result, node.Labels[s.labelKey])
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+func (s *poolSource) designated(ctx context.Context) (string, error) {
+	var shootInfo corev1.ConfigMap
+	if err := s.reader.Get(ctx, ShootInfoKey, &shootInfo); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to get shoot info: %w", err)
+	}
+	return shootInfo.Annotations[AnnotationKymaPool], nil
+}
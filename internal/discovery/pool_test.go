@@ -0,0 +1,77 @@
+package discovery_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testNode(name, pool string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{config.DefaultPoolLabelKey: pool},
+	}}
+}
+
+func testShootInfo(pool string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   discovery.ShootInfoKey.Namespace,
+		Name:        discovery.ShootInfoKey.Name,
+		Annotations: map[string]string{discovery.AnnotationKymaPool: pool},
+	}}
+}
+
+func Test_PoolSource(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		objs     []client.Object
+		expected map[string]string
+	}{
+		{
+			name:     "designated by annotation",
+			objs:     []client.Object{testNode("a", "pool-a"), testNode("b", "cpu-worker-0"), testShootInfo("pool-a")},
+			expected: map[string]string{config.KeyKymaWorkerPoolName: "pool-a"},
+		},
+		{
+			name:     "named by convention",
+			objs:     []client.Object{testNode("a", "pool-a"), testNode("b", "cpu-worker-0")},
+			expected: map[string]string{config.KeyKymaWorkerPoolName: "cpu-worker-0"},
+		},
+		{
+			name:     "single candidate",
+			objs:     []client.Object{testNode("a", "pool-a"), testNode("b", "pool-a")},
+			expected: map[string]string{config.KeyKymaWorkerPoolName: "pool-a"},
+		},
+		{
+			name: "ambiguous",
+			objs: []client.Object{testNode("a", "pool-a"), testNode("b", "pool-b")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := fake.NewClientBuilder().WithObjects(tc.objs...).Build()
+
+			values, err := discovery.PoolSource(reader, config.DefaultPoolLabelKey, discovery.DefaultKymaPoolName).
+				Load(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func Test_PoolSource_designated_pool_without_nodes(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(testNode("a", "pool-a"), testShootInfo("pool-b")).Build()
+
+	_, err := discovery.PoolSource(reader, config.DefaultPoolLabelKey, discovery.DefaultKymaPoolName).
+		Load(context.Background())
+
+	assert.ErrorContains(t, err, "has no nodes")
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RuntimeGroupVersionKind is the kind of the Runtime CR of the Kyma Infrastructure Manager.
+var RuntimeGroupVersionKind = schema.GroupVersionKind{
+	Group:   "infrastructuremanager.kyma-project.io",
+	Version: "v1",
+	Kind:    "Runtime",
+}
+
+type runtimeSource struct {
+	reader client.Reader
+	key    client.ObjectKey
+}
+
+// RuntimeSource provides the Kyma worker pool and its zones defined in the
+// Runtime CR, the first worker of the Runtime is the Kyma worker pool.
+// A missing Runtime CR provides no settings.
+func RuntimeSource(reader client.Reader, key client.ObjectKey) config.Source {
+	return &runtimeSource{reader: reader, key: key}
+}
+
+func (s *runtimeSource) Name() string {
+	return fmt.Sprintf("runtime %s", s.key)
+}
+
+func (s *runtimeSource) Load(ctx context.Context) (map[string]string, error) {
+	rt := &unstructured.Unstructured{}
+	rt.SetGroupVersionKind(RuntimeGroupVersionKind)
+	if err := s.reader.Get(ctx, s.key, rt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get runtime: %w", err)
+	}
+
+	workers, _, err := unstructured.NestedSlice(rt.Object, "spec", "shoot", "provider", "workers")
+	if err != nil {
+		return nil, fmt.Errorf("invalid workers of runtime %s: %w", s.key, err)
+	}
+	if len(workers) == 0 {
+		return nil, nil
+	}
+
+	worker, ok := workers[0].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid worker of runtime %s", s.key)
+	}
+
+	name, _, err := unstructured.NestedString(worker, "name")
+	if err != nil || name == "" {
+		return nil, fmt.Errorf("missing worker name in runtime %s", s.key)
+	}
+
+	result := map[string]string{config.KeyKymaWorkerPoolName: name}
+
+	zones, _, err := unstructured.NestedStringSlice(worker, "zones")
+	if err != nil {
+		return nil, fmt.Errorf("invalid zones of worker %s in runtime %s: %w", name, s.key, err)
+	}
+	if len(zones) > 0 {
+		result[config.KeyKymaWorkerPoolZones] = strings.Join(zones, ",")
+	}
+	return result, nil
+}
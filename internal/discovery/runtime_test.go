@@ -0,0 +1,54 @@
+package discovery_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testRuntimeKey = client.ObjectKey{Namespace: "kcp-system", Name: "test-runtime"}
+
+func testRuntime(workers ...any) *unstructured.Unstructured {
+	rt := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"shoot": map[string]any{
+				"provider": map[string]any{"workers": workers},
+			},
+		},
+	}}
+	rt.SetGroupVersionKind(discovery.RuntimeGroupVersionKind)
+	rt.SetNamespace(testRuntimeKey.Namespace)
+	rt.SetName(testRuntimeKey.Name)
+	return rt
+}
+
+func Test_RuntimeSource(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(testRuntime(
+		map[string]any{"name": "cpu-worker-0", "zones": []any{"eu-central-1a", "eu-central-1b"}},
+		map[string]any{"name": "additional"},
+	)).Build()
+
+	values, err := discovery.RuntimeSource(reader, testRuntimeKey).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		config.KeyKymaWorkerPoolName:  "cpu-worker-0",
+		config.KeyKymaWorkerPoolZones: "eu-central-1a,eu-central-1b",
+	}, values)
+}
+
+func Test_RuntimeSource_missing_runtime(t *testing.T) {
+	reader := fake.NewClientBuilder().Build()
+
+	values, err := discovery.RuntimeSource(reader, testRuntimeKey).Load(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
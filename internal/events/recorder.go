@@ -0,0 +1,149 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// DefaultInterval is the default interval identical events are deduplicated in
+	DefaultInterval = time.Minute
+	// DefaultQPS is the default rate of events recorded per second
+	DefaultQPS = 5
+	// DefaultBurst is the default number of events recorded at once
+	DefaultBurst = 50
+
+	// maxKeys bounds the number of keys a Deduplicator remembers
+	maxKeys = 1000
+)
+
+// Deduplicator allows a key at most once per interval, e.g. an event per object
+// and reason. Keys beyond the bound are not allowed until older keys expired.
+type Deduplicator struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	allowed map[string]time.Time
+}
+
+// NewDeduplicator returns a Deduplicator allowing a key once per interval.
+func NewDeduplicator(interval time.Duration) *Deduplicator {
+	return &Deduplicator{interval: interval, allowed: map[string]time.Time{}}
+}
+
+// Allow returns true if the key wasn't allowed within the interval before now.
+func (d *Deduplicator) Allow(key string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if last, ok := d.allowed[key]; ok && now.Sub(last) < d.interval {
+		return false
+	}
+	if len(d.allowed) >= maxKeys {
+		for k, last := range d.allowed {
+			if now.Sub(last) >= d.interval {
+				delete(d.allowed, k)
+			}
+		}
+		if len(d.allowed) >= maxKeys {
+			return false
+		}
+	}
+	d.allowed[key] = now
+	return true
+}
+
+// Options are the options of the Recorder.
+type Options struct {
+	// Interval identical events of an object are deduplicated in, events
+	// aren't deduplicated if not positive
+	Interval time.Duration
+	// QPS is the rate of events recorded per second, events aren't rate
+	// limited if not positive
+	QPS float32
+	// Burst is the number of events recorded at once before the rate applies
+	Burst int
+	// Metrics counts the suppressed events, optional
+	Metrics metrics.Metrics
+}
+
+// Recorder is the event recorder shared by all features emitting events. It
+// drops identical events of an object within the interval and limits the rate
+// of all events with a token bucket, so the events of a pod storm don't flood
+// the API server and etcd.
+type Recorder struct {
+	recorder record.EventRecorder
+	dedup    *Deduplicator
+	limiter  flowcontrol.RateLimiter
+	metrics  metrics.Metrics
+}
+
+var _ record.EventRecorder = &Recorder{}
+
+// NewRecorder returns a Recorder recording the events with the recorder.
+func NewRecorder(recorder record.EventRecorder, opts Options) *Recorder {
+	r := &Recorder{recorder: recorder, metrics: opts.Metrics}
+	if opts.Interval > 0 {
+		r.dedup = NewDeduplicator(opts.Interval)
+	}
+	if opts.QPS > 0 {
+		r.limiter = flowcontrol.NewTokenBucketRateLimiter(opts.QPS, max(opts.Burst, 1))
+	}
+	return r
+}
+
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.allow(object, eventtype, reason, message) {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) AnnotatedEventf(object runtime.Object, annotations map[string]string,
+	eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r.allow(object, eventtype, reason, message) {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+func (r *Recorder) allow(object runtime.Object, eventtype, reason, message string) bool {
+	if r.dedup != nil && !r.dedup.Allow(objectKey(object)+"/"+eventtype+"/"+reason+"/"+message, time.Now()) {
+		r.suppressed(metrics.EventSuppressedDuplicate)
+		return false
+	}
+	if r.limiter != nil && !r.limiter.TryAccept() {
+		r.suppressed(metrics.EventSuppressedRateLimited)
+		return false
+	}
+	return true
+}
+
+func (r *Recorder) suppressed(cause string) {
+	if r.metrics != nil {
+		r.metrics.IncEventsSuppressed(cause)
+	}
+}
+
+// objectKey identifies the object the event is recorded on.
+func objectKey(object runtime.Object) string {
+	if ref, ok := object.(*corev1.ObjectReference); ok {
+		return ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+	}
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return fmt.Sprintf("%T", object)
+	}
+	return fmt.Sprintf("%T/%s/%s", object, accessor.GetNamespace(), accessor.GetName())
+}
@@ -0,0 +1,64 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/events"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func recorded(recorder *record.FakeRecorder) []string {
+	close(recorder.Events)
+	var recorded []string
+	for event := range recorder.Events {
+		recorded = append(recorded, event)
+	}
+	return recorded
+}
+
+func Test_Recorder_deduplication(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("IncEventsSuppressed", metrics.EventSuppressedDuplicate).Twice()
+	fake := record.NewFakeRecorder(10)
+	recorder := events.NewRecorder(fake, events.Options{Interval: time.Minute, Metrics: mtr})
+
+	target := &corev1.ObjectReference{Kind: "Pod", Namespace: "test", Name: "a"}
+	recorder.Event(target, corev1.EventTypeWarning, "Test", "first")
+	recorder.Eventf(target, corev1.EventTypeWarning, "Test", "%s", "first")
+	// other messages and objects are recorded
+	recorder.Event(target, corev1.EventTypeWarning, "Test", "second")
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "b"}}
+	recorder.Event(pod, corev1.EventTypeWarning, "Test", "first")
+	recorder.Event(pod, corev1.EventTypeWarning, "Test", "first")
+
+	assert.Equal(t, []string{"Warning Test first", "Warning Test second", "Warning Test first"}, recorded(fake))
+}
+
+func Test_Recorder_rateLimit(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("IncEventsSuppressed", metrics.EventSuppressedRateLimited).Once()
+	fake := record.NewFakeRecorder(10)
+	recorder := events.NewRecorder(fake, events.Options{QPS: 0.001, Burst: 2, Metrics: mtr})
+
+	target := &corev1.ObjectReference{Kind: "Pod", Namespace: "test", Name: "a"}
+	for _, message := range []string{"a", "b", "c"} {
+		recorder.Event(target, corev1.EventTypeNormal, "Test", message)
+	}
+
+	assert.Equal(t, []string{"Normal Test a", "Normal Test b"}, recorded(fake))
+}
+
+func Test_Deduplicator(t *testing.T) {
+	dedup := events.NewDeduplicator(time.Minute)
+	now := time.Now()
+	assert.True(t, dedup.Allow("a", now))
+	assert.False(t, dedup.Allow("a", now.Add(30*time.Second)))
+	assert.True(t, dedup.Allow("b", now))
+	assert.True(t, dedup.Allow("a", now.Add(time.Minute)))
+}
@@ -0,0 +1,147 @@
+package featuregate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is the name of a feature that can be toggled with the feature gate.
+type Feature string
+
+// Stage describes the maturity of a feature.
+type Stage string
+
+const (
+	Alpha = Stage("ALPHA")
+	Beta  = Stage("BETA")
+	GA    = Stage("GA")
+)
+
+// FeatureSpec describes a known feature.
+type FeatureSpec struct {
+	// Default is the value of the feature if it was not set explicitly
+	Default bool
+	// Stage is the maturity of the feature
+	Stage Stage
+}
+
+// FeatureGate keeps track of the known features and their state. It implements
+// flag.Value so it can be bound directly to a command line flag.
+type FeatureGate struct {
+	mu      sync.RWMutex
+	known   map[Feature]FeatureSpec
+	enabled map[Feature]bool
+}
+
+// New creates a feature gate aware of the given features.
+func New(known map[Feature]FeatureSpec) *FeatureGate {
+	return &FeatureGate{
+		known:   maps.Clone(known),
+		enabled: map[Feature]bool{},
+	}
+}
+
+// Set parses a comma separated list of key=value pairs, e.g. "A=true,B=false"
+// and updates the state of the features accordingly.
+func (g *FeatureGate) Set(value string) error {
+	parsed := map[Feature]bool{}
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		k, v, found := strings.Cut(s, "=")
+		if !found {
+			return fmt.Errorf("missing bool value for feature %q", k)
+		}
+
+		enabled, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid value of %s=%s: %w", k, v, err)
+		}
+
+		parsed[Feature(strings.TrimSpace(k))] = enabled
+	}
+
+	return g.SetFromMap(parsed)
+}
+
+// SetFromMap updates the state of the given features. It fails if any of the
+// features is unknown, in which case no feature is updated.
+func (g *FeatureGate) SetFromMap(m map[Feature]bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for f := range m {
+		if _, ok := g.known[f]; !ok {
+			return fmt.Errorf("unrecognized feature gate: %s", f)
+		}
+	}
+
+	maps.Copy(g.enabled, m)
+	return nil
+}
+
+// String returns the explicitly set features in the same format accepted by Set.
+func (g *FeatureGate) String() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	pairs := make([]string, 0, len(g.enabled))
+	for f, enabled := range g.enabled {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", f, enabled))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Type implements pflag.Value.
+func (g *FeatureGate) Type() string {
+	return "mapStringBool"
+}
+
+// Enabled returns true if the feature is enabled. Unknown features are
+// always disabled.
+func (g *FeatureGate) Enabled(f Feature) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if enabled, ok := g.enabled[f]; ok {
+		return enabled
+	}
+	return g.known[f].Default
+}
+
+// States returns the state of all known features.
+func (g *FeatureGate) States() map[Feature]bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	states := make(map[Feature]bool, len(g.known))
+	for f, spec := range g.known {
+		states[f] = spec.Default
+		if enabled, ok := g.enabled[f]; ok {
+			states[f] = enabled
+		}
+	}
+	return states
+}
+
+// KnownFeatures returns a sorted description of the known features, suitable
+// for the usage message of the flag.
+func (g *FeatureGate) KnownFeatures() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	known := make([]string, 0, len(g.known))
+	for f, spec := range g.known {
+		known = append(known, fmt.Sprintf("%s=true|false (%s - default=%t)", f, spec.Stage, spec.Default))
+	}
+	slices.Sort(known)
+	return known
+}
@@ -0,0 +1,64 @@
+package featuregate_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAlpha featuregate.Feature = "TestAlpha"
+	testBeta  featuregate.Feature = "TestBeta"
+)
+
+func testGate() *featuregate.FeatureGate {
+	return featuregate.New(map[featuregate.Feature]featuregate.FeatureSpec{
+		testAlpha: {Default: false, Stage: featuregate.Alpha},
+		testBeta:  {Default: true, Stage: featuregate.Beta},
+	})
+}
+
+func Test_FeatureGate_defaults(t *testing.T) {
+	gate := testGate()
+
+	assert.False(t, gate.Enabled(testAlpha))
+	assert.True(t, gate.Enabled(testBeta))
+	assert.False(t, gate.Enabled("Unknown"))
+}
+
+func Test_FeatureGate_Set(t *testing.T) {
+	gate := testGate()
+
+	err := gate.Set("TestAlpha=true, TestBeta=false")
+
+	assert.NoError(t, err)
+	assert.True(t, gate.Enabled(testAlpha))
+	assert.False(t, gate.Enabled(testBeta))
+	assert.Equal(t, "TestAlpha=true,TestBeta=false", gate.String())
+}
+
+func Test_FeatureGate_Set_errors(t *testing.T) {
+	for _, value := range []string{
+		"TestAlpha",
+		"TestAlpha=maybe",
+		"TestAlpha=true,Unknown=true",
+	} {
+		t.Run(value, func(t *testing.T) {
+			gate := testGate()
+
+			assert.Error(t, gate.Set(value))
+			assert.False(t, gate.Enabled(testAlpha), "gate must not be partially updated")
+		})
+	}
+}
+
+func Test_FeatureGate_States(t *testing.T) {
+	gate := testGate()
+	assert.NoError(t, gate.Set("TestAlpha=true"))
+
+	assert.Equal(t, map[featuregate.Feature]bool{
+		testAlpha: true,
+		testBeta:  true,
+	}, gate.States())
+}
@@ -0,0 +1,24 @@
+package featuregate
+
+const (
+	// RequiredMode allows injecting the node affinity as
+	// requiredDuringSchedulingIgnoredDuringExecution instead of a preference.
+	RequiredMode Feature = "RequiredMode"
+
+	// Enforcement allows kim-snatch to override scheduling constraints
+	// (e.g. nodeSelector) set by the workload owner.
+	Enforcement Feature = "Enforcement"
+
+	// Descheduling allows kim-snatch to evict Kyma pods running outside of
+	// the kyma worker pool.
+	Descheduling Feature = "Descheduling"
+)
+
+var defaultFeatures = map[Feature]FeatureSpec{
+	RequiredMode: {Default: false, Stage: Alpha},
+	Enforcement:  {Default: false, Stage: Alpha},
+	Descheduling: {Default: false, Stage: Alpha},
+}
+
+// DefaultFeatureGate is the feature gate bound to the --feature-gates flag.
+var DefaultFeatureGate = New(defaultFeatures)
@@ -0,0 +1,120 @@
+package garden
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultTimeout is the timeout of the requests to the Garden cluster.
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnreachable is returned by the Client while the Garden cluster cannot be reached.
+var ErrUnreachable = errors.New("garden cluster unreachable")
+
+// Options configure the connection to the Garden cluster.
+type Options struct {
+	// Kubeconfig is the path to the kubeconfig of the Garden cluster
+	Kubeconfig string
+	// TokenFile is the path to a projected service account token, it replaces
+	// the credentials of the kubeconfig and is re-read when it is rotated
+	TokenFile string
+	// Timeout of every request, DefaultTimeout if not set
+	Timeout time.Duration
+}
+
+// RESTConfig builds the configuration of the connection to the Garden cluster.
+func RESTConfig(opts Options) (*rest.Config, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", opts.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TokenFile != "" {
+		restConfig.BearerToken = ""
+		restConfig.BearerTokenFile = opts.TokenFile
+		restConfig.Username = ""
+		restConfig.Password = ""
+		restConfig.CertFile, restConfig.CertData = "", nil
+		restConfig.KeyFile, restConfig.KeyData = "", nil
+		restConfig.ExecProvider = nil
+		restConfig.AuthProvider = nil
+	}
+
+	restConfig.Timeout = opts.Timeout
+	if restConfig.Timeout == 0 {
+		restConfig.Timeout = DefaultTimeout
+	}
+	return restConfig, nil
+}
+
+// Client is a read-only client of the Garden cluster tracking whether the
+// Garden cluster is reachable.
+type Client struct {
+	reader  client.Reader
+	metrics metrics.Metrics
+
+	reachable atomic.Bool
+}
+
+// NewClient creates the read-only client of the Garden cluster.
+func NewClient(opts Options, scheme *runtime.Scheme, mtr metrics.Metrics) (*Client, error) {
+	restConfig, err := RESTConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	return NewClientFor(reader, mtr), nil
+}
+
+// NewClientFor wraps the reader and tracks whether the Garden cluster is reachable.
+func NewClientFor(reader client.Reader, mtr metrics.Metrics) *Client {
+	c := &Client{reader: reader, metrics: mtr}
+	// the Garden cluster is assumed to be reachable until a request fails
+	c.reachable.Store(true)
+	return c
+}
+
+// Get reads the object from the Garden cluster.
+func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.observe(c.reader.Get(ctx, key, obj, opts...))
+}
+
+// List reads the objects from the Garden cluster.
+func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.observe(c.reader.List(ctx, list, opts...))
+}
+
+// Reachable reports whether the last request reached the Garden cluster.
+func (c *Client) Reachable() bool {
+	return c.reachable.Load()
+}
+
+// observe records whether the Garden cluster responded, every error returned
+// by the API server proves it is reachable.
+func (c *Client) observe(err error) error {
+	var status apierrors.APIStatus
+	reachable := err == nil || errors.As(err, &status)
+
+	c.reachable.Store(reachable)
+	if c.metrics != nil {
+		c.metrics.SetGardenReachable(reachable)
+	}
+
+	if !reachable {
+		return errors.Join(ErrUnreachable, err)
+	}
+	return err
+}
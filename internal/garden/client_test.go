@@ -0,0 +1,80 @@
+package garden_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: garden
+  cluster:
+    server: https://api.garden.example.com
+contexts:
+- name: garden
+  context:
+    cluster: garden
+    user: garden
+current-context: garden
+users:
+- name: garden
+  user:
+    token: static-token
+`
+
+func Test_Client_Reachable(t *testing.T) {
+	var getErr error
+	reader := fake.NewClientBuilder().
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+				return getErr
+			},
+		}).
+		Build()
+
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetGardenReachable", false).Once()
+	mtr.On("SetGardenReachable", true).Twice()
+	c := garden.NewClientFor(reader, mtr)
+	assert.True(t, c.Reachable())
+
+	getErr = errors.New("dial tcp: connection refused")
+	err := c.Get(context.Background(), client.ObjectKey{Name: "test"}, &corev1.ConfigMap{})
+	assert.ErrorIs(t, err, garden.ErrUnreachable)
+	assert.False(t, c.Reachable())
+
+	// errors of the API server prove the Garden cluster is reachable
+	getErr = apierrors.NewNotFound(corev1.Resource("configmaps"), "test")
+	err = c.Get(context.Background(), client.ObjectKey{Name: "test"}, &corev1.ConfigMap{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.NotErrorIs(t, err, garden.ErrUnreachable)
+	assert.True(t, c.Reachable())
+
+	getErr = nil
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "test"}, &corev1.ConfigMap{}))
+	assert.True(t, c.Reachable())
+}
+
+func Test_RESTConfig_TokenFile(t *testing.T) {
+	kubeconfig := t.TempDir() + "/kubeconfig"
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600))
+
+	restConfig, err := garden.RESTConfig(garden.Options{Kubeconfig: kubeconfig, TokenFile: "/var/run/secrets/garden/token"})
+	require.NoError(t, err)
+	assert.Empty(t, restConfig.BearerToken)
+	assert.Equal(t, "/var/run/secrets/garden/token", restConfig.BearerTokenFile)
+	assert.Equal(t, garden.DefaultTimeout, restConfig.Timeout)
+}
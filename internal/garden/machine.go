@@ -0,0 +1,28 @@
+package garden
+
+import (
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelMachineName is the label the machine-controller-manager sets on the
+// nodes to the name of their Machine.
+const LabelMachineName = "node.gardener.cloud/machine-name"
+
+// MachineOfPool returns true if the name of the Machine of the node shows it
+// was created for the given worker pool. The Machine names follow the pattern
+// <shoot namespace>-<pool>-z<zone index>-<hash>-<suffix>. The node name is used
+// if the node carries no machine name label, some providers name the nodes
+// after their Machines.
+func MachineOfPool(node metav1.Object, pool string) bool {
+	if pool == "" {
+		return false
+	}
+
+	machine := node.GetLabels()[LabelMachineName]
+	if machine == "" {
+		machine = node.GetName()
+	}
+	return regexp.MustCompile(`-` + regexp.QuoteMeta(pool) + `-z\d+-`).MatchString(machine)
+}
@@ -0,0 +1,23 @@
+package garden_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/garden"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MachineOfPool(t *testing.T) {
+	byLabel := &metav1.ObjectMeta{
+		Name:   "ip-10-250-0-1",
+		Labels: map[string]string{garden.LabelMachineName: "shoot--kyma--test-cpu-worker-0-z1-5d8b7-abcde"},
+	}
+	byName := &metav1.ObjectMeta{Name: "shoot--kyma--test-cpu-worker-0-z3-5d8b7-abcde"}
+
+	assert.True(t, garden.MachineOfPool(byLabel, "cpu-worker-0"))
+	assert.True(t, garden.MachineOfPool(byName, "cpu-worker-0"))
+	assert.False(t, garden.MachineOfPool(byLabel, "worker-0-z1"))
+	assert.False(t, garden.MachineOfPool(byLabel, "cpu-worker"))
+	assert.False(t, garden.MachineOfPool(byLabel, ""))
+}
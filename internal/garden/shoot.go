@@ -0,0 +1,51 @@
+package garden
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ShootGroupVersionKind is the kind of the Gardener Shoot.
+var ShootGroupVersionKind = schema.GroupVersionKind{
+	Group:   "core.gardener.cloud",
+	Version: "v1beta1",
+	Kind:    "Shoot",
+}
+
+// ShootWorkers describes the worker pools defined in the Shoot spec.
+type ShootWorkers struct {
+	// Pools are the names of the workers in spec.provider.workers
+	Pools []string
+	// Deleting is true if the Shoot is being deleted
+	Deleting bool
+}
+
+// GetShootWorkers reads the worker pools of the Shoot from the Garden cluster.
+func GetShootWorkers(ctx context.Context, reader client.Reader, key client.ObjectKey) (ShootWorkers, error) {
+	shoot := &unstructured.Unstructured{}
+	shoot.SetGroupVersionKind(ShootGroupVersionKind)
+	if err := reader.Get(ctx, key, shoot); err != nil {
+		return ShootWorkers{}, fmt.Errorf("unable to get shoot %s: %w", key, err)
+	}
+
+	workers, _, err := unstructured.NestedSlice(shoot.Object, "spec", "provider", "workers")
+	if err != nil {
+		return ShootWorkers{}, fmt.Errorf("invalid workers of shoot %s: %w", key, err)
+	}
+
+	result := ShootWorkers{Deleting: shoot.GetDeletionTimestamp() != nil}
+	for _, worker := range workers {
+		w, ok := worker.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, ok := w["name"].(string); ok {
+			result.Pools = append(result.Pools, name)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// Names of the caches the admission decisions are made from.
+const (
+	CacheNamespaces = "namespaces"
+	CacheNodes      = "nodes"
+	CacheConfig     = "config"
+)
+
+// CachesSyncedChecker returns a healthz.Checker failing until all caches
+// returned true, the error names the caches that haven't synced yet. It is a
+// readiness check, the webhook isn't called before the decisions can be made
+// with warm caches. Once synced, the check passes for good, so a relist doesn't
+// take the replica out of the webhook Service.
+func CachesSyncedChecker(caches map[string]func() bool) healthz.Checker {
+	names := slices.Sorted(maps.Keys(caches))
+	var synced atomic.Bool
+	return func(*http.Request) error {
+		if synced.Load() {
+			return nil
+		}
+		var unsynced []string
+		for _, name := range names {
+			if !caches[name]() {
+				unsynced = append(unsynced, name)
+			}
+		}
+		if len(unsynced) > 0 {
+			return fmt.Errorf("caches not synced: %s", strings.Join(unsynced, ", "))
+		}
+		synced.Store(true)
+		return nil
+	}
+}
@@ -0,0 +1,166 @@
+package health
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Names of the subsystems.
+const (
+	SubsystemCertificate = "certificate"
+	SubsystemNodeCache   = "node-cache"
+	SubsystemConfig      = "config"
+	SubsystemRemediation = "remediation"
+)
+
+// Status is the state of a subsystem.
+type Status struct {
+	// Healthy is true if the last run of the subsystem succeeded, it is false
+	// until the subsystem ran
+	Healthy bool `json:"healthy"`
+	// LastError is the error of the last failed run
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime is the time the subsystem failed last
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+	// LastSuccessTime is the time the subsystem succeeded last
+	LastSuccessTime *time.Time `json:"lastSuccessTime,omitempty"`
+	// Standby is true while the subsystem only runs on the leader and the
+	// replica doesn't lead, a subsystem on standby is healthy
+	Standby bool `json:"standby,omitempty"`
+}
+
+// Report is the state of all subsystems.
+type Report struct {
+	// Healthy is true if all subsystems are healthy
+	Healthy    bool              `json:"healthy"`
+	Subsystems map[string]Status `json:"subsystems"`
+}
+
+// Subsystem tracks the state of a subsystem reported by its runs. A nil
+// Subsystem ignores the reports, so reporting is optional for the callers.
+type Subsystem struct {
+	mu      sync.Mutex
+	status  Status
+	elected <-chan struct{}
+}
+
+// LeaderElected marks the subsystem to run on the leader only, it is on standby
+// until elected is closed.
+func (s *Subsystem) LeaderElected(elected <-chan struct{}) *Subsystem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.elected = elected
+	return s
+}
+
+// Report records the result of a run of the subsystem.
+func (s *Subsystem) Report(err error) {
+	if s == nil {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Healthy = err == nil
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.status.LastErrorTime = &now
+		return
+	}
+	s.status.LastSuccessTime = &now
+}
+
+// Status returns the state of the subsystem.
+func (s *Subsystem) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.elected != nil {
+		select {
+		case <-s.elected:
+		default:
+			return Status{Healthy: true, Standby: true}
+		}
+	}
+	return s.status
+}
+
+// Registry holds the subsystems, the zero value is empty.
+type Registry struct {
+	mu         sync.Mutex
+	subsystems map[string]*Subsystem
+	checks     map[string]func() error
+}
+
+// Subsystem returns the subsystem of the name, it is registered on first use.
+func (r *Registry) Subsystem(name string) *Subsystem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.subsystems[name]; ok {
+		return s
+	}
+	if r.subsystems == nil {
+		r.subsystems = map[string]*Subsystem{}
+	}
+	s := &Subsystem{}
+	r.subsystems[name] = s
+	return s
+}
+
+// AddCheck registers a subsystem whose state is checked whenever the report is
+// built, e.g. of the serving certificate.
+func (r *Registry) AddCheck(name string, check func() error) {
+	r.Subsystem(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.checks == nil {
+		r.checks = map[string]func() error{}
+	}
+	r.checks[name] = check
+}
+
+// Report runs the checks and returns the state of all subsystems.
+func (r *Registry) Report() Report {
+	r.mu.Lock()
+	subsystems := maps.Clone(r.subsystems)
+	checks := maps.Clone(r.checks)
+	r.mu.Unlock()
+
+	report := Report{Healthy: true, Subsystems: make(map[string]Status, len(subsystems))}
+	for name, s := range subsystems {
+		if check, ok := checks[name]; ok {
+			s.Report(check())
+		}
+		status := s.Status()
+		report.Subsystems[name] = status
+		report.Healthy = report.Healthy && status.Healthy
+	}
+	return report
+}
+
+// Handler serves the report as JSON, the status is 503 Service Unavailable
+// while a subsystem is unhealthy.
+func Handler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			rw.Header().Set("Allow", http.MethodGet)
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		report := r.Report()
+		data, err := json.Marshal(report)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = rw.Write(data)
+	})
+}
@@ -0,0 +1,95 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, registry *health.Registry) (int, health.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	health.Handler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz/detailed", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var report health.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func Test_Handler(t *testing.T) {
+	var registry health.Registry
+	config := registry.Subsystem(health.SubsystemConfig)
+	var certErr error
+	registry.AddCheck(health.SubsystemCertificate, func() error { return certErr })
+
+	// a subsystem is unhealthy until it ran
+	code, report := serve(t, &registry)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, report.Healthy)
+	assert.True(t, report.Subsystems[health.SubsystemCertificate].Healthy)
+	assert.Equal(t, health.Status{}, report.Subsystems[health.SubsystemConfig])
+
+	config.Report(nil)
+	code, report = serve(t, &registry)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, report.Healthy)
+	assert.NotNil(t, report.Subsystems[health.SubsystemConfig].LastSuccessTime)
+
+	// the last success is kept next to the error
+	config.Report(errors.New("unable to reload configuration"))
+	certErr = errors.New("serving certificate expired")
+	code, report = serve(t, &registry)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	status := report.Subsystems[health.SubsystemConfig]
+	assert.False(t, status.Healthy)
+	assert.Equal(t, "unable to reload configuration", status.LastError)
+	assert.NotNil(t, status.LastErrorTime)
+	assert.NotNil(t, status.LastSuccessTime)
+	assert.Equal(t, "serving certificate expired", report.Subsystems[health.SubsystemCertificate].LastError)
+}
+
+func Test_Subsystem_nil(t *testing.T) {
+	var subsystem *health.Subsystem
+	assert.NotPanics(t, func() { subsystem.Report(errors.New("test")) })
+}
+
+func Test_Subsystem_leader_elected(t *testing.T) {
+	var registry health.Registry
+	elected := make(chan struct{})
+	registry.Subsystem(health.SubsystemRemediation).LeaderElected(elected)
+
+	// the subsystem is on standby until the replica leads
+	code, report := serve(t, &registry)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.Status{Healthy: true, Standby: true}, report.Subsystems[health.SubsystemRemediation])
+
+	close(elected)
+	code, report = serve(t, &registry)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, report.Subsystems[health.SubsystemRemediation].Standby)
+}
+
+func Test_CachesSyncedChecker(t *testing.T) {
+	nodes, config := false, false
+	check := health.CachesSyncedChecker(map[string]func() bool{
+		health.CacheNamespaces: func() bool { return true },
+		health.CacheNodes:      func() bool { return nodes },
+		health.CacheConfig:     func() bool { return config },
+	})
+
+	assert.EqualError(t, check(nil), "caches not synced: config, nodes")
+	config = true
+	assert.EqualError(t, check(nil), "caches not synced: nodes")
+	nodes = true
+	assert.NoError(t, check(nil))
+
+	// the replica stays ready once the caches synced
+	nodes = false
+	assert.NoError(t, check(nil))
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"io"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// AlertOptions are the thresholds of the default alerts.
+type AlertOptions struct {
+	// ErrorRatio is the ratio of failed admission requests of pods alerted on
+	ErrorRatio float64
+	// CertificateExpiry is the remaining validity of a certificate of the webhook
+	// server alerted on
+	CertificateExpiry time.Duration
+	// UtilizationPercent is the utilization of the kyma worker pool alerted on
+	UtilizationPercent int
+	// For is the time a condition must hold until it is alerted on
+	For time.Duration
+	// SLOObjective is the ratio of admission requests of pods the webhook must
+	// mutate or pass within the SLO latency
+	SLOObjective float64
+	// SLOLatency is the latency of the SLO, it must match the
+	// --admission-slo-latency of kim-snatch
+	SLOLatency time.Duration
+}
+
+// DefaultAlertOptions returns the default thresholds of the alerts.
+func DefaultAlertOptions() AlertOptions {
+	return AlertOptions{
+		ErrorRatio:         0.05,
+		CertificateExpiry:  7 * 24 * time.Hour,
+		UtilizationPercent: 90,
+		For:                15 * time.Minute,
+		SLOObjective:       0.999,
+		SLOLatency:         500 * time.Millisecond,
+	}
+}
+
+// sloWindows are the windows the error ratio of the SLO is recorded for, the
+// burn rate alerts pair a long window with a short one.
+var sloWindows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// prometheusRule is the PrometheusRule of the default alerts, the expressions
+// use the metrics registered by NewMetrics. The SLO group records the error
+// ratio of the SLO per window and alerts on the multiwindow burn rates of the
+// Google SRE workbook.
+var prometheusRule = template.Must(template.New("rule").Funcs(template.FuncMap{
+	"seconds":  func(d time.Duration) string { return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) },
+	"duration": func(d time.Duration) string { return strconv.FormatFloat(d.Minutes(), 'f', -1, 64) + "m" },
+	"ratio":    func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) },
+	"percent":  func(f float64) string { return strconv.FormatFloat(f*100, 'g', 6, 64) },
+	"budget":   func(objective float64) string { return strconv.FormatFloat(1-objective, 'g', 6, 64) },
+	"windows":  func() []string { return sloWindows },
+}).Parse(`# Code generated by make prometheus-rule. DO NOT EDIT.
+# Default alerts of kim-snatch (Prometheus Operator)
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  labels:
+    control-plane: controller-manager
+    app.kubernetes.io/name: kim-snatch
+    app.kubernetes.io/managed-by: kustomize
+  name: controller-manager-alerts
+  namespace: system
+spec:
+  groups:
+    - name: kim-snatch
+      rules:
+        - alert: KimSnatchWebhookErrors
+          expr: |
+            sum(rate(kim_snatch_admission_total{result="error"}[5m]))
+              / sum(rate(kim_snatch_admission_total[5m])) > {{ ratio .ErrorRatio }}
+          for: {{ duration .For }}
+          labels:
+            severity: warning
+          annotations:
+            summary: More than {{ percent .ErrorRatio }}% of the pod admission requests of kim-snatch fail
+            description: The node affinity of the failed pods is not injected, check the logs of kim-snatch.
+        - alert: KimSnatchCertificateExpiring
+          expr: min by (certificate) (kim_snatch_certificate_expiry_seconds) < {{ seconds .CertificateExpiry }}
+          labels:
+            severity: warning
+          annotations:
+            summary: The {{"{{"}} $labels.certificate {{"}}"}} certificate of the kim-snatch webhook server expires soon
+            description: The API server fails to call the webhook once the certificate expired, check the certificate provider.
+        - alert: KimSnatchPoolSaturated
+          expr: max by (resource) (kim_snatch_pool_utilization_percent) > {{ .UtilizationPercent }}
+          for: {{ duration .For }}
+          labels:
+            severity: warning
+          annotations:
+            summary: More than {{ .UtilizationPercent }}% of the {{"{{"}} $labels.resource {{"}}"}} of the kyma worker pool is requested
+            description: Pods of the kyma namespaces may stay pending, consider scaling the kyma worker pool.
+        - alert: KimSnatchPodsPending
+          expr: sum(kim_snatch_pending_due_to_placement) > 0
+          for: {{ duration .For }}
+          labels:
+            severity: warning
+          annotations:
+            summary: Pods of the kyma namespaces are pending due to the injected node affinity
+            description: The kim_snatch_pending_due_to_placement metric counts the pending pods per cause.
+    - name: kim-snatch-slo
+      rules:
+{{- range windows }}
+        - record: kim_snatch:admission_slo_errors:ratio_rate{{ . }}
+          expr: |
+            sum(rate(kim_snatch_admission_slo_requests_total{outcome!="good"}[{{ . }}]))
+              / sum(rate(kim_snatch_admission_slo_requests_total[{{ . }}]))
+{{- end }}
+        - alert: KimSnatchSLOBurnRateFast
+          expr: |
+            (kim_snatch:admission_slo_errors:ratio_rate1h > (14.4 * {{ budget .SLOObjective }})
+              and kim_snatch:admission_slo_errors:ratio_rate5m > (14.4 * {{ budget .SLOObjective }}))
+            or
+            (kim_snatch:admission_slo_errors:ratio_rate6h > (6 * {{ budget .SLOObjective }})
+              and kim_snatch:admission_slo_errors:ratio_rate30m > (6 * {{ budget .SLOObjective }}))
+          labels:
+            severity: critical
+          annotations:
+            summary: kim-snatch burns the error budget of its {{ percent .SLOObjective }}% within {{ .SLOLatency }} SLO fast
+            description: Pods are admitted without the node affinity or late, check the logs and the admission metrics of kim-snatch.
+        - alert: KimSnatchSLOBurnRateSlow
+          expr: |
+            (kim_snatch:admission_slo_errors:ratio_rate1d > (3 * {{ budget .SLOObjective }})
+              and kim_snatch:admission_slo_errors:ratio_rate2h > (3 * {{ budget .SLOObjective }}))
+            or
+            (kim_snatch:admission_slo_errors:ratio_rate3d > {{ budget .SLOObjective }}
+              and kim_snatch:admission_slo_errors:ratio_rate6h > {{ budget .SLOObjective }})
+          labels:
+            severity: warning
+          annotations:
+            summary: kim-snatch burns the error budget of its {{ percent .SLOObjective }}% within {{ .SLOLatency }} SLO
+            description: Pods are admitted without the node affinity or late, check the logs and the admission metrics of kim-snatch.
+`))
+
+// WritePrometheusRule writes the PrometheusRule of the default alerts.
+func WritePrometheusRule(w io.Writer, opts AlertOptions) error {
+	return prometheusRule.Execute(w, opts)
+}
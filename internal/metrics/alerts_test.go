@@ -0,0 +1,69 @@
+package metrics_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_WritePrometheusRule(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, metrics.WritePrometheusRule(&buf, metrics.DefaultAlertOptions()))
+
+	var rule struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Groups []struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					Alert  string `json:"alert"`
+					Record string `json:"record"`
+					Expr   string `json:"expr"`
+				} `json:"rules"`
+			} `json:"groups"`
+		} `json:"spec"`
+	}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &rule))
+	assert.Equal(t, "PrometheusRule", rule.Kind)
+	require.Len(t, rule.Spec.Groups, 2)
+	var alerts []string
+	for _, r := range rule.Spec.Groups[0].Rules {
+		alerts = append(alerts, r.Alert)
+		assert.Contains(t, r.Expr, "kim_snatch_")
+	}
+	assert.Equal(t, []string{"KimSnatchWebhookErrors", "KimSnatchCertificateExpiring", "KimSnatchPoolSaturated",
+		"KimSnatchPodsPending"}, alerts)
+
+	// the burn rate alerts use the recorded error ratios of the SLO
+	slo := rule.Spec.Groups[1]
+	assert.Equal(t, "kim-snatch-slo", slo.Name)
+	records := map[string]bool{}
+	alerts = nil
+	for _, r := range slo.Rules {
+		if r.Record != "" {
+			records[r.Record] = true
+			assert.Contains(t, r.Expr, "kim_snatch_admission_slo_requests_total")
+			continue
+		}
+		alerts = append(alerts, r.Alert)
+		for _, window := range []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"} {
+			if strings.Contains(r.Expr, "ratio_rate"+window+" ") {
+				assert.True(t, records["kim_snatch:admission_slo_errors:ratio_rate"+window], window)
+			}
+		}
+	}
+	assert.Len(t, records, 7)
+	assert.Equal(t, []string{"KimSnatchSLOBurnRateFast", "KimSnatchSLOBurnRateSlow"}, alerts)
+	assert.Contains(t, buf.String(), "(14.4 * 0.001)")
+
+	// the manifest is kept up to date with make prometheus-rule
+	manifest, err := os.ReadFile("../../config/prometheus/rule.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, string(manifest), buf.String(), "config/prometheus/rule.yaml is outdated, run make prometheus-rule")
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import "sync"
+
+// LabelOther is the label value the values exceeding the cardinality of a
+// LabelGuard are aggregated in.
+const LabelOther = "_other"
+
+// LabelGuard bounds the cardinality of a label. The first distinct values are
+// kept, all later ones are aggregated in LabelOther.
+type LabelGuard struct {
+	max int
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewLabelGuard returns a LabelGuard keeping max distinct values.
+func NewLabelGuard(max int) *LabelGuard {
+	return &LabelGuard{max: max, seen: map[string]struct{}{}}
+}
+
+// Value returns the label value for the value, a nil LabelGuard returns an
+// empty value, Prometheus treats the label as absent then.
+func (g *LabelGuard) Value(value string) string {
+	if g == nil {
+		return ""
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.seen[value]; ok {
+		return value
+	}
+	if len(g.seen) >= g.max {
+		return LabelOther
+	}
+	g.seen[value] = struct{}{}
+	return value
+}
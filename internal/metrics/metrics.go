@@ -1,19 +1,255 @@
 package metrics
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	ctrlMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+// Reasons of skipped mutations.
+const (
+	// SkipReasonPoolNotReady is the reason while the kyma worker pool has no ready nodes
+	SkipReasonPoolNotReady = "pool_not_ready"
+	// SkipReasonFallback is the reason while the kyma worker pool has no nodes at all,
+	// the pods are annotated with the pool instead
+	SkipReasonFallback = "fallback"
+	// SkipReasonPoolSaturated is the reason for low priority pods while the kyma worker pool is saturated
+	SkipReasonPoolSaturated = "pool_saturated"
+	// SkipReasonCleanup is the reason while the node affinity is being removed from the cluster
+	SkipReasonCleanup = "cleanup"
+	// SkipReasonOmittedNamespace is the reason for pods of omitted namespaces
+	SkipReasonOmittedNamespace = "omitted_namespace"
+	// SkipReasonExcludedByRule is the reason for pods excluded by an exclusion or inclusion rule
+	SkipReasonExcludedByRule = "excluded_by_rule"
+	// SkipReasonRuleError is the reason for pods the rules could not be evaluated for
+	SkipReasonRuleError = "rule_error"
+	// SkipReasonConflict is the reason for pods already requiring another worker pool
+	SkipReasonConflict = "conflict"
+	// SkipReasonSubresource is the reason for admission requests of a subresource of a pod
+	SkipReasonSubresource = "subresource"
+	// SkipReasonDeadlineExceeded is the reason for pods whose admission request exceeded its latency budget
+	SkipReasonDeadlineExceeded = "deadline_exceeded"
+	// SkipReasonDependencyUnavailable is the reason while the breaker of a dependency is open
+	SkipReasonDependencyUnavailable = "dependency_unavailable"
+)
+
+// Results of admission requests.
+const (
+	// AdmissionResultMutated is the result of pods the node affinity was injected into,
+	// the reason is the affinity mode
+	AdmissionResultMutated = "mutated"
+	// AdmissionResultSkipped is the result of pods the injection was skipped for,
+	// the reason is one of the skip reasons
+	AdmissionResultSkipped = "skipped"
+	// AdmissionResultError is the result of admission requests failed to be handled
+	AdmissionResultError = "error"
+)
+
+// Reasons of admission requests besides the affinity modes and skip reasons.
+const (
+	// AdmissionReasonInvalidObject is the reason for admission requests of objects other than pods
+	AdmissionReasonInvalidObject = "invalid_object"
+	// AdmissionReasonPanic is the reason for admission requests the defaulting panicked for
+	AdmissionReasonPanic = "panic"
+)
+
+// Reasons of AdmissionReviews the webhook can't decode.
+const (
+	// DecodeErrorEmptyBody is the reason of requests without body
+	DecodeErrorEmptyBody = "empty_body"
+	// DecodeErrorRead is the reason of requests whose body couldn't be read
+	DecodeErrorRead = "read_error"
+	// DecodeErrorTooLarge is the reason of reviews exceeding the size the webhook accepts
+	DecodeErrorTooLarge = "too_large"
+	// DecodeErrorContentType is the reason of requests of another content type than JSON
+	DecodeErrorContentType = "content_type"
+	// DecodeErrorMalformed is the reason of reviews that are no valid JSON
+	DecodeErrorMalformed = "malformed"
+	// DecodeErrorUnknownVersion is the reason of reviews of an unknown API version or kind
+	DecodeErrorUnknownVersion = "unknown_version"
+	// DecodeErrorMissingRequest is the reason of reviews without request
+	DecodeErrorMissingRequest = "missing_request"
+
+	// ReviewVersionUnknown is the version of reviews the version couldn't be told of
+	ReviewVersionUnknown = "unknown"
+)
+
+// Results of evictions.
+const (
+	// EvictionResultEvicted is the result of pods evicted from outside of the kyma worker pool
+	EvictionResultEvicted = "evicted"
+	// EvictionResultBlocked is the result of evictions rejected due to a PodDisruptionBudget
+	EvictionResultBlocked = "blocked"
+	// EvictionResultFailed is the result of evictions failed for other reasons
+	EvictionResultFailed = "failed"
+)
+
+// Outcomes of admission requests of pods measured against the SLO, the
+// webhook must mutate or pass a pod within the SLO latency.
+const (
+	// SLOOutcomeGood is the outcome of requests allowed within the SLO latency
+	SLOOutcomeGood = "good"
+	// SLOOutcomeSlow is the outcome of requests allowed after the SLO latency
+	SLOOutcomeSlow = "slow"
+	// SLOOutcomeError is the outcome of requests failed to be handled
+	SLOOutcomeError = "error"
+)
+
+// Results of audit records.
+const (
+	// AuditResultWritten is the result of audit records written to the sink
+	AuditResultWritten = "written"
+	// AuditResultDropped is the result of audit records dropped while the buffer was full
+	AuditResultDropped = "dropped"
+)
+
+// Causes of suppressed events.
+const (
+	// EventSuppressedDuplicate is the cause of events identical to one recorded within the interval
+	EventSuppressedDuplicate = "duplicate"
+	// EventSuppressedRateLimited is the cause of events exceeding the rate of events
+	EventSuppressedRateLimited = "rate_limited"
+)
+
+// Reasons of requests the webhook server rejected without handling them.
+const (
+	// RejectReasonQueueFull is the reason of requests of a client whose queue was full
+	RejectReasonQueueFull = "queue_full"
+	// RejectReasonDeadline is the reason of requests that waited as long as their timeout allows
+	RejectReasonDeadline = "deadline"
+	// RejectReasonCanceled is the reason of requests the client gave up while they waited
+	RejectReasonCanceled = "canceled"
+)
+
+// Reasons of requests to the webhook server refused during the TLS handshake.
+const (
+	// UnauthorizedReasonTLS is the reason of connections whose TLS handshake failed otherwise
+	UnauthorizedReasonTLS = "tls"
+	// UnauthorizedReasonClientCertificate is the reason of clients without a certificate issued by the client CA
+	UnauthorizedReasonClientCertificate = "client_certificate"
+	// UnauthorizedReasonClientName is the reason of client certificates carrying none of the allowed client names
+	UnauthorizedReasonClientName = "client_name"
+)
+
+// States of the breakers of the dependencies of the admission requests.
+const (
+	// BreakerStateClosed is the state while the dependency is looked up
+	BreakerStateClosed = "closed"
+	// BreakerStateOpen is the state while the dependency is unsynced or failed consistently
+	BreakerStateOpen = "open"
+	// BreakerStateHalfOpen is the state while a single lookup probes the dependency
+	BreakerStateHalfOpen = "half_open"
+)
+
+// breakerStates are the states of the breakers.
+var breakerStates = []string{BreakerStateClosed, BreakerStateOpen, BreakerStateHalfOpen}
+
+// Classes of admission requests of pods the node affinity isn't injected in,
+// every skip reason belongs to exactly one class.
+const (
+	// SkipClassUnmanagedNamespace is the class of pods of omitted namespaces
+	SkipClassUnmanagedNamespace = "unmanaged_namespace"
+	// SkipClassOptedOut is the class of pods excluded by a rule on the pod itself
+	SkipClassOptedOut = "opted_out"
+	// SkipClassOwnerExcluded is the class of pods excluded by a rule on their owner references
+	SkipClassOwnerExcluded = "owner_excluded"
+	// SkipClassConflict is the class of pods already requiring another worker pool
+	SkipClassConflict = "conflict"
+	// SkipClassPoolMissing is the class of pods skipped while the kyma worker pool has no nodes or no ready nodes
+	SkipClassPoolMissing = "pool_missing"
+	// SkipClassPoolSaturated is the class of low priority pods skipped while the kyma worker pool is saturated
+	SkipClassPoolSaturated = "pool_saturated"
+	// SkipClassSubresource is the class of admission requests of a subresource of a pod
+	SkipClassSubresource = "subresource"
+	// SkipClassCleanup is the class of pods skipped while the node affinity is being removed
+	SkipClassCleanup = "cleanup"
+	// SkipClassRuleError is the class of pods the rules could not be evaluated for
+	SkipClassRuleError = "rule_error"
+	// SkipClassDeadlineExceeded is the class of pods whose admission request exceeded its latency budget
+	SkipClassDeadlineExceeded = "deadline_exceeded"
+	// SkipClassDependencyUnavailable is the class of pods skipped while the breaker of a dependency is open
+	SkipClassDependencyUnavailable = "dependency_unavailable"
+)
+
+// Certificates of the webhook server.
+const (
+	// CertificateServing is the serving certificate of the webhook server
+	CertificateServing = "serving"
+	// CertificateCA is the CA the serving certificate is issued by
+	CertificateCA = "ca"
+)
+
 //go:generate mockery --name=Metrics
 type Metrics interface {
 	SetDefaultShoot()
 	SetFallbackShoot()
+	SetConfigDrift(reason string, drifted bool)
+	SetPoolAtMaxSize(atMaxSize bool)
+	SetGardenReachable(reachable bool)
+	SetPoolLabelMismatch(nodes int)
+	SetPoolNodes(state string, nodes int)
+	SetPendingDueToPlacement(cause string, pods int)
+	SetPoolUtilization(resource string, percent float64)
+	SetSelfOnPool(onPool bool)
+	SetPodDistribution(onPool, offPool int)
+	IncEvictions(result string)
+	SetDeschedulingCandidates(pods int)
+	IncWorkloadReapplied(namespace, kind, name string)
+	SetCertificateExpiry(certificate string, seconds float64)
+	SetWebhookSelfTest(success bool)
+	ObserveAdmission(namespace, result, reason string, duration time.Duration)
+	AddAuditRecords(result string, records int)
+	SetBuildInfo(version, revision, goVersion string)
+	SetConfigHash(hash string)
+	IncEventsSuppressed(cause string)
+	ObserveAdmissionReviewSize(version string, bytes int)
+	IncAdmissionDecodeError(reason string)
+	IncAdmissionSkip(class string)
+	IncAdmissionSLO(outcome string)
+	IncWebhookRejected(reason string)
+	IncUnauthorizedRequest(reason string)
+	ObserveWebhookQueueWait(duration time.Duration)
+	SetWebhookRequestRate(rate float64)
+	SetDependencyBreaker(dependency, state string)
+	SetAPIServerUnreachable(unreachable bool)
 }
 
 type metricsImpl struct {
 	shootsDefault  prometheus.Counter
 	shootsFallback prometheus.Counter
+	configDrift    *prometheus.GaugeVec
+	poolAtMaxSize  prometheus.Gauge
+	gardenUp       prometheus.Gauge
+	poolLabels     prometheus.Gauge
+	poolNodes      *prometheus.GaugeVec
+	pending        *prometheus.GaugeVec
+	utilization    *prometheus.GaugeVec
+	selfOnPool     prometheus.Gauge
+	podsOnPool     prometheus.Gauge
+	podsOffPool    prometheus.Gauge
+	evictions      *prometheus.CounterVec
+	candidates     prometheus.Gauge
+	reapplied      *prometheus.CounterVec
+	certExpiry     *prometheus.GaugeVec
+	selfTest       prometheus.Gauge
+	admissions     *prometheus.CounterVec
+	admissionTime  *prometheus.HistogramVec
+	auditRecords   *prometheus.CounterVec
+	buildInfo      *prometheus.GaugeVec
+	configHash     prometheus.Gauge
+	suppressed     *prometheus.CounterVec
+	reviewSize     *prometheus.HistogramVec
+	decodeErrors   *prometheus.CounterVec
+	skips          *prometheus.CounterVec
+	slo            *prometheus.CounterVec
+	rejected       *prometheus.CounterVec
+	unauthorized   *prometheus.CounterVec
+	queueWait      prometheus.Histogram
+	requestRate    prometheus.Gauge
+	breakers       *prometheus.GaugeVec
+	apiServerDown  prometheus.Gauge
 }
 
 func (m metricsImpl) SetDefaultShoot() {
@@ -24,6 +260,161 @@ func (m metricsImpl) SetFallbackShoot() {
 	m.shootsFallback.Inc()
 }
 
+func (m metricsImpl) SetConfigDrift(reason string, drifted bool) {
+	var value float64
+	if drifted {
+		value = 1
+	}
+	m.configDrift.WithLabelValues(reason).Set(value)
+}
+
+func (m metricsImpl) SetPoolAtMaxSize(atMaxSize bool) {
+	var value float64
+	if atMaxSize {
+		value = 1
+	}
+	m.poolAtMaxSize.Set(value)
+}
+
+func (m metricsImpl) SetGardenReachable(reachable bool) {
+	var value float64
+	if reachable {
+		value = 1
+	}
+	m.gardenUp.Set(value)
+}
+
+func (m metricsImpl) SetPoolLabelMismatch(nodes int) {
+	m.poolLabels.Set(float64(nodes))
+}
+
+func (m metricsImpl) SetPoolNodes(state string, nodes int) {
+	m.poolNodes.WithLabelValues(state).Set(float64(nodes))
+}
+
+func (m metricsImpl) SetPendingDueToPlacement(cause string, pods int) {
+	m.pending.WithLabelValues(cause).Set(float64(pods))
+}
+
+func (m metricsImpl) SetPoolUtilization(resource string, percent float64) {
+	m.utilization.WithLabelValues(resource).Set(percent)
+}
+
+func (m metricsImpl) SetSelfOnPool(onPool bool) {
+	var value float64
+	if onPool {
+		value = 1
+	}
+	m.selfOnPool.Set(value)
+}
+
+func (m metricsImpl) SetPodDistribution(onPool, offPool int) {
+	m.podsOnPool.Set(float64(onPool))
+	m.podsOffPool.Set(float64(offPool))
+}
+
+func (m metricsImpl) IncEvictions(result string) {
+	m.evictions.WithLabelValues(result).Inc()
+}
+
+func (m metricsImpl) SetDeschedulingCandidates(pods int) {
+	m.candidates.Set(float64(pods))
+}
+
+func (m metricsImpl) IncWorkloadReapplied(namespace, kind, name string) {
+	m.reapplied.WithLabelValues(namespace, kind, name).Inc()
+}
+
+func (m metricsImpl) SetCertificateExpiry(certificate string, seconds float64) {
+	m.certExpiry.WithLabelValues(certificate).Set(seconds)
+}
+
+func (m metricsImpl) SetWebhookSelfTest(success bool) {
+	var value float64
+	if success {
+		value = 1
+	}
+	m.selfTest.Set(value)
+}
+
+func (m metricsImpl) ObserveAdmission(namespace, result, reason string, duration time.Duration) {
+	m.admissions.WithLabelValues(namespace, result, reason).Inc()
+	m.admissionTime.WithLabelValues(namespace, result).Observe(duration.Seconds())
+}
+
+func (m metricsImpl) AddAuditRecords(result string, records int) {
+	m.auditRecords.WithLabelValues(result).Add(float64(records))
+}
+
+func (m metricsImpl) SetBuildInfo(version, revision, goVersion string) {
+	m.buildInfo.Reset()
+	m.buildInfo.WithLabelValues(version, revision, goVersion).Set(1)
+}
+
+// SetConfigHash sets the first 12 hex digits of the hash as number, float64
+// represents 48 bits exactly.
+func (m metricsImpl) SetConfigHash(hash string) {
+	value, err := strconv.ParseUint(hash[:min(len(hash), 12)], 16, 64)
+	if err != nil {
+		value = 0
+	}
+	m.configHash.Set(float64(value))
+}
+
+func (m metricsImpl) IncEventsSuppressed(cause string) {
+	m.suppressed.WithLabelValues(cause).Inc()
+}
+
+func (m metricsImpl) ObserveAdmissionReviewSize(version string, bytes int) {
+	m.reviewSize.WithLabelValues(version).Observe(float64(bytes))
+}
+
+func (m metricsImpl) IncAdmissionDecodeError(reason string) {
+	m.decodeErrors.WithLabelValues(reason).Inc()
+}
+
+func (m metricsImpl) IncAdmissionSkip(class string) {
+	m.skips.WithLabelValues(class).Inc()
+}
+
+func (m metricsImpl) IncAdmissionSLO(outcome string) {
+	m.slo.WithLabelValues(outcome).Inc()
+}
+
+func (m metricsImpl) IncWebhookRejected(reason string) {
+	m.rejected.WithLabelValues(reason).Inc()
+}
+
+func (m metricsImpl) IncUnauthorizedRequest(reason string) {
+	m.unauthorized.WithLabelValues(reason).Inc()
+}
+
+func (m metricsImpl) ObserveWebhookQueueWait(duration time.Duration) {
+	m.queueWait.Observe(duration.Seconds())
+}
+
+func (m metricsImpl) SetWebhookRequestRate(rate float64) {
+	m.requestRate.Set(rate)
+}
+
+func (m metricsImpl) SetDependencyBreaker(dependency, state string) {
+	for _, s := range breakerStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		m.breakers.WithLabelValues(dependency, s).Set(value)
+	}
+}
+
+func (m metricsImpl) SetAPIServerUnreachable(unreachable bool) {
+	var value float64
+	if unreachable {
+		value = 1
+	}
+	m.apiServerDown.Set(value)
+}
+
 func NewMetrics() Metrics {
 	m := &metricsImpl{
 		shootsDefault: prometheus.NewCounter(
@@ -38,7 +429,201 @@ func NewMetrics() Metrics {
 				Name:      "shoots_fallback",
 				Help:      "Indicates the number of Shoots with missing NodeAffinity",
 			}),
+		configDrift: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "config_drift",
+				Help:      "Indicates if the effective configuration diverged from its sources (1) or not (0)",
+			}, []string{"reason"}),
+		poolAtMaxSize: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pool_at_max_size",
+				Help:      "Indicates if the kyma worker pool is scaled to its maximum size (1) or not (0)",
+			}),
+		gardenUp: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "garden_reachable",
+				Help:      "Indicates if the Garden cluster was reachable on the last request (1) or not (0)",
+			}),
+		poolLabels: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pool_label_mismatch_nodes",
+				Help:      "Indicates the number of nodes of the kyma worker pool without the pool label",
+			}),
+		poolNodes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pool_nodes",
+				Help:      "Indicates the number of nodes of the kyma worker pool per state",
+			}, []string{"state"}),
+		pending: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pending_due_to_placement",
+				Help:      "Indicates the number of pods pending due to the injected node affinity per cause",
+			}, []string{"cause"}),
+		utilization: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pool_utilization_percent",
+				Help:      "Indicates the percentage of the allocatable resources of the kyma worker pool requested by pods",
+			}, []string{"resource"}),
+		selfOnPool: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "self_on_pool",
+				Help:      "Indicates if kim-snatch itself runs on the kyma worker pool (1) or not (0)",
+			}),
+		podsOnPool: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pods_on_pool",
+				Help:      "Indicates the number of pods of the kyma namespaces running on the kyma worker pool",
+			}),
+		podsOffPool: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "pods_off_pool",
+				Help:      "Indicates the number of pods of the kyma namespaces running outside of the kyma worker pool",
+			}),
+		evictions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "evictions_total",
+				Help:      "Indicates the number of evictions of pods running outside of the kyma worker pool per result",
+			}, []string{"result"}),
+		candidates: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "descheduling_candidates",
+				Help:      "Indicates the number of pods running outside of the kyma worker pool planned for eviction",
+			}),
+		reapplied: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "workload_reapplications_total",
+				Help:      "Indicates the number of times the node affinity removed from a workload by another manager was applied again",
+			}, []string{"namespace", "kind", "name"}),
+		certExpiry: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "certificate_expiry_seconds",
+				Help:      "Indicates the number of seconds until the certificate of the webhook server expires per certificate",
+			}, []string{"certificate"}),
+		selfTest: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "webhook_self_test_success",
+				Help:      "Indicates if the last admission review kim-snatch sent to its own webhook server succeeded (1) or not (0)",
+			}),
+		admissions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "admission_total",
+				Help:      "Indicates the number of pod admission requests per namespace, result and reason",
+			}, []string{"namespace", "result", "reason"}),
+		admissionTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Subsystem: "kim_snatch",
+				Name:      "admission_duration_seconds",
+				Help:      "Indicates the time the defaulting of pods took per namespace and result",
+				Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 12),
+			}, []string{"namespace", "result"}),
+		auditRecords: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "audit_records_total",
+				Help:      "Indicates the number of audit records of admission requests per result",
+			}, []string{"result"}),
+		buildInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "build_info",
+				Help:      "Indicates the version, revision and Go version kim-snatch was built with, the value is always 1",
+			}, []string{"version", "revision", "goversion"}),
+		configHash: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "config_hash",
+				Help:      "Indicates the first 12 hex digits of the hash of the effective configuration as number",
+			}),
+		suppressed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "events_suppressed_total",
+				Help:      "Indicates the number of events not recorded per cause",
+			}, []string{"cause"}),
+		reviewSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Subsystem: "kim_snatch",
+				Name:      "admission_review_size_bytes",
+				Help:      "Indicates the size of the AdmissionReviews of pods per version",
+				Buckets:   prometheus.ExponentialBuckets(1024, 2, 13),
+			}, []string{"version"}),
+		decodeErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "admission_decode_errors_total",
+				Help:      "Indicates the number of AdmissionReviews of pods the webhook can't decode per reason",
+			}, []string{"reason"}),
+		skips: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "admission_skips_total",
+				Help:      "Indicates the number of admission requests of pods the node affinity isn't injected in per class",
+			}, []string{"class"}),
+		slo: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "admission_slo_requests_total",
+				Help:      "Indicates the number of admission requests of pods per outcome measured against the SLO",
+			}, []string{"outcome"}),
+		rejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "webhook_rejected_total",
+				Help:      "Indicates the number of requests the webhook server rejected without handling them per reason",
+			}, []string{"reason"}),
+		unauthorized: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "kim_snatch",
+				Name:      "unauthorized_requests_total",
+				Help:      "Indicates the number of connections to the webhook server refused in the TLS handshake per reason",
+			}, []string{"reason"}),
+		queueWait: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Subsystem: "kim_snatch",
+				Name:      "webhook_queue_wait_seconds",
+				Help:      "Indicates the time the requests of the webhook server waited until they were handled",
+				Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 12),
+			}),
+		requestRate: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "webhook_requests_per_second",
+				Help:      "Indicates the number of requests the webhook server received per second averaged over the last minute",
+			}),
+		breakers: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "dependency_breaker_state",
+				Help:      "Indicates the state of the breaker of a dependency of the admission requests (1) per dependency and state",
+			}, []string{"dependency", "state"}),
+		apiServerDown: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: "kim_snatch",
+				Name:      "apiserver_unreachable",
+				Help:      "Indicates if the last probe of the API server failed (1) or not (0)",
+			}),
 	}
-	ctrlMetrics.Registry.MustRegister(m.shootsDefault, m.shootsFallback)
+	ctrlMetrics.Registry.MustRegister(m.shootsDefault, m.shootsFallback, m.configDrift, m.poolAtMaxSize, m.gardenUp,
+		m.poolLabels, m.poolNodes, m.pending, m.utilization,
+		m.selfOnPool, m.podsOnPool, m.podsOffPool, m.evictions, m.candidates, m.reapplied, m.certExpiry,
+		m.selfTest, m.admissions, m.admissionTime, m.auditRecords, m.buildInfo, m.configHash,
+		m.suppressed, m.reviewSize, m.decodeErrors, m.skips, m.slo,
+		m.rejected, m.unauthorized, m.queueWait, m.requestRate, m.breakers, m.apiServerDown)
 	return m
 }
@@ -2,23 +2,172 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
 
 // Metrics is an autogenerated mock type for the Metrics type
 type Metrics struct {
 	mock.Mock
 }
 
+// AddAuditRecords provides a mock function with given fields: result, records
+func (_m *Metrics) AddAuditRecords(result string, records int) {
+	_m.Called(result, records)
+}
+
+// IncAdmissionDecodeError provides a mock function with given fields: reason
+func (_m *Metrics) IncAdmissionDecodeError(reason string) {
+	_m.Called(reason)
+}
+
+// IncAdmissionSLO provides a mock function with given fields: outcome
+func (_m *Metrics) IncAdmissionSLO(outcome string) {
+	_m.Called(outcome)
+}
+
+// IncAdmissionSkip provides a mock function with given fields: class
+func (_m *Metrics) IncAdmissionSkip(class string) {
+	_m.Called(class)
+}
+
+// IncEventsSuppressed provides a mock function with given fields: cause
+func (_m *Metrics) IncEventsSuppressed(cause string) {
+	_m.Called(cause)
+}
+
+// IncEvictions provides a mock function with given fields: result
+func (_m *Metrics) IncEvictions(result string) {
+	_m.Called(result)
+}
+
+// IncUnauthorizedRequest provides a mock function with given fields: reason
+func (_m *Metrics) IncUnauthorizedRequest(reason string) {
+	_m.Called(reason)
+}
+
+// IncWebhookRejected provides a mock function with given fields: reason
+func (_m *Metrics) IncWebhookRejected(reason string) {
+	_m.Called(reason)
+}
+
+// IncWorkloadReapplied provides a mock function with given fields: namespace, kind, name
+func (_m *Metrics) IncWorkloadReapplied(namespace string, kind string, name string) {
+	_m.Called(namespace, kind, name)
+}
+
+// ObserveAdmission provides a mock function with given fields: namespace, result, reason, duration
+func (_m *Metrics) ObserveAdmission(namespace string, result string, reason string, duration time.Duration) {
+	_m.Called(namespace, result, reason, duration)
+}
+
+// ObserveAdmissionReviewSize provides a mock function with given fields: version, bytes
+func (_m *Metrics) ObserveAdmissionReviewSize(version string, bytes int) {
+	_m.Called(version, bytes)
+}
+
+// ObserveWebhookQueueWait provides a mock function with given fields: duration
+func (_m *Metrics) ObserveWebhookQueueWait(duration time.Duration) {
+	_m.Called(duration)
+}
+
+// SetAPIServerUnreachable provides a mock function with given fields: unreachable
+func (_m *Metrics) SetAPIServerUnreachable(unreachable bool) {
+	_m.Called(unreachable)
+}
+
+// SetBuildInfo provides a mock function with given fields: version, revision, goVersion
+func (_m *Metrics) SetBuildInfo(version string, revision string, goVersion string) {
+	_m.Called(version, revision, goVersion)
+}
+
+// SetCertificateExpiry provides a mock function with given fields: certificate, seconds
+func (_m *Metrics) SetCertificateExpiry(certificate string, seconds float64) {
+	_m.Called(certificate, seconds)
+}
+
+// SetConfigDrift provides a mock function with given fields: reason, drifted
+func (_m *Metrics) SetConfigDrift(reason string, drifted bool) {
+	_m.Called(reason, drifted)
+}
+
+// SetConfigHash provides a mock function with given fields: hash
+func (_m *Metrics) SetConfigHash(hash string) {
+	_m.Called(hash)
+}
+
 // SetDefaultShoot provides a mock function with no fields
 func (_m *Metrics) SetDefaultShoot() {
 	_m.Called()
 }
 
+// SetDependencyBreaker provides a mock function with given fields: dependency, state
+func (_m *Metrics) SetDependencyBreaker(dependency string, state string) {
+	_m.Called(dependency, state)
+}
+
+// SetDeschedulingCandidates provides a mock function with given fields: pods
+func (_m *Metrics) SetDeschedulingCandidates(pods int) {
+	_m.Called(pods)
+}
+
 // SetFallbackShoot provides a mock function with no fields
 func (_m *Metrics) SetFallbackShoot() {
 	_m.Called()
 }
 
+// SetGardenReachable provides a mock function with given fields: reachable
+func (_m *Metrics) SetGardenReachable(reachable bool) {
+	_m.Called(reachable)
+}
+
+// SetPendingDueToPlacement provides a mock function with given fields: cause, pods
+func (_m *Metrics) SetPendingDueToPlacement(cause string, pods int) {
+	_m.Called(cause, pods)
+}
+
+// SetPodDistribution provides a mock function with given fields: onPool, offPool
+func (_m *Metrics) SetPodDistribution(onPool int, offPool int) {
+	_m.Called(onPool, offPool)
+}
+
+// SetPoolAtMaxSize provides a mock function with given fields: atMaxSize
+func (_m *Metrics) SetPoolAtMaxSize(atMaxSize bool) {
+	_m.Called(atMaxSize)
+}
+
+// SetPoolUtilization provides a mock function with given fields: resource, percent
+func (_m *Metrics) SetPoolUtilization(resource string, percent float64) {
+	_m.Called(resource, percent)
+}
+
+// SetPoolLabelMismatch provides a mock function with given fields: nodes
+func (_m *Metrics) SetPoolLabelMismatch(nodes int) {
+	_m.Called(nodes)
+}
+
+// SetPoolNodes provides a mock function with given fields: state, nodes
+func (_m *Metrics) SetPoolNodes(state string, nodes int) {
+	_m.Called(state, nodes)
+}
+
+// SetSelfOnPool provides a mock function with given fields: onPool
+func (_m *Metrics) SetSelfOnPool(onPool bool) {
+	_m.Called(onPool)
+}
+
+// SetWebhookRequestRate provides a mock function with given fields: rate
+func (_m *Metrics) SetWebhookRequestRate(rate float64) {
+	_m.Called(rate)
+}
+
+// SetWebhookSelfTest provides a mock function with given fields: success
+func (_m *Metrics) SetWebhookSelfTest(success bool) {
+	_m.Called(success)
+}
+
 // NewMetrics creates a new instance of Metrics. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMetrics(t interface {
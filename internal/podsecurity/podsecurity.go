@@ -0,0 +1,105 @@
+// Package podsecurity verifies that kim-snatch runs in conformance with the
+// restricted profile of the Pod Security Standards, so it can be deployed to
+// namespaces enforcing the profile. kim-snatch itself keeps the certificates it
+// generates in memory and only reads the mounted ones, so it runs with a
+// read-only root filesystem.
+package podsecurity
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+// capabilityNetBindService is the only capability the restricted profile allows to add.
+const capabilityNetBindService = "NET_BIND_SERVICE"
+
+// Violations returns the controls of the restricted profile the pod violates,
+// e.g. "container manager: allowPrivilegeEscalation != false". The pod
+// conforms if none are returned.
+func Violations(pod *corev1.Pod) []string {
+	spec := pod.Spec
+	var violations []string
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "hostNetwork, hostPID, or hostIPC set")
+	}
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume %s: hostPath", volume.Name))
+		} else if !allowedVolume(volume.VolumeSource) {
+			violations = append(violations, fmt.Sprintf("volume %s: restricted volume type", volume.Name))
+		}
+	}
+
+	podContext := ptr.Deref(spec.SecurityContext, corev1.PodSecurityContext{})
+	if ptr.Deref(podContext.RunAsUser, 1) == 0 {
+		violations = append(violations, "pod: runAsUser = 0")
+	}
+	containers := slices.Concat(spec.InitContainers, spec.Containers)
+	for _, c := range spec.EphemeralContainers {
+		containers = append(containers, corev1.Container(c.EphemeralContainerCommon))
+	}
+	for _, c := range containers {
+		violations = append(violations, containerViolations(c, podContext)...)
+	}
+	return violations
+}
+
+// allowedVolume returns true for the volume types the restricted profile allows.
+func allowedVolume(v corev1.VolumeSource) bool {
+	return v.ConfigMap != nil || v.CSI != nil || v.DownwardAPI != nil || v.EmptyDir != nil ||
+		v.Ephemeral != nil || v.PersistentVolumeClaim != nil || v.Projected != nil || v.Secret != nil
+}
+
+func containerViolations(c corev1.Container, pod corev1.PodSecurityContext) []string {
+	prefix := "container " + c.Name + ": "
+	sc := ptr.Deref(c.SecurityContext, corev1.SecurityContext{})
+	var violations []string
+	if ptr.Deref(sc.Privileged, false) {
+		violations = append(violations, prefix+"privileged")
+	}
+	if ptr.Deref(sc.AllowPrivilegeEscalation, true) {
+		violations = append(violations, prefix+"allowPrivilegeEscalation != false")
+	}
+	if !ptr.Deref(sc.RunAsNonRoot, ptr.Deref(pod.RunAsNonRoot, false)) {
+		violations = append(violations, prefix+"runAsNonRoot != true")
+	}
+	if ptr.Deref(sc.RunAsUser, 1) == 0 {
+		violations = append(violations, prefix+"runAsUser = 0")
+	}
+	seccomp := sc.SeccompProfile
+	if seccomp == nil {
+		seccomp = pod.SeccompProfile
+	}
+	if seccomp == nil || (seccomp.Type != corev1.SeccompProfileTypeRuntimeDefault &&
+		seccomp.Type != corev1.SeccompProfileTypeLocalhost) {
+		violations = append(violations, prefix+"seccompProfile.type not RuntimeDefault or Localhost")
+	}
+	capabilities := ptr.Deref(sc.Capabilities, corev1.Capabilities{})
+	if !slices.Contains(capabilities.Drop, "ALL") {
+		violations = append(violations, prefix+"capabilities.drop doesn't include ALL")
+	}
+	for _, capability := range capabilities.Add {
+		if capability != capabilityNetBindService {
+			violations = append(violations, fmt.Sprintf("%scapabilities.add includes %s", prefix, capability))
+		}
+	}
+	for _, port := range c.Ports {
+		if port.HostPort != 0 {
+			violations = append(violations, fmt.Sprintf("%shostPort %d", prefix, port.HostPort))
+		}
+	}
+	return violations
+}
+
+// ProcessViolations returns the violations of the running process, which the
+// pod spec doesn't tell, e.g. an image running as root without a runAsUser.
+func ProcessViolations() []string {
+	if os.Geteuid() == 0 {
+		return []string{"process runs as uid 0"}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package podsecurity_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/podsecurity"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func restrictedPod() *corev1.Pod {
+	return &corev1.Pod{Spec: corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   ptr.To(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name: "manager",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: ptr.To(false),
+				ReadOnlyRootFilesystem:   ptr.To(true),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			},
+		}},
+		Volumes: []corev1.Volume{{
+			Name:         "kim-snatch-certificates",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "kim-snatch-certificates"}},
+		}},
+	}}
+}
+
+func Test_Violations_restricted(t *testing.T) {
+	assert.Empty(t, podsecurity.Violations(restrictedPod()))
+}
+
+func Test_Violations(t *testing.T) {
+	pod := restrictedPod()
+	pod.Spec.HostNetwork = true
+	pod.Spec.SecurityContext.SeccompProfile = nil
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name:         "host",
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}},
+	})
+	sc := pod.Spec.Containers[0].SecurityContext
+	sc.AllowPrivilegeEscalation = nil
+	sc.RunAsUser = ptr.To(int64(0))
+	sc.Capabilities.Add = []corev1.Capability{"NET_BIND_SERVICE", "NET_ADMIN"}
+	pod.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: 9443, HostPort: 9443}}
+
+	assert.ElementsMatch(t, []string{
+		"hostNetwork, hostPID, or hostIPC set",
+		"volume host: hostPath",
+		"container manager: allowPrivilegeEscalation != false",
+		"container manager: runAsUser = 0",
+		"container manager: seccompProfile.type not RuntimeDefault or Localhost",
+		"container manager: capabilities.add includes NET_ADMIN",
+		"container manager: hostPort 9443",
+	}, podsecurity.Violations(pod))
+}
+
+func Test_Violations_container_overrides_pod(t *testing.T) {
+	pod := restrictedPod()
+	pod.Spec.SecurityContext = nil
+	pod.Spec.Containers[0].SecurityContext.RunAsNonRoot = ptr.To(true)
+	pod.Spec.Containers[0].SecurityContext.SeccompProfile = &corev1.SeccompProfile{
+		Type: corev1.SeccompProfileTypeLocalhost,
+	}
+	pod.Spec.InitContainers = []corev1.Container{{Name: "init"}}
+
+	assert.ElementsMatch(t, []string{
+		"container init: allowPrivilegeEscalation != false",
+		"container init: runAsNonRoot != true",
+		"container init: seccompProfile.type not RuntimeDefault or Localhost",
+		"container init: capabilities.drop doesn't include ALL",
+	}, podsecurity.Violations(pod))
+}
@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"net/http"
+	"time"
+)
+
+// Health describes how up to date the view of the watcher on the nodes is.
+type Health struct {
+	// Synced is true once the nodes were listed
+	Synced bool `json:"synced"`
+	// LastSync is the time the nodes were listed last
+	LastSync *time.Time `json:"lastSync,omitempty"`
+	// LastChange is the time a node last joined, left or changed its worker pool
+	LastChange *time.Time `json:"lastChange,omitempty"`
+	Nodes      int        `json:"nodes"`
+	ReadyNodes int        `json:"readyNodes"`
+}
+
+// Health returns how up to date the view of the watcher on the nodes is.
+func (w *Watcher) Health() Health {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.health()
+}
+
+// health must be called with the lock held.
+func (w *Watcher) health() Health {
+	health := Health{Synced: w.synced, Nodes: len(w.nodes)}
+	for _, node := range w.nodes {
+		if node.Ready {
+			health.ReadyNodes++
+		}
+	}
+	if lastSync := w.lastSync; !lastSync.IsZero() {
+		health.LastSync = &lastSync
+	}
+	if lastChange := w.lastChange; !lastChange.IsZero() {
+		health.LastChange = &lastChange
+	}
+	return health
+}
+
+// HealthHandler serves the health of the watcher as JSON.
+func HealthHandler(w *Watcher) http.Handler {
+	return jsonHandler(func() any {
+		return w.Health()
+	})
+}
@@ -0,0 +1,58 @@
+package pool_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_Watcher_Health(t *testing.T) {
+	node := testNode("a", "zone-a", true)
+	w, _ := testWatcher(node, testNode("b", "zone-b", false))
+	assert.Equal(t, pool.Health{}, w.Health())
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+
+	health := w.Health()
+	assert.True(t, health.Synced)
+	assert.Equal(t, 2, health.Nodes)
+	assert.Equal(t, 1, health.ReadyNodes)
+	require.NotNil(t, health.LastChange)
+	firstChange := *health.LastChange
+
+	// the last change is kept while the membership doesn't change
+	_, err = w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, firstChange, *w.Health().LastChange)
+
+	node.Labels[config.DefaultPoolLabelKey] = "other"
+	require.NoError(t, w.Reader.(client.Client).Update(context.Background(), node))
+	_, err = w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.True(t, w.Health().LastChange.After(firstChange))
+	assert.Equal(t, 1, w.Health().Nodes)
+
+	rec := httptest.NewRecorder()
+	pool.HealthHandler(w).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz/pool", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body pool.Health
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.Synced)
+	assert.NotNil(t, body.LastChange)
+
+	rec = httptest.NewRecorder()
+	pool.HealthHandler(w).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/healthz/pool", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,108 @@
+package pool
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Pool describes a worker pool as seen from the labels of its nodes.
+type Pool struct {
+	Name  string `json:"name"`
+	Nodes int    `json:"nodes"`
+	// Zones are the sorted zones the nodes of the pool run in
+	Zones []string `json:"zones"`
+	// MachineTypes are the sorted instance types of the nodes of the pool
+	MachineTypes []string `json:"machineTypes"`
+}
+
+// buildPools groups the nodes into pools by the pool label.
+func buildPools(nodes []corev1.Node, labelKey string) []Pool {
+	byName := map[string]*Pool{}
+	for _, node := range nodes {
+		name, ok := node.Labels[labelKey]
+		if !ok {
+			continue
+		}
+
+		p, ok := byName[name]
+		if !ok {
+			p = &Pool{Name: name}
+			byName[name] = p
+		}
+		p.Nodes++
+		p.Zones = appendLabel(p.Zones, node.Labels[corev1.LabelTopologyZone])
+		p.MachineTypes = appendLabel(p.MachineTypes, node.Labels[corev1.LabelInstanceTypeStable])
+	}
+
+	result := make([]Pool, 0, len(byName))
+	for _, p := range byName {
+		slices.Sort(p.Zones)
+		slices.Sort(p.MachineTypes)
+		result = append(result, *p)
+	}
+	slices.SortFunc(result, func(a, b Pool) int { return cmp.Compare(a.Name, b.Name) })
+	return result
+}
+
+func appendLabel(values []string, value string) []string {
+	if value == "" || slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// Pools returns the worker pools of the cluster.
+func (w *Watcher) Pools() []Pool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Clone(w.pools)
+}
+
+// Pool returns the worker pool of the given name.
+func (w *Watcher) Pool(name string) (Pool, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	i := slices.IndexFunc(w.pools, func(p Pool) bool { return p.Name == name })
+	if i < 0 {
+		return Pool{}, false
+	}
+	return w.pools[i], true
+}
+
+// PoolsHandler serves the worker pools of the cluster and the active Kyma
+// worker pool as JSON.
+func PoolsHandler(w *Watcher) http.Handler {
+	return jsonHandler(func() any {
+		return struct {
+			ActivePool string `json:"activePool"`
+			Pools      []Pool `json:"pools"`
+		}{
+			ActivePool: w.ActivePool(),
+			Pools:      w.Pools(),
+		}
+	})
+}
+
+// jsonHandler serves the value returned by the function as JSON on GET requests.
+func jsonHandler(value func() any) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.Header().Set("Allow", http.MethodGet)
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		data, err := json.Marshal(value())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(data)
+	})
+}
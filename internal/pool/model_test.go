@@ -0,0 +1,50 @@
+package pool_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func Test_Watcher_Pools(t *testing.T) {
+	a := testNode("a", "zone-a", true)
+	a.Labels[corev1.LabelInstanceTypeStable] = "m5.large"
+	b := testNode("b", "zone-b", true)
+	b.Labels[corev1.LabelInstanceTypeStable] = "m5.xlarge"
+	c := testNode("c", "zone-a", true)
+	c.Labels[corev1.LabelInstanceTypeStable] = "m5.large"
+
+	w, _ := testWatcher(a, b, c)
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+
+	expected := pool.Pool{
+		Name:         testPool,
+		Nodes:        3,
+		Zones:        []string{"zone-a", "zone-b"},
+		MachineTypes: []string{"m5.large", "m5.xlarge"},
+	}
+	p, ok := w.Pool(testPool)
+	require.True(t, ok)
+	assert.Equal(t, expected, p)
+
+	rec := httptest.NewRecorder()
+	pool.PoolsHandler(w).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pools", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		ActivePool string      `json:"activePool"`
+		Pools      []pool.Pool `json:"pools"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, testPool, body.ActivePool)
+	assert.Equal(t, []pool.Pool{expected}, body.Pools)
+}
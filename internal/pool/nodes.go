@@ -0,0 +1,174 @@
+package pool
+
+import (
+	"cmp"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// States of the nodes of the Kyma worker pool reported by the kim_snatch_pool_nodes metric.
+const (
+	NodeStateReady    = "ready"
+	NodeStateNotReady = "not_ready"
+	NodeStateCordoned = "cordoned"
+)
+
+// NodeStates are all states of the nodes of the Kyma worker pool.
+var NodeStates = []string{NodeStateReady, NodeStateNotReady, NodeStateCordoned}
+
+// Node is a member of the Kyma worker pool.
+type Node struct {
+	Name  string `json:"name"`
+	Zone  string `json:"zone,omitempty"`
+	Ready bool   `json:"ready"`
+	// Unschedulable is true while the node is cordoned, e.g. during maintenance
+	Unschedulable bool `json:"unschedulable,omitempty"`
+	// Allocatable are the resources of the node available for pods
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+	Taints      []corev1.Taint      `json:"taints,omitempty"`
+}
+
+// State returns the state of the node reported by the metrics.
+func (n Node) State() string {
+	switch {
+	case !n.Ready:
+		return NodeStateNotReady
+	case n.Unschedulable:
+		return NodeStateCordoned
+	}
+	return NodeStateReady
+}
+
+// Available returns true if new pods can be scheduled on the node.
+func (n Node) Available() bool {
+	return n.Ready && !n.Unschedulable
+}
+
+// buildNodes returns the members of the pool sorted by name.
+func buildNodes(nodes []corev1.Node) []Node {
+	result := make([]Node, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		result = append(result, Node{
+			Name:          node.Name,
+			Zone:          node.Labels[corev1.LabelTopologyZone],
+			Ready:         isReady(node),
+			Unschedulable: node.Spec.Unschedulable,
+			Allocatable:   node.Status.Allocatable.DeepCopy(),
+			Taints:        slices.Clone(node.Spec.Taints),
+		})
+	}
+	slices.SortFunc(result, func(a, b Node) int { return cmp.Compare(a.Name, b.Name) })
+	return result
+}
+
+// buildMembership maps the names of the nodes to their worker pools.
+func buildMembership(nodes []corev1.Node, labelKey string) map[string]string {
+	result := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		result[node.Name] = node.Labels[labelKey]
+	}
+	return result
+}
+
+// buildTaints returns the distinct taints of the nodes sorted by key, effect
+// and value, the times the taints were added are dropped.
+func buildTaints(nodes []Node) []corev1.Taint {
+	var result []corev1.Taint
+	for _, node := range nodes {
+		for _, taint := range node.Taints {
+			taint.TimeAdded = nil
+			if !slices.ContainsFunc(result, func(t corev1.Taint) bool {
+				return t.Key == taint.Key && t.Effect == taint.Effect && t.Value == taint.Value
+			}) {
+				result = append(result, taint)
+			}
+		}
+	}
+	slices.SortFunc(result, func(a, b corev1.Taint) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Effect, b.Effect), cmp.Compare(a.Value, b.Value))
+	})
+	return result
+}
+
+// countStates counts the nodes per state, every state is present.
+func countStates(nodes []Node) map[string]int {
+	count := map[string]int{}
+	for _, state := range NodeStates {
+		count[state] = 0
+	}
+	for _, node := range nodes {
+		count[node.State()]++
+	}
+	return count
+}
+
+func hasAvailable(nodes []Node) bool {
+	return slices.ContainsFunc(nodes, Node.Available)
+}
+
+// Nodes returns the members of the Kyma worker pool.
+func (w *Watcher) Nodes() []Node {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Clone(w.nodes)
+}
+
+// Taints returns the distinct taints of the nodes of the Kyma worker pool.
+func (w *Watcher) Taints() []corev1.Taint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return buildTaints(w.nodes)
+}
+
+// IsMember returns true if the node belongs to the Kyma worker pool.
+func (w *Watcher) IsMember(name string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, found := slices.BinarySearchFunc(w.nodes, name, func(n Node, name string) int {
+		return cmp.Compare(n.Name, name)
+	})
+	return found
+}
+
+// ReadyNodes returns the number of ready nodes of the Kyma worker pool.
+func (w *Watcher) ReadyNodes() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	ready := 0
+	for _, node := range w.nodes {
+		if node.Ready {
+			ready++
+		}
+	}
+	return ready
+}
+
+// Allocatable returns the resources of the available nodes of the Kyma worker
+// pool, cordoned nodes don't take new pods.
+func (w *Watcher) Allocatable() corev1.ResourceList {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.allocatable()
+}
+
+// allocatable must be called with the lock held.
+func (w *Watcher) allocatable() corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, node := range w.nodes {
+		if !node.Available() {
+			continue
+		}
+		for name, quantity := range node.Allocatable {
+			sum, ok := total[name]
+			if !ok {
+				sum = resource.Quantity{}
+			}
+			sum.Add(quantity)
+			total[name] = sum
+		}
+	}
+	return total
+}
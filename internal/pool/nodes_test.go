@@ -0,0 +1,146 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func withAllocatable(node *corev1.Node, cpu, memory string) *corev1.Node {
+	node.Status.Allocatable = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+	return node
+}
+
+func Test_Watcher_Nodes(t *testing.T) {
+	other := testNode("c", "zone-a", true)
+	other.Labels["worker.gardener.cloud/pool"] = "other"
+
+	w, _ := testWatcher(
+		withAllocatable(testNode("b", "zone-b", true), "2", "4Gi"),
+		withAllocatable(testNode("a", "zone-a", true), "1500m", "2Gi"),
+		withAllocatable(testNode("d", "zone-a", false), "4", "8Gi"),
+		other,
+	)
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPoolNodes", pool.NodeStateReady, 2).Once()
+	mtr.On("SetPoolNodes", pool.NodeStateNotReady, 1).Once()
+	mtr.On("SetPoolNodes", pool.NodeStateCordoned, 0).Once()
+	w.Metrics = mtr
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+
+	nodes := w.Nodes()
+	require.Len(t, nodes, 3)
+	assert.Equal(t, []string{"a", "b", "d"}, []string{nodes[0].Name, nodes[1].Name, nodes[2].Name})
+	assert.Equal(t, "zone-b", nodes[1].Zone)
+
+	assert.True(t, w.IsMember("d"))
+	assert.False(t, w.IsMember("c"))
+	assert.Equal(t, 2, w.ReadyNodes())
+
+	// only the ready nodes contribute to the allocatable resources
+	allocatable := w.Allocatable()
+	assert.Equal(t, "3500m", allocatable.Cpu().String())
+	assert.Equal(t, "6Gi", allocatable.Memory().String())
+}
+
+func Test_Watcher_PoolReady(t *testing.T) {
+	node := testNode("a", "zone-a", false)
+	w, recorder := testWatcher(node)
+
+	// the pool is considered ready until the nodes were listed
+	assert.True(t, w.PoolReady())
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.False(t, w.PoolReady())
+
+	node.Status.Conditions[0].Status = corev1.ConditionTrue
+	require.NoError(t, w.Reader.(client.Client).Status().Update(context.Background(), node))
+	_, err = w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.True(t, w.PoolReady())
+
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, pool.EventReasonPoolNotReady)
+	assert.Contains(t, <-recorder.Events, pool.EventReasonPoolReady)
+}
+
+func Test_Watcher_cordoned_nodes(t *testing.T) {
+	cordoned := withAllocatable(testNode("b", "zone-b", true), "2", "4Gi")
+	cordoned.Spec.Unschedulable = true
+
+	w, recorder := testWatcher(
+		withAllocatable(testNode("a", "zone-a", true), "1500m", "2Gi"),
+		cordoned,
+	)
+	mtr := mocks.NewMetrics(t)
+	mtr.On("SetPoolNodes", pool.NodeStateReady, 1).Once()
+	mtr.On("SetPoolNodes", pool.NodeStateNotReady, 0).Once()
+	mtr.On("SetPoolNodes", pool.NodeStateCordoned, 1).Once()
+	w.Metrics = mtr
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+
+	// cordoned nodes take no new pods, the zone is avoided and their
+	// resources are not available
+	assert.Equal(t, []string{"zone-b"}, w.OutageZones())
+	assert.Equal(t, pool.ZoneState{Nodes: 1, Ready: 1, Cordoned: 1}, w.Zones()["zone-b"])
+	allocatable := w.Allocatable()
+	assert.Equal(t, "1500m", allocatable.Cpu().String())
+	assert.Equal(t, 2, w.ReadyNodes())
+	assert.True(t, w.PoolReady())
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, pool.EventReasonZoneOutage)
+}
+
+func Test_Watcher_all_nodes_cordoned(t *testing.T) {
+	node := testNode("a", "zone-a", true)
+	node.Spec.Unschedulable = true
+	w, _ := testWatcher(node)
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.False(t, w.PoolReady())
+	assert.Empty(t, w.OutageZones())
+}
+
+func Test_Watcher_Taints(t *testing.T) {
+	a := testNode("a", "zone-a", true)
+	a.Spec.Taints = []corev1.Taint{
+		{Key: "dedicated", Value: "kyma", Effect: corev1.TaintEffectNoSchedule},
+	}
+	b := testNode("b", "zone-b", true)
+	b.Spec.Taints = []corev1.Taint{
+		{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+		{Key: "dedicated", Value: "kyma", Effect: corev1.TaintEffectNoSchedule, TimeAdded: &metav1.Time{}},
+	}
+	other := testNode("c", "zone-a", true)
+	other.Labels["worker.gardener.cloud/pool"] = "other"
+	other.Spec.Taints = []corev1.Taint{{Key: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	w, _ := testWatcher(a, b, other)
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+
+	// the taints of other pools are ignored, duplicates are merged
+	assert.Equal(t, []corev1.Taint{
+		{Key: "dedicated", Value: "kyma", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+	}, w.Taints())
+}
@@ -0,0 +1,60 @@
+package pool
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Snapshot is the complete view of the watcher on the Kyma worker pool.
+type Snapshot struct {
+	// ConfiguredPool is the Kyma worker pool of the configuration
+	ConfiguredPool string `json:"configuredPool"`
+	// ActivePool is the pool the node affinity is injected for, it differs from
+	// the configured one if the pool was recreated under a new name
+	ActivePool string `json:"activePool"`
+	// Ready is false while the pool has no ready and schedulable nodes, the
+	// node affinity is not injected then
+	Ready bool                 `json:"ready"`
+	Nodes []Node               `json:"nodes"`
+	Zones map[string]ZoneState `json:"zones"`
+	// OutageZones are excluded from the injected node affinity
+	OutageZones []string `json:"outageZones,omitempty"`
+	// Allocatable are the resources of the available nodes
+	Allocatable corev1.ResourceList `json:"allocatable"`
+	// Taints are the distinct taints of the nodes
+	Taints []corev1.Taint `json:"taints,omitempty"`
+	Health
+}
+
+// Snapshot returns the current view of the watcher on the Kyma worker pool,
+// all values are taken at the same time.
+func (w *Watcher) Snapshot() Snapshot {
+	configured := ""
+	if w.Config != nil {
+		configured = w.Config().KymaWorkerPoolName
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return Snapshot{
+		ConfiguredPool: configured,
+		ActivePool:     w.activePool,
+		Ready:          !w.synced || hasAvailable(w.nodes),
+		Nodes:          slices.Clone(w.nodes),
+		Zones:          maps.Clone(w.zones),
+		OutageZones:    slices.Clone(w.outage),
+		Allocatable:    w.allocatable(),
+		Taints:         buildTaints(w.nodes),
+		Health:         w.health(),
+	}
+}
+
+// SnapshotHandler serves the view of the watcher on the Kyma worker pool as JSON.
+func SnapshotHandler(w *Watcher) http.Handler {
+	return jsonHandler(func() any {
+		return w.Snapshot()
+	})
+}
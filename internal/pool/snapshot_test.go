@@ -0,0 +1,52 @@
+package pool_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func Test_Watcher_Snapshot(t *testing.T) {
+	w, _ := testWatcher(
+		withAllocatable(testNode("a", "zone-a", true), "2", "4Gi"),
+		withAllocatable(testNode("b", "zone-b", false), "2", "4Gi"),
+	)
+
+	// the pool is considered ready until the nodes were listed
+	snapshot := w.Snapshot()
+	assert.True(t, snapshot.Ready)
+	assert.False(t, snapshot.Synced)
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+
+	snapshot = w.Snapshot()
+	assert.Equal(t, testPool, snapshot.ConfiguredPool)
+	assert.Equal(t, testPool, snapshot.ActivePool)
+	assert.True(t, snapshot.Ready)
+	assert.Len(t, snapshot.Nodes, 2)
+	assert.Equal(t, []string{"zone-b"}, snapshot.OutageZones)
+	assert.Equal(t, pool.ZoneState{Nodes: 1, Ready: 1}, snapshot.Zones["zone-a"])
+	assert.Equal(t, "2", snapshot.Allocatable.Cpu().String())
+	assert.True(t, snapshot.Synced)
+	assert.Equal(t, 1, snapshot.ReadyNodes)
+
+	rec := httptest.NewRecorder()
+	pool.SnapshotHandler(w).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pool", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, testPool, body["activePool"])
+	// the health is inlined
+	assert.Equal(t, true, body["synced"])
+	assert.NotNil(t, body["lastSync"])
+	assert.Len(t, body["nodes"], 2)
+}
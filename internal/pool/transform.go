@@ -0,0 +1,21 @@
+package pool
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TrimNode is the cache transform of the nodes, it drops the fields of a node
+// kim-snatch never reads and which grow with the workloads of the node: the
+// container images, the volumes, and the managed fields. It is a
+// cache.TransformFunc.
+func TrimNode(obj any) (any, error) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return obj, nil
+	}
+	node.ManagedFields = nil
+	node.Status.Images = nil
+	node.Status.VolumesInUse = nil
+	node.Status.VolumesAttached = nil
+	return node, nil
+}
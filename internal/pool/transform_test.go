@@ -0,0 +1,41 @@
+package pool_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_TrimNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "node-1",
+			Labels:        map[string]string{"worker.gardener.cloud/pool": "kyma"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubelet"}},
+		},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule}}},
+		Status: corev1.NodeStatus{
+			Conditions:      []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Images:          []corev1.ContainerImage{{Names: []string{"europe-docker.pkg.dev/kyma-project/prod/snatch"}}},
+			VolumesInUse:    []corev1.UniqueVolumeName{"kubernetes.io/csi/pd.csi.storage.gke.io^disk-1"},
+			VolumesAttached: []corev1.AttachedVolume{{Name: "kubernetes.io/csi/pd.csi.storage.gke.io^disk-1"}},
+		},
+	}
+	expected := node.DeepCopy()
+	expected.ManagedFields = nil
+	expected.Status.Images, expected.Status.VolumesInUse, expected.Status.VolumesAttached = nil, nil, nil
+
+	trimmed, err := pool.TrimNode(node)
+	require.NoError(t, err)
+	assert.Equal(t, expected, trimmed)
+
+	// other objects are kept
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubelet"}}}}
+	kept, err := pool.TrimNode(pod)
+	require.NoError(t, err)
+	assert.Same(t, pod, kept)
+}
@@ -0,0 +1,386 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/breaker"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/health"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	EventReasonZoneOutage   = "ZoneOutage"
+	EventReasonZoneRecovery = "ZoneRecovered"
+	EventReasonPoolRenamed  = "PoolRenamed"
+	EventReasonPoolNotReady = "PoolNotReady"
+	EventReasonPoolReady    = "PoolReady"
+
+	// AnnotationPredecessor on the nodes of a worker pool names the pool it replaces
+	AnnotationPredecessor = "kim-snatch.kyma-project.io/predecessor-pool"
+)
+
+// PoolChangeFunc is called when the watcher switches to a successor pool.
+type PoolChangeFunc = func(ctx context.Context, previous, current string)
+
+// PoolPresenceFunc is called when the Kyma worker pool loses its last node or
+// gets its first node again.
+type PoolPresenceFunc = func(ctx context.Context, present bool)
+
+// poolRequest is the only request the watcher works on, every node event
+// results in the complete state of the pool being computed again.
+var poolRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "kyma-worker-pool"}}
+
+// ZoneState counts the nodes of the Kyma worker pool in a zone.
+type ZoneState struct {
+	Nodes int `json:"nodes"`
+	Ready int `json:"ready"`
+	// Cordoned counts the ready nodes that are marked unschedulable
+	Cordoned int `json:"cordoned"`
+}
+
+// available returns the number of nodes new pods can be scheduled on.
+func (s ZoneState) available() int {
+	return s.Ready - s.Cordoned
+}
+
+// Watcher tracks the nodes of the Kyma worker pool, their readiness and
+// allocatable resources.
+type Watcher struct {
+	Reader   client.Reader
+	Config   func() config.Config
+	Metrics  metrics.Metrics
+	Recorder record.EventRecorder
+
+	// EventTarget is the object events are recorded for, events are not
+	// recorded if not set
+	EventTarget *corev1.ObjectReference
+	// OnPoolChange is called when the configured pool was recreated under a
+	// new name, e.g. to move existing workloads
+	OnPoolChange []PoolChangeFunc
+	// OnPoolPresence is called when the Kyma worker pool disappears entirely or
+	// returns, e.g. while it is recreated
+	OnPoolPresence []PoolPresenceFunc
+	// Subsystem tracks whether the nodes could be listed, optional
+	Subsystem *health.Subsystem
+	// Breaker stops the admission requests from using the state of the pool
+	// while the nodes can't be listed, optional
+	Breaker *breaker.Breaker
+
+	mu         sync.RWMutex
+	pools      []Pool
+	nodes      []Node
+	zones      map[string]ZoneState
+	outage     []string
+	activePool string
+	synced     bool
+	// membership maps the names of the nodes to their worker pools
+	membership map[string]string
+	lastChange time.Time
+	lastSync   time.Time
+}
+
+func (w *Watcher) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	cfg := w.Config()
+
+	var nodes corev1.NodeList
+	if err := w.Reader.List(ctx, &nodes, client.HasLabels{cfg.PoolLabelKey}); err != nil {
+		err = fmt.Errorf("unable to list nodes of worker pools: %w", err)
+		w.Subsystem.Report(err)
+		w.Breaker.Report(err)
+		return ctrl.Result{}, err
+	}
+	w.Subsystem.Report(nil)
+	w.Breaker.Report(nil)
+
+	pools := buildPools(nodes.Items, cfg.PoolLabelKey)
+	activePool := resolvePool(cfg, nodes.Items)
+	allNodes := slices.Clone(nodes.Items)
+	nodes.Items = slices.DeleteFunc(nodes.Items, func(node corev1.Node) bool {
+		return node.Labels[cfg.PoolLabelKey] != activePool
+	})
+
+	zones := map[string]ZoneState{}
+	for _, node := range nodes.Items {
+		zone := node.Labels[corev1.LabelTopologyZone]
+		state := zones[zone]
+		state.Nodes++
+		if isReady(&node) {
+			state.Ready++
+			if node.Spec.Unschedulable {
+				state.Cordoned++
+			}
+		}
+		zones[zone] = state
+	}
+
+	outage := outageZones(zones)
+	members := buildNodes(nodes.Items)
+
+	ready := hasAvailable(members)
+
+	membership := buildMembership(allNodes, cfg.PoolLabelKey)
+	now := time.Now()
+
+	w.mu.Lock()
+	previous, previousPool := w.outage, w.activePool
+	previousReady := !w.synced || hasAvailable(w.nodes)
+	previousPresent := !w.synced || len(w.nodes) > 0
+	w.pools, w.nodes, w.zones, w.outage, w.activePool = pools, members, zones, outage, activePool
+	w.synced = true
+	changed := !maps.Equal(w.membership, membership)
+	if changed {
+		w.membership, w.lastChange = membership, now
+	}
+	w.lastSync = now
+	w.mu.Unlock()
+
+	if changed {
+		logger.V(1).Info("worker pool membership changed", "nodes", len(membership))
+	}
+
+	if w.Metrics != nil {
+		for state, count := range countStates(members) {
+			w.Metrics.SetPoolNodes(state, count)
+		}
+	}
+
+	if activePool != cfg.KymaWorkerPoolName && activePool != previousPool {
+		logger.Info("kyma worker pool was recreated", "pool", cfg.KymaWorkerPoolName, "successor", activePool)
+		w.event(corev1.EventTypeWarning, EventReasonPoolRenamed,
+			fmt.Sprintf("kyma worker pool %s has no nodes, using its successor %s",
+				cfg.KymaWorkerPoolName, activePool))
+		for _, onChange := range w.OnPoolChange {
+			onChange(ctx, cfg.KymaWorkerPoolName, activePool)
+		}
+	}
+
+	if present := len(members) > 0; present != previousPresent {
+		logger.Info("kyma worker pool presence changed", "pool", activePool, "present", present)
+		for _, onPresence := range w.OnPoolPresence {
+			onPresence(ctx, present)
+		}
+	}
+
+	if ready != previousReady {
+		if ready {
+			logger.Info("kyma worker pool has schedulable nodes", "pool", activePool)
+			w.event(corev1.EventTypeNormal, EventReasonPoolReady,
+				fmt.Sprintf("kyma worker pool %s has schedulable nodes, node affinity is injected again", activePool))
+		} else {
+			logger.Info("kyma worker pool has no schedulable nodes", "pool", activePool)
+			w.event(corev1.EventTypeWarning, EventReasonPoolNotReady,
+				fmt.Sprintf("kyma worker pool %s has no ready and schedulable nodes, node affinity is not injected",
+					activePool))
+		}
+	}
+
+	for _, zone := range outage {
+		if !slices.Contains(previous, zone) {
+			logger.Info("zone outage detected", "zone", zone, "pool", cfg.KymaWorkerPoolName)
+			w.event(corev1.EventTypeWarning, EventReasonZoneOutage,
+				fmt.Sprintf("all nodes of kyma worker pool %s in zone %s are not ready or cordoned, the zone is avoided",
+					cfg.KymaWorkerPoolName, zone))
+		}
+	}
+	for _, zone := range previous {
+		if !slices.Contains(outage, zone) {
+			logger.Info("zone recovered", "zone", zone, "pool", cfg.KymaWorkerPoolName)
+			w.event(corev1.EventTypeNormal, EventReasonZoneRecovery,
+				fmt.Sprintf("kyma worker pool %s in zone %s recovered", cfg.KymaWorkerPoolName, zone))
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// resolvePool returns the configured pool, or its successor if the configured
+// pool has no nodes. The successor is taken from the configuration or from the
+// predecessor annotation of the nodes.
+func resolvePool(cfg config.Config, nodes []corev1.Node) string {
+	count := map[string]int{}
+	annotated := ""
+	for _, node := range nodes {
+		pool := node.Labels[cfg.PoolLabelKey]
+		count[pool]++
+		if node.Annotations[AnnotationPredecessor] == cfg.KymaWorkerPoolName {
+			annotated = pool
+		}
+	}
+
+	if count[cfg.KymaWorkerPoolName] > 0 {
+		return cfg.KymaWorkerPoolName
+	}
+	if successor, ok := cfg.PoolSuccessors[cfg.KymaWorkerPoolName]; ok && count[successor] > 0 {
+		return successor
+	}
+	if annotated != "" {
+		return annotated
+	}
+	return cfg.KymaWorkerPoolName
+}
+
+// outageZones returns the sorted zones without a single available node, ready
+// nodes that are cordoned don't take new pods either. Zones are
+// only reported as long as at least one zone has ready nodes, so that pods
+// are never excluded from the complete pool.
+func outageZones(zones map[string]ZoneState) []string {
+	var outage []string
+	healthy := false
+	for zone, state := range zones {
+		if state.available() == 0 {
+			if zone != "" {
+				outage = append(outage, zone)
+			}
+			continue
+		}
+		healthy = true
+	}
+
+	if !healthy {
+		return nil
+	}
+	slices.Sort(outage)
+	return outage
+}
+
+func isReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (w *Watcher) event(eventType, reason, message string) {
+	if w.Recorder != nil && w.EventTarget != nil {
+		w.Recorder.Event(w.EventTarget, eventType, reason, message)
+	}
+}
+
+// Zones returns the state of the Kyma worker pool per zone.
+func (w *Watcher) Zones() map[string]ZoneState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return maps.Clone(w.zones)
+}
+
+// ActivePool returns the worker pool the Kyma components are scheduled on,
+// it differs from the configured one if the pool was recreated under a new name.
+func (w *Watcher) ActivePool() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.activePool
+}
+
+// PoolReady returns false if the Kyma worker pool has no ready nodes that are
+// schedulable. It is true until the nodes were listed for the first time.
+func (w *Watcher) PoolReady() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return !w.synced || hasAvailable(w.nodes)
+}
+
+// Synced returns true once the nodes were listed for the first time.
+func (w *Watcher) Synced() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.synced
+}
+
+// PoolPresent returns false if the Kyma worker pool and its successors have no
+// nodes at all. It is true until the nodes were listed for the first time.
+func (w *Watcher) PoolPresent() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return !w.synced || len(w.nodes) > 0
+}
+
+// OutageZones returns the zones all nodes of the Kyma worker pool are not ready
+// or cordoned in.
+func (w *Watcher) OutageZones() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Clone(w.outage)
+}
+
+// LastChange returns the time the worker pool of a node changed last, a node
+// gained or lost the pool label, or was added or removed.
+func (w *Watcher) LastChange() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastChange
+}
+
+// LastSync returns the time the nodes were listed last.
+func (w *Watcher) LastSync() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastSync
+}
+
+// SetupWithManager sets up the watcher with the Manager. The watcher runs on
+// every replica, as every replica serves admission requests. The nodes are
+// listed once the cache synced even if no node of a pool exists, so the
+// watcher is synced without a node event.
+func (w *Watcher) SetupWithManager(mgr ctrl.Manager) error {
+	initial := source.Func(func(_ context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+		queue.Add(poolRequest)
+		return nil
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pool").
+		WithOptions(controller.Options{NeedLeaderElection: ptr.To(false)}).
+		WatchesRawSource(initial).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(
+			func(context.Context, client.Object) []reconcile.Request {
+				return []reconcile.Request{poolRequest}
+			}), builder.WithPredicates(nodeChanged)).
+		Complete(w)
+}
+
+// nodeChanged filters the periodic status updates of the nodes, changes of the
+// pool label, the readiness, cordon state, taints and allocatable resources,
+// created and deleted nodes are processed immediately.
+var nodeChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		return !maps.Equal(oldNode.Labels, newNode.Labels) ||
+			oldNode.Annotations[AnnotationPredecessor] != newNode.Annotations[AnnotationPredecessor] ||
+			isReady(oldNode) != isReady(newNode) ||
+			oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+			!equality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) ||
+			!equality.Semantic.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable)
+	},
+}
@@ -0,0 +1,148 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testPool = "kyma-pool"
+
+func testNode(name, zone string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				config.DefaultPoolLabelKey: testPool,
+				corev1.LabelTopologyZone:   zone,
+			},
+		},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: status},
+		}},
+	}
+}
+
+func testWatcher(objs ...client.Object) (*pool.Watcher, *record.FakeRecorder) {
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = testPool
+	recorder := record.NewFakeRecorder(10)
+
+	return &pool.Watcher{
+		Reader:      fake.NewClientBuilder().WithObjects(objs...).Build(),
+		Config:      func() config.Config { return cfg },
+		Recorder:    recorder,
+		EventTarget: &corev1.ObjectReference{Kind: "Pod", Namespace: "kyma-system", Name: "kim-snatch"},
+	}, recorder
+}
+
+func Test_Watcher_zone_outage(t *testing.T) {
+	w, recorder := testWatcher(
+		testNode("a", "zone-a", true),
+		testNode("b", "zone-b", false),
+		testNode("c", "zone-b", false),
+	)
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zone-b"}, w.OutageZones())
+	assert.Equal(t, map[string]pool.ZoneState{
+		"zone-a": {Nodes: 1, Ready: 1},
+		"zone-b": {Nodes: 2, Ready: 0},
+	}, w.Zones())
+	assert.Len(t, recorder.Events, 1)
+}
+
+func Test_Watcher_no_outage_if_all_zones_are_down(t *testing.T) {
+	w, recorder := testWatcher(
+		testNode("a", "zone-a", false),
+		testNode("b", "zone-b", false),
+	)
+
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+
+	require.NoError(t, err)
+	assert.Empty(t, w.OutageZones())
+	// no zone outage is reported, the whole pool is not ready
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, pool.EventReasonPoolNotReady)
+	assert.False(t, w.PoolReady())
+}
+
+func Test_Watcher_pool_successor(t *testing.T) {
+	successor := testNode("new", "zone-a", true)
+	successor.Labels[config.DefaultPoolLabelKey] = "kyma-pool-v2"
+
+	annotated := successor.DeepCopy()
+	annotated.Annotations = map[string]string{pool.AnnotationPredecessor: testPool}
+
+	for name, tc := range map[string]struct {
+		node       *corev1.Node
+		successors map[string]string
+	}{
+		"configured": {node: successor, successors: map[string]string{testPool: "kyma-pool-v2"}},
+		"annotated":  {node: annotated},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, recorder := testWatcher(tc.node)
+			cfg := w.Config()
+			cfg.PoolSuccessors = tc.successors
+			w.Config = func() config.Config { return cfg }
+
+			var changed []string
+			w.OnPoolChange = []pool.PoolChangeFunc{func(_ context.Context, previous, current string) {
+				changed = []string{previous, current}
+			}}
+
+			_, err := w.Reconcile(context.Background(), ctrl.Request{})
+
+			require.NoError(t, err)
+			assert.Equal(t, "kyma-pool-v2", w.ActivePool())
+			assert.Equal(t, []string{testPool, "kyma-pool-v2"}, changed)
+			assert.Len(t, recorder.Events, 1)
+		})
+	}
+}
+
+func Test_Watcher_pool_presence(t *testing.T) {
+	node := testNode("a", "zone-a", true)
+	w, _ := testWatcher(node)
+
+	var presence []bool
+	w.OnPoolPresence = []pool.PoolPresenceFunc{func(_ context.Context, present bool) {
+		presence = append(presence, present)
+	}}
+
+	// the pool is considered present until the nodes were listed
+	assert.True(t, w.PoolPresent())
+	_, err := w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.True(t, w.PoolPresent())
+	assert.Empty(t, presence)
+
+	require.NoError(t, w.Reader.(client.Client).Delete(context.Background(), node))
+	_, err = w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.False(t, w.PoolPresent())
+
+	require.NoError(t, w.Reader.(client.Client).Create(context.Background(), testNode("b", "zone-a", true)))
+	_, err = w.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.True(t, w.PoolPresent())
+	assert.Equal(t, []bool{false, true}, presence)
+}
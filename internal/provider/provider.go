@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Names of the supported providers.
+const (
+	Gardener = "gardener"
+	GKE      = "gke"
+	AKS      = "aks"
+	EKS      = "eks"
+	// Generic relies on an arbitrary node label, the label key must be configured
+	Generic = "generic"
+)
+
+// Provider describes how the nodes of a distribution are grouped into worker pools.
+type Provider interface {
+	// Name of the provider
+	Name() string
+	// PoolLabelKey is the key of the node label holding the name of the worker
+	// pool, empty if it must be configured
+	PoolLabelKey() string
+	// PoolName returns the worker pool of the node
+	PoolName(node *corev1.Node, labelKey string) (string, bool)
+}
+
+type labelProvider struct {
+	name     string
+	labelKey string
+}
+
+func (p labelProvider) Name() string {
+	return p.name
+}
+
+func (p labelProvider) PoolLabelKey() string {
+	return p.labelKey
+}
+
+func (p labelProvider) PoolName(node *corev1.Node, labelKey string) (string, bool) {
+	if labelKey == "" {
+		labelKey = p.labelKey
+	}
+	pool, ok := node.Labels[labelKey]
+	return pool, ok
+}
+
+var providers = map[string]Provider{
+	Gardener: labelProvider{name: Gardener, labelKey: "worker.gardener.cloud/pool"},
+	GKE:      labelProvider{name: GKE, labelKey: "cloud.google.com/gke-nodepool"},
+	AKS:      labelProvider{name: AKS, labelKey: "kubernetes.azure.com/agentpool"},
+	EKS:      labelProvider{name: EKS, labelKey: "eks.amazonaws.com/nodegroup"},
+	Generic:  labelProvider{name: Generic},
+}
+
+// Get returns the provider of the given name.
+func Get(name string) (Provider, error) {
+	p, ok := providers[strings.TrimSpace(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, must be one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return p, nil
+}
+
+// Names returns the sorted names of the supported providers.
+func Names() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
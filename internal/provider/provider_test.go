@@ -0,0 +1,35 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Provider_PoolName(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		"cloud.google.com/gke-nodepool": "default-pool",
+		"example.com/pool":              "custom",
+	}}}
+
+	gke, err := provider.Get(provider.GKE)
+	require.NoError(t, err)
+	pool, ok := gke.PoolName(node, "")
+	assert.True(t, ok)
+	assert.Equal(t, "default-pool", pool)
+
+	generic, err := provider.Get(provider.Generic)
+	require.NoError(t, err)
+	pool, ok = generic.PoolName(node, "example.com/pool")
+	assert.True(t, ok)
+	assert.Equal(t, "custom", pool)
+}
+
+func Test_Get_unknown(t *testing.T) {
+	_, err := provider.Get("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,11 @@
+// Package certificate holds the RBAC markers of the certificate-role, the
+// permissions to provision the serving certificate and to publish its CA. The
+// certificate Secret is read and written in the namespace of kim-snatch only.
+package certificate
+
+//+kubebuilder:rbac:groups="",namespace=system,resources=secrets,resourceNames=kim-snatch-certificates,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups="",namespace=system,resources=secrets,verbs=create
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;patch
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;create;patch
+//+kubebuilder:rbac:groups=cert.gardener.cloud,resources=certificates,verbs=get;create;patch
@@ -0,0 +1,17 @@
+// Package manager holds the RBAC markers of the manager-role, the permissions
+// to load the configuration and to run the controllers that aren't part of
+// another subsystem. The Secrets are read in the namespace of kim-snatch only.
+package manager
+
+//+kubebuilder:rbac:groups="",resources=configmaps;pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",namespace=system,resources=secrets,resourceNames=kim-snatch-secrets;kim-snatch-certificates;kim-snatch-audit-signing,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods/eviction,verbs=create
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=list;watch
+//+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;patch
+//+kubebuilder:rbac:groups=kim-snatch.kyma-project.io,resources=snatchconfigs,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups=kim-snatch.kyma-project.io,resources=snatchconfigs/status,verbs=get;patch;update
+//+kubebuilder:rbac:groups=infrastructuremanager.kyma-project.io,resources=runtimes,verbs=get
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=create;patch
+//+kubebuilder:rbac:groups=operator.kyma-project.io,resources=kymas,verbs=get;list;watch
@@ -0,0 +1,7 @@
+// Package nodes holds the RBAC markers of the nodes-role, the permissions to
+// watch the nodes of the worker pools.
+package nodes
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
@@ -0,0 +1,194 @@
+// Package rbac holds the permissions kim-snatch needs per subsystem. Each
+// subsystem has a ClusterRole of its own, and a Role in the namespace of
+// kim-snatch for the Secrets, generated from the kubebuilder markers of its
+// subpackage, e.g. internal/rbac/webhook. Rules mirrors the markers, so the
+// permissions are verified on startup.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Names of the subsystems, each has a ClusterRole <subsystem>-role.
+const (
+	// SubsystemManager loads the configuration and runs the controllers that
+	// aren't part of another subsystem
+	SubsystemManager = "manager"
+	// SubsystemWebhook serves the admission requests and manages the webhooks
+	SubsystemWebhook = "webhook"
+	// SubsystemCertificate provisions the serving certificate and publishes its CA
+	SubsystemCertificate = "certificate"
+	// SubsystemNodes watches the nodes of the worker pools
+	SubsystemNodes = "nodes"
+	// SubsystemRemediation patches and restarts the workloads of the Kyma
+	// namespaces, only with --remediate-workloads or --rollout-on-config-change
+	SubsystemRemediation = "remediation"
+)
+
+// Names of the Secrets of kim-snatch, the Roles grant access to these only.
+const (
+	secretConfig       = "kim-snatch-secrets"
+	secretCertificates = "kim-snatch-certificates"
+	secretAuditSigning = "kim-snatch-audit-signing"
+)
+
+// Rule grants the verbs on the resources of an API group, like a
+// kubebuilder:rbac marker.
+type Rule struct {
+	Group     string
+	Resources []string
+	Verbs     []string
+	// Namespaced rules are granted by the Role in the namespace of kim-snatch
+	Namespaced bool
+	// ResourceNames restrict the rule to the named objects, optional
+	ResourceNames []string
+}
+
+// Rules are the rules of the ClusterRole of each subsystem.
+var Rules = map[string][]Rule{
+	SubsystemManager: {
+		{Group: "", Resources: []string{"configmaps", "pods"}, Verbs: []string{"get", "list", "watch"}},
+		{Group: "", Resources: []string{"secrets"}, Verbs: []string{"get", "list", "watch"}, Namespaced: true,
+			ResourceNames: []string{secretConfig, secretCertificates, secretAuditSigning}},
+		{Group: "", Resources: []string{"pods/eviction"}, Verbs: []string{"create"}},
+		{Group: "", Resources: []string{"events"}, Verbs: []string{"create", "patch"}},
+		{Group: "admissionregistration.k8s.io", Resources: []string{"mutatingwebhookconfigurations"},
+			Verbs: []string{"list", "watch"}},
+		{Group: "apps", Resources: []string{"replicasets"}, Verbs: []string{"get"}},
+		{Group: "apps", Resources: []string{"deployments"}, Verbs: []string{"get", "patch"}},
+		{Group: "kim-snatch.kyma-project.io", Resources: []string{"snatchconfigs"},
+			Verbs: []string{"get", "list", "watch", "create", "patch"}},
+		{Group: "kim-snatch.kyma-project.io", Resources: []string{"snatchconfigs/status"},
+			Verbs: []string{"get", "patch", "update"}},
+		{Group: "infrastructuremanager.kyma-project.io", Resources: []string{"runtimes"}, Verbs: []string{"get"}},
+		{Group: "networking.k8s.io", Resources: []string{"networkpolicies"}, Verbs: []string{"create", "patch"}},
+		{Group: "operator.kyma-project.io", Resources: []string{"kymas"}, Verbs: []string{"get", "list", "watch"}},
+	},
+	SubsystemWebhook: {
+		{Group: "", Resources: []string{"namespaces"}, Verbs: []string{"get", "list", "watch"}},
+		{Group: "", Resources: []string{"services", "pods"}, Verbs: []string{"get"}},
+		{Group: "", Resources: []string{"events"}, Verbs: []string{"create", "patch"}},
+		{Group: "admissionregistration.k8s.io", Resources: []string{"mutatingwebhookconfigurations"},
+			Verbs: []string{"get", "patch"}},
+		{Group: "apps", Resources: []string{"deployments", "replicasets"}, Verbs: []string{"get"}},
+	},
+	SubsystemCertificate: {
+		{Group: "", Resources: []string{"secrets"}, Verbs: []string{"get", "list", "watch", "update"}, Namespaced: true,
+			ResourceNames: []string{secretCertificates}},
+		// the name of a created object isn't known to the authorizer
+		{Group: "", Resources: []string{"secrets"}, Verbs: []string{"create"}, Namespaced: true},
+		{Group: "admissionregistration.k8s.io", Resources: []string{"mutatingwebhookconfigurations"},
+			Verbs: []string{"get", "list", "watch", "patch"}},
+		{Group: "apiextensions.k8s.io", Resources: []string{"customresourcedefinitions"},
+			Verbs: []string{"get", "patch"}},
+		{Group: "cert-manager.io", Resources: []string{"certificates"}, Verbs: []string{"get", "create", "patch"}},
+		{Group: "cert.gardener.cloud", Resources: []string{"certificates"}, Verbs: []string{"get", "create", "patch"}},
+	},
+	SubsystemNodes: {
+		{Group: "", Resources: []string{"nodes"}, Verbs: []string{"get", "list", "watch"}},
+		{Group: "", Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+		{Group: "", Resources: []string{"events"}, Verbs: []string{"create", "patch"}},
+	},
+	SubsystemRemediation: {
+		{Group: "apps", Resources: []string{"deployments", "statefulsets"},
+			Verbs: []string{"get", "list", "watch", "patch"}},
+	},
+}
+
+// Permission is a verb on a resource, the subresource is separated by a slash.
+// Namespaced permissions are granted in the namespace of kim-snatch only, and
+// for the object of the Name only if it is set.
+type Permission struct {
+	Group      string
+	Resource   string
+	Verb       string
+	Namespaced bool
+	Name       string
+}
+
+func (p Permission) String() string {
+	resource, subresource, ok := strings.Cut(p.Resource, "/")
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	if ok {
+		resource += "/" + subresource
+	}
+	if p.Name != "" {
+		resource += " " + p.Name
+	}
+	return p.Verb + " " + resource
+}
+
+// Permissions returns the permissions the rules of the subsystem grant.
+func Permissions(subsystem string) []Permission {
+	var permissions []Permission
+	for _, rule := range Rules[subsystem] {
+		names := rule.ResourceNames
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, resource := range rule.Resources {
+			for _, verb := range rule.Verbs {
+				for _, name := range names {
+					permissions = append(permissions, Permission{Group: rule.Group, Resource: resource, Verb: verb,
+						Namespaced: rule.Namespaced, Name: name})
+				}
+			}
+		}
+	}
+	return permissions
+}
+
+// Missing returns the permissions of the subsystems kim-snatch lacks, per
+// subsystem. Every permission is reviewed once with a SelfSubjectAccessReview
+// for all namespaces, as granted by the ClusterRoleBindings, or for the
+// namespace of kim-snatch, as granted by the RoleBindings.
+func Missing(ctx context.Context, c client.Client, namespace string, subsystems ...string) (
+	map[string][]Permission, error) {
+	allowed := map[Permission]bool{}
+	missing := map[string][]Permission{}
+	for _, subsystem := range subsystems {
+		for _, permission := range Permissions(subsystem) {
+			ok, reviewed := allowed[permission]
+			if !reviewed {
+				var err error
+				if ok, err = review(ctx, c, namespace, permission); err != nil {
+					return nil, fmt.Errorf("unable to review permission %s: %w", permission, err)
+				}
+				allowed[permission] = ok
+			}
+			if !ok {
+				missing[subsystem] = append(missing[subsystem], permission)
+			}
+		}
+	}
+	return missing, nil
+}
+
+func review(ctx context.Context, c client.Client, namespace string, permission Permission) (bool, error) {
+	resource, subresource, _ := strings.Cut(permission.Resource, "/")
+	accessReview := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       permission.Group,
+				Resource:    resource,
+				Subresource: subresource,
+				Verb:        permission.Verb,
+				Name:        permission.Name,
+			},
+		},
+	}
+	if permission.Namespaced {
+		accessReview.Spec.ResourceAttributes.Namespace = namespace
+	}
+	if err := c.Create(ctx, accessReview); err != nil {
+		return false, err
+	}
+	return accessReview.Status.Allowed, nil
+}
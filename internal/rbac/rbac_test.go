@@ -0,0 +1,122 @@
+package rbac_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var markerPattern = regexp.MustCompile(
+	`(?m)^//\+kubebuilder:rbac:groups=([^,]*),(namespace=[^,]*,)?resources=([^,]*),(?:resourceNames=([^,]*),)?verbs=(\S+)$`)
+
+// the ClusterRoles are generated from the markers, the startup check reviews
+// the rules, both must grant the same permissions
+func Test_Rules_match_markers(t *testing.T) {
+	for subsystem := range rbac.Rules {
+		t.Run(subsystem, func(t *testing.T) {
+			source, err := os.ReadFile(filepath.Join(subsystem, "rbac.go"))
+			require.NoError(t, err)
+
+			var marked []rbac.Permission
+			for _, marker := range markerPattern.FindAllStringSubmatch(string(source), -1) {
+				group := strings.Trim(marker[1], `"`)
+				namespaced := marker[2] != ""
+				for _, resource := range strings.Split(marker[3], ";") {
+					for _, verb := range strings.Split(marker[5], ";") {
+						for _, name := range strings.Split(marker[4], ";") {
+							marked = append(marked, rbac.Permission{Group: group, Resource: resource, Verb: verb,
+								Namespaced: namespaced, Name: name})
+						}
+					}
+				}
+			}
+			assert.ElementsMatch(t, marked, rbac.Permissions(subsystem))
+		})
+	}
+}
+
+func Test_Permission_String(t *testing.T) {
+	assert.Equal(t, "create pods/eviction", rbac.Permission{Resource: "pods/eviction", Verb: "create"}.String())
+	assert.Equal(t, "patch snatchconfigs.kim-snatch.kyma-project.io/status", rbac.Permission{
+		Group: "kim-snatch.kyma-project.io", Resource: "snatchconfigs/status", Verb: "patch",
+	}.String())
+	assert.Equal(t, "get secrets kim-snatch-secrets", rbac.Permission{
+		Resource: "secrets", Verb: "get", Namespaced: true, Name: "kim-snatch-secrets",
+	}.String())
+}
+
+func Test_Missing(t *testing.T) {
+	var reviews int
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			reviews++
+			review := obj.(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = attributes.Verb != "patch" || attributes.Resource != "mutatingwebhookconfigurations"
+			return nil
+		},
+	}).Build()
+
+	missing, err := rbac.Missing(context.Background(), c, "kyma-system", rbac.SubsystemWebhook,
+		rbac.SubsystemCertificate, rbac.SubsystemRemediation)
+	require.NoError(t, err)
+	patch := rbac.Permission{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations", Verb: "patch"}
+	assert.Equal(t, map[string][]rbac.Permission{
+		rbac.SubsystemWebhook:     {patch},
+		rbac.SubsystemCertificate: {patch},
+	}, missing)
+
+	// the permissions shared by the subsystems are reviewed once
+	assert.Less(t, reviews, len(rbac.Permissions(rbac.SubsystemWebhook))+
+		len(rbac.Permissions(rbac.SubsystemCertificate))+len(rbac.Permissions(rbac.SubsystemRemediation)))
+}
+
+func Test_Missing_namespaced(t *testing.T) {
+	var secrets []authorizationv1.ResourceAttributes
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			review := obj.(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			if attributes.Resource == "secrets" {
+				secrets = append(secrets, *attributes)
+			}
+			// the Secrets are granted in the namespace of kim-snatch only
+			review.Status.Allowed = attributes.Resource != "secrets" || attributes.Namespace == "kyma-system"
+			return nil
+		},
+	}).Build()
+
+	missing, err := rbac.Missing(context.Background(), c, "kyma-system", rbac.SubsystemCertificate)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	assert.ElementsMatch(t, []authorizationv1.ResourceAttributes{
+		{Namespace: "kyma-system", Resource: "secrets", Verb: "get", Name: "kim-snatch-certificates"},
+		{Namespace: "kyma-system", Resource: "secrets", Verb: "list", Name: "kim-snatch-certificates"},
+		{Namespace: "kyma-system", Resource: "secrets", Verb: "watch", Name: "kim-snatch-certificates"},
+		{Namespace: "kyma-system", Resource: "secrets", Verb: "update", Name: "kim-snatch-certificates"},
+		{Namespace: "kyma-system", Resource: "secrets", Verb: "create"},
+	}, secrets)
+}
+
+func Test_Missing_error(t *testing.T) {
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(context.Context, client.WithWatch, client.Object, ...client.CreateOption) error {
+			return errors.New("connection refused")
+		},
+	}).Build()
+
+	_, err := rbac.Missing(context.Background(), c, "kyma-system", rbac.SubsystemNodes)
+	assert.ErrorContains(t, err, "unable to review permission get nodes")
+}
@@ -0,0 +1,6 @@
+// Package remediation holds the RBAC markers of the remediation-role, the
+// permissions to patch and restart the workloads of the Kyma namespaces. The
+// role is only needed with --remediate-workloads or --rollout-on-config-change.
+package remediation
+
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;patch
@@ -0,0 +1,9 @@
+// Package webhook holds the RBAC markers of the webhook-role, the permissions
+// to serve the admission requests and to manage the webhooks.
+package webhook
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=services;pods,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments;replicasets,verbs=get
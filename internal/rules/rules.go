@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// costLimit bounds the evaluation cost of a single expression, so that an
+// expensive rule can not stall the admission of pods.
+const costLimit = 1_000_000
+
+// Variable is the name of the variable the evaluated object is bound to.
+const Variable = "object"
+
+// Rules are compiled exclusion and inclusion rules. An object matches the
+// rules if it matches no exclusion rule and, if there are any inclusion rules,
+// at least one inclusion rule.
+type Rules struct {
+	exclude []rule
+	include []rule
+}
+
+type rule struct {
+	expression string
+	program    cel.Program
+}
+
+// Compile compiles the CEL expressions of the exclusion and inclusion rules,
+// every expression must evaluate to a bool.
+func Compile(exclude, include []string) (*Rules, error) {
+	env, err := cel.NewEnv(cel.Variable(Variable, cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+
+	var result Rules
+	var errs []error
+	for _, expr := range exclude {
+		r, err := compile(env, expr)
+		errs = append(errs, err)
+		result.exclude = append(result.exclude, r)
+	}
+	for _, expr := range include {
+		r, err := compile(env, expr)
+		errs = append(errs, err)
+		result.include = append(result.include, r)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func compile(env *cel.Env, expr string) (rule, error) {
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return rule{}, fmt.Errorf("invalid expression %q: %w", expr, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return rule{}, fmt.Errorf("expression %q must evaluate to bool, got %s", expr, ast.OutputType())
+	}
+
+	program, err := env.Program(ast, cel.CostLimit(costLimit))
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid expression %q: %w", expr, err)
+	}
+	return rule{expression: expr, program: program}, nil
+}
+
+// Match evaluates the rules against the object. It returns false together with
+// the matching expression if the object is excluded. Expressions that fail to
+// evaluate, e.g. because of a missing map key, do not match.
+func (r *Rules) Match(obj runtime.Object) (bool, string, error) {
+	if r == nil || (len(r.exclude) == 0 && len(r.include) == 0) {
+		return true, "", nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to convert object: %w", err)
+	}
+	vars := map[string]any{Variable: content}
+
+	for _, exclude := range r.exclude {
+		if exclude.eval(vars) {
+			return false, exclude.expression, nil
+		}
+	}
+
+	if len(r.include) == 0 {
+		return true, "", nil
+	}
+	for _, include := range r.include {
+		if include.eval(vars) {
+			return true, "", nil
+		}
+	}
+	return false, "no inclusion rule matched", nil
+}
+
+func (r rule) eval(vars map[string]any) bool {
+	out, _, err := r.program.Eval(vars)
+	if err != nil {
+		return false
+	}
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
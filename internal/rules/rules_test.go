@@ -0,0 +1,81 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-me", Labels: labels}}
+}
+
+func Test_Compile_invalid(t *testing.T) {
+	for name, expr := range map[string]string{
+		"syntax":   "object.metadata.labels['app'] ==",
+		"not bool": "'test'",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := rules.Compile([]string{expr}, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_Rules_Match(t *testing.T) {
+	tests := map[string]struct {
+		exclude []string
+		include []string
+		pod     *corev1.Pod
+		matched bool
+	}{
+		"no rules": {
+			pod:     testPod(nil),
+			matched: true,
+		},
+		"excluded": {
+			exclude: []string{"object.metadata.labels['app'] == 'nats'"},
+			pod:     testPod(map[string]string{"app": "nats"}),
+		},
+		"not excluded": {
+			exclude: []string{"object.metadata.labels['app'] == 'nats'"},
+			pod:     testPod(map[string]string{"app": "test"}),
+			matched: true,
+		},
+		"evaluation error does not exclude": {
+			exclude: []string{"object.metadata.labels['app'] == 'nats'"},
+			pod:     testPod(nil),
+			matched: true,
+		},
+		"included": {
+			include: []string{"object.metadata.name.startsWith('test')"},
+			pod:     testPod(nil),
+			matched: true,
+		},
+		"not included": {
+			include: []string{"object.metadata.name == 'other'"},
+			pod:     testPod(nil),
+		},
+		"exclusion wins": {
+			exclude: []string{"'app' in object.metadata.labels"},
+			include: []string{"object.metadata.name == 'test-me'"},
+			pod:     testPod(map[string]string{"app": "test"}),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := rules.Compile(tt.exclude, tt.include)
+			require.NoError(t, err)
+
+			matched, _, err := r.Match(tt.pod)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.matched, matched)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version and Revision are set on build, e.g. with
+// -ldflags "-X github.com/kyma-project/kim-snatch/internal/version.Version=1.2.3".
+var (
+	// Version of kim-snatch
+	Version = "dev"
+	// Revision is the git commit kim-snatch was built from
+	Revision = ""
+)
+
+// Info returns the version, revision and Go version kim-snatch was built with.
+// The revision is taken from the build information of the binary if it wasn't
+// set on build.
+func Info() (version, revision, goVersion string) {
+	revision = Revision
+	if info, ok := debug.ReadBuildInfo(); ok && revision == "" {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+	return Version, revision, runtime.Version()
+}
@@ -19,6 +19,8 @@ type BuildUpdateCABundleOpts struct {
 	CABundle []byte
 	// FiledManager the name of the filed manager for patch operation
 	FieldManager string
+	// RolloverGrace the CAs replaced by CABundle are kept in the webhooks, see RolloverCABundle
+	RolloverGrace time.Duration
 }
 
 // buildUpdateCABundle - builds a function that will update certificate authority
@@ -41,11 +43,14 @@ func BuildUpdateCABundle(
 		}
 
 		var updated bool
+		now := time.Now()
 		for i := 0; i < len(mWhCfg.Webhooks); i++ {
-			if bytes.Equal(opts.CABundle, mWhCfg.Webhooks[i].ClientConfig.CABundle) {
+			caBundle, _ := RolloverCABundle(mWhCfg.Webhooks[i].ClientConfig.CABundle, opts.CABundle,
+				opts.RolloverGrace, now)
+			if bytes.Equal(caBundle, mWhCfg.Webhooks[i].ClientConfig.CABundle) {
 				continue
 			}
-			mWhCfg.Webhooks[i].ClientConfig.CABundle = opts.CABundle
+			mWhCfg.Webhooks[i].ClientConfig.CABundle = caBundle
 			updated = true
 		}
 
@@ -0,0 +1,66 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type BuildUpdateConversionCABundleOpts struct {
+	// Name of the the custom resource definition to be updated
+	Name string
+	// CABundle the conversion webhook of the custom resource definition will be updated with
+	CABundle []byte
+	// RolloverGrace the CAs replaced by CABundle are kept in the conversion webhook, see RolloverCABundle
+	RolloverGrace time.Duration
+}
+
+// BuildUpdateConversionCABundle - builds a function that will update certificate authority
+// of the conversion webhook of a custom resource definition. Custom resource definitions
+// without conversion webhook are not updated.
+func BuildUpdateConversionCABundle(
+	ctx context.Context,
+	rtClient client.Client,
+	opts BuildUpdateConversionCABundleOpts) func() error {
+
+	logger := slog.Default()
+	return func() error {
+		getCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := rtClient.Get(getCtx, client.ObjectKey{Name: opts.Name}, &crd); err != nil {
+			return fmt.Errorf("unable to get custom resource definition: %w", err)
+		}
+
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter ||
+			conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil {
+			logger.Info("custom resource definition has no conversion webhook", "name", crd.Name)
+			return nil
+		}
+
+		caBundle, _ := RolloverCABundle(conversion.Webhook.ClientConfig.CABundle, opts.CABundle,
+			opts.RolloverGrace, time.Now())
+		if bytes.Equal(caBundle, conversion.Webhook.ClientConfig.CABundle) {
+			logger.Info("custom resource definition conversion webhook up to date", "name", crd.Name)
+			return nil
+		}
+
+		// merge patch only touches the caBundle, the rest of the definition is owned by the installer
+		patch := client.MergeFromWithOptions(crd.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		conversion.Webhook.ClientConfig.CABundle = caBundle
+
+		patchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		logger.Info("attempting to patch custom resource definition conversion webhook", "name", crd.Name)
+
+		return rtClient.Patch(patchCtx, &crd, patch)
+	}
+}
@@ -0,0 +1,69 @@
+package callback_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testCRD(name string, conversion *apiextensionsv1.CustomResourceConversion) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Conversion: conversion,
+		},
+	}
+}
+
+func testCRDClient(t *testing.T, crd *apiextensionsv1.CustomResourceDefinition) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(crd).
+		Build()
+}
+
+func Test_BuildUpdateConversionCABundle(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := testCRDClient(t, testCRD("test-me", &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{CABundle: []byte("test-me")},
+		},
+	}))
+
+	err := callback.BuildUpdateConversionCABundle(ctx, fakeClient, callback.BuildUpdateConversionCABundleOpts{
+		Name:     "test-me",
+		CABundle: []byte("updated"),
+	})()
+
+	require.NoError(t, err)
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "test-me"}, &crd))
+	assert.Equal(t, []byte("updated"), crd.Spec.Conversion.Webhook.ClientConfig.CABundle)
+}
+
+func Test_BuildUpdateConversionCABundle_no_webhook(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := testCRDClient(t, testCRD("test-me", &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.NoneConverter,
+	}))
+
+	err := callback.BuildUpdateConversionCABundle(ctx, fakeClient, callback.BuildUpdateConversionCABundleOpts{
+		Name:     "test-me",
+		CABundle: []byte("updated"),
+	})()
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,48 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// DefaultBackoff is the backoff of the updates of the webhook configurations,
+// the last attempt is about 3s after the first one.
+var DefaultBackoff = wait.Backoff{
+	Steps:    6,
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
+// RetryUpdate runs update, one of the functions built by this package, until
+// it succeeds or fails with an error that isn't transient, at most for the
+// steps of backoff. Every attempt reads the object again and applies it with
+// its resourceVersion, so a conflicting write of another client is resolved
+// with its changes instead of overwriting them. The error of the last attempt
+// is returned, retries stop once ctx is done.
+func RetryUpdate(ctx context.Context, backoff wait.Backoff, update func() error) error {
+	return retry.OnError(backoff, func(err error) bool {
+		return ctx.Err() == nil && IsTransient(err)
+	}, update)
+}
+
+// IsTransient returns true if the update may succeed on retry: the object
+// changed meanwhile, the API server is overloaded, timed out, or unreachable.
+func IsTransient(err error) bool {
+	return apierrors.IsConflict(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
@@ -0,0 +1,86 @@
+package callback_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	"github.com/stretchr/testify/assert"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var testBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond}
+
+func Test_RetryUpdate(t *testing.T) {
+	ctx := context.Background()
+	mWhCfg := testMWhCfg("test-me", []byte("test-me"))
+	resource := admissionregistration.Resource("mutatingwebhookconfigurations")
+
+	// the write of another client and an overloaded API server are retried
+	failures := []error{
+		apierrors.NewConflict(resource, "test-me", errors.New("the object has been modified")),
+		apierrors.NewTooManyRequests("too many requests", 1),
+	}
+	patchFake := buildPatchFake(&mWhCfg)
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(&mWhCfg).
+		WithScheme(testScheme(t)).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if len(failures) > 0 {
+					err := failures[0]
+					failures = failures[1:]
+					return err
+				}
+				return patchFake(ctx, c, obj, patch, opts...)
+			},
+		}).Build()
+
+	err := callback.RetryUpdate(ctx, testBackoff, callback.BuildUpdateCABundle(ctx, fakeClient,
+		callback.BuildUpdateCABundleOpts{Name: "test-me", CABundle: []byte("updated")}))
+
+	assert.NoError(t, err)
+	assert.Empty(t, failures)
+	assert.Equal(t, []byte("updated"), mWhCfg.Webhooks[0].ClientConfig.CABundle)
+}
+
+func Test_RetryUpdate_not_transient(t *testing.T) {
+	ctx := context.Background()
+	resource := admissionregistration.Resource("mutatingwebhookconfigurations")
+
+	var attempts int
+	err := callback.RetryUpdate(ctx, testBackoff, func() error {
+		attempts++
+		return apierrors.NewNotFound(resource, "test-me")
+	})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Equal(t, 1, attempts)
+
+	// the retries stop with the context
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	attempts = 0
+	err = callback.RetryUpdate(ctx, testBackoff, func() error {
+		attempts++
+		return apierrors.NewServiceUnavailable("unavailable")
+	})
+	assert.True(t, apierrors.IsServiceUnavailable(err))
+	assert.Equal(t, 1, attempts)
+}
+
+func Test_IsTransient(t *testing.T) {
+	assert.True(t, callback.IsTransient(apierrors.NewInternalError(errors.New("etcdserver: leader changed"))))
+	assert.True(t, callback.IsTransient(apierrors.NewTimeoutError("timeout", 1)))
+	assert.True(t, callback.IsTransient(context.DeadlineExceeded))
+	assert.False(t, callback.IsTransient(apierrors.NewForbidden(admissionregistration.Resource("mutatingwebhookconfigurations"),
+		"test-me", errors.New("forbidden"))))
+	assert.False(t, callback.IsTransient(errors.New("invalid")))
+}
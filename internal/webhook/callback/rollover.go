@@ -0,0 +1,73 @@
+package callback
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+)
+
+// RolloverCABundle returns the CA bundle to advertise instead of the advertised
+// one. The CAs of the advertised bundle replaced by a CA rotation are kept next
+// to the CAs of caBundle until the grace period after the newest CA of caBundle
+// became valid elapsed, so the API server still trusts the replicas serving the
+// old certificate until they reloaded it. until is the end of the grace
+// period, or the zero time if no CA is kept. A grace period of 0 disables the
+// rollover.
+func RolloverCABundle(advertised, caBundle []byte, grace time.Duration, now time.Time) ([]byte, time.Time) {
+	if grace <= 0 {
+		return caBundle, time.Time{}
+	}
+
+	current := parseCertificates(caBundle)
+	var validFrom time.Time
+	for _, cert := range current {
+		if cert.NotBefore.After(validFrom) {
+			validFrom = cert.NotBefore
+		}
+	}
+	until := validFrom.Add(grace)
+	if len(current) == 0 || !now.Before(until) {
+		return caBundle, time.Time{}
+	}
+
+	bundle := bytes.TrimRight(bytes.Clone(caBundle), "\n")
+	bundle = append(bundle, '\n')
+	var kept bool
+	for _, cert := range parseCertificates(advertised) {
+		if !now.Before(cert.NotAfter) || containsCertificate(current, cert) {
+			continue
+		}
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		kept = true
+	}
+	if !kept {
+		return caBundle, time.Time{}
+	}
+	return bundle, until
+}
+
+func parseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package callback_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCA(t *testing.T, serial int64, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func Test_RolloverCABundle(t *testing.T) {
+	now := time.Now()
+	oldCA := testCA(t, 1, now.Add(-24*time.Hour), now.Add(24*time.Hour))
+	newCA := testCA(t, 2, now.Add(-time.Minute), now.Add(24*time.Hour))
+
+	// the replaced CA is advertised next to the new one
+	bundle, until := callback.RolloverCABundle(oldCA, newCA, time.Hour, now)
+	assert.Equal(t, append(bytes.Clone(newCA), oldCA...), bundle)
+	assert.WithinDuration(t, now.Add(59*time.Minute), until, time.Second)
+
+	// the rolled over bundle is kept until the grace period elapsed
+	kept, _ := callback.RolloverCABundle(bundle, newCA, time.Hour, now.Add(30*time.Minute))
+	assert.Equal(t, bundle, kept)
+	dropped, until := callback.RolloverCABundle(bundle, newCA, time.Hour, now.Add(time.Hour))
+	assert.Equal(t, newCA, dropped)
+	assert.True(t, until.IsZero())
+
+	// expired CAs and disabled rollovers only advertise the new CA
+	expiredCA := testCA(t, 3, now.Add(-48*time.Hour), now.Add(-time.Hour))
+	bundle, _ = callback.RolloverCABundle(expiredCA, newCA, time.Hour, now)
+	assert.Equal(t, newCA, bundle)
+	bundle, _ = callback.RolloverCABundle(oldCA, newCA, 0, now)
+	assert.Equal(t, newCA, bundle)
+}
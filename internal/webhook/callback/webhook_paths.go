@@ -0,0 +1,88 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type BuildUpdateWebhookPathsOpts struct {
+	// Name of the the mutating webhook configuration to be updated
+	Name string
+	// Paths maps the previous paths of the webhooks to the current ones, the
+	// webhooks with other paths are left unchanged
+	Paths map[string]string
+	// FiledManager the name of the filed manager for patch operation
+	FieldManager string
+}
+
+// PreviousPaths returns the webhooks of the mutating webhook configuration
+// that are still registered with one of the previous paths.
+func PreviousPaths(mWhCfg *admissionregistration.MutatingWebhookConfiguration, paths map[string]string) []string {
+	var webhooks []string
+	for _, webhook := range mWhCfg.Webhooks {
+		if ref := webhook.ClientConfig.Service; ref != nil && ref.Path != nil {
+			if _, ok := paths[*ref.Path]; ok {
+				webhooks = append(webhooks, webhook.Name)
+			}
+		}
+	}
+	return webhooks
+}
+
+// BuildUpdateWebhookPaths - builds a function that will move the webhooks of
+// the mutating webhook configuration from their previous paths to the current ones
+func BuildUpdateWebhookPaths(
+	ctx context.Context,
+	rtClient client.Client,
+	opts BuildUpdateWebhookPathsOpts) func() error {
+
+	logger := slog.Default()
+	return func() error {
+		getCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		var mWhCfg admissionregistration.MutatingWebhookConfiguration
+		if err := rtClient.Get(
+			getCtx,
+			client.ObjectKey{Name: opts.Name},
+			&mWhCfg); err != nil {
+			return fmt.Errorf("unable to get mutating webhook configuration: %w", err)
+		}
+
+		if len(PreviousPaths(&mWhCfg, opts.Paths)) == 0 {
+			logger.Info("mutating webhook configuration paths up to date")
+			return nil
+		}
+		for i := 0; i < len(mWhCfg.Webhooks); i++ {
+			ref := mWhCfg.Webhooks[i].ClientConfig.Service
+			if ref == nil || ref.Path == nil {
+				continue
+			}
+			if path, ok := opts.Paths[*ref.Path]; ok {
+				ref.Path = ptr.To(path)
+			}
+		}
+
+		mWhCfg.Kind = "MutatingWebhookConfiguration"
+		mWhCfg.APIVersion = "admissionregistration.k8s.io/v1"
+		mWhCfg.ManagedFields = nil
+
+		patchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		logger.Info("attempting to patch mutating webhook configuration paths",
+			"name", mWhCfg.Name,
+			"paths", opts.Paths)
+
+		return rtClient.Patch(patchCtx, &mWhCfg, client.Apply, &client.PatchOptions{
+			FieldManager: opts.FieldManager,
+			Force:        ptr.To(true),
+		})
+	}
+}
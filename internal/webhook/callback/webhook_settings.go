@@ -0,0 +1,79 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type BuildUpdateWebhookSettingsOpts struct {
+	// Name of the the mutating webhook configuration to be updated
+	Name string
+	// FailurePolicy the mutating webhook configuration webhooks will be updated with
+	FailurePolicy admissionregistration.FailurePolicyType
+	// TimeoutSeconds the mutating webhook configuration webhooks will be updated with
+	TimeoutSeconds int32
+	// FiledManager the name of the filed manager for patch operation
+	FieldManager string
+}
+
+// BuildUpdateWebhookSettings - builds a function that will update failure policy and
+// timeout of all webhooks of the mutating webhook configuration
+func BuildUpdateWebhookSettings(
+	ctx context.Context,
+	rtClient client.Client,
+	opts BuildUpdateWebhookSettingsOpts) func() error {
+
+	logger := slog.Default()
+	return func() error {
+		getCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		var mWhCfg admissionregistration.MutatingWebhookConfiguration
+		if err := rtClient.Get(
+			getCtx,
+			client.ObjectKey{Name: opts.Name},
+			&mWhCfg); err != nil {
+			return fmt.Errorf("unable to get mutating webhook configuration: %w", err)
+		}
+
+		var updated bool
+		for i := 0; i < len(mWhCfg.Webhooks); i++ {
+			webhook := &mWhCfg.Webhooks[i]
+			if ptr.Deref(webhook.FailurePolicy, "") == opts.FailurePolicy &&
+				ptr.Deref(webhook.TimeoutSeconds, 0) == opts.TimeoutSeconds {
+				continue
+			}
+			webhook.FailurePolicy = ptr.To(opts.FailurePolicy)
+			webhook.TimeoutSeconds = ptr.To(opts.TimeoutSeconds)
+			updated = true
+		}
+
+		if !updated {
+			logger.Info("mutating webhook configuration settings up to date")
+			return nil
+		}
+
+		mWhCfg.Kind = "MutatingWebhookConfiguration"
+		mWhCfg.APIVersion = "admissionregistration.k8s.io/v1"
+		mWhCfg.ManagedFields = nil
+
+		patchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		logger.Info("attempting to patch mutating webhook configuration settings",
+			"name", mWhCfg.Name,
+			"failurePolicy", opts.FailurePolicy,
+			"timeoutSeconds", opts.TimeoutSeconds)
+
+		return rtClient.Patch(patchCtx, &mWhCfg, client.Apply, &client.PatchOptions{
+			FieldManager: opts.FieldManager,
+			Force:        ptr.To(true),
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package callback_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/webhook/callback"
+	"github.com/stretchr/testify/assert"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_BuildUpdateWebhookSettings(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+
+	mWhCfg := testMWhCfg("test-me", []byte("test-me"))
+
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(&mWhCfg).
+		WithScheme(scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: buildPatchFake(&mWhCfg),
+		}).Build()
+
+	err := callback.BuildUpdateWebhookSettings(ctx, fakeClient, callback.BuildUpdateWebhookSettingsOpts{
+		Name:           "test-me",
+		FailurePolicy:  admissionregistration.Fail,
+		TimeoutSeconds: 5,
+	})()
+
+	assert.NoError(t, err)
+	assert.Equal(t, ptr.To(admissionregistration.Fail), mWhCfg.Webhooks[0].FailurePolicy)
+	assert.Equal(t, ptr.To(int32(5)), mWhCfg.Webhooks[0].TimeoutSeconds)
+	assert.Equal(t, int64(1), mWhCfg.Generation)
+}
+
+func Test_BuildUpdateWebhookSettings_up_to_date(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+
+	mWhCfg := testMWhCfg("test-me", []byte("test-me"))
+	mWhCfg.Webhooks[0].FailurePolicy = ptr.To(admissionregistration.Ignore)
+	mWhCfg.Webhooks[0].TimeoutSeconds = ptr.To(int32(10))
+
+	// default fake client fails on server side apply, patch must not be called
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(&mWhCfg).
+		WithScheme(scheme).
+		Build()
+
+	err := callback.BuildUpdateWebhookSettings(ctx, fakeClient, callback.BuildUpdateWebhookSettingsOpts{
+		Name:           "test-me",
+		FailurePolicy:  admissionregistration.Ignore,
+		TimeoutSeconds: 10,
+	})()
+
+	assert.NoError(t, err)
+}
+
+func Test_BuildUpdateWebhookPaths(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+
+	mWhCfg := testMWhCfg("test-me", []byte("test-me"))
+	mWhCfg.Webhooks[0].ClientConfig.Service = &admissionregistration.ServiceReference{Path: ptr.To("/previous")}
+	mWhCfg.Webhooks = append(mWhCfg.Webhooks, admissionregistration.MutatingWebhook{
+		ClientConfig: admissionregistration.WebhookClientConfig{
+			Service: &admissionregistration.ServiceReference{Path: ptr.To("/other")},
+		},
+	})
+	paths := map[string]string{"/previous": "/current"}
+	assert.Len(t, callback.PreviousPaths(&mWhCfg, paths), 1)
+
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(&mWhCfg).
+		WithScheme(scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: buildPatchFake(&mWhCfg),
+		}).Build()
+
+	err := callback.BuildUpdateWebhookPaths(ctx, fakeClient, callback.BuildUpdateWebhookPathsOpts{
+		Name:  "test-me",
+		Paths: paths,
+	})()
+
+	assert.NoError(t, err)
+	assert.Equal(t, ptr.To("/current"), mWhCfg.Webhooks[0].ClientConfig.Service.Path)
+	assert.Equal(t, ptr.To("/other"), mWhCfg.Webhooks[1].ClientConfig.Service.Path)
+	assert.Equal(t, int64(1), mWhCfg.Generation)
+	assert.Empty(t, callback.PreviousPaths(&mWhCfg, paths))
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+// handshakeErrorPattern matches the message net/http logs for a connection
+// whose TLS handshake failed.
+var handshakeErrorPattern = regexp.MustCompile(`^http: TLS handshake error from (\S+): (.*)$`)
+
+// errClientNotAllowed is returned by verifyClientNames, it is part of the
+// handshake error net/http logs.
+var errClientNotAllowed = errors.New("client certificate not allowed")
+
+// handshakeAudit is the ErrorLog of the HTTP server, it logs and counts the
+// connections refused in the TLS handshake, e.g. clients without a certificate
+// issued by the client CA. The connections of the pod itself, like the one of
+// the readiness check, are only logged on debug level.
+type handshakeAudit struct {
+	metrics metrics.Metrics
+}
+
+func (a *handshakeAudit) Write(p []byte) (int, error) {
+	message := strings.TrimSpace(string(p))
+	match := handshakeErrorPattern.FindStringSubmatch(message)
+	if match == nil {
+		log.Info(message)
+		return len(p), nil
+	}
+
+	remoteAddr, cause := match[1], match[2]
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			log.V(1).Info("TLS handshake failed", "remoteAddr", remoteAddr, "error", cause)
+			return len(p), nil
+		}
+	}
+
+	reason := unauthorizedReason(cause)
+	if a.metrics != nil {
+		a.metrics.IncUnauthorizedRequest(reason)
+	}
+	log.Info("Refused unauthorized request", "remoteAddr", remoteAddr, "reason", reason, "error", cause)
+	return len(p), nil
+}
+
+// unauthorizedReason returns the reason the TLS handshake failed with the cause.
+func unauthorizedReason(cause string) string {
+	switch {
+	case strings.Contains(cause, errClientNotAllowed.Error()):
+		return metrics.UnauthorizedReasonClientName
+	case strings.Contains(cause, "tls: failed to verify certificate"),
+		strings.Contains(cause, "tls: client didn't provide a certificate"):
+		return metrics.UnauthorizedReasonClientCertificate
+	}
+	return metrics.UnauthorizedReasonTLS
+}
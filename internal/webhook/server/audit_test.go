@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	stdlog "log"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+)
+
+func Test_handshakeAudit(t *testing.T) {
+	m := mocks.NewMetrics(t)
+	m.On("IncUnauthorizedRequest", metrics.UnauthorizedReasonClientCertificate).Twice()
+	m.On("IncUnauthorizedRequest", metrics.UnauthorizedReasonClientName).Once()
+	m.On("IncUnauthorizedRequest", metrics.UnauthorizedReasonTLS).Once()
+	logger := stdlog.New(&handshakeAudit{metrics: m}, "", 0)
+
+	logger.Printf("http: TLS handshake error from 10.250.0.7:43512: tls: client didn't provide a certificate")
+	logger.Printf("http: TLS handshake error from 10.250.0.7:43514: tls: failed to verify certificate: " +
+		"x509: certificate signed by unknown authority")
+	logger.Printf("http: TLS handshake error from [fd00::7]:43516: %v",
+		verifyClientNames([]string{"kube-apiserver"})(tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "attacker"}}},
+		}))
+	logger.Printf("http: TLS handshake error from 10.250.0.7:43518: EOF")
+
+	// the connections of the readiness check and other server errors are not counted
+	logger.Printf("http: TLS handshake error from 127.0.0.1:43520: tls: client didn't provide a certificate")
+	logger.Printf("http: superfluous response.WriteHeader call")
+}
@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// budgetShare is the share of the timeout of the API server a request may
+// take, the rest is left to the network and to writing the response.
+const budgetShare = 0.8
+
+// budgetHandler derives the deadline of a request from the timeout the API
+// server sent with it, so the webhooks abandon their lookups before the API
+// server gives up on them and applies the failure policy. The deadline starts
+// when the request arrives, the time it waits for the limiter counts.
+type budgetHandler struct {
+	handler http.Handler
+}
+
+func (h *budgetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	budget := latencyBudget(r)
+	if budget <= 0 {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), budget)
+	defer cancel()
+	h.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// latencyBudget returns the time the request may take, zero if the API server
+// sent no timeout.
+func latencyBudget(r *http.Request) time.Duration {
+	timeout, err := time.ParseDuration(r.URL.Query().Get("timeout"))
+	if err != nil || timeout <= 0 {
+		return 0
+	}
+	return time.Duration(float64(timeout) * budgetShare)
+}
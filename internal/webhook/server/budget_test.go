@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_budgetHandler(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	h := &budgetHandler{handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	})}
+
+	start := time.Now()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mutate--v1-pod?timeout=10s", nil))
+	assert.True(t, ok)
+	assert.WithinDuration(t, start.Add(8*time.Second), deadline, time.Second)
+
+	// requests without timeout have no deadline
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mutate--v1-pod", nil))
+	assert.False(t, ok)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mutate--v1-pod?timeout=bad", nil))
+	assert.False(t, ok)
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchInterval is the interval the certificate files are read in
+// regardless of the file system events.
+const defaultWatchInterval = 10 * time.Second
+
+var errNoCertificate = errors.New("no serving certificate loaded")
+
+// certWatcher serves the certificate of the certificate directory and reloads
+// it when the files change. The directory is watched instead of the files since
+// the kubelet replaces all files of a mounted Secret at once by swapping the
+// ..data symlink of the volume. The files are read in an interval as well, in
+// case an event was missed.
+type certWatcher struct {
+	certPath string
+	keyPath  string
+	// caPath is the CA bundle, a changed CA bundle invokes the callback, optional
+	caPath string
+	// dnsName the certificate must be valid for, a certificate not valid for it
+	// is not served, optional
+	dnsName  string
+	interval time.Duration
+	// callback is invoked whenever the certificate or the CA bundle changed, it
+	// is invoked again with the next read until it succeeds
+	callback func(tls.Certificate) error
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	// digest of the loaded files, it is only accessed by the reading goroutine
+	digest []byte
+	// failed is set if the callback failed for the loaded files
+	failed bool
+}
+
+// GetCertificate returns the loaded certificate, it is the GetCertificate of
+// the tls.Config of the webhook server.
+func (w *certWatcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.cert == nil {
+		return nil, errNoCertificate
+	}
+	return w.cert, nil
+}
+
+// Start watches the certificate directory until the context is cancelled.
+func (w *certWatcher) Start(ctx context.Context) error {
+	watcher, err := w.watch()
+	if err != nil {
+		return err
+	}
+	w.run(ctx, watcher)
+	return nil
+}
+
+func (w *certWatcher) watch() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create file watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(w.certPath)); err != nil {
+		watcher.Close() //nolint:errcheck
+		return nil, fmt.Errorf("unable to watch certificate directory: %w", err)
+	}
+	return watcher, nil
+}
+
+func (w *certWatcher) run(ctx context.Context, watcher *fsnotify.Watcher) {
+	defer watcher.Close() //nolint:errcheck
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Info("Starting certificate watcher", "dir", filepath.Dir(w.certPath), "interval", w.interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error(err, "certificate watcher error")
+			continue
+		}
+		if err := w.read(); err != nil {
+			log.Error(err, "unable to reload certificate, serving the loaded certificate")
+		}
+	}
+}
+
+// read loads the certificate if the files changed since the last read. A key
+// pair that doesn't match, e.g. while the files are written one after another,
+// keeps the loaded certificate.
+func (w *certWatcher) read() error {
+	certPEM, err := os.ReadFile(w.certPath)
+	if err != nil {
+		return fmt.Errorf("unable to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(w.keyPath)
+	if err != nil {
+		return fmt.Errorf("unable to read key: %w", err)
+	}
+	var caPEM []byte
+	if w.caPath != "" {
+		if caPEM, err = os.ReadFile(w.caPath); err != nil {
+			return fmt.Errorf("unable to read ca bundle: %w", err)
+		}
+	}
+
+	hash := sha256.New()
+	for _, data := range [][]byte{certPEM, keyPEM, caPEM} {
+		hash.Write(data)
+	}
+	digest := hash.Sum(nil)
+
+	if bytes.Equal(digest, w.digest) && !w.failed {
+		return nil
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("unable to load key pair: %w", err)
+	}
+	if w.dnsName != "" {
+		// the certificate of an external source may be issued for another service
+		if err := cert.Leaf.VerifyHostname(w.dnsName); err != nil {
+			return fmt.Errorf("certificate not valid for the webhook service: %w", err)
+		}
+	}
+	if !bytes.Equal(digest, w.digest) {
+		log.Info("Updated current TLS certificate")
+	}
+	w.mu.Lock()
+	w.cert = &cert
+	w.mu.Unlock()
+	w.digest, w.failed = digest, false
+
+	if w.callback != nil {
+		if err := w.callback(cert); err != nil {
+			w.failed = true
+			return fmt.Errorf("certificate callback failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWriteCertificate writes a self-signed key pair for the common name into
+// the directory, swapping a ..data symlink like the kubelet does for the
+// volumes of Secrets.
+func testWriteCertificate(t *testing.T, dir, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data := filepath.Join(dir, "..data-"+commonName)
+	require.NoError(t, os.Mkdir(data, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "tls.crt"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "tls.key"),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "ca.crt"), []byte(commonName), 0o600))
+
+	link := filepath.Join(dir, "..data-tmp")
+	require.NoError(t, os.Symlink(filepath.Base(data), link))
+	require.NoError(t, os.Rename(link, filepath.Join(dir, "..data")))
+	for _, name := range []string{"tls.crt", "tls.key", "ca.crt"} {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err != nil {
+			require.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+		}
+	}
+}
+
+func testCommonName(t *testing.T, w *certWatcher) string {
+	t.Helper()
+
+	cert, err := w.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func Test_certWatcher(t *testing.T) {
+	dir := t.TempDir()
+	testWriteCertificate(t, dir, "first")
+
+	var callbackErr error
+	var loaded []string
+	w := &certWatcher{
+		certPath: filepath.Join(dir, "tls.crt"),
+		keyPath:  filepath.Join(dir, "tls.key"),
+		caPath:   filepath.Join(dir, "ca.crt"),
+		interval: time.Hour,
+		callback: func(tls.Certificate) error {
+			ca, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+			require.NoError(t, err)
+			loaded = append(loaded, string(ca))
+			return callbackErr
+		},
+	}
+	require.NoError(t, w.read())
+	assert.Equal(t, "first", testCommonName(t, w))
+
+	callbackErr = errors.New("webhook configuration not found")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := w.watch()
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.run(ctx, watcher)
+	}()
+
+	// the swapped files are reloaded without waiting for the interval
+	testWriteCertificate(t, dir, "second")
+	assert.Eventually(t, func() bool {
+		return testCommonName(t, w) == "second"
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	// a failed callback is retried with the next read
+	callbackErr = nil
+	require.NoError(t, w.read())
+	assert.Equal(t, "second", loaded[len(loaded)-1])
+	calls := len(loaded)
+	require.NoError(t, w.read())
+	assert.Len(t, loaded, calls)
+}
+
+func Test_certWatcher_dnsName(t *testing.T) {
+	dir := t.TempDir()
+	testWriteCertificate(t, dir, "kim-snatch-webhook-service.kyma-system.svc")
+
+	w := &certWatcher{
+		certPath: filepath.Join(dir, "tls.crt"),
+		keyPath:  filepath.Join(dir, "tls.key"),
+		dnsName:  "kim-snatch-webhook-service.kyma-system.svc",
+		interval: time.Hour,
+	}
+	require.NoError(t, w.read())
+
+	// a certificate issued for another service is not served
+	testWriteCertificate(t, dir, "other-service.kyma-system.svc")
+	require.Error(t, w.read())
+	assert.Equal(t, "kim-snatch-webhook-service.kyma-system.svc", testCommonName(t, w))
+}
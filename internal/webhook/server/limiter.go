@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+// limiter handles at most maxInFlight requests at once. The other requests
+// wait in a queue per client, the clients are served in turns, so a storm of
+// one API server doesn't starve the requests of the others. A request is
+// rejected right away if the queue of its client is full, and once it waited
+// longer than it can afford: half of the timeout the API server sent with it,
+// at most maxWait. The API server applies the failure policy to rejected
+// requests instead of waiting for their timeout.
+type limiter struct {
+	handler     http.Handler
+	maxInFlight int
+	maxQueued   int
+	maxWait     time.Duration
+	metrics     metrics.Metrics
+
+	mu       sync.Mutex
+	inFlight int
+	queues   map[string][]*waiter
+	// clients are the clients with waiting requests in the order they are served
+	clients []string
+}
+
+// waiter is a request waiting for a slot, ready is closed once it got one.
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+func newLimiter(handler http.Handler, o Options) *limiter {
+	return &limiter{
+		handler:     handler,
+		maxInFlight: o.MaxInFlight,
+		maxQueued:   o.MaxQueuedPerClient,
+		maxWait:     o.MaxQueueWait,
+		metrics:     o.Metrics,
+		queues:      map[string][]*waiter{},
+	}
+}
+
+func (l *limiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	if reason := l.acquire(r.Context(), clientOf(r), l.wait(r)); reason != "" {
+		if l.metrics != nil {
+			l.metrics.IncWebhookRejected(reason)
+		}
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	defer l.release()
+	if l.metrics != nil {
+		l.metrics.ObserveWebhookQueueWait(time.Since(start))
+	}
+	l.handler.ServeHTTP(w, r)
+}
+
+// wait returns the time the request can wait for a slot.
+func (l *limiter) wait(r *http.Request) time.Duration {
+	timeout, err := time.ParseDuration(r.URL.Query().Get("timeout"))
+	if err != nil || timeout <= 0 {
+		return l.maxWait
+	}
+	return min(timeout/2, l.maxWait)
+}
+
+// acquire returns once the request got a slot, or the reason it was rejected.
+func (l *limiter) acquire(ctx context.Context, client string, wait time.Duration) string {
+	l.mu.Lock()
+	if l.inFlight < l.maxInFlight && len(l.clients) == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return ""
+	}
+	if len(l.queues[client]) >= l.maxQueued {
+		l.mu.Unlock()
+		return metrics.RejectReasonQueueFull
+	}
+	if wait <= 0 {
+		l.mu.Unlock()
+		return metrics.RejectReasonDeadline
+	}
+	wt := &waiter{ready: make(chan struct{})}
+	if len(l.queues[client]) == 0 {
+		l.clients = append(l.clients, client)
+	}
+	l.queues[client] = append(l.queues[client], wt)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	reason := metrics.RejectReasonDeadline
+	select {
+	case <-wt.ready:
+		return ""
+	case <-timer.C:
+	case <-ctx.Done():
+		reason = metrics.RejectReasonCanceled
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if wt.granted {
+		// the slot was granted meanwhile, the request is handled anyway
+		return ""
+	}
+	l.remove(client, wt)
+	return reason
+}
+
+// release passes the slot to the next client in turn.
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.clients) == 0 {
+		l.inFlight--
+		return
+	}
+	client := l.clients[0]
+	queue := l.queues[client]
+	wt := queue[0]
+	l.clients = l.clients[1:]
+	if len(queue) > 1 {
+		l.queues[client] = queue[1:]
+		l.clients = append(l.clients, client)
+	} else {
+		delete(l.queues, client)
+	}
+	wt.granted = true
+	close(wt.ready)
+}
+
+func (l *limiter) remove(client string, wt *waiter) {
+	queue := l.queues[client]
+	for i, queued := range queue {
+		if queued == wt {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		l.queues[client] = queue
+		return
+	}
+	delete(l.queues, client)
+	for i, c := range l.clients {
+		if c == client {
+			l.clients = append(l.clients[:i:i], l.clients[i+1:]...)
+			break
+		}
+	}
+}
+
+// clientOf identifies the client by its address, every instance of the API
+// server is a client of its own.
+func clientOf(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_limiter(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveWebhookQueueWait", mock.Anything)
+	mtr.On("IncWebhookRejected", metrics.RejectReasonQueueFull).Once()
+	mtr.On("IncWebhookRejected", metrics.RejectReasonDeadline).Once()
+	mtr.On("IncWebhookRejected", metrics.RejectReasonCanceled).Once()
+
+	handled := make(chan string, 10)
+	release := make(chan struct{})
+	l := newLimiter(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		handled <- r.URL.Path
+		<-release
+	}), Options{MaxInFlight: 1, MaxQueuedPerClient: 2, MaxQueueWait: 5 * time.Second, Metrics: mtr})
+
+	serve := func(ctx context.Context, client, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequestWithContext(ctx, http.MethodPost, path, nil)
+		r.RemoteAddr = client + ":443"
+		rw := httptest.NewRecorder()
+		l.ServeHTTP(rw, r)
+		return rw
+	}
+	queued := func(client string) int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return len(l.queues[client])
+	}
+	codes := make(chan int, 10)
+	enqueue := func(client, path string, position int) {
+		go func() { codes <- serve(context.Background(), client, path).Code }()
+		require.Eventually(t, func() bool { return queued(client) == position }, time.Second, time.Millisecond)
+	}
+
+	enqueue("10.0.0.1", "/first", 0)
+	assert.Equal(t, "/first", <-handled)
+	enqueue("10.0.0.1", "/second", 1)
+	enqueue("10.0.0.1", "/third", 2)
+	enqueue("10.0.0.2", "/other", 1)
+
+	// the queue of a client is bounded
+	rw := serve(context.Background(), "10.0.0.1", "/rejected")
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+
+	// a request waits half of its timeout at most
+	assert.Equal(t, http.StatusTooManyRequests, serve(context.Background(), "10.0.0.3", "/late?timeout=20ms").Code)
+
+	// a request the client gave up is removed from the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, http.StatusTooManyRequests, serve(ctx, "10.0.0.3", "/canceled").Code)
+	assert.Zero(t, queued("10.0.0.3"))
+
+	// the clients are served in turns
+	for _, path := range []string{"/second", "/other", "/third"} {
+		release <- struct{}{}
+		assert.Equal(t, path, <-handled)
+	}
+	release <- struct{}{}
+	for range 4 {
+		assert.Equal(t, http.StatusOK, <-codes)
+	}
+
+	// the slot is returned once the queues are empty
+	close(release)
+	assert.Equal(t, http.StatusOK, serve(context.Background(), "10.0.0.1", "/last").Code)
+	assert.Zero(t, l.inFlight)
+}
+
+func Test_limiter_wait(t *testing.T) {
+	l := newLimiter(nil, Options{MaxQueueWait: time.Second})
+	for query, expected := range map[string]time.Duration{
+		"":              time.Second,
+		"?timeout=10s":  time.Second,
+		"?timeout=1s":   500 * time.Millisecond,
+		"?timeout=bad":  time.Second,
+		"?timeout=-10s": time.Second,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/mutate--v1-pod"+query, nil)
+		assert.Equal(t, expected, l.wait(r), query)
+	}
+}
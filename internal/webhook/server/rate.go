@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+// rateWindow is the number of seconds the request rate is averaged over, long
+// enough to smooth out single bursts, short enough for a HorizontalPodAutoscaler
+// to follow a Pod creation storm.
+const rateWindow = 60
+
+// rateMeter counts the requests arriving at the webhook server, including the
+// ones the limiter rejects, so the rate is the demand on the replica.
+type rateMeter struct {
+	handler http.Handler
+	metrics metrics.Metrics
+
+	requests atomic.Int64
+
+	mu sync.Mutex
+	// seconds are the requests of the last seconds, next is the index of the
+	// oldest one, ticks the number of seconds counted so far
+	seconds [rateWindow]int64
+	next    int
+	ticks   int
+}
+
+func (m *rateMeter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.requests.Add(1)
+	m.handler.ServeHTTP(w, r)
+}
+
+// run publishes the rate every second until ctx is done.
+func (m *rateMeter) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.metrics.SetWebhookRequestRate(m.tick())
+		}
+	}
+}
+
+// tick closes the current second and returns the requests per second of the
+// window, averaged over the seconds counted so far after the start.
+func (m *rateMeter) tick() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seconds[m.next] = m.requests.Swap(0)
+	m.next = (m.next + 1) % rateWindow
+	m.ticks = min(m.ticks+1, rateWindow)
+
+	var sum int64
+	for _, requests := range m.seconds {
+		sum += requests
+	}
+	return float64(sum) / float64(m.ticks)
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rateMeter(t *testing.T) {
+	var handled int
+	meter := &rateMeter{handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) { handled++ })}
+	serve := func(requests int) {
+		for range requests {
+			meter.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mutate", nil))
+		}
+	}
+
+	// the rate is averaged over the seconds since the start
+	serve(10)
+	assert.Equal(t, 10.0, meter.tick())
+	serve(20)
+	assert.Equal(t, 15.0, meter.tick())
+	assert.Equal(t, 30, handled)
+
+	// the seconds out of the window are dropped
+	for range rateWindow - 2 {
+		meter.tick()
+	}
+	assert.Equal(t, 20.0/rateWindow, meter.tick())
+	assert.Equal(t, 0.0, meter.tick())
+}
@@ -20,18 +20,21 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	stdlog "log"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/kyma-project/kim-snatch/internal/httpserver"
 	logf "github.com/kyma-project/kim-snatch/internal/log"
-	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -69,6 +72,16 @@ type Options struct {
 	// Defaults to "", which means server does not verify client's certificate.
 	ClientCAName string
 
+	// ClientCAFile is the path of the CA certificate file used to verify the client
+	// certificates, it takes precedence over ClientCAName.
+	// Defaults to "", which means ClientCAName is used.
+	ClientCAFile string
+
+	// ClientNames are the names one of which a verified client certificate must
+	// carry as common name or DNS name.
+	// Defaults to nil, which means any client certificate issued by the client CA is accepted.
+	ClientNames []string
+
 	// TLSOpts is used to allow configuring the TLS config used for the server.
 	// This also allows providing a certificate via GetCertificate.
 	TLSOpts []func(*tls.Config)
@@ -76,11 +89,53 @@ type Options struct {
 	// WebhookMux is the multiplexer that handles different webhooks.
 	WebhookMux *http.ServeMux
 
-	Callback func(tls.Certificate)
+	// CAName is the CA bundle name, a changed CA bundle invokes the Callback as well.
+	// Defaults to "", which means only a changed key pair invokes the Callback.
+	CAName string
+
+	// DNSName is the name of the webhook service the certificate must be valid for,
+	// a certificate not valid for it is not served.
+	// Defaults to "", which means the names of the certificate are not verified.
+	DNSName string
+
+	// Callback is invoked with the loaded certificate whenever the certificate or
+	// the CA bundle changed, a failed Callback is retried with the next reload.
+	Callback func(tls.Certificate) error
+
+	// DrainDelay is the time the server keeps accepting requests once it was
+	// stopped, so the endpoints of the webhook service are updated before the
+	// listener is closed.
+	// Defaults to 0, which means the server is shut down right away.
+	DrainDelay time.Duration
+
+	// ShutdownTimeout is the time the in-flight requests are waited for once the
+	// listener was closed. Defaults to 1 minute.
+	ShutdownTimeout time.Duration
+
+	// MaxInFlight is the number of requests handled at once, the other requests
+	// wait in a queue per client.
+	// Defaults to 0, which means the requests are not limited.
+	MaxInFlight int
+
+	// MaxQueuedPerClient is the number of requests of a client waiting for
+	// one of the MaxInFlight slots, further requests are rejected right away.
+	// Defaults to 0, which means no request waits.
+	MaxQueuedPerClient int
+
+	// MaxQueueWait is the time a request waits for a slot at most, a request
+	// waits half of the timeout the API server sent with it if shorter.
+	// Defaults to 1 second.
+	MaxQueueWait time.Duration
+
+	// Metrics counts the rejected and the unauthorized requests, measures the
+	// time the requests waited, and the rate of the requests, optional.
+	Metrics metrics.Metrics
 }
 
+var _ webhook.Server = &DefaultServer{}
+
 // NewServer constructs a new webhook.Server from the provided options.
-func NewServer(o Options) webhook.Server {
+func NewServer(o Options) *DefaultServer {
 	return &DefaultServer{
 		Options: o,
 	}
@@ -104,6 +159,9 @@ type DefaultServer struct {
 	mu sync.Mutex
 
 	webhookMux *http.ServeMux
+
+	// getCertificate is the GetCertificate of the TLS config of the started server
+	getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
 }
 
 // setDefaults does defaulting for the Server.
@@ -127,6 +185,14 @@ func (o *Options) setDefaults() {
 	if len(o.KeyName) == 0 {
 		o.KeyName = "tls.key"
 	}
+
+	if o.ShutdownTimeout <= 0 {
+		o.ShutdownTimeout = time.Minute
+	}
+
+	if o.MaxQueueWait <= 0 {
+		o.MaxQueueWait = time.Second
+	}
 }
 
 func (s *DefaultServer) setDefaults() {
@@ -181,15 +247,30 @@ func (s *DefaultServer) Start(ctx context.Context) error {
 		certPath := filepath.Join(s.Options.CertDir, s.Options.CertName)
 		keyPath := filepath.Join(s.Options.CertDir, s.Options.KeyName)
 
+		var caPath string
+		if s.Options.CAName != "" {
+			caPath = filepath.Join(s.Options.CertDir, s.Options.CAName)
+		}
+
 		// Create the certificate watcher and
 		// set the config's GetCertificate on the TLSConfig
-		certWatcher, err := certwatcher.New(certPath, keyPath)
-		if err != nil {
-			return err
+		certWatcher := &certWatcher{
+			certPath: certPath,
+			keyPath:  keyPath,
+			caPath:   caPath,
+			dnsName:  s.Options.DNSName,
+			interval: defaultWatchInterval,
+			callback: s.Options.Callback,
+		}
+		if err := certWatcher.read(); err != nil {
+			// a failed callback is retried, the server is started with the certificate
+			if _, certErr := certWatcher.GetCertificate(nil); certErr != nil {
+				return err
+			}
+			log.Error(err, "unable to handle loaded certificate")
 		}
 
 		cfg.GetCertificate = certWatcher.GetCertificate
-		certWatcher.RegisterCallback(s.Options.Callback)
 
 		startWatcher := func() {
 			if err := certWatcher.Start(ctx); err != nil {
@@ -201,10 +282,18 @@ func (s *DefaultServer) Start(ctx context.Context) error {
 		go startWatcher()
 	}
 
+	s.mu.Lock()
+	s.getCertificate = cfg.GetCertificate
+	s.mu.Unlock()
+
 	// Load CA to verify client certificate, if configured.
-	if s.Options.ClientCAName != "" {
+	clientCAPath := s.Options.ClientCAFile
+	if clientCAPath == "" && s.Options.ClientCAName != "" {
+		clientCAPath = filepath.Join(s.Options.CertDir, s.Options.ClientCAName)
+	}
+	if clientCAPath != "" {
 		certPool := x509.NewCertPool()
-		clientCABytes, err := os.ReadFile(filepath.Join(s.Options.CertDir, s.Options.ClientCAName))
+		clientCABytes, err := os.ReadFile(clientCAPath)
 		if err != nil {
 			return fmt.Errorf("failed to read client CA cert: %w", err)
 		}
@@ -216,6 +305,10 @@ func (s *DefaultServer) Start(ctx context.Context) error {
 
 		cfg.ClientCAs = certPool
 		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if len(s.Options.ClientNames) > 0 {
+			cfg.VerifyConnection = verifyClientNames(s.Options.ClientNames)
+		}
+		log.Info("Verifying client certificates", "clientCA", clientCAPath, "clientNames", s.Options.ClientNames)
 	}
 
 	listener, err := tls.Listen("tcp", net.JoinHostPort(s.Options.Host, strconv.Itoa(s.Options.Port)), cfg)
@@ -225,14 +318,32 @@ func (s *DefaultServer) Start(ctx context.Context) error {
 
 	log.Info("Serving webhook server", "host", s.Options.Host, "port", s.Options.Port)
 
-	srv := httpserver.New(s.webhookMux)
+	var handler http.Handler = s.webhookMux
+	if s.Options.MaxInFlight > 0 {
+		handler = newLimiter(handler, s.Options)
+	}
+	handler = &budgetHandler{handler: handler}
+	if s.Options.Metrics != nil {
+		meter := &rateMeter{handler: handler, metrics: s.Options.Metrics}
+		go meter.run(ctx)
+		handler = meter
+	}
+	srv := httpserver.New(handler)
+	srv.ErrorLog = stdlog.New(&handshakeAudit{metrics: s.Options.Metrics}, "", 0)
 
 	idleConnsClosed := make(chan struct{})
 	go func() {
 		<-ctx.Done()
-		log.Info("Shutting down webhook server with timeout of 1 minute")
+		if s.Options.DrainDelay > 0 {
+			// the API server keeps sending requests until it sees the endpoints
+			// without this replica, the clients are asked to reconnect meanwhile
+			log.Info("Draining webhook server", "delay", s.Options.DrainDelay)
+			srv.SetKeepAlivesEnabled(false)
+			time.Sleep(s.Options.DrainDelay)
+		}
+		log.Info("Shutting down webhook server", "timeout", s.Options.ShutdownTimeout)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), s.Options.ShutdownTimeout)
 		defer cancel()
 		if err := srv.Shutdown(ctx); err != nil {
 			// Error from closing listeners, or context timeout
@@ -280,7 +391,40 @@ func (s *DefaultServer) StartedChecker() healthz.Checker {
 	}
 }
 
+// GetCertificate returns the certificate the server serves, it fails until the
+// server has been started.
+func (s *DefaultServer) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	getCertificate := s.getCertificate
+	s.mu.Unlock()
+
+	if getCertificate == nil {
+		return nil, fmt.Errorf("webhook server has not been started yet")
+	}
+	return getCertificate(hello)
+}
+
 // WebhookMux returns the servers WebhookMux
 func (s *DefaultServer) WebhookMux() *http.ServeMux {
 	return s.webhookMux
 }
+
+// verifyClientNames returns a VerifyConnection of a tls.Config accepting only
+// client certificates carrying one of the names, e.g. the name of the client
+// certificate of the API server.
+func verifyClientNames(names []string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("no client certificate")
+		}
+		client := state.PeerCertificates[0]
+		for _, name := range append([]string{client.Subject.CommonName}, client.DNSNames...) {
+			if slices.Contains(names, name) {
+				return nil
+			}
+		}
+		log.V(1).Info("Rejected client certificate", "commonName", client.Subject.CommonName,
+			"dnsNames", client.DNSNames)
+		return fmt.Errorf("%w: %q", errClientNotAllowed, client.Subject.CommonName)
+	}
+}
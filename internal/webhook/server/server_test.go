@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_verifyClientNames(t *testing.T) {
+	verify := verifyClientNames([]string{"kube-apiserver", "apiserver.kyma-system.svc"})
+	state := func(commonName string, dnsNames ...string) tls.ConnectionState {
+		return tls.ConnectionState{PeerCertificates: []*x509.Certificate{{
+			Subject:  pkix.Name{CommonName: commonName},
+			DNSNames: dnsNames,
+		}}}
+	}
+
+	assert.NoError(t, verify(state("kube-apiserver")))
+	assert.NoError(t, verify(state("apiserver", "apiserver.kyma-system.svc")))
+	assert.Error(t, verify(state("attacker", "attacker.default.svc")))
+	assert.Error(t, verify(tls.ConnectionState{}))
+}
+
+func Test_DefaultServer_drain(t *testing.T) {
+	dir := t.TempDir()
+	testWriteCertificate(t, dir, "localhost")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	srv := NewServer(Options{
+		Host:            "127.0.0.1",
+		Port:            port,
+		CertDir:         dir,
+		DrainDelay:      500 * time.Millisecond,
+		ShutdownTimeout: 5 * time.Second,
+	})
+	release := make(chan struct{})
+	srv.Register("/slow", http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-release }))
+	srv.Register("/fast", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error)
+	go func() { stopped <- srv.Start(ctx) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+	}}
+	url := "https://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	get := func(path string) error {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	require.Eventually(t, func() bool { return get("/fast") == nil }, 5*time.Second, 10*time.Millisecond)
+
+	inFlight := make(chan error)
+	go func() { inFlight <- get("/slow") }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	// new requests are served within the drain delay
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, get("/fast"))
+	select {
+	case <-stopped:
+		t.Fatal("server stopped within the drain delay")
+	default:
+	}
+
+	// the in-flight request is finished before the server stops
+	time.Sleep(500 * time.Millisecond)
+	close(release)
+	assert.NoError(t, <-inFlight)
+	assert.NoError(t, <-stopped)
+	assert.Error(t, get("/fast"))
+}
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+// budgetExceeded skips the pod once the deadline the webhook server derived
+// from the timeout of the API server passed, the pod is passed unchanged
+// instead of the API server timing out and applying the failure policy.
+func budgetExceeded(ctx context.Context) bool {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	decisionFrom(ctx).skipped(metrics.SkipReasonDeadlineExceeded)
+	decisionFrom(ctx).warn("node affinity not injected: the latency budget of the admission request was exceeded")
+	return true
+}
@@ -0,0 +1,151 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// decision is the outcome of the defaulting of a pod, the defaulting function
+// records it in the context of the admission request.
+type decision struct {
+	// result is one of the admission results of the metrics package, it is
+	// empty until decided
+	result string
+	// reason is the affinity mode for mutated pods, the skip reason for skipped
+	// ones or the reason of the failure
+	reason string
+	// rule is the expression of the rule excluding skipped pods
+	rule string
+	// pool is the worker pool the node affinity of mutated pods selects
+	pool string
+	// pod is the name of the pod, or its generateName if it has no name yet
+	pod string
+	// generated is set if the pod has no name yet
+	generated bool
+	// uid is the UID of the admission request, it correlates the request, the
+	// events, and the mutated pod
+	uid types.UID
+	// notices are the notable parts of the decision the user is told about
+	notices []notice
+	// warnings are returned to the user in the admission response
+	warnings []string
+}
+
+// notice is a notable part of a decision, e.g. a conflict with the pod spec.
+type notice struct {
+	// reason is the reason of the event recorded for the notice
+	reason string
+	// message tells the user what was decided and why
+	message string
+}
+
+type decisionKey struct{}
+
+// withDecision returns a context the defaulting function records its decision
+// in, the decision of the context is kept if it already has one.
+func withDecision(ctx context.Context) (context.Context, *decision) {
+	if d, ok := ctx.Value(decisionKey{}).(*decision); ok {
+		return ctx, d
+	}
+	d := &decision{}
+	return context.WithValue(ctx, decisionKey{}, d), d
+}
+
+// decisionFrom returns the decision of the context, the decision is discarded
+// if the defaulting function is called without one.
+func decisionFrom(ctx context.Context) *decision {
+	if d, ok := ctx.Value(decisionKey{}).(*decision); ok {
+		return d
+	}
+	return &decision{}
+}
+
+func (d *decision) mutated(pool, reason string) {
+	d.result, d.reason, d.pool = metrics.AdmissionResultMutated, reason, pool
+}
+
+func (d *decision) skipped(reason string) {
+	d.result, d.reason = metrics.AdmissionResultSkipped, reason
+}
+
+func (d *decision) failed(reason string) {
+	d.result, d.reason = metrics.AdmissionResultError, reason
+}
+
+// notice records a notable part of the decision, it is recorded as event and
+// returned as warning.
+func (d *decision) notice(reason, message string) {
+	d.notices = append(d.notices, notice{reason: reason, message: message})
+	d.warn(message)
+}
+
+// warn records a warning for the user only.
+func (d *decision) warn(message string) {
+	d.warnings = append(d.warnings, warningPrefix+message)
+}
+
+// summary describes the decision for the user.
+func (d *decision) summary() string {
+	switch d.result {
+	case metrics.AdmissionResultMutated:
+		if d.pool == "" {
+			return "node affinity injected"
+		}
+		return fmt.Sprintf("node affinity to worker pool %s injected", d.pool)
+	case metrics.AdmissionResultSkipped:
+		return fmt.Sprintf("node affinity not injected: %s", d.reason)
+	default:
+		return fmt.Sprintf("node affinity not injected, defaulting failed: %s", d.reason)
+	}
+}
+
+// eventAnnotations returns the annotations of the events of the decision.
+func (d *decision) eventAnnotations() map[string]string {
+	if d.uid == "" {
+		return nil
+	}
+	return map[string]string{AnnotationAdmissionUID: string(d.uid)}
+}
+
+// auditAnnotations returns the audit annotations of the decision, the API server
+// prefixes the keys with the name of the webhook.
+func (d *decision) auditAnnotations() map[string]string {
+	if d.result == "" {
+		return nil
+	}
+	value := d.result
+	if d.result == metrics.AdmissionResultMutated {
+		if d.pool != "" {
+			value += "/" + d.pool
+		}
+	} else if d.reason != "" {
+		value += "/" + d.reason
+	}
+	annotations := map[string]string{AuditAnnotationDecision: value}
+	if d.reason != "" {
+		annotations[AuditAnnotationReason] = d.reason
+	}
+	return annotations
+}
+
+// Keys of the audit annotations of the admission responses.
+const (
+	// AuditAnnotationDecision is the result of the decision followed by the
+	// worker pool of mutated pods or the reason, e.g. mutated/pool-a or
+	// skipped/omitted_namespace
+	AuditAnnotationDecision = "decision"
+	// AuditAnnotationReason is the affinity mode of mutated pods or the reason
+	// of skipped ones or of the failure
+	AuditAnnotationReason = "reason"
+)
+
+// AnnotationAdmissionUID on mutated pods is the UID of the admission request
+// the node affinity was injected in, the API server audit entry, the log lines,
+// the events, and the audit record of the request carry the same UID.
+const AnnotationAdmissionUID = "kim-snatch.kyma-project.io/admission-uid"
+
+// warningPrefix tells the user the warnings are returned by kim-snatch.
+const warningPrefix = "kim-snatch: "
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultDecisionLogSize is the default number of decisions the DecisionLog keeps.
+const DefaultDecisionLogSize = 100
+
+// Decision is the decision of an admission request of a pod.
+type Decision struct {
+	// Time the admission request was handled at
+	Time time.Time `json:"time"`
+	// UID of the admission request
+	UID       types.UID `json:"uid"`
+	Namespace string    `json:"namespace"`
+	// Pod is the name of the pod, empty if it was generated
+	Pod string `json:"pod,omitempty"`
+	// GenerateName is the prefix of the generated name of the pod
+	GenerateName string `json:"generateName,omitempty"`
+	// Result is one of the admission results of the metrics package
+	Result string `json:"result"`
+	// Reason is the affinity mode of mutated pods or the reason of skipped ones or of the failure
+	Reason string `json:"reason,omitempty"`
+	// Pool is the worker pool the node affinity of mutated pods selects
+	Pool string `json:"pool,omitempty"`
+	// Warnings returned to the user in the admission response
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// matches returns true if the decision is of the pod, the pod matches pods
+// with a generated name by the prefix.
+func (d *Decision) matches(namespace, pod string) bool {
+	if namespace != "" && d.Namespace != namespace {
+		return false
+	}
+	if pod == "" {
+		return true
+	}
+	if d.Pod != "" {
+		return d.Pod == pod
+	}
+	return strings.HasPrefix(pod, d.GenerateName)
+}
+
+// DecisionLog keeps the last decisions of the pod defaulting in a ring buffer,
+// so it can be told whether a pod was processed and why it was skipped.
+type DecisionLog struct {
+	mu        sync.Mutex
+	decisions []Decision
+	// next is the position the next decision is recorded at
+	next int
+	full bool
+}
+
+// NewDecisionLog returns a DecisionLog keeping the last size decisions.
+func NewDecisionLog(size int) *DecisionLog {
+	if size <= 0 {
+		size = DefaultDecisionLogSize
+	}
+	return &DecisionLog{decisions: make([]Decision, size)}
+}
+
+func (l *DecisionLog) record(d Decision) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.decisions[l.next] = d
+	l.next = (l.next + 1) % len(l.decisions)
+	l.full = l.full || l.next == 0
+}
+
+// Decisions returns the kept decisions of the namespace and the pod, newest
+// first. An empty namespace or pod matches all of them.
+func (l *DecisionLog) Decisions(namespace, pod string) []Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.decisions)
+	}
+	decisions := []Decision{}
+	for i := 1; i <= count; i++ {
+		d := l.decisions[(l.next-i+len(l.decisions))%len(l.decisions)]
+		if d.matches(namespace, pod) {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions
+}
+
+// DecisionsHandler serves the kept decisions as JSON, the namespace and pod
+// query parameters filter them.
+func DecisionsHandler(l *DecisionLog) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.Header().Set("Allow", http.MethodGet)
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		data, err := json.Marshal(l.Decisions(query.Get("namespace"), query.Get("pod")))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(data)
+	})
+}
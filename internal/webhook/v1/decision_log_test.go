@@ -0,0 +1,97 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func Test_DecisionLog(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	decisions := webhookv1.NewDecisionLog(3)
+	wh := webhookv1.NewPodWebhook(scheme, webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(func(cfg *config.Config) {
+			cfg.OmittedNamespaces = []string{"omitted"}
+		}),
+	}), webhookv1.PodCustomDefaulterOpts{Decisions: decisions})
+
+	handle := func(uid types.UID, pod *corev1.Pod) {
+		raw, err := json.Marshal(pod)
+		require.NoError(t, err)
+		resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       uid,
+			Operation: admissionv1.Create,
+			Namespace: pod.Namespace,
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+		require.True(t, resp.Allowed)
+	}
+
+	generated := testPod("test")
+	generated.Name, generated.GenerateName = "", "test-rs-"
+	handle("a", testPod("test"))
+	handle("b", testPod("omitted"))
+	handle("c", generated)
+	handle("d", testPod("test"))
+
+	// the oldest decision is dropped
+	all := decisions.Decisions("", "")
+	require.Len(t, all, 3)
+	assert.Equal(t, []types.UID{"d", "c", "b"}, []types.UID{all[0].UID, all[1].UID, all[2].UID})
+	assert.Equal(t, metrics.AdmissionResultMutated, all[0].Result)
+	assert.Equal(t, testPlacement.Pool, all[0].Pool)
+	assert.Equal(t, metrics.AdmissionResultSkipped, all[2].Result)
+	assert.Equal(t, metrics.SkipReasonOmittedNamespace, all[2].Reason)
+
+	// pods with a generated name match by the prefix
+	matched := decisions.Decisions("test", "test-rs-x7k2p")
+	require.Len(t, matched, 1)
+	assert.Equal(t, types.UID("c"), matched[0].UID)
+	assert.Equal(t, "test-rs-", matched[0].GenerateName)
+	assert.Len(t, decisions.Decisions("test", "test-me"), 1)
+	assert.Empty(t, decisions.Decisions("other", ""))
+
+	rec := httptest.NewRecorder()
+	webhookv1.DecisionsHandler(decisions).ServeHTTP(rec,
+		httptest.NewRequest(http.MethodGet, "/debug/decisions?namespace=omitted", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var served []webhookv1.Decision
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	require.Len(t, served, 1)
+	assert.Equal(t, types.UID("b"), served[0].UID)
+}
+
+func Test_PodCustomDefaulter_decisionEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{Recorder: recorder, EventInterval: time.Minute, DecisionEvents: true})
+
+	require.NoError(t, defaulter.Default(context.Background(), testPod("test")))
+	// the decisions of the same pod are rate limited
+	require.NoError(t, defaulter.Default(context.Background(), testPod("test")))
+
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	assert.Equal(t, []string{"Normal " + webhookv1.EventReasonAdmissionDecision +
+		" node affinity to worker pool " + testPlacement.Pool + " injected"}, events)
+}
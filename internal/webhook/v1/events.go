@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/events"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events recorded for notable decisions of the pod defaulting.
+const (
+	// EventReasonInjectionSkipped is the reason of events telling the node
+	// affinity wasn't injected while the kyma worker pool is unavailable or
+	// the rules couldn't be evaluated
+	EventReasonInjectionSkipped = "AffinityInjectionSkipped"
+	// EventReasonAffinityConflict is the reason of events telling the node
+	// affinity wasn't injected as the pod requires another worker pool
+	EventReasonAffinityConflict = "AffinityConflict"
+	// EventReasonAffinityEnforced is the reason of events telling the pool
+	// requirement was added to the required node affinity of the pod
+	EventReasonAffinityEnforced = "AffinityEnforced"
+	// EventReasonAdmissionDecision is the reason of the Normal events telling
+	// the decision of the pod defaulting, recorded if enabled
+	EventReasonAdmissionDecision = "AdmissionDecision"
+)
+
+// eventRecorder records the notices of decisions as Warning events, at most one
+// event per target and reason is recorded per interval.
+type eventRecorder struct {
+	recorder record.EventRecorder
+	dedup    *events.Deduplicator
+}
+
+func newEventRecorder(recorder record.EventRecorder, interval time.Duration) *eventRecorder {
+	if recorder == nil || interval <= 0 {
+		return nil
+	}
+	return &eventRecorder{recorder: recorder, dedup: events.NewDeduplicator(interval)}
+}
+
+// record records the notices of the decision on the pod.
+func (r *eventRecorder) record(pod *corev1.Pod, namespace string, d *decision) {
+	if r == nil || len(d.notices) == 0 {
+		return
+	}
+	target := eventTarget(pod, namespace)
+	if target == nil {
+		return
+	}
+	for _, n := range d.notices {
+		if r.dedup.Allow(target.Kind+"/"+target.Namespace+"/"+target.Name+"/"+n.reason, time.Now()) {
+			r.recorder.AnnotatedEventf(target, d.eventAnnotations(), corev1.EventTypeWarning, n.reason, "%s", n.message)
+		}
+	}
+}
+
+// recordDecision records the decision of the pod as Normal event.
+func (r *eventRecorder) recordDecision(pod *corev1.Pod, namespace string, d *decision) {
+	if r == nil {
+		return
+	}
+	target := eventTarget(pod, namespace)
+	if target == nil {
+		return
+	}
+	if r.dedup.Allow(target.Kind+"/"+target.Namespace+"/"+target.Name+"/"+EventReasonAdmissionDecision, time.Now()) {
+		r.recorder.AnnotatedEventf(target, d.eventAnnotations(), corev1.EventTypeNormal, EventReasonAdmissionDecision,
+			"%s", d.summary())
+	}
+}
+
+// eventTarget returns the object the events of the pod are recorded on. Pods
+// being created have no UID yet and often no name, the events of pods with a
+// generated name are recorded on their controller.
+func eventTarget(pod *corev1.Pod, namespace string) *corev1.ObjectReference {
+	if pod.Name != "" {
+		return &corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Namespace:  namespace,
+			Name:       pod.Name,
+		}
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		return &corev1.ObjectReference{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+			Namespace:  namespace,
+			Name:       owner.Name,
+			UID:        owner.UID,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Namespace annotations overriding the global placement for pods created in the namespace.
+const (
+	AnnotationPool   = "kim-snatch.kyma-project.io/pool"
+	AnnotationWeight = "kim-snatch.kyma-project.io/weight"
+	AnnotationMode   = "kim-snatch.kyma-project.io/mode"
+)
+
+// PlacementResolver returns the placement for pods created in the given namespace.
+type PlacementResolver = func(ctx context.Context, namespace string, defaults Placement) (Placement, error)
+
+// NamespaceMetadata returns an empty metadata-only namespace, the namespaces are
+// only read as metadata so that the informer cache keeps no full copies of them.
+func NamespaceMetadata() *metav1.PartialObjectMetadata {
+	ns := &metav1.PartialObjectMetadata{}
+	ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	return ns
+}
+
+// NamespacePlacementResolver builds a resolver applying the placement overrides
+// defined as annotations of the namespace. The reader is expected to be backed by
+// a metadata informer cache so that no request hits the API server during admission.
+func NamespacePlacementResolver(reader client.Reader) PlacementResolver {
+	return func(ctx context.Context, namespace string, defaults Placement) (Placement, error) {
+		if namespace == "" {
+			return defaults, nil
+		}
+
+		ns := NamespaceMetadata()
+		if err := reader.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+			return defaults, fmt.Errorf("unable to get namespace: %w", err)
+		}
+
+		return applyOverrides(defaults, ns.Annotations), nil
+	}
+}
+
+// applyOverrides returns the placement with the overrides of the annotations
+// applied, invalid overrides are ignored.
+func applyOverrides(placement Placement, annotations map[string]string) Placement {
+	if pool, ok := annotations[AnnotationPool]; ok && pool != "" {
+		placement.Pool = pool
+	}
+
+	if value, ok := annotations[AnnotationWeight]; ok {
+		weight, err := config.ParseWeight(value)
+		if err != nil {
+			podlog.Error(err, "ignoring invalid namespace annotation", "annotation", AnnotationWeight)
+		} else {
+			placement.Weight = weight
+		}
+	}
+
+	if value, ok := annotations[AnnotationMode]; ok {
+		mode, err := config.ParseMode(value)
+		if err != nil {
+			podlog.Error(err, "ignoring invalid namespace annotation", "annotation", AnnotationMode)
+		} else {
+			placement.Mode = mode
+		}
+	}
+
+	return placement
+}
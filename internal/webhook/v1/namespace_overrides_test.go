@@ -0,0 +1,171 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testPlacement = webhookv1.Placement{
+	LabelKey: config.DefaultPoolLabelKey,
+	Pool:     "kyma-pool",
+	Weight:   10,
+	Mode:     config.ModePreferred,
+}
+
+func testConfig(modify ...func(*config.Config)) func() config.Config {
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = testPlacement.Pool
+	for _, m := range modify {
+		m(&cfg)
+	}
+	return func() config.Config { return cfg }
+}
+
+func testNamespace(name string, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+}
+
+func testPod(namespace string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-me",
+			Namespace: namespace,
+		},
+	}
+}
+
+func enableFeature(t *testing.T, f featuregate.Feature) {
+	require.NoError(t, featuregate.DefaultFeatureGate.SetFromMap(map[featuregate.Feature]bool{f: true}))
+	t.Cleanup(func() {
+		require.NoError(t, featuregate.DefaultFeatureGate.SetFromMap(map[featuregate.Feature]bool{f: false}))
+	})
+}
+
+func Test_NamespacePlacementResolver(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		expected    webhookv1.Placement
+	}{
+		{
+			name:     "no overrides",
+			expected: testPlacement,
+		},
+		{
+			name: "all overrides",
+			annotations: map[string]string{
+				webhookv1.AnnotationPool:   "other-pool",
+				webhookv1.AnnotationWeight: "42",
+				webhookv1.AnnotationMode:   config.ModeRequired,
+			},
+			expected: webhookv1.Placement{
+				LabelKey: config.DefaultPoolLabelKey,
+				Pool:     "other-pool",
+				Weight:   42,
+				Mode:     config.ModeRequired,
+			},
+		},
+		{
+			name: "invalid overrides are ignored",
+			annotations: map[string]string{
+				webhookv1.AnnotationWeight: "420",
+				webhookv1.AnnotationMode:   "always",
+			},
+			expected: testPlacement,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithObjects(testNamespace("test", tc.annotations)).
+				Build()
+
+			resolve := webhookv1.NamespacePlacementResolver(fakeClient)
+			placement, err := resolve(context.Background(), "test", testPlacement)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, placement)
+		})
+	}
+}
+
+func Test_NamespacePlacementResolver_missing_namespace(t *testing.T) {
+	resolve := webhookv1.NamespacePlacementResolver(fake.NewClientBuilder().Build())
+
+	placement, err := resolve(context.Background(), "test", testPlacement)
+
+	assert.Error(t, err)
+	assert.Equal(t, testPlacement, placement)
+}
+
+func Test_ApplyDefaults_namespace_override(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(testNamespace("test", map[string]string{webhookv1.AnnotationPool: "other-pool"})).
+		Build()
+
+	pod := testPod("test")
+	webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config:           testConfig(),
+		ResolvePlacement: webhookv1.NamespacePlacementResolver(fakeClient),
+	})(context.Background(), pod)
+
+	terms := pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	assert.Equal(t, int32(10), terms[0].Weight)
+	assert.Equal(t, []string{"other-pool"}, terms[0].Preference.MatchExpressions[0].Values)
+}
+
+func Test_ApplyDefaults_omitted_namespace(t *testing.T) {
+	pod := testPod("kube-system")
+	webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	})(context.Background(), pod)
+
+	assert.Nil(t, pod.Spec.Affinity)
+}
+
+func Test_ApplyDefaults_required_mode(t *testing.T) {
+	required := testConfig(func(cfg *config.Config) {
+		cfg.AffinityMode = config.ModeRequired
+	})
+
+	t.Run("disabled feature falls back to preferred", func(t *testing.T) {
+		pod := testPod("test")
+		webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{Config: required})(context.Background(), pod)
+
+		assert.Nil(t, pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		assert.Len(t, pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	})
+
+	t.Run("requirement is added to every term", func(t *testing.T) {
+		enableFeature(t, featuregate.RequiredMode)
+
+		pod := testPod("test")
+		pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{}, {}},
+			},
+		}}
+		webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{Config: required})(context.Background(), pod)
+
+		terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		require.Len(t, terms, 2)
+		for _, term := range terms {
+			assert.Equal(t, []string{"kyma-pool"}, term.MatchExpressions[0].Values)
+		}
+		assert.Empty(t, pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+	})
+}
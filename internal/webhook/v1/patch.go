@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Paths of the fields the defaulting changes.
+const (
+	pathAnnotations = "/metadata/annotations"
+	pathAffinity    = "/spec/affinity"
+	pathTolerations = "/spec/tolerations"
+)
+
+// pointerEscaper escapes a map key in a JSON pointer.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// podDefaulter is the admission handler of the pod webhook, it replaces the
+// handler of admission.WithCustomDefaulter. The decoder is created once
+// instead of recognizing the encoding of every request, and the patch of the
+// common mutation, the node affinity, tolerations and annotations added to a
+// pod without node affinity, is built from the changed fields instead of
+// marshalling the pod and diffing it with the request.
+type podDefaulter struct {
+	defaulter *PodCustomDefaulter
+	decoder   runtime.Decoder
+}
+
+func newPodDefaulter(scheme *runtime.Scheme, defaulter *PodCustomDefaulter) *podDefaulter {
+	return &podDefaulter{
+		defaulter: defaulter,
+		decoder: jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme,
+			jsonserializer.SerializerOptions{}),
+	}
+}
+
+func (h *podDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == admissionv1.Delete {
+		return admission.Response{AdmissionResponse: admissionv1.AdmissionResponse{
+			Allowed: true,
+			Result:  &metav1.Status{Code: http.StatusOK},
+		}}
+	}
+
+	ctx = admission.NewContextWithRequest(ctx, req)
+	if len(req.Object.Raw) == 0 {
+		return admission.Errored(http.StatusBadRequest, errors.New("there is no content to decode"))
+	}
+	pod := &corev1.Pod{}
+	if _, _, err := h.decoder.Decode(req.Object.Raw, nil, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	original := pod.DeepCopy()
+
+	if err := h.defaulter.Default(ctx, pod); err != nil {
+		var apiStatus apierrors.APIStatus
+		if errors.As(err, &apiStatus) {
+			status := apiStatus.Status()
+			return admission.Response{AdmissionResponse: admissionv1.AdmissionResponse{Result: &status}}
+		}
+		return admission.Denied(err.Error())
+	}
+
+	patches, ok := podPatch(original, pod)
+	if !ok {
+		// the fields unknown to the scheme are missing in both, they are kept
+		var err error
+		if patches, err = diffPods(original, pod); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+	resp := admission.Response{Patches: patches, AdmissionResponse: admissionv1.AdmissionResponse{Allowed: true}}
+	if len(patches) > 0 {
+		resp.PatchType = ptr.To(admissionv1.PatchTypeJSONPatch)
+	}
+	return resp
+}
+
+// podPatch returns the patch of the annotations, node affinity, and
+// tolerations the defaulting added. It returns false if the defaulting
+// changed other fields, an existing node affinity, or existing tolerations.
+func podPatch(original, current *corev1.Pod) ([]jsonpatch.JsonPatchOperation, bool) {
+	if original.Spec.Affinity != nil && !equality.Semantic.DeepEqual(original.Spec.Affinity, current.Spec.Affinity) {
+		return nil, false
+	}
+	added := len(current.Spec.Tolerations) - len(original.Spec.Tolerations)
+	if added < 0 ||
+		!equality.Semantic.DeepEqual(original.Spec.Tolerations, current.Spec.Tolerations[:len(original.Spec.Tolerations)]) {
+		return nil, false
+	}
+
+	// the rest of the pod must be unchanged
+	annotations, affinity, tolerations := current.Annotations, current.Spec.Affinity, current.Spec.Tolerations
+	current.Annotations, current.Spec.Affinity, current.Spec.Tolerations =
+		original.Annotations, original.Spec.Affinity, original.Spec.Tolerations
+	unchanged := equality.Semantic.DeepEqual(original, current)
+	current.Annotations, current.Spec.Affinity, current.Spec.Tolerations = annotations, affinity, tolerations
+	if !unchanged {
+		return nil, false
+	}
+
+	var patches []jsonpatch.JsonPatchOperation
+	switch {
+	case len(original.Annotations) == 0 && len(annotations) > 0:
+		patches = append(patches, jsonpatch.NewOperation("add", pathAnnotations, annotations))
+	case !maps.Equal(original.Annotations, annotations):
+		for _, key := range slices.Sorted(maps.Keys(annotations)) {
+			if value, ok := original.Annotations[key]; !ok || value != annotations[key] {
+				// add replaces an existing annotation
+				patches = append(patches, jsonpatch.NewOperation("add",
+					pathAnnotations+"/"+pointerEscaper.Replace(key), annotations[key]))
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(original.Annotations)) {
+			if _, ok := annotations[key]; !ok {
+				patches = append(patches, jsonpatch.NewOperation("remove",
+					pathAnnotations+"/"+pointerEscaper.Replace(key), nil))
+			}
+		}
+	}
+	if original.Spec.Affinity == nil && affinity != nil {
+		patches = append(patches, jsonpatch.NewOperation("add", pathAffinity, affinity))
+	}
+	switch {
+	case added == 0:
+	case len(original.Spec.Tolerations) == 0:
+		patches = append(patches, jsonpatch.NewOperation("add", pathTolerations, tolerations))
+	default:
+		for _, toleration := range tolerations[len(original.Spec.Tolerations):] {
+			patches = append(patches, jsonpatch.NewOperation("add", pathTolerations+"/-", toleration))
+		}
+	}
+	return patches, true
+}
+
+// diffPods returns the patch between the marshalled pods.
+func diffPods(original, current *corev1.Pod) ([]jsonpatch.JsonPatchOperation, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.CreatePatch(originalJSON, currentJSON)
+}
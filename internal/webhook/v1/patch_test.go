@@ -0,0 +1,111 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func Test_NewPodWebhook_patch(t *testing.T) {
+	taints := []corev1.Taint{{Key: "dedicated", Value: "kyma", Effect: corev1.TaintEffectNoSchedule}}
+	for _, tc := range []struct {
+		name       string
+		pod        func(*corev1.Pod)
+		defaultPod func(context.Context, *corev1.Pod)
+	}{
+		{
+			name: "pod without annotations",
+		},
+		{
+			name: "pod with annotations",
+			pod: func(pod *corev1.Pod) {
+				pod.Annotations = map[string]string{"sidecar.istio.io/inject": "false", "a~b/c": "d"}
+			},
+		},
+		{
+			name: "pod with tolerations",
+			pod: func(pod *corev1.Pod) {
+				pod.Spec.Tolerations = []corev1.Toleration{{Key: "node.kubernetes.io/not-ready",
+					Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute}}
+			},
+		},
+		{
+			name: "pod with node affinity",
+			pod: func(pod *corev1.Pod) {
+				pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+						Weight: 1,
+						Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"a"},
+						}}},
+					}},
+				}}
+			},
+		},
+		{
+			name: "defaulting changing other fields",
+			defaultPod: func(_ context.Context, pod *corev1.Pod) {
+				pod.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": "kyma"}
+				delete(pod.Labels, "app")
+			},
+			pod: func(pod *corev1.Pod) {
+				pod.Labels = map[string]string{"app": "nats"}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defaultPod := tc.defaultPod
+			if defaultPod == nil {
+				defaultPod = webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+					Config: testConfig(func(cfg *config.Config) { cfg.TolerationAllowList = []string{"dedicated"} }),
+					Taints: func() []corev1.Taint { return taints },
+				})
+			}
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+			wh := webhookv1.NewPodWebhook(scheme, defaultPod, webhookv1.PodCustomDefaulterOpts{})
+
+			pod := testPod("test")
+			if tc.pod != nil {
+				tc.pod(pod)
+			}
+			raw, err := json.Marshal(pod)
+			require.NoError(t, err)
+			resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "test-uid",
+				Operation: admissionv1.Create,
+				Namespace: pod.Namespace,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			require.True(t, resp.Allowed)
+			require.NotEmpty(t, resp.Patches)
+
+			// the patch applied to the request results in the defaulted pod
+			data, err := json.Marshal(resp.Patches)
+			require.NoError(t, err)
+			patch, err := jsonpatch.DecodePatch(data)
+			require.NoError(t, err)
+			patched, err := patch.Apply(raw)
+			require.NoError(t, err)
+
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{UID: "test-uid", Namespace: pod.Namespace},
+			})
+			require.NoError(t, webhookv1.NewPodCustomDefaulter(defaultPod, webhookv1.PodCustomDefaulterOpts{}).
+				Default(ctx, pod))
+			expected, err := json.Marshal(pod)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(patched))
+		})
+	}
+}
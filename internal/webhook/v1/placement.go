@@ -0,0 +1,237 @@
+package v1
+
+import (
+	"slices"
+
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Placement describes the node affinity injected into a pod.
+type Placement struct {
+	// LabelKey is the key of the worker pool node label, defaults to worker.gardener.cloud/pool
+	LabelKey string
+	// Pool is the expected value of the worker pool node label
+	Pool string
+	// Weight is the weight of the preferred scheduling term
+	Weight int32
+	// Mode is the way the node affinity is injected, one of config.ModePreferred, config.ModeRequired
+	Mode string
+	// ExcludedZones are the zones the pod must not be scheduled in, e.g. during an outage
+	ExcludedZones []string
+}
+
+// PlacementFromConfig returns the placement described by the configuration.
+func PlacementFromConfig(cfg config.Config) Placement {
+	return Placement{
+		LabelKey: cfg.PoolLabelKey,
+		Pool:     cfg.KymaWorkerPoolName,
+		Weight:   cfg.AffinityWeight,
+		Mode:     cfg.AffinityMode,
+	}
+}
+
+func (p Placement) labelKey() string {
+	if p.LabelKey == "" {
+		return kymaNodeSelectorKey
+	}
+	return p.LabelKey
+}
+
+func (p Placement) requirements() []corev1.NodeSelectorRequirement {
+	key := p.labelKey()
+
+	result := []corev1.NodeSelectorRequirement{{
+		Key:      key,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{p.Pool},
+	}}
+
+	if len(p.ExcludedZones) > 0 {
+		result = append(result, corev1.NodeSelectorRequirement{
+			Key:      corev1.LabelTopologyZone,
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   p.ExcludedZones,
+		})
+	}
+	return result
+}
+
+func injectNodeAffinity(pod *corev1.Pod, placement Placement) {
+	InjectNodeAffinity(&pod.Spec, placement)
+}
+
+// InjectNodeAffinity adds the node affinity of the placement to the pod spec,
+// e.g. to the pod template of a workload.
+func InjectNodeAffinity(spec *corev1.PodSpec, placement Placement) {
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	if placement.Mode == config.ModeRequired {
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.RequiredMode) {
+			injectRequired(spec.Affinity.NodeAffinity, placement)
+			return
+		}
+		podlog.Info("required mode disabled by feature gate, injecting preferred node affinity",
+			"feature", featuregate.RequiredMode)
+	}
+
+	injectPreferred(spec.Affinity.NodeAffinity, placement)
+}
+
+// SelectedPools returns the sorted pools the node affinity of the pod spec
+// selects with the label key of the placement.
+func SelectedPools(spec *corev1.PodSpec, placement Placement) []string {
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil {
+		return nil
+	}
+
+	var pools []string
+	collect := func(term corev1.NodeSelectorTerm) {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == placement.labelKey() && expression.Operator == corev1.NodeSelectorOpIn {
+				pools = append(pools, expression.Values...)
+			}
+		}
+	}
+
+	nodeAffinity := spec.Affinity.NodeAffinity
+	for _, term := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		collect(term.Preference)
+	}
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, term := range nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			collect(term)
+		}
+	}
+
+	slices.Sort(pools)
+	return slices.Compact(pools)
+}
+
+// RemovePools removes every node affinity expression on the label key of the
+// placement from the pod spec, terms without expressions left are removed.
+func RemovePools(spec *corev1.PodSpec, placement Placement) {
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil {
+		return
+	}
+
+	isPool := func(expression corev1.NodeSelectorRequirement) bool {
+		return expression.Key == placement.labelKey()
+	}
+	// the zones are only excluded together with the pool
+	isZone := func(expression corev1.NodeSelectorRequirement) bool {
+		return expression.Key == corev1.LabelTopologyZone && expression.Operator == corev1.NodeSelectorOpNotIn
+	}
+	remove := func(term corev1.NodeSelectorTerm) corev1.NodeSelectorTerm {
+		if !slices.ContainsFunc(term.MatchExpressions, isPool) {
+			return term
+		}
+		term.MatchExpressions = slices.DeleteFunc(slices.Clone(term.MatchExpressions),
+			func(e corev1.NodeSelectorRequirement) bool { return isPool(e) || isZone(e) })
+		return term
+	}
+	empty := func(term corev1.NodeSelectorTerm) bool {
+		return len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0
+	}
+
+	nodeAffinity := spec.Affinity.NodeAffinity
+	var preferred []corev1.PreferredSchedulingTerm
+	for _, term := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		term.Preference = remove(term.Preference)
+		if !empty(term.Preference) {
+			preferred = append(preferred, term)
+		}
+	}
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = preferred
+
+	if selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; selector != nil {
+		var terms []corev1.NodeSelectorTerm
+		for _, term := range selector.NodeSelectorTerms {
+			term = remove(term)
+			if !empty(term) {
+				terms = append(terms, term)
+			}
+		}
+		if len(terms) == 0 {
+			nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+		} else {
+			selector.NodeSelectorTerms = terms
+		}
+	}
+}
+
+func injectPreferred(nodeAffinity *corev1.NodeAffinity, placement Placement) {
+	if nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution =
+			[]corev1.PreferredSchedulingTerm{}
+	}
+
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution =
+		append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.PreferredSchedulingTerm{
+				Weight: placement.Weight,
+				Preference: corev1.NodeSelectorTerm{
+					MatchExpressions: placement.requirements(),
+				},
+			})
+}
+
+// injectRequired adds the pool requirement to every node selector term, the
+// terms are ORed so the requirement must be part of each of them.
+func injectRequired(nodeAffinity *corev1.NodeAffinity, placement Placement) {
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	for i := range selector.NodeSelectorTerms {
+		selector.NodeSelectorTerms[i].MatchExpressions =
+			append(selector.NodeSelectorTerms[i].MatchExpressions, placement.requirements()...)
+	}
+}
+
+// conflictingPools returns the sorted pools other than the pool of the placement
+// the pod spec requires with its node selector or required node affinity, the
+// pod can't be scheduled if the node affinity of the placement is added.
+func conflictingPools(spec *corev1.PodSpec, placement Placement) []string {
+	key := placement.labelKey()
+
+	var pools []string
+	if pool, ok := spec.NodeSelector[key]; ok && pool != placement.Pool {
+		pools = append(pools, pool)
+	}
+	if spec.Affinity != nil && spec.Affinity.NodeAffinity != nil &&
+		spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			for _, expression := range term.MatchExpressions {
+				if expression.Key == key && expression.Operator == corev1.NodeSelectorOpIn &&
+					!slices.Contains(expression.Values, placement.Pool) {
+					pools = append(pools, expression.Values...)
+				}
+			}
+		}
+	}
+
+	slices.Sort(pools)
+	return slices.Compact(pools)
+}
+
+// requiredTerms returns the number of required node selector terms of the pod spec.
+func requiredTerms(spec *corev1.PodSpec) int {
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil ||
+		spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return 0
+	}
+	return len(spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+}
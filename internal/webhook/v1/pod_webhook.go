@@ -19,13 +19,27 @@ package v1
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net/http"
 	"slices"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/kyma-project/kim-snatch/internal/audit"
+	"github.com/kyma-project/kim-snatch/internal/breaker"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/rules"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 const (
@@ -36,30 +50,203 @@ const (
 // log is for logging in this package.
 var podlog = logf.Log.WithName("pod-resource")
 
-type defaultPod = func(*corev1.Pod)
+type defaultPod = func(context.Context, *corev1.Pod)
 
-// SetupPodWebhookWithManager registers the webhook for Pod in the manager.
-func SetupPodWebhookWithManager(mgr ctrl.Manager, defdefaultPod defaultPod) error {
-	return ctrl.NewWebhookManagedBy(mgr).For(&corev1.Pod{}).
-		WithDefaulter(&PodCustomDefaulter{defaultPod: defdefaultPod}).
-		Complete()
+const (
+	// PodWebhookPath is the path of the webhook for Pod of this release
+	PodWebhookPath = "/mutate--v1-pod-v2"
+	// PreviousPodWebhookPath is the path of the webhook for Pod of the previous
+	// release, it matches the path of the kubebuilder marker. The manifests
+	// register the previous path, which both releases serve during an upgrade,
+	// the WebhookPathMigration moves the webhook to PodWebhookPath once the
+	// rollout completed.
+	PreviousPodWebhookPath = "/mutate--v1-pod"
+)
+
+// PodWebhookPaths maps the previous paths of the webhook for Pod to the path
+// of this release.
+var PodWebhookPaths = map[string]string{PreviousPodWebhookPath: PodWebhookPath}
+
+// SetupPodWebhookWithManager registers the webhook for Pod in the manager, on
+// the path of this release and on the previous ones.
+func SetupPodWebhookWithManager(mgr ctrl.Manager, defdefaultPod defaultPod, opts PodCustomDefaulterOpts) error {
+	podlog.Info("Registering a mutating webhook", "path", PodWebhookPath, "previousPath", PreviousPodWebhookPath)
+	var handler http.Handler = NewPodWebhook(mgr.GetScheme(), defdefaultPod, opts)
+	if opts.Metrics != nil {
+		handler = NewReviewHandler(handler, opts.Metrics)
+	}
+	mgr.GetWebhookServer().Register(PodWebhookPath, handler)
+	mgr.GetWebhookServer().Register(PreviousPodWebhookPath, handler)
+	return nil
 }
 
-// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1,matchPolicy=Exact,reinvocationPolicy=Never
+// NewPodWebhook returns the webhook defaulting pods with the defaulting function.
+func NewPodWebhook(scheme *runtime.Scheme, defaultPod defaultPod, opts PodCustomDefaulterOpts) *admission.Webhook {
+	// the CustomDefaulter can only return an error, the handler adds the
+	// rest of the decision to the response
+	wh := &admission.Webhook{}
+	wh.Handler = &decisionHandler{
+		handler:    newPodDefaulter(scheme, NewPodCustomDefaulter(defaultPod, opts)),
+		audit:      opts.Audit,
+		decisions:  opts.Decisions,
+		metrics:    opts.Metrics,
+		sloLatency: opts.SLOLatency,
+	}
+	return wh
+}
 
-//+kubebuilder:rbac:groups="",resources=nodes,verbs=list
-//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;patch
+// DefaultSLOLatency is the default latency the webhook must mutate or pass a
+// pod within.
+const DefaultSLOLatency = 500 * time.Millisecond
+
+// decisionHandler adds the warnings and audit annotations of the decision of the
+// defaulting to the admission response and records it in the audit log. It
+// measures every request against the SLO: the response must allow the pod,
+// mutated or not, within the SLO latency.
+type decisionHandler struct {
+	handler    admission.Handler
+	audit      *audit.Logger
+	decisions  *DecisionLog
+	metrics    metrics.Metrics
+	sloLatency time.Duration
+}
+
+func (h *decisionHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	start := time.Now()
+	ctx, decided := withDecision(ctx)
+	resp := h.handler.Handle(ctx, req)
+	if h.audit != nil && decided.result != "" {
+		h.audit.Record(audit.Record{
+			Time:           start,
+			UID:            req.UID,
+			Namespace:      req.Namespace,
+			Pod:            decided.pod,
+			Result:         decided.result,
+			Reason:         decided.reason,
+			Pool:           decided.pool,
+			Patch:          patchSummary(resp),
+			LatencySeconds: time.Since(start).Seconds(),
+		})
+	}
+	if decided.result != "" {
+		entry := Decision{
+			Time:      start,
+			UID:       req.UID,
+			Namespace: req.Namespace,
+			Pod:       decided.pod,
+			Result:    decided.result,
+			Reason:    decided.reason,
+			Pool:      decided.pool,
+			Warnings:  decided.warnings,
+		}
+		if decided.generated {
+			entry.Pod, entry.GenerateName = "", decided.pod
+		}
+		h.decisions.record(entry)
+	}
+	resp.Warnings = append(resp.Warnings, decided.warnings...)
+	if resp.AuditAnnotations == nil {
+		resp.AuditAnnotations = map[string]string{}
+	}
+	maps.Copy(resp.AuditAnnotations, decided.auditAnnotations())
+	if h.metrics != nil {
+		h.metrics.IncAdmissionSLO(sloOutcome(resp, time.Since(start), h.sloLatency))
+	}
+	return resp
+}
+
+// sloOutcome returns the SLO outcome of the response of an admission request
+// handled in the latency.
+func sloOutcome(resp admission.Response, latency, sloLatency time.Duration) string {
+	if sloLatency <= 0 {
+		sloLatency = DefaultSLOLatency
+	}
+	switch {
+	case !resp.Allowed:
+		return metrics.SLOOutcomeError
+	case latency > sloLatency:
+		return metrics.SLOOutcomeSlow
+	default:
+		return metrics.SLOOutcomeGood
+	}
+}
+
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1,matchPolicy=Exact,reinvocationPolicy=Never
 
 // PodCustomDefaulter struct is responsible for setting default values on the custom resource of the
 // Kind Pod when those are created or updated.
 type PodCustomDefaulter struct {
-	defaultPod func(*corev1.Pod)
+	defaultPod defaultPod
+	metrics    metrics.Metrics
+	namespaces *metrics.LabelGuard
+	events     *eventRecorder
+	// decisionEvents records every decision as event
+	decisionEvents bool
+	skipLogs       *skipSampler
 }
 
 var _ webhook.CustomDefaulter = &PodCustomDefaulter{}
 
+// PodCustomDefaulterOpts are the options of the PodCustomDefaulter.
+type PodCustomDefaulterOpts struct {
+	// Metrics counts the admission requests per result and reason, optional
+	Metrics metrics.Metrics
+	// Namespaces bounds the namespaces the admission requests are counted
+	// per, the requests aren't counted per namespace if nil
+	Namespaces *metrics.LabelGuard
+	// Recorder records Warning events for notable decisions, optional
+	Recorder record.EventRecorder
+	// EventInterval is the minimum interval between events of the same reason
+	// on the same object, events are disabled if not positive
+	EventInterval time.Duration
+	// Audit records the decision of every admission request, optional
+	Audit *audit.Logger
+	// Decisions keeps the last decisions for lookup, optional
+	Decisions *DecisionLog
+	// DecisionEvents records every decision as Normal event, rate limited
+	// like the Warning events
+	DecisionEvents bool
+	// SLOLatency is the latency the webhook must mutate or pass a pod within to
+	// meet the SLO, defaults to DefaultSLOLatency
+	SLOLatency time.Duration
+	// SkipLogInterval is the interval a skipped pod is logged in per skip
+	// class, every skipped pod is logged if not positive
+	SkipLogInterval time.Duration
+}
+
+// NewPodCustomDefaulter returns a PodCustomDefaulter defaulting pods with the
+// defaulting function.
+func NewPodCustomDefaulter(defaultPod defaultPod, opts PodCustomDefaulterOpts) *PodCustomDefaulter {
+	return &PodCustomDefaulter{
+		defaultPod: defaultPod,
+		metrics:    opts.Metrics,
+		namespaces: opts.Namespaces,
+		events:     newEventRecorder(opts.Recorder, opts.EventInterval),
+
+		decisionEvents: opts.DecisionEvents,
+		skipLogs:       newSkipSampler(opts.SkipLogInterval),
+	}
+}
+
 // Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Pod.
 func (d *PodCustomDefaulter) Default(ctx context.Context, obj runtime.Object) (err error) {
+	start := time.Now()
+	ctx, decided := withDecision(ctx)
+	defer func() {
+		if d.metrics == nil {
+			return
+		}
+		var namespace string
+		if req, err := admission.RequestFromContext(ctx); err == nil {
+			namespace = req.Namespace
+		}
+		d.metrics.ObserveAdmission(d.namespaces.Value(namespace), decided.result, decided.reason, time.Since(start))
+	}()
+	defer func() {
+		if decided.result == metrics.AdmissionResultSkipped {
+			d.skipped(ctx, decided)
+		}
+	}()
 	defer func() {
 		r := recover()
 		if r == nil {
@@ -75,73 +262,287 @@ func (d *PodCustomDefaulter) Default(ctx context.Context, obj runtime.Object) (e
 		default:
 			err = fmt.Errorf("unknown defaulting function panic: %s", r)
 		}
+		decided.failed(metrics.AdmissionReasonPanic)
 	}()
 
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.SubResource != "" {
+		// e.g. a binding or ephemeral containers, the node affinity is only injected on create
+		decided.pod = req.Name
+		decided.skipped(metrics.SkipReasonSubresource)
+		return nil
+	}
+
 	pod, ok := obj.(*corev1.Pod)
 
 	if !ok {
+		decided.failed(metrics.AdmissionReasonInvalidObject)
 		return fmt.Errorf("expected an Pod object but got %T", obj)
 	}
 
-	podlog.Info(
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		decided.uid = req.UID
+	}
+	logger := podLogger(ctx)
+	logger.Info(
 		"injecting node affinity",
 		"name", pod.GetName(),
 		"ns", pod.GetNamespace(),
 		"uuid", pod.GetUID(),
 		"labels", pod.GetLabels(),
 	)
-	d.defaultPod(pod)
+	decided.pod = pod.Name
+	if decided.pod == "" {
+		decided.pod, decided.generated = pod.GenerateName, true
+	}
+	d.defaultPod(ctx, pod)
+	if decided.result == "" {
+		// the defaulting function didn't tell
+		decided.mutated("", "")
+	}
+	if decided.result == metrics.AdmissionResultMutated && decided.uid != "" {
+		// links the pod to the audit entry, the log lines and the events of the request
+		metav1.SetMetaDataAnnotation(&pod.ObjectMeta, AnnotationAdmissionUID, string(decided.uid))
+	}
+	d.events.record(pod, podNamespace(ctx, pod), decided)
+	if d.decisionEvents {
+		d.events.recordDecision(pod, podNamespace(ctx, pod), decided)
+	}
 	return nil
 }
 
-func ApplyDefaults(nodeSelectorValue string, omittedNamespaces []string) defaultPod {
-	return func(pod *corev1.Pod) {
-		if slices.Contains(omittedNamespaces, pod.Namespace) {
-			podlog.Info("omitting affinity injection: forbidden namespace", "name", pod.Namespace)
+// skipped counts the skipped pod per skip class and logs it sampled per class,
+// so a storm of skipped pods doesn't flood the logs.
+func (d *PodCustomDefaulter) skipped(ctx context.Context, decided *decision) {
+	class := skipClass(decided)
+	if d.metrics != nil {
+		d.metrics.IncAdmissionSkip(class)
+	}
+	suppressed, ok := d.skipLogs.sample(class, time.Now())
+	if !ok {
+		return
+	}
+	var namespace string
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		namespace = req.Namespace
+	}
+	podLogger(ctx).V(1).Info("omitting affinity injection",
+		"class", class,
+		"reason", decided.reason,
+		"name", decided.pod,
+		"ns", namespace,
+		"rule", decided.rule,
+		"warnings", decided.warnings,
+		"suppressed", suppressed,
+	)
+}
+
+// patchSummary returns the operations and paths of the patch of the response.
+func patchSummary(resp admission.Response) []string {
+	var summary []string
+	for _, patch := range resp.Patches {
+		summary = append(summary, patch.Operation+" "+patch.Path)
+	}
+	return summary
+}
+
+// ApplyDefaultsOpts are the options of the pod defaulting function.
+type ApplyDefaultsOpts struct {
+	// Config returns the current configuration, it is called for every pod
+	Config func() config.Config
+	// ResolvePlacement resolves the placement for the namespace of the pod, optional
+	ResolvePlacement PlacementResolver
+	// Rules returns the current exclusion and inclusion rules, optional
+	Rules func() *rules.Rules
+	// PreferOnly returns true if the node affinity must not be required, optional
+	PreferOnly func() bool
+	// OutageZones returns the zones of the kyma worker pool without ready nodes, optional
+	OutageZones func() []string
+	// ActivePool returns the successor of the kyma worker pool if it was recreated, optional
+	ActivePool func() string
+	// PoolPresent returns false while the kyma worker pool has no nodes at all,
+	// the pods are annotated with the pool then, optional
+	PoolPresent func() bool
+	// PoolReady returns false while the kyma worker pool has no ready nodes, optional
+	PoolReady func() bool
+	// Saturated returns true while the pods request most of the kyma worker pool, optional
+	Saturated func() bool
+	// Taints returns the taints of the nodes of the kyma worker pool, optional
+	Taints func() []corev1.Taint
+	// NamespaceBreaker stops the placement resolution while the namespace cache
+	// is unsynced or failing, optional
+	NamespaceBreaker *breaker.Breaker
+	// NodeBreaker stops the lookups of the state of the kyma worker pool while
+	// the node cache is unsynced or failing, optional
+	NodeBreaker *breaker.Breaker
+	// BreakerMode is the way pods are handled while a breaker is open, defaults
+	// to BreakerModePassThrough
+	BreakerMode string
+}
+
+// Ways pods are handled while the breaker of a dependency is open.
+const (
+	// BreakerModePassThrough passes the pods unchanged
+	BreakerModePassThrough = "pass-through"
+	// BreakerModeDefaults injects the configured placement without the dependency
+	BreakerModeDefaults = "defaults"
+)
+
+// dependencyUnavailable skips the pod if the breaker of the dependency is open
+// and pods are passed through, it returns false if the pod is defaulted
+// without the dependency.
+func dependencyUnavailable(ctx context.Context, mode, dependency string) bool {
+	if mode == BreakerModeDefaults {
+		return false
+	}
+	decisionFrom(ctx).skipped(metrics.SkipReasonDependencyUnavailable)
+	decisionFrom(ctx).warn("node affinity not injected: the " + dependency + " of the cluster are unavailable")
+	return true
+}
+
+func ApplyDefaults(opts ApplyDefaultsOpts) defaultPod {
+	return func(ctx context.Context, pod *corev1.Pod) {
+		logger := podLogger(ctx)
+		cfg := opts.Config()
+		if cfg.Cleanup {
+			decisionFrom(ctx).skipped(metrics.SkipReasonCleanup)
+			decisionFrom(ctx).warn("node affinity not injected: cleanup in progress")
+			return
+		}
+		namespace := podNamespace(ctx, pod)
+		if slices.Contains(cfg.OmittedNamespaces, namespace) {
+			decisionFrom(ctx).skipped(metrics.SkipReasonOmittedNamespace)
+			return
+		}
+
+		if opts.Rules != nil {
+			matched, reason, err := opts.Rules().Match(pod)
+			if err != nil {
+				logger.Error(err, "unable to evaluate rules, omitting affinity injection")
+				decisionFrom(ctx).skipped(metrics.SkipReasonRuleError)
+				decisionFrom(ctx).notice(EventReasonInjectionSkipped,
+					"node affinity not injected: the rules could not be evaluated")
+				return
+			}
+			if !matched {
+				decisionFrom(ctx).skipped(metrics.SkipReasonExcludedByRule)
+				decisionFrom(ctx).rule = reason
+				decisionFrom(ctx).warn("node affinity not injected: excluded by rule " + reason)
+				return
+			}
+		}
+
+		placement := PlacementFromConfig(cfg)
+		if opts.ResolvePlacement != nil && !opts.NamespaceBreaker.Allow() {
+			if dependencyUnavailable(ctx, opts.BreakerMode, breaker.DependencyNamespaces) {
+				return
+			}
+		} else if opts.ResolvePlacement != nil {
+			resolved, err := opts.ResolvePlacement(ctx, namespace, placement)
+			opts.NamespaceBreaker.Report(err)
+			if budgetExceeded(ctx) {
+				// e.g. a namespace missing in the cache
+				return
+			}
+			if err != nil {
+				logger.Error(err, "unable to resolve namespace placement, using defaults", "ns", namespace)
+			} else {
+				placement = resolved
+			}
+		}
+
+		if budgetExceeded(ctx) {
 			return
 		}
 
-		if pod.Spec.Affinity == nil {
-			pod.Spec.Affinity = &corev1.Affinity{}
+		// successors and zone outages are only known for the kyma worker pool
+		var tolerations []corev1.Toleration
+		if placement.Pool == cfg.KymaWorkerPoolName && !opts.NodeBreaker.Allow() {
+			if dependencyUnavailable(ctx, opts.BreakerMode, breaker.DependencyNodes) {
+				return
+			}
+		} else if placement.Pool == cfg.KymaWorkerPoolName {
+			if opts.ActivePool != nil {
+				if active := opts.ActivePool(); active != "" {
+					placement.Pool = active
+				}
+			}
+			if opts.OutageZones != nil {
+				placement.ExcludedZones = opts.OutageZones()
+			}
+			if opts.PoolPresent != nil && !opts.PoolPresent() {
+				applyFallback(ctx, pod, placement.Pool)
+				return
+			}
+			// pods must not be biased toward a pool that is being created or scaled from zero
+			if opts.PoolReady != nil && !opts.PoolReady() {
+				decisionFrom(ctx).skipped(metrics.SkipReasonPoolNotReady)
+				decisionFrom(ctx).notice(EventReasonInjectionSkipped, fmt.Sprintf(
+					"node affinity not injected: the worker pool %s has no ready nodes", placement.Pool))
+				return
+			}
+			// low priority pods leave the remaining room of a saturated pool to the others
+			if opts.Saturated != nil && cfg.SaturationMinPriority != nil &&
+				ptr.Deref(pod.Spec.Priority, 0) < *cfg.SaturationMinPriority && opts.Saturated() {
+				decisionFrom(ctx).skipped(metrics.SkipReasonPoolSaturated)
+				decisionFrom(ctx).notice(EventReasonInjectionSkipped, fmt.Sprintf(
+					"node affinity not injected: the worker pool %s is saturated", placement.Pool))
+				return
+			}
+			if opts.Taints != nil && len(cfg.TolerationAllowList) > 0 {
+				tolerations = tolerationsFor(opts.Taints(), cfg.TolerationAllowList)
+			}
 		}
 
-		if pod.Spec.Affinity.NodeAffinity == nil {
-			pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+		// pods requiring another pool are left alone, they wouldn't be schedulable anymore
+		if pools := conflictingPools(&pod.Spec, placement); len(pools) > 0 {
+			decisionFrom(ctx).skipped(metrics.SkipReasonConflict)
+			decisionFrom(ctx).notice(EventReasonAffinityConflict, fmt.Sprintf(
+				"node affinity not injected: the pod requires the worker pools %v instead of %s", pools, placement.Pool))
+			return
 		}
 
-		if pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution == nil {
-			pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution =
-				[]corev1.PreferredSchedulingTerm{}
+		if placement.Mode == config.ModeRequired && opts.PreferOnly != nil && opts.PreferOnly() {
+			logger.Info("injecting required node affinity as preferred: kyma worker pool at maximum size")
+			placement.Mode = config.ModePreferred
 		}
 
-		pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution =
-			append(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
-				corev1.PreferredSchedulingTerm{
-					Weight: 10,
-					Preference: corev1.NodeSelectorTerm{
-						MatchExpressions: []corev1.NodeSelectorRequirement{
-							{
-								Key:      kymaNodeSelectorKey,
-								Operator: corev1.NodeSelectorOpIn,
-								Values:   []string{nodeSelectorValue},
-							},
-						},
-					},
-				})
+		if terms := requiredTerms(&pod.Spec); terms > 0 && placement.Mode == config.ModeRequired &&
+			featuregate.DefaultFeatureGate.Enabled(featuregate.RequiredMode) {
+			decisionFrom(ctx).notice(EventReasonAffinityEnforced, fmt.Sprintf(
+				"the worker pool %s was added to the %d required node selector terms of the pod", placement.Pool, terms))
+		}
+		injectNodeAffinity(pod, placement)
+		injectTolerations(pod, tolerations)
+		decisionFrom(ctx).mutated(placement.Pool, placement.Mode)
 	}
 }
 
-var ErrNodeNotFound = fmt.Errorf("node selector not found")
+// podLogger returns the logger of the pod defaulting, it carries the UID of the
+// admission request as requestID like the log lines of controller-runtime.
+func podLogger(ctx context.Context) logr.Logger {
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		return podlog.WithValues("requestID", req.UID)
+	}
+	return podlog
+}
 
-func ApplyDefaultsFallback(nodeSelectorValue string) defaultPod {
-	return func(pod *corev1.Pod) {
-		if pod.Annotations == nil {
-			pod.Annotations = map[string]string{}
-		}
+// podNamespace returns the namespace of the pod, the namespace of pods that are
+// being created is not always set on the object itself.
+func podNamespace(ctx context.Context, pod *corev1.Pod) string {
+	if pod.Namespace != "" {
+		return pod.Namespace
+	}
 
-		pod.Annotations[kymaNodeSelectorKey] = nodeSelectorValue
-		podlog.Error(ErrNodeNotFound, "unable to set node selector",
-			"node-selector-value", nodeSelectorValue,
-		)
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		return req.Namespace
 	}
+	return ""
+}
+
+// applyFallback annotates the pod with the pool instead of injecting the node
+// affinity, the pool has no nodes the pod could be scheduled on.
+func applyFallback(ctx context.Context, pod *corev1.Pod, pool string) {
+	metav1.SetMetaDataAnnotation(&pod.ObjectMeta, kymaNodeSelectorKey, pool)
+	decisionFrom(ctx).skipped(metrics.SkipReasonFallback)
+	decisionFrom(ctx).warn("node affinity not injected: the worker pool " + pool + " has no nodes")
 }
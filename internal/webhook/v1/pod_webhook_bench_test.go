@@ -0,0 +1,97 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// benchmarkRequest returns the admission request of a pod of a Deployment with
+// a sidecar, the common pod the node affinity is injected in.
+func benchmarkRequest(tb testing.TB) admission.Request {
+	tb.Helper()
+
+	container := func(name string) corev1.Container {
+		return corev1.Container{
+			Name:  name,
+			Image: "europe-docker.pkg.dev/kyma-project/prod/" + name + ":1.0.0",
+			Args:  []string{"--metrics-bind-address=:8080", "--leader-elect"},
+			Env:   []corev1.EnvVar{{Name: "GOMEMLIMIT", Value: "100MiB"}},
+			Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 8080}},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: "token", MountPath: "/var/run/secrets/tokens"}},
+		}
+	}
+	pod := testPod("kyma-system")
+	pod.Name, pod.GenerateName = "", "telemetry-manager-7d9c8b5f4-"
+	pod.Labels = map[string]string{"app.kubernetes.io/name": "telemetry-manager", "pod-template-hash": "7d9c8b5f4"}
+	pod.Annotations = map[string]string{"sidecar.istio.io/inject": "false"}
+	pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet",
+		Name: "telemetry-manager-7d9c8b5f4", UID: "8c4e1b5e-6f0a-4f5e-9b1e-3f2d1c0b9a87"}}
+	pod.Spec.Containers = []corev1.Container{container("telemetry-manager"), container("sidecar")}
+	pod.Spec.Volumes = []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{
+		Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+			ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"},
+		}}},
+	}}}
+	pod.Spec.Tolerations = []corev1.Toleration{{Key: "node.kubernetes.io/not-ready", Operator: corev1.TolerationOpExists,
+		Effect: corev1.TaintEffectNoExecute}}
+	raw, err := json.Marshal(pod)
+	require.NoError(tb, err)
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       "2f8a4c3e-1b7d-4e6f-a5c9-0d3b2e1f4a68",
+		Operation: admissionv1.Create,
+		Namespace: pod.Namespace,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+// maxAllocations bounds the allocations of an admission request of the common
+// pod, the patch of the common mutation isn't built from a diff of the pod.
+const maxAllocations = 500
+
+func Test_NewPodWebhook_allocations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	wh := webhookv1.NewPodWebhook(scheme, webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{})
+	req := benchmarkRequest(t)
+
+	allocations := testing.AllocsPerRun(100, func() {
+		wh.Handle(context.Background(), req)
+	})
+	assert.LessOrEqual(t, allocations, float64(maxAllocations))
+}
+
+func BenchmarkPodWebhook(b *testing.B) {
+	scheme := runtime.NewScheme()
+	require.NoError(b, corev1.AddToScheme(scheme))
+	wh := webhookv1.NewPodWebhook(scheme, webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{})
+	req := benchmarkRequest(b)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if resp := wh.Handle(ctx, req); !resp.Allowed || len(resp.Patches) == 0 {
+			b.Fatalf("pod not mutated: %v", resp.Result)
+		}
+	}
+}
@@ -1,84 +1,567 @@
-/*
-Copyright 2024.
+package v1_test
 
-Licensed under the Apache License, Version 2.0 (the "License");
-you may not use this file except in compliance with the License.
-You may obtain a copy of the License at
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
 
-    http://www.apache.org/licenses/LICENSE-2.0
+	"github.com/kyma-project/kim-snatch/internal/audit"
+	"github.com/kyma-project/kim-snatch/internal/breaker"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/featuregate"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/kyma-project/kim-snatch/internal/rules"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
 
-Unless required by applicable law or agreed to in writing, software
-distributed under the License is distributed on an "AS IS" BASIS,
-WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-See the License for the specific language governing permissions and
-limitations under the License.
-*/
+func Test_ApplyDefaults_rules(t *testing.T) {
+	compiled, err := rules.Compile([]string{"object.metadata.labels['app'] == 'nats'"}, nil)
+	require.NoError(t, err)
 
-package v1
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+		Rules:  func() *rules.Rules { return compiled },
+	})
 
-import (
-	. "github.com/onsi/ginkgo/v2"
-	. "github.com/onsi/gomega"
+	excluded := testPod("test")
+	excluded.Labels = map[string]string{"app": "nats"}
+	defaultPod(context.Background(), excluded)
+	assert.Nil(t, excluded.Spec.Affinity)
 
-	corev1 "k8s.io/api/core/v1"
-	// TODO (user): Add any additional imports if needed
-)
+	included := testPod("test")
+	defaultPod(context.Background(), included)
+	assert.NotNil(t, included.Spec.Affinity)
+}
+
+func Test_ApplyDefaults_cleanup(t *testing.T) {
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(func(cfg *config.Config) {
+			cfg.Cleanup = true
+		}),
+	})
+
+	pod := testPod("test")
+	defaultPod(context.Background(), pod)
+
+	assert.Nil(t, pod.Spec.Affinity)
+}
+
+func Test_ApplyDefaults_prefer_only(t *testing.T) {
+	enableFeature(t, featuregate.RequiredMode)
+
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(func(cfg *config.Config) {
+			cfg.AffinityMode = config.ModeRequired
+		}),
+		PreferOnly: func() bool { return true },
+	})
+
+	pod := testPod("test")
+	defaultPod(context.Background(), pod)
+
+	require.NotNil(t, pod.Spec.Affinity)
+	assert.Nil(t, pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	assert.Len(t, pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+}
+
+func Test_ApplyDefaults_outage_zones(t *testing.T) {
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config:      testConfig(),
+		OutageZones: func() []string { return []string{"zone-b"} },
+	})
+
+	pod := testPod("test")
+	defaultPod(context.Background(), pod)
 
-var _ = Describe("Pod Webhook", func() {
-	var (
-		obj       *corev1.Pod
-		oldObj    *corev1.Pod
-		defaulter PodCustomDefaulter
-	)
-
-	BeforeEach(func() {
-		obj = &corev1.Pod{}
-		oldObj = &corev1.Pod{}
-		defaulter = PodCustomDefaulter{}
-		Expect(defaulter).NotTo(BeNil(), "Expected defaulter to be initialized")
-		Expect(oldObj).NotTo(BeNil(), "Expected oldObj to be initialized")
-		Expect(obj).NotTo(BeNil(), "Expected obj to be initialized")
-		// TODO (user): Add any setup logic common to all tests
+	require.NotNil(t, pod.Spec.Affinity)
+	terms := pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	assert.Contains(t, terms[0].Preference.MatchExpressions, corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelTopologyZone,
+		Operator: corev1.NodeSelectorOpNotIn,
+		Values:   []string{"zone-b"},
 	})
+}
+
+func Test_ApplyDefaults_pool_not_ready(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveAdmission", "", metrics.AdmissionResultSkipped, metrics.SkipReasonPoolNotReady,
+		mock.AnythingOfType("time.Duration")).Once()
+	mtr.On("IncAdmissionSkip", metrics.SkipClassPoolMissing).Once()
+
+	defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config:    testConfig(),
+		PoolReady: func() bool { return false },
+	}), webhookv1.PodCustomDefaulterOpts{Metrics: mtr})
+
+	pod := testPod("test")
+	require.NoError(t, defaulter.Default(context.Background(), pod))
+
+	assert.Nil(t, pod.Spec.Affinity)
+}
+
+func Test_ApplyDefaults_pool_saturated(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveAdmission", "", metrics.AdmissionResultSkipped, metrics.SkipReasonPoolSaturated,
+		mock.AnythingOfType("time.Duration")).Once()
+	mtr.On("ObserveAdmission", "", metrics.AdmissionResultMutated, config.ModePreferred,
+		mock.AnythingOfType("time.Duration")).Once()
+	mtr.On("IncAdmissionSkip", metrics.SkipClassPoolSaturated).Once()
+
+	defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(func(cfg *config.Config) {
+			cfg.SaturationMinPriority = ptr.To(int32(1000))
+		}),
+		Saturated: func() bool { return true },
+	}), webhookv1.PodCustomDefaulterOpts{Metrics: mtr})
+
+	lowPriority := testPod("test")
+	require.NoError(t, defaulter.Default(context.Background(), lowPriority))
+	assert.Nil(t, lowPriority.Spec.Affinity)
+
+	highPriority := testPod("test")
+	highPriority.Spec.Priority = ptr.To(int32(2000))
+	require.NoError(t, defaulter.Default(context.Background(), highPriority))
+	assert.NotNil(t, highPriority.Spec.Affinity)
+}
+
+func Test_ApplyDefaults_dependency_unavailable(t *testing.T) {
+	unsynced := breaker.New(breaker.Options{
+		Dependency: breaker.DependencyNodes,
+		Synced:     func() bool { return false },
+	})
+	for _, tc := range []struct {
+		mode     string
+		affinity bool
+	}{
+		{mode: webhookv1.BreakerModePassThrough},
+		{mode: webhookv1.BreakerModeDefaults, affinity: true},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+				Config: testConfig(),
+				// the pool state isn't known without the nodes
+				PoolReady:   func() bool { return false },
+				NodeBreaker: unsynced,
+				BreakerMode: tc.mode,
+			})
+
+			pod := testPod("test")
+			defaultPod(context.Background(), pod)
+			assert.Equal(t, tc.affinity, pod.Spec.Affinity != nil)
+		})
+	}
+}
+
+func Test_ApplyDefaults_tolerations(t *testing.T) {
+	taints := []corev1.Taint{
+		{Key: "dedicated", Value: "kyma", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+		{Key: "node.kubernetes.io/not-ready", Effect: corev1.TaintEffectNoExecute},
+	}
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(func(cfg *config.Config) {
+			cfg.TolerationAllowList = []string{"dedicated", "spot"}
+		}),
+		Taints: func() []corev1.Taint { return taints },
+	})
+
+	pod := testPod("test")
+	pod.Spec.Tolerations = []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "kyma", Effect: corev1.TaintEffectNoSchedule},
+	}
+	defaultPod(context.Background(), pod)
+
+	// only allowed taints are tolerated, existing tolerations are kept
+	assert.Equal(t, []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "kyma", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectPreferNoSchedule},
+	}, pod.Spec.Tolerations)
+}
 
-	AfterEach(func() {
-		// TODO (user): Add any teardown logic common to all tests
+func Test_ApplyDefaults_tolerations_without_allow_list(t *testing.T) {
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+		Taints: func() []corev1.Taint {
+			return []corev1.Taint{{Key: "dedicated", Value: "kyma", Effect: corev1.TaintEffectNoSchedule}}
+		},
 	})
 
-	Context("When creating Pod under Defaulting Webhook", func() {
-		// TODO (user): Add logic for defaulting webhooks
-		// Example:
-		// It("Should apply defaults when a required field is empty", func() {
-		//     By("simulating a scenario where defaults should be applied")
-		//     obj.SomeFieldWithDefault = ""
-		//     By("calling the Default method to apply defaults")
-		//     defaulter.Default(ctx, obj)
-		//     By("checking that the default values are set")
-		//     Expect(obj.SomeFieldWithDefault).To(Equal("default_value"))
-		// })
+	pod := testPod("test")
+	defaultPod(context.Background(), pod)
+	assert.NotNil(t, pod.Spec.Affinity)
+	assert.Empty(t, pod.Spec.Tolerations)
+}
+
+func Test_PodCustomDefaulter_metrics(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		cfg    func(*config.Config)
+		obj    runtime.Object
+		result string
+		reason string
+		class  string
+	}{
+		{
+			name:   "mutated",
+			obj:    testPod("test"),
+			result: metrics.AdmissionResultMutated,
+			reason: config.ModePreferred,
+		},
+		{
+			name:   "skipped",
+			cfg:    func(cfg *config.Config) { cfg.OmittedNamespaces = []string{"test"} },
+			obj:    testPod("test"),
+			result: metrics.AdmissionResultSkipped,
+			reason: metrics.SkipReasonOmittedNamespace,
+			class:  metrics.SkipClassUnmanagedNamespace,
+		},
+		{
+			name:   "error",
+			obj:    &corev1.Namespace{},
+			result: metrics.AdmissionResultError,
+			reason: metrics.AdmissionReasonInvalidObject,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			modify := []func(*config.Config){}
+			if tc.cfg != nil {
+				modify = append(modify, tc.cfg)
+			}
+			mtr := mocks.NewMetrics(t)
+			mtr.On("ObserveAdmission", "", tc.result, tc.reason, mock.AnythingOfType("time.Duration")).Once()
+			if tc.class != "" {
+				mtr.On("IncAdmissionSkip", tc.class).Once()
+			}
+
+			defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+				Config: testConfig(modify...),
+			}), webhookv1.PodCustomDefaulterOpts{Metrics: mtr})
+			err := defaulter.Default(context.Background(), tc.obj)
+			assert.Equal(t, tc.result == metrics.AdmissionResultError, err != nil)
+		})
+	}
+}
+
+func Test_PodCustomDefaulter_panic(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveAdmission", "", metrics.AdmissionResultError, metrics.AdmissionReasonPanic,
+		mock.AnythingOfType("time.Duration")).Once()
+
+	defaulter := webhookv1.NewPodCustomDefaulter(func(context.Context, *corev1.Pod) {
+		panic("test")
+	}, webhookv1.PodCustomDefaulterOpts{Metrics: mtr})
+	assert.EqualError(t, defaulter.Default(context.Background(), testPod("test")), "test")
+}
+
+func Test_ApplyDefaults_fallback_metrics(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveAdmission", "", metrics.AdmissionResultSkipped, metrics.SkipReasonFallback,
+		mock.AnythingOfType("time.Duration")).Once()
+	mtr.On("IncAdmissionSkip", metrics.SkipClassPoolMissing).Once()
+
+	defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config:      testConfig(),
+		PoolPresent: func() bool { return false },
+	}), webhookv1.PodCustomDefaulterOpts{Metrics: mtr})
+	require.NoError(t, defaulter.Default(context.Background(), testPod("test")))
+}
+
+func Test_ApplyDefaults_fallback(t *testing.T) {
+	present := false
+	cfg := config.Default()
+	cfg.KymaWorkerPoolName = testPlacement.Pool
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config:      func() config.Config { return cfg },
+		PoolPresent: func() bool { return present },
 	})
 
-	Context("When creating or updating Pod under Validating Webhook", func() {
-		// TODO (user): Add logic for validating webhooks
-		// Example:
-		// It("Should deny creation if a required field is missing", func() {
-		//     By("simulating an invalid creation scenario")
-		//     obj.SomeRequiredField = ""
-		//     Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
-		// })
-		//
-		// It("Should admit creation if all required fields are present", func() {
-		//     By("simulating an invalid creation scenario")
-		//     obj.SomeRequiredField = "valid_value"
-		//     Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
-		// })
-		//
-		// It("Should validate updates correctly", func() {
-		//     By("simulating a valid update scenario")
-		//     oldObj.SomeRequiredField = "updated_value"
-		//     obj.SomeRequiredField = "updated_value"
-		//     Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeNil())
-		// })
+	// the pods are annotated while the pool has no nodes
+	pod := testPod("test")
+	defaultPod(context.Background(), pod)
+	assert.Nil(t, pod.Spec.Affinity)
+	assert.Equal(t, testPlacement.Pool, pod.Annotations["worker.gardener.cloud/pool"])
+
+	// omitted namespaces and the cleanup are respected while falling back
+	cfg.OmittedNamespaces = []string{"test"}
+	pod = testPod("test")
+	defaultPod(context.Background(), pod)
+	assert.Empty(t, pod.Annotations)
+	cfg.OmittedNamespaces, cfg.Cleanup = nil, true
+	pod = testPod("test")
+	defaultPod(context.Background(), pod)
+	assert.Empty(t, pod.Annotations)
+
+	// the node affinity is injected as soon as nodes joined the pool
+	cfg.Cleanup, present = false, true
+	pod = testPod("test")
+	defaultPod(context.Background(), pod)
+	assert.NotNil(t, pod.Spec.Affinity)
+	assert.Empty(t, pod.Annotations)
+}
+
+func Test_ApplyDefaults_conflict(t *testing.T) {
+	defaultPod := webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
 	})
 
-})
+	selected := testPod("test")
+	selected.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": "other"}
+	defaultPod(context.Background(), selected)
+	assert.Nil(t, selected.Spec.Affinity)
+
+	same := testPod("test")
+	same.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": testPlacement.Pool}
+	defaultPod(context.Background(), same)
+	assert.NotNil(t, same.Spec.Affinity)
+}
+
+func Test_PodCustomDefaulter_events(t *testing.T) {
+	enableFeature(t, featuregate.RequiredMode)
+	recorder := record.NewFakeRecorder(10)
+	defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(func(cfg *config.Config) {
+			cfg.AffinityMode = config.ModeRequired
+		}),
+	}), webhookv1.PodCustomDefaulterOpts{Recorder: recorder, EventInterval: time.Minute})
+
+	conflicting := func() *corev1.Pod {
+		pod := testPod("test")
+		pod.Name = ""
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "test-rs", Controller: ptr.To(true),
+		}}
+		pod.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": "other"}
+		return pod
+	}
+	// the second event of the same controller is suppressed
+	require.NoError(t, defaulter.Default(context.Background(), conflicting()))
+	require.NoError(t, defaulter.Default(context.Background(), conflicting()))
+
+	enforced := testPod("test")
+	enforced.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"},
+			}}}},
+		},
+	}}
+	require.NoError(t, defaulter.Default(context.Background(), enforced))
+
+	// pods without notable decisions have no events
+	require.NoError(t, defaulter.Default(context.Background(), testPod("test")))
+
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Contains(t, events[0], "Warning "+webhookv1.EventReasonAffinityConflict)
+	assert.Contains(t, events[1], "Warning "+webhookv1.EventReasonAffinityEnforced)
+}
+
+func Test_NewPodWebhook_decision(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	wh := webhookv1.NewPodWebhook(scheme, webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{})
+
+	handle := func(pod *corev1.Pod) admission.Response {
+		raw, err := json.Marshal(pod)
+		require.NoError(t, err)
+		return wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Namespace: pod.Namespace,
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+	}
+
+	conflicting := testPod("test")
+	conflicting.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": "other"}
+	resp := handle(conflicting)
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+	assert.Equal(t, []string{"kim-snatch: node affinity not injected: the pod requires the worker pools [other] instead of " +
+		testPlacement.Pool}, resp.Warnings)
+	assert.Equal(t, map[string]string{
+		webhookv1.AuditAnnotationDecision: "skipped/" + metrics.SkipReasonConflict,
+		webhookv1.AuditAnnotationReason:   metrics.SkipReasonConflict,
+	}, resp.AuditAnnotations)
+
+	resp = handle(testPod("test"))
+	assert.True(t, resp.Allowed)
+	assert.NotEmpty(t, resp.Patches)
+	assert.Empty(t, resp.Warnings)
+	assert.Equal(t, map[string]string{
+		webhookv1.AuditAnnotationDecision: "mutated/" + testPlacement.Pool,
+		webhookv1.AuditAnnotationReason:   config.ModePreferred,
+	}, resp.AuditAnnotations)
+}
+
+func Test_NewPodWebhook_audit(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	var buf bytes.Buffer
+	logger := audit.NewLogger(audit.NewWriterSink(&buf), audit.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, logger.Start(ctx))
+	}()
+
+	wh := webhookv1.NewPodWebhook(scheme, webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{Audit: logger})
+	raw, err := json.Marshal(testPod("test"))
+	require.NoError(t, err)
+	resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       "test-uid",
+		Operation: admissionv1.Create,
+		Namespace: "test",
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+	require.True(t, resp.Allowed)
+	cancel()
+	<-done
+
+	var record audit.Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, types.UID("test-uid"), record.UID)
+	assert.Equal(t, "test-me", record.Pod)
+	assert.Equal(t, metrics.AdmissionResultMutated, record.Result)
+	assert.Equal(t, testPlacement.Pool, record.Pool)
+	assert.ElementsMatch(t, []string{"add /spec/affinity", "add /metadata/annotations"}, record.Patch)
+	require.NoError(t, audit.Verify([]audit.Record{record}))
+}
+
+func Test_NewPodWebhook_correlation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	recorder := record.NewFakeRecorder(10)
+	wh := webhookv1.NewPodWebhook(scheme, webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{Recorder: recorder, EventInterval: time.Minute})
+
+	handle := func(pod *corev1.Pod) admission.Response {
+		raw, err := json.Marshal(pod)
+		require.NoError(t, err)
+		return wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Create,
+			Namespace: pod.Namespace,
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+	}
+
+	// the mutated pod carries the UID of the request
+	resp := handle(testPod("test"))
+	require.True(t, resp.Allowed)
+	var annotations any
+	for _, patch := range resp.Patches {
+		if patch.Path == "/metadata/annotations" {
+			annotations = patch.Value
+		}
+	}
+	// the patch is sent as JSON
+	data, err := json.Marshal(annotations)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"`+webhookv1.AnnotationAdmissionUID+`":"test-uid"}`, string(data))
+
+	// skipped pods are unchanged, their events carry the UID
+	conflicting := testPod("test")
+	conflicting.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": "other"}
+	resp = handle(conflicting)
+	require.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0], webhookv1.EventReasonAffinityConflict)
+	assert.Contains(t, events[0], webhookv1.AnnotationAdmissionUID+":test-uid")
+}
+
+func Test_PodCustomDefaulter_namespaces(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveAdmission", "a", metrics.AdmissionResultMutated, config.ModePreferred,
+		mock.AnythingOfType("time.Duration")).Twice()
+	mtr.On("ObserveAdmission", metrics.LabelOther, metrics.AdmissionResultMutated, config.ModePreferred,
+		mock.AnythingOfType("time.Duration")).Once()
+
+	defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(webhookv1.ApplyDefaultsOpts{
+		Config: testConfig(),
+	}), webhookv1.PodCustomDefaulterOpts{Metrics: mtr, Namespaces: metrics.NewLabelGuard(1)})
+
+	// namespaces beyond the first one are aggregated
+	for _, namespace := range []string{"a", "b", "a"} {
+		ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{Namespace: namespace},
+		})
+		require.NoError(t, defaulter.Default(ctx, testPod(namespace)))
+	}
+}
+
+func Test_NewPodWebhook_slo(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	raw, err := json.Marshal(testPod("test"))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		defaultPod func(context.Context, *corev1.Pod)
+		outcome    string
+	}{
+		{
+			name:       "good",
+			defaultPod: func(context.Context, *corev1.Pod) {},
+			outcome:    metrics.SLOOutcomeGood,
+		},
+		{
+			name:       "slow",
+			defaultPod: func(context.Context, *corev1.Pod) { time.Sleep(20 * time.Millisecond) },
+			outcome:    metrics.SLOOutcomeSlow,
+		},
+		{
+			name:       "error",
+			defaultPod: func(context.Context, *corev1.Pod) { panic("test") },
+			outcome:    metrics.SLOOutcomeError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mtr := mocks.NewMetrics(t)
+			mtr.On("ObserveAdmission", "", mock.Anything, mock.Anything, mock.AnythingOfType("time.Duration")).Once()
+			mtr.On("IncAdmissionSLO", tc.outcome).Once()
+
+			wh := webhookv1.NewPodWebhook(scheme, tc.defaultPod, webhookv1.PodCustomDefaulterOpts{
+				Metrics:    mtr,
+				SLOLatency: 10 * time.Millisecond,
+			})
+			wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Namespace: "test",
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+// maxReviewSize is the size of the AdmissionReviews the webhook of
+// controller-runtime accepts.
+const maxReviewSize = 7 * 1024 * 1024
+
+// reviewMeta is the part of an AdmissionReview the request is classified by.
+type reviewMeta struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Request    *json.RawMessage `json:"request"`
+}
+
+// reviewHandler measures the size of the AdmissionReviews and counts those the
+// webhook can't decode per reason, e.g. to detect a version skew between the
+// API server and the webhook. The review is passed to the webhook unchanged,
+// the webhook responds to it as before.
+type reviewHandler struct {
+	handler http.Handler
+	metrics metrics.Metrics
+}
+
+// NewReviewHandler returns a handler measuring the AdmissionReviews passed to
+// the webhook handler.
+func NewReviewHandler(handler http.Handler, mtr metrics.Metrics) http.Handler {
+	return &reviewHandler{handler: handler, metrics: mtr}
+}
+
+func (h *reviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil || r.Body == http.NoBody {
+		h.metrics.IncAdmissionDecodeError(metrics.DecodeErrorEmptyBody)
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReviewSize+1))
+	// the webhook reads the rest of the body itself
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		h.metrics.IncAdmissionDecodeError(metrics.DecodeErrorRead)
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	version, reason := classifyReview(r.Header.Get("Content-Type"), body)
+	h.metrics.ObserveAdmissionReviewSize(version, len(body))
+	if reason != "" {
+		h.metrics.IncAdmissionDecodeError(reason)
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// classifyReview returns the version of the AdmissionReview and the reason the
+// webhook can't decode it, the reason is empty for valid reviews.
+func classifyReview(contentType string, body []byte) (version, reason string) {
+	version = metrics.ReviewVersionUnknown
+	if len(body) > maxReviewSize {
+		return version, metrics.DecodeErrorTooLarge
+	}
+	if contentType != "application/json" {
+		return version, metrics.DecodeErrorContentType
+	}
+	var meta reviewMeta
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return version, metrics.DecodeErrorMalformed
+	}
+	switch meta.APIVersion {
+	case admissionv1.SchemeGroupVersion.String():
+		version = admissionv1.SchemeGroupVersion.Version
+	case admissionv1beta1.SchemeGroupVersion.String():
+		version = admissionv1beta1.SchemeGroupVersion.Version
+	default:
+		return version, metrics.DecodeErrorUnknownVersion
+	}
+	if meta.Kind != "AdmissionReview" {
+		return version, metrics.DecodeErrorUnknownVersion
+	}
+	if meta.Request == nil {
+		return version, metrics.DecodeErrorMissingRequest
+	}
+	return version, ""
+}
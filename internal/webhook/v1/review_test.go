@@ -0,0 +1,90 @@
+package v1_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ReviewHandler(t *testing.T) {
+	review, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  &admissionv1.AdmissionRequest{UID: "test"},
+	})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		body        string
+		contentType string
+		version     string
+		reason      string
+	}{
+		"valid":           {body: string(review), version: "v1"},
+		"v1beta1":         {body: `{"apiVersion":"admission.k8s.io/v1beta1","kind":"AdmissionReview","request":{}}`, version: "v1beta1"},
+		"empty":           {reason: metrics.DecodeErrorEmptyBody},
+		"content type":    {body: string(review), contentType: "text/plain", version: metrics.ReviewVersionUnknown, reason: metrics.DecodeErrorContentType},
+		"malformed":       {body: `{"apiVersion":`, version: metrics.ReviewVersionUnknown, reason: metrics.DecodeErrorMalformed},
+		"unknown version": {body: `{"apiVersion":"admission.k8s.io/v2","kind":"AdmissionReview","request":{}}`, version: metrics.ReviewVersionUnknown, reason: metrics.DecodeErrorUnknownVersion},
+		"missing request": {body: `{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`, version: "v1", reason: metrics.DecodeErrorMissingRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mtr := mocks.NewMetrics(t)
+			if tc.version != "" {
+				mtr.On("ObserveAdmissionReviewSize", tc.version, len(tc.body)).Once()
+			}
+			if tc.reason != "" {
+				mtr.On("IncAdmissionDecodeError", tc.reason).Once()
+			}
+
+			// the webhook receives the complete review
+			var received []byte
+			handler := webhookv1.NewReviewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Body != nil {
+					received, _ = io.ReadAll(r.Body)
+				}
+			}), mtr)
+
+			var body io.Reader = http.NoBody
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/mutate--v1-pod", body)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.body, string(received))
+		})
+	}
+}
+
+func Test_ReviewHandler_tooLarge(t *testing.T) {
+	mtr := mocks.NewMetrics(t)
+	mtr.On("ObserveAdmissionReviewSize", metrics.ReviewVersionUnknown, mock.AnythingOfType("int")).Once()
+	mtr.On("IncAdmissionDecodeError", metrics.DecodeErrorTooLarge).Once()
+
+	large := bytes.Repeat([]byte{' '}, 8*1024*1024)
+	var received int
+	handler := webhookv1.NewReviewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = len(data)
+	}), mtr)
+	req := httptest.NewRequest(http.MethodPost, "/mutate--v1-pod", bytes.NewReader(large))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, len(large), received)
+}
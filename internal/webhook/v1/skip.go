@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+)
+
+// DefaultSkipLogInterval is the default interval a skipped pod is logged in per skip class.
+const DefaultSkipLogInterval = 10 * time.Second
+
+// skipClasses are the skip classes of the skip reasons, the excluded_by_rule
+// reason is classified by the rule.
+var skipClasses = map[string]string{
+	metrics.SkipReasonOmittedNamespace:      metrics.SkipClassUnmanagedNamespace,
+	metrics.SkipReasonConflict:              metrics.SkipClassConflict,
+	metrics.SkipReasonPoolNotReady:          metrics.SkipClassPoolMissing,
+	metrics.SkipReasonFallback:              metrics.SkipClassPoolMissing,
+	metrics.SkipReasonPoolSaturated:         metrics.SkipClassPoolSaturated,
+	metrics.SkipReasonSubresource:           metrics.SkipClassSubresource,
+	metrics.SkipReasonCleanup:               metrics.SkipClassCleanup,
+	metrics.SkipReasonRuleError:             metrics.SkipClassRuleError,
+	metrics.SkipReasonDeadlineExceeded:      metrics.SkipClassDeadlineExceeded,
+	metrics.SkipReasonDependencyUnavailable: metrics.SkipClassDependencyUnavailable,
+}
+
+// skipClass returns the skip class of a skipped decision. Pods excluded by a
+// rule on their owner references are owner_excluded, pods excluded by any
+// other rule opted out.
+func skipClass(d *decision) string {
+	if d.reason == metrics.SkipReasonExcludedByRule {
+		if strings.Contains(d.rule, "ownerReferences") {
+			return metrics.SkipClassOwnerExcluded
+		}
+		return metrics.SkipClassOptedOut
+	}
+	if class, ok := skipClasses[d.reason]; ok {
+		return class
+	}
+	// the defaulting function skipped the pod for a reason of its own
+	return metrics.SkipClassOptedOut
+}
+
+// skipSampler bounds the log lines of skipped pods to one per skip class and
+// interval, the log line tells how many were suppressed since the last one.
+type skipSampler struct {
+	interval time.Duration
+
+	mu sync.Mutex
+	// last is the time the class was logged at, suppressed the skipped pods
+	// not logged since
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+func newSkipSampler(interval time.Duration) *skipSampler {
+	return &skipSampler{interval: interval, last: map[string]time.Time{}, suppressed: map[string]int{}}
+}
+
+// sample returns true if the skipped pod of the class is logged, together with
+// the number of skipped pods of the class suppressed since the last log line.
+// Every skipped pod is logged if the interval isn't positive.
+func (s *skipSampler) sample(class string, now time.Time) (int, bool) {
+	if s.interval <= 0 {
+		return 0, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.last[class]; ok && now.Sub(last) < s.interval {
+		s.suppressed[class]++
+		return 0, false
+	}
+	suppressed := s.suppressed[class]
+	s.last[class], s.suppressed[class] = now, 0
+	return suppressed, true
+}
@@ -0,0 +1,139 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/kim-snatch/internal/breaker"
+	"github.com/kyma-project/kim-snatch/internal/config"
+	"github.com/kyma-project/kim-snatch/internal/metrics"
+	"github.com/kyma-project/kim-snatch/internal/metrics/mocks"
+	"github.com/kyma-project/kim-snatch/internal/rules"
+	webhookv1 "github.com/kyma-project/kim-snatch/internal/webhook/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func Test_PodCustomDefaulter_skip_classes(t *testing.T) {
+	compiled, err := rules.Compile([]string{
+		"object.metadata.labels['app'] == 'nats'",
+		"object.metadata.ownerReferences.exists(o, o.kind == 'DaemonSet')",
+	}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name        string
+		opts        webhookv1.ApplyDefaultsOpts
+		pod         func(*corev1.Pod)
+		subResource string
+		timeout     time.Duration
+		reason      string
+		class       string
+	}{
+		{
+			name: "unmanaged namespace",
+			opts: webhookv1.ApplyDefaultsOpts{
+				Config: testConfig(func(cfg *config.Config) { cfg.OmittedNamespaces = []string{"test"} }),
+			},
+			reason: metrics.SkipReasonOmittedNamespace,
+			class:  metrics.SkipClassUnmanagedNamespace,
+		},
+		{
+			name:   "opted out",
+			opts:   webhookv1.ApplyDefaultsOpts{Config: testConfig(), Rules: func() *rules.Rules { return compiled }},
+			pod:    func(pod *corev1.Pod) { pod.Labels = map[string]string{"app": "nats"} },
+			reason: metrics.SkipReasonExcludedByRule,
+			class:  metrics.SkipClassOptedOut,
+		},
+		{
+			name: "owner excluded",
+			opts: webhookv1.ApplyDefaultsOpts{Config: testConfig(), Rules: func() *rules.Rules { return compiled }},
+			pod: func(pod *corev1.Pod) {
+				pod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent"}}
+			},
+			reason: metrics.SkipReasonExcludedByRule,
+			class:  metrics.SkipClassOwnerExcluded,
+		},
+		{
+			name: "conflict",
+			opts: webhookv1.ApplyDefaultsOpts{Config: testConfig()},
+			pod: func(pod *corev1.Pod) {
+				pod.Spec.NodeSelector = map[string]string{"worker.gardener.cloud/pool": "other"}
+			},
+			reason: metrics.SkipReasonConflict,
+			class:  metrics.SkipClassConflict,
+		},
+		{
+			name:   "pool missing",
+			opts:   webhookv1.ApplyDefaultsOpts{Config: testConfig(), PoolReady: func() bool { return false }},
+			reason: metrics.SkipReasonPoolNotReady,
+			class:  metrics.SkipClassPoolMissing,
+		},
+		{
+			name:        "subresource",
+			opts:        webhookv1.ApplyDefaultsOpts{Config: testConfig()},
+			subResource: "binding",
+			reason:      metrics.SkipReasonSubresource,
+			class:       metrics.SkipClassSubresource,
+		},
+		{
+			name: "deadline exceeded",
+			opts: webhookv1.ApplyDefaultsOpts{
+				Config: testConfig(),
+				// a namespace missing in the cache is waited for until the deadline
+				ResolvePlacement: func(ctx context.Context, _ string, defaults webhookv1.Placement) (webhookv1.Placement, error) {
+					<-ctx.Done()
+					return defaults, ctx.Err()
+				},
+			},
+			timeout: 10 * time.Millisecond,
+			reason:  metrics.SkipReasonDeadlineExceeded,
+			class:   metrics.SkipClassDeadlineExceeded,
+		},
+		{
+			name: "dependency unavailable",
+			opts: webhookv1.ApplyDefaultsOpts{
+				Config: testConfig(),
+				ResolvePlacement: func(_ context.Context, _ string, defaults webhookv1.Placement) (webhookv1.Placement, error) {
+					return defaults, nil
+				},
+				NamespaceBreaker: breaker.New(breaker.Options{
+					Dependency: breaker.DependencyNamespaces,
+					Synced:     func() bool { return false },
+				}),
+			},
+			reason: metrics.SkipReasonDependencyUnavailable,
+			class:  metrics.SkipClassDependencyUnavailable,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mtr := mocks.NewMetrics(t)
+			mtr.On("ObserveAdmission", "", metrics.AdmissionResultSkipped, tc.reason,
+				mock.AnythingOfType("time.Duration")).Once()
+			mtr.On("IncAdmissionSkip", tc.class).Once()
+
+			defaulter := webhookv1.NewPodCustomDefaulter(webhookv1.ApplyDefaults(tc.opts),
+				webhookv1.PodCustomDefaulterOpts{Metrics: mtr, SkipLogInterval: webhookv1.DefaultSkipLogInterval})
+			pod := testPod("test")
+			if tc.pod != nil {
+				tc.pod(pod)
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Namespace: "test", SubResource: tc.subResource},
+			})
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			require.NoError(t, defaulter.Default(ctx, pod))
+			assert.Nil(t, pod.Spec.Affinity)
+		})
+	}
+}
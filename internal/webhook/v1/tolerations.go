@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// tolerationsFor returns the tolerations of the taints whose keys are allowed,
+// the values and effects are taken from the taints.
+func tolerationsFor(taints []corev1.Taint, allowList []string) []corev1.Toleration {
+	var result []corev1.Toleration
+	for _, taint := range taints {
+		if !slices.Contains(allowList, taint.Key) {
+			continue
+		}
+
+		toleration := corev1.Toleration{
+			Key:      taint.Key,
+			Operator: corev1.TolerationOpEqual,
+			Value:    taint.Value,
+			Effect:   taint.Effect,
+		}
+		if taint.Value == "" {
+			toleration.Operator = corev1.TolerationOpExists
+		}
+		result = append(result, toleration)
+	}
+	return result
+}
+
+// injectTolerations adds the tolerations the pod doesn't have yet, existing
+// tolerations are never changed.
+func injectTolerations(pod *corev1.Pod, tolerations []corev1.Toleration) {
+	for _, toleration := range tolerations {
+		if slices.ContainsFunc(pod.Spec.Tolerations, func(existing corev1.Toleration) bool {
+			return existing.MatchToleration(&toleration)
+		}) {
+			continue
+		}
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+	}
+}
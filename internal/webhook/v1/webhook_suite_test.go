@@ -29,6 +29,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/kyma-project/kim-snatch/internal/config"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 
@@ -119,7 +120,11 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	err = SetupPodWebhookWithManager(mgr, ApplyDefaults(testNodeKymaLabelValue, []string{"kube-system"}))
+	testConfig := config.Default()
+	testConfig.KymaWorkerPoolName = testNodeKymaLabelValue
+	err = SetupPodWebhookWithManager(mgr, ApplyDefaults(ApplyDefaultsOpts{
+		Config: func() config.Config { return testConfig },
+	}), PodCustomDefaulterOpts{})
 	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:webhook
@@ -0,0 +1,34 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+// ConversionPath is the path the conversion webhook is served on.
+const ConversionPath = "/convert"
+
+// SetupSnatchConfigWebhookWithManager registers the conversion webhook for SnatchConfig
+// in the manager. The builder registers the conversion webhook only if more than
+// one version of the API exists, it is registered explicitly so that the CRD can
+// refer to it before a second version is introduced.
+func SetupSnatchConfigWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(ConversionPath, conversion.NewWebhookHandler(mgr.GetScheme()))
+	return nil
+}